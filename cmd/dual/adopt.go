@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/lightfastai/dual/internal/config"
+	"github.com/lightfastai/dual/internal/context"
+	"github.com/lightfastai/dual/internal/health"
+	"github.com/lightfastai/dual/internal/hooks"
+	"github.com/lightfastai/dual/internal/logger"
+	"github.com/lightfastai/dual/internal/registry"
+	"github.com/lightfastai/dual/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	adoptName     string
+	adoptRunHooks bool
+	adoptStrict   bool
+)
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt [path]",
+	Short: "Register an existing git worktree as a dual context",
+	Long: `Register an existing git worktree as a dual context.
+
+Use this for worktrees created with 'git worktree add' instead of 'dual create'.
+The context is named after the worktree's branch unless --name is given, and
+points at the worktree so dual detects it there. The worktree itself is left
+untouched.
+
+A --name that differs from the context dual detects in the worktree (its
+branch, or its .dual-context file) gets a warning: commands run there would
+use the detected context instead. With --strict it is an error.
+
+The path defaults to the current directory. It must be a linked worktree of
+this project's repository.
+
+With --run-hooks, postWorktreeCreate hooks run as they would for 'dual create',
+and env overrides they print are stored for the new context. If a hook fails,
+the context stays registered.
+
+Examples:
+  dual adopt ../worktrees/feature-x          # Adopt a worktree by path
+  dual adopt                                 # Adopt the worktree you are in
+  dual adopt ../wt/fix --name fix-login      # Choose the context name
+  dual adopt ../wt/fix --name fix --strict   # Fail if fix isn't what dual detects there
+  dual adopt ../wt/feature-y --run-hooks     # Also run setup hooks`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runAdopt,
+}
+
+func init() {
+	adoptCmd.Flags().StringVar(&adoptName, "name", "", "Context name (default: the worktree's branch)")
+	adoptCmd.Flags().BoolVar(&adoptRunHooks, "run-hooks", false, "Run postWorktreeCreate hooks for the adopted worktree")
+	adoptCmd.Flags().BoolVar(&adoptStrict, "strict", false, "Fail instead of warning when --name differs from the context detected in the worktree")
+	rootCmd.AddCommand(adoptCmd)
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	target := "."
+	if len(args) > 0 {
+		target = args[0]
+	}
+
+	// Load config
+	cfg, projectRoot, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
+	}
+
+	// Get the normalized project identifier for registry operations
+	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to get project identifier: %w", err)
+	}
+
+	worktreePath, err := resolveAdoptPath(target, projectIdentifier)
+	if err != nil {
+		return err
+	}
+
+	contextName := adoptName
+	if contextName == "" {
+		contextName, err = worktreeBranch(worktreePath)
+		if err != nil {
+			return err
+		}
+	} else if err := checkAdoptName(cfg, worktreePath, contextName); err != nil {
+		return err
+	}
+
+	// Load registry (using projectIdentifier to ensure worktrees access parent repo's registry)
+	reg, err := registry.LoadRegistry(projectIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+	defer reg.Close()
+
+	if reg.ContextExists(projectIdentifier, contextName) {
+		return fmt.Errorf("context %q already exists\nHint: Pass --name to adopt the worktree under a different name", contextName)
+	}
+	if existing := contextForPath(reg, projectIdentifier, worktreePath); existing != "" {
+		return fmt.Errorf("worktree %s is already registered as context %q", worktreePath, existing)
+	}
+
+	if err := reg.SetContext(projectIdentifier, contextName, worktreePath); err != nil {
+		return fmt.Errorf("failed to create context: %w", err)
+	}
+	if err := reg.SaveRegistry(); err != nil {
+		return fmt.Errorf("failed to save registry: %w", err)
+	}
+
+	logger.Success("Adopted worktree %s as context: %s", worktreePath, contextName)
+
+	if !adoptRunHooks {
+		return nil
+	}
+
+	hookCtx := hooks.HookContext{
+		Event:       hooks.PostWorktreeCreate,
+		ContextName: contextName,
+		ContextPath: worktreePath,
+		ProjectRoot: projectIdentifier,
+	}
+	envOverrides, err := hooks.NewManager(cfg, projectIdentifier).Execute(hooks.PostWorktreeCreate, hookCtx)
+	if err != nil {
+		return fmt.Errorf("postWorktreeCreate hook failed: %w\nHint: The context was registered; fix the hook and run it manually", err)
+	}
+	applyEnvOverrides(cfg, reg, projectIdentifier, contextName, worktreePath, envOverrides)
+
+	return nil
+}
+
+// checkAdoptName warns, or with --strict fails, when contextName is not the
+// context dual detects in the worktree, since commands run there would not
+// find the adopted context
+func checkAdoptName(cfg *config.Config, worktreePath, contextName string) error {
+	source := cfg.GetContextSource()
+	detected := context.DetectContextInDir(worktreePath, source)
+	if detected == contextName {
+		return nil
+	}
+
+	hint := fmt.Sprintf("Write %q to %s", contextName, filepath.Join(worktreePath, context.DualContextFile))
+	if detected != context.DefaultContext {
+		hint = fmt.Sprintf("Omit --name to use %q, or write %q to %s", detected, contextName, filepath.Join(worktreePath, context.DualContextFile))
+	}
+	if source == context.SourceBranch {
+		hint += " and set context.source: fileFirst in dual.config.yml"
+	}
+
+	if adoptStrict {
+		return fmt.Errorf("context name %q differs from %q, which dual detects in %s\nHint: %s", contextName, detected, worktreePath, hint)
+	}
+	logger.Warn("Context name %q differs from %q, which dual detects in %s; commands run there will not use the adopted context", contextName, detected, worktreePath)
+	logger.Detail("  Hint: %s", hint)
+	return nil
+}
+
+// resolveAdoptPath returns the root of the linked worktree containing target,
+// checking that it belongs to the project's repository
+func resolveAdoptPath(target, projectIdentifier string) (string, error) {
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve path %s: %w", target, err)
+	}
+
+	detector := worktree.NewDetector()
+	gitRoot, err := detector.FindGitRoot(absTarget)
+	if err != nil {
+		return "", fmt.Errorf("%s is not inside a git worktree\nHint: Pass the path of a worktree created with 'git worktree add'", absTarget)
+	}
+
+	isWT, err := detector.IsWorktree(gitRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect %s: %w", gitRoot, err)
+	}
+	if !isWT {
+		return "", fmt.Errorf("%s is not a linked git worktree\nHint: The main checkout needs no adopting; use 'dual create' for new worktrees", gitRoot)
+	}
+
+	parentRepo, err := detector.GetProjectRoot(gitRoot)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the repository of worktree %s: %w", gitRoot, err)
+	}
+	if health.CanonicalPath(parentRepo) != health.CanonicalPath(projectIdentifier) {
+		return "", fmt.Errorf("worktree %s belongs to %s, not this project (%s)\nHint: Run 'dual adopt' from that repository instead", gitRoot, parentRepo, projectIdentifier)
+	}
+
+	return gitRoot, nil
+}
+
+// worktreeBranch returns the branch checked out in a worktree
+func worktreeBranch(worktreePath string) (string, error) {
+	// #nosec G204 - Git command with controlled arguments
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = worktreePath
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to determine the branch of %s: %w", worktreePath, err)
+	}
+
+	branch := strings.TrimSpace(string(output))
+	if branch == "HEAD" {
+		return "", fmt.Errorf("worktree %s has a detached HEAD\nHint: Check out a branch in it, or pass --name to choose the context name", worktreePath)
+	}
+	return branch, nil
+}
+
+// contextForPath returns the name of the context whose path is worktreePath, if any
+func contextForPath(reg *registry.Registry, projectIdentifier, worktreePath string) string {
+	contexts, err := reg.ListContexts(projectIdentifier)
+	if err != nil {
+		return ""
+	}
+	for name, ctx := range contexts {
+		if ctx.Path != "" && health.CanonicalPath(ctx.Path) == health.CanonicalPath(worktreePath) {
+			return name
+		}
+	}
+	return ""
+}
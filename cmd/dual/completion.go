@@ -102,7 +102,7 @@ func contextCompletion(cmd *cobra.Command, args []string, toComplete string) ([]
 	}
 
 	// Load registry (using projectIdentifier to ensure worktrees access parent repo's registry)
-	reg, err := registry.LoadRegistry(projectIdentifier)
+	reg, err := registry.LoadRegistryReadOnly(projectIdentifier)
 	if err != nil {
 		return []string{}, cobra.ShellCompDirectiveNoFileComp
 	}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/lightfastai/dual/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and validate the dual configuration",
+	Long:  `Commands for working with the dual.config.yml configuration file.`,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "Validate a dual configuration file",
+	Long: `Validate a dual.config.yml file and report every problem found.
+
+Unlike 'dual doctor', this command only checks the configuration file, which
+makes it fast enough to run from a pre-commit hook.
+
+If no path is given, dual.config.yml is searched for starting from the current
+directory. The path may point at the config file itself or at the directory
+containing it.
+
+Exit code:
+  0 - Configuration is valid
+  1 - Problems found
+
+Examples:
+  dual config validate                   # Validate the project's config
+  dual config validate ./dual.config.yml # Validate a specific file
+  dual config validate ../other-project  # Validate a config in another directory`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigValidate,
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	// Resolve the config file to validate
+	var configPath string
+	if len(args) > 0 {
+		configPath = args[0]
+		if info, err := os.Stat(configPath); err == nil && info.IsDir() {
+			configPath = filepath.Join(configPath, config.ConfigFileName)
+		}
+	} else {
+		path, err := config.FindConfigPath()
+		if err != nil {
+			return err
+		}
+		configPath = path
+	}
+
+	absPath, err := filepath.Abs(configPath)
+	if err == nil {
+		configPath = absPath
+	}
+
+	if _, err := config.LoadConfigFrom(configPath); err != nil {
+		var validationErrs config.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			// Parse or read failure - nothing further to validate
+			fmt.Fprintf(os.Stderr, "✗ %v\n", err)
+			return fmt.Errorf("configuration in %s is invalid", configPath)
+		}
+
+		fmt.Fprintf(os.Stderr, "Found %d problem(s) in %s:\n\n", len(validationErrs), configPath)
+		for _, validationErr := range validationErrs {
+			printValidationError(validationErr)
+		}
+		return fmt.Errorf("configuration in %s is invalid", configPath)
+	}
+
+	fmt.Printf("✓ Configuration is valid: %s\n", configPath)
+	return nil
+}
+
+// printValidationError prints a single config problem with its field, context and fixes
+func printValidationError(validationErr *config.ValidationError) {
+	fmt.Fprintf(os.Stderr, "✗ %s: %s\n", validationErr.Field, validationErr.Message())
+
+	// Sort context keys for consistent output
+	keys := make([]string, 0, len(validationErr.Err.Context))
+	for k := range validationErr.Err.Context {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(os.Stderr, "    %s: %s\n", k, validationErr.Err.Context[k])
+	}
+
+	if validationErr.Err.Cause != nil {
+		fmt.Fprintf(os.Stderr, "    Cause: %v\n", validationErr.Err.Cause)
+	}
+
+	if fixes := validationErr.Fixes(); len(fixes) > 0 {
+		fmt.Fprintln(os.Stderr, "    How to fix:")
+		for _, fix := range fixes {
+			if fix == "" {
+				fmt.Fprintln(os.Stderr)
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "      %s\n", fix)
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lightfastai/dual/internal/config"
+	"github.com/lightfastai/dual/internal/context"
+	"github.com/lightfastai/dual/internal/hooks"
+	"github.com/lightfastai/dual/internal/logger"
+	"github.com/lightfastai/dual/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+var (
+	contextPruneOlderThan string
+	contextPruneDryRun    bool
+	contextPruneForce     bool
+	contextInfoJSON       bool
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage dual contexts in the registry",
+	Long:  `Commands for maintaining the contexts recorded in the project registry.`,
+}
+
+var contextPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove stale contexts whose worktrees no longer exist",
+	Long: `Remove contexts that are older than a cutoff and whose worktree is gone.
+
+A context is only pruned if BOTH conditions hold:
+  - It was created longer ago than --older-than
+  - Its worktree path no longer exists on disk
+
+Contexts with an existing worktree are never pruned, no matter how old.
+Only registry entries are removed; nothing on disk is touched.
+
+Each context runs the preContextDelete hooks before removal and the
+postContextDelete hooks after the registry is saved. If a preContextDelete
+hook fails, that context is kept.
+
+Durations accept Go syntax (e.g. 72h) plus days (d) and weeks (w).
+
+Examples:
+  dual context prune --older-than 30d            # Prune with confirmation
+  dual context prune --older-than 2w --dry-run   # Show what would be pruned
+  dual context prune --older-than 720h --force   # Prune without confirmation`,
+	Args: cobra.NoArgs,
+	RunE: runContextPrune,
+}
+
+var contextInfoCmd = &cobra.Command{
+	Use:   "info [context]",
+	Short: "Show details and env overrides for a single context",
+	Long: `Show the registry details of one context: its worktree path, when it was
+created, and every environment override set on it, grouped by layer
+(global and per service).
+
+Without an argument, the current context is shown.
+
+Examples:
+  dual context info                # Current context
+  dual context info feature-auth   # A specific context
+  dual context info --json         # Machine-readable output`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runContextInfo,
+}
+
+func init() {
+	contextInfoCmd.Flags().BoolVar(&contextInfoJSON, "json", false, "Output as JSON")
+	contextPruneCmd.Flags().StringVar(&contextPruneOlderThan, "older-than", "", "Only prune contexts created longer ago than this (e.g. 30d, 2w, 72h)")
+	contextPruneCmd.Flags().BoolVar(&contextPruneDryRun, "dry-run", false, "Show which contexts would be pruned without removing them")
+	contextPruneCmd.Flags().BoolVarP(&contextPruneForce, "force", "f", false, "Skip confirmation prompt")
+	_ = contextPruneCmd.MarkFlagRequired("older-than")
+
+	contextCmd.AddCommand(contextPruneCmd)
+	contextCmd.AddCommand(contextInfoCmd)
+	rootCmd.AddCommand(contextCmd)
+}
+
+func runContextPrune(cmd *cobra.Command, args []string) error {
+	maxAge, err := parseAgeDuration(contextPruneOlderThan)
+	if err != nil {
+		return fmt.Errorf("invalid --older-than value %q: %w\nHint: Use a duration like 30d, 2w or 72h", contextPruneOlderThan, err)
+	}
+
+	// Load config
+	cfg, projectRoot, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
+	}
+
+	// Get the normalized project identifier
+	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to get project identifier: %w", err)
+	}
+
+	// Detect current context so it is never pruned
+	currentContext, err := context.DetectContextWithSource(cfg.GetContextSource())
+	if err != nil {
+		currentContext = ""
+	}
+
+	// Load registry (using projectIdentifier to ensure worktrees access parent repo's registry)
+	reg, err := registry.LoadRegistry(projectIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+	defer reg.Close()
+
+	contexts, err := reg.ListContexts(projectIdentifier)
+	if err != nil && !errors.Is(err, registry.ErrProjectNotFound) {
+		return fmt.Errorf("failed to list contexts: %w", err)
+	}
+
+	stale := findStaleContexts(contexts, projectIdentifier, currentContext, maxAge)
+	if len(stale) == 0 {
+		logger.Info("No stale contexts older than %s", contextPruneOlderThan)
+		return nil
+	}
+
+	reportStaleContexts(contexts, stale)
+	if contextPruneDryRun {
+		logger.Detail("")
+		logger.Info("Dry run: %d context(s) would be pruned", len(stale))
+		return nil
+	}
+
+	// Confirm unless --force
+	if !contextPruneForce {
+		confirmed, err := confirmPrune(len(stale))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			logger.Info("Prune cancelled")
+			return nil
+		}
+	}
+
+	return pruneContexts(cfg, reg, projectIdentifier, contexts, stale)
+}
+
+// findStaleContexts returns, sorted, the contexts that are both older than
+// maxAge and missing on disk. The current context is never stale.
+func findStaleContexts(contexts map[string]registry.Context, projectIdentifier, currentContext string, maxAge time.Duration) []string {
+	var stale []string
+	for name, ctx := range contexts {
+		if name == currentContext || ctx.Path == "" {
+			continue
+		}
+		if time.Since(ctx.Created) < maxAge || contextPathExists(projectIdentifier, ctx) {
+			continue
+		}
+		stale = append(stale, name)
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// reportStaleContexts lists the contexts that would be pruned
+func reportStaleContexts(contexts map[string]registry.Context, stale []string) {
+	fmt.Fprintf(os.Stderr, "Stale contexts (older than %s, worktree missing):\n", contextPruneOlderThan)
+	for _, name := range stale {
+		ctx := contexts[name]
+		fmt.Fprintf(os.Stderr, "  %s (created %s, path: %s)\n", name, ctx.Created.Format("2006-01-02"), ctx.Path)
+	}
+}
+
+// confirmPrune asks whether to prune count contexts and reports the answer
+func confirmPrune(count int) (bool, error) {
+	fmt.Fprintf(os.Stderr, "\nPrune %d context(s) from the registry? (y/N): ", count)
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false, fmt.Errorf("failed to read confirmation: %w", err)
+	}
+
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == "yes", nil
+}
+
+// pruneContexts removes the stale contexts from the registry, running the
+// context delete hooks around it
+func pruneContexts(cfg *config.Config, reg *registry.Registry, projectIdentifier string, contexts map[string]registry.Context, stale []string) error {
+	hookMgr := hooks.NewManager(cfg, projectIdentifier)
+
+	// A failing preContextDelete hook keeps that context in the registry
+	var pruned []string
+	for _, name := range stale {
+		hookCtx := hooks.HookContext{
+			Event:       hooks.PreContextDelete,
+			ContextName: name,
+			ContextPath: contexts[name].Path,
+			ProjectRoot: projectIdentifier,
+		}
+		if _, err := hookMgr.Execute(hooks.PreContextDelete, hookCtx); err != nil {
+			logger.Warn("skipping %s: preContextDelete hook failed: %v", name, err)
+			continue
+		}
+		if err := reg.DeleteContext(projectIdentifier, name); err != nil {
+			return fmt.Errorf("failed to delete context %q: %w", name, err)
+		}
+		pruned = append(pruned, name)
+	}
+
+	if len(pruned) == 0 {
+		return fmt.Errorf("no contexts were pruned\nHint: Fix the preContextDelete hook errors above and try again")
+	}
+
+	// Save once after all deletions
+	if err := reg.SaveRegistry(); err != nil {
+		return fmt.Errorf("failed to save registry: %w", err)
+	}
+
+	// Run postContextDelete hooks (non-fatal - contexts already removed)
+	for _, name := range pruned {
+		hookMgr.ExecuteWithFallback(hooks.PostContextDelete, hooks.HookContext{
+			Event:       hooks.PostContextDelete,
+			ContextName: name,
+			ContextPath: contexts[name].Path,
+			ProjectRoot: projectIdentifier,
+		})
+	}
+
+	logger.Info("Pruned %d context(s)", len(pruned))
+	return nil
+}
+
+func runContextInfo(cmd *cobra.Command, args []string) error {
+	// Load config
+	cfg, projectRoot, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
+	}
+
+	// Get the normalized project identifier
+	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to get project identifier: %w", err)
+	}
+
+	currentContext, err := context.DetectContextWithSource(cfg.GetContextSource())
+	if err != nil {
+		currentContext = ""
+	}
+
+	contextName := currentContext
+	if len(args) == 1 {
+		contextName = args[0]
+	}
+
+	// Load registry (using projectIdentifier to ensure worktrees access parent repo's registry)
+	reg, err := registry.LoadRegistryReadOnly(projectIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+	defer reg.Close()
+
+	ctx, err := reg.GetContext(projectIdentifier, contextName)
+	if err != nil {
+		return fmt.Errorf("context %q not found: %w\nHint: Run 'dual list' to see available contexts", contextName, err)
+	}
+
+	globalOverrides, serviceOverrides := contextOverrideLayers(ctx)
+
+	if contextInfoJSON {
+		return outputContextInfoJSON(contextName, contextName == currentContext, ctx, globalOverrides, serviceOverrides)
+	}
+
+	currentMarker := ""
+	if contextName == currentContext {
+		currentMarker = " (current)"
+	}
+	path := ctx.Path
+	if path == "" {
+		path = projectIdentifier
+	}
+
+	fmt.Printf("Context: %s%s\n", contextName, currentMarker)
+	fmt.Printf("Path:    %s\n", path)
+	fmt.Printf("Created: %s\n", ctx.Created.Format("2006-01-02 15:04:05"))
+
+	total := len(globalOverrides)
+	for _, overrides := range serviceOverrides {
+		total += len(overrides)
+	}
+	fmt.Printf("\nEnvironment overrides: %d\n", total)
+	if total == 0 {
+		return nil
+	}
+
+	printOverrideLayer("Global", globalOverrides)
+	for _, service := range sortedMapKeys(serviceOverrides) {
+		printOverrideLayer("Service "+service, serviceOverrides[service])
+	}
+	return nil
+}
+
+// contextOverrideLayers splits a context's overrides into the global layer
+// (including unmigrated legacy overrides) and the per-service layers
+func contextOverrideLayers(ctx *registry.Context) (map[string]string, map[string]map[string]string) {
+	global := make(map[string]string)
+	for key, value := range ctx.EnvOverrides {
+		global[key] = value
+	}
+
+	services := make(map[string]map[string]string)
+	if ctx.EnvOverridesV2 != nil {
+		for key, value := range ctx.EnvOverridesV2.Global {
+			global[key] = value
+		}
+		for service, overrides := range ctx.EnvOverridesV2.Services {
+			if len(overrides) > 0 {
+				services[service] = overrides
+			}
+		}
+	}
+	return global, services
+}
+
+// printOverrideLayer prints one layer of overrides sorted by key
+func printOverrideLayer(label string, overrides map[string]string) {
+	if len(overrides) == 0 {
+		return
+	}
+	fmt.Printf("  %s (%d):\n", label, len(overrides))
+	for _, key := range sortedMapKeys(overrides) {
+		fmt.Printf("    %s=%s\n", key, overrides[key])
+	}
+}
+
+func outputContextInfoJSON(name string, current bool, ctx *registry.Context, global map[string]string, services map[string]map[string]string) error {
+	type overridesJSON struct {
+		Count    int                          `json:"count"`
+		Global   map[string]string            `json:"global"`
+		Services map[string]map[string]string `json:"services"`
+	}
+	type contextInfoJSON struct {
+		Name      string        `json:"name"`
+		Current   bool          `json:"current"`
+		Created   string        `json:"created"`
+		Path      string        `json:"path,omitempty"`
+		Overrides overridesJSON `json:"overrides"`
+	}
+
+	count := len(global)
+	for _, overrides := range services {
+		count += len(overrides)
+	}
+
+	output := contextInfoJSON{
+		Name:    name,
+		Current: current,
+		Created: ctx.Created.Format("2006-01-02T15:04:05Z"),
+		Path:    ctx.Path,
+		Overrides: overridesJSON{
+			Count:    count,
+			Global:   global,
+			Services: services,
+		},
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+
+	fmt.Println(string(data))
+	return nil
+}
+
+// sortedMapKeys returns the keys of a map in sorted order
+func sortedMapKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseAgeDuration parses a duration, additionally accepting whole days ("30d")
+// and weeks ("2w") which time.ParseDuration does not support
+func parseAgeDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("duration is empty")
+	}
+
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(value, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit != 0 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("expected a whole number before %q", value[len(value)-1:])
+		}
+		return time.Duration(n) * unit, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("duration must not be negative")
+	}
+	return d, nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -9,14 +10,24 @@ import (
 	"strings"
 
 	"github.com/lightfastai/dual/internal/config"
+	"github.com/lightfastai/dual/internal/context"
 	"github.com/lightfastai/dual/internal/env"
 	dualerrors "github.com/lightfastai/dual/internal/errors"
+	"github.com/lightfastai/dual/internal/health"
 	"github.com/lightfastai/dual/internal/hooks"
+	"github.com/lightfastai/dual/internal/logger"
 	"github.com/lightfastai/dual/internal/registry"
+	"github.com/lightfastai/dual/internal/worktree"
 	"github.com/spf13/cobra"
 )
 
-var createFromRef string
+var (
+	createFromRef         string
+	createOnHookFailure   string
+	createCopyContextFrom string
+	createPath            string
+	createNoContextFile   bool
+)
 
 var createCmd = &cobra.Command{
 	Use:   "create <branch-name>",
@@ -28,15 +39,48 @@ This command:
 2. Registers a new dual context
 3. Runs lifecycle hooks (postWorktreeCreate)
 
+When run from inside a worktree, dual operates on the main checkout: the new
+worktree is placed next to the others and, unless --from is given, branches
+from the current worktree's HEAD.
+
+--copy-context-from seeds the new context with the env overrides (global and
+per-service) of an existing context and regenerates the service env files.
+Overrides written by postWorktreeCreate hooks are applied afterwards and win.
+
+--path places this one worktree under a different base directory than the
+configured worktrees.path. A relative --path is resolved against the current
+directory; the worktree name itself still follows worktrees.naming.
+
+A .dual-context file naming the context is written to the new worktree's root,
+so context detection there still works in detached-HEAD states. It is added to
+the repository's .git/info/exclude unless already ignored. Use
+--no-context-file, or set worktrees.writeContextFile: false, to skip it.
+
+If a postWorktreeCreate hook fails, the onHookFailure policy decides what happens:
+  warn      Keep the worktree and print a warning (default)
+  abort     Keep the worktree and exit with an error
+  rollback  Remove the worktree and context and exit with an error
+
 Examples:
-  dual create feature-auth              # Create worktree for feature-auth branch
-  dual create hotfix-123 --from main    # Create from specific ref`,
+  dual create feature-auth                             # Create worktree for feature-auth branch
+  dual create hotfix-123 --from main                   # Create from specific ref
+  dual create feature-w --from origin/feature-w        # Track a remote branch, fetching it if needed
+  dual create feature-x --on-hook-failure rollback     # Clean up if setup hooks fail
+  dual create feature-y --copy-context-from feature-x  # Reuse feature-x's env overrides
+  dual create spike --path /tmp/scratch                # Create under /tmp/scratch/spike
+  dual create feature-z --no-context-file              # Don't write .dual-context`,
 	Args: cobra.ExactArgs(1),
 	RunE: runCreate,
 }
 
 func init() {
-	createCmd.Flags().StringVar(&createFromRef, "from", "", "Create worktree from this ref (branch/commit)")
+	createCmd.Flags().StringVar(&createFromRef, "from", "", "Create worktree from this ref (branch/commit, or remote branch to track)")
+	createCmd.Flags().StringVar(&createOnHookFailure, "on-hook-failure", "", "What to do if a postWorktreeCreate hook fails: warn, abort or rollback (overrides onHookFailure)")
+	createCmd.Flags().StringVar(&createCopyContextFrom, "copy-context-from", "", "Copy env overrides from this existing context into the new one")
+	createCmd.Flags().StringVar(&createPath, "path", "", "Create the worktree under this directory instead of the configured worktrees path")
+	createCmd.Flags().BoolVar(&createNoContextFile, "no-context-file", false, "Don't write a .dual-context file into the new worktree (overrides worktrees.writeContextFile)")
+	_ = createCmd.RegisterFlagCompletionFunc("copy-context-from", contextCompletion)
+	_ = createCmd.MarkFlagDirname("path")
 	rootCmd.AddCommand(createCmd)
 }
 
@@ -49,52 +93,160 @@ func runCreate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
 	}
 
-	// Validate we're in project root
-	if err := validateProjectRoot(projectRoot); err != nil {
+	// From inside a worktree, operate on the main checkout instead
+	cfg, projectRoot, inWorktree, err := resolveCreateRoot(cfg, projectRoot)
+	if err != nil {
 		return err
 	}
 
+	// Resolve hook failure policy before touching anything
+	if createOnHookFailure != "" {
+		if err := config.ValidateHookFailurePolicy(createOnHookFailure); err != nil {
+			return err
+		}
+		cfg.OnHookFailure = createOnHookFailure
+	}
+
+	// Resolve --path against the directory dual was run from
+	worktreesBasePath, err := resolveWorktreesBasePath(cfg, projectRoot)
+	if err != nil {
+		return err
+	}
+	warnLowDiskSpace(worktreesBasePath)
+
+	// Validate we're in project root
+	if !inWorktree {
+		if err := validateProjectRoot(projectRoot); err != nil {
+			return err
+		}
+	}
+
 	// Get the normalized project identifier for registry operations
 	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
 	if err != nil {
 		return fmt.Errorf("failed to get project identifier: %w", err)
 	}
 
-	// Load registry (using projectIdentifier to ensure worktrees access parent repo's registry)
-	reg, err := registry.LoadRegistry(projectIdentifier)
+	// Hold the registry lock from the existence check until the hooks have run,
+	// using projectIdentifier so worktrees access the parent repo's registry
+	var worktreePath string
+	err = registry.Update(projectIdentifier, func(reg *registry.Registry) error {
+		// Validate context doesn't exist
+		if reg.ContextExists(projectIdentifier, branchName) {
+			return fmt.Errorf("context %q already exists\nHint: Use a different branch name or delete the existing context first", branchName)
+		}
+
+		// Read the overrides to inherit before creating anything
+		var copiedOverrides *hooks.EnvOverrides
+		if createCopyContextFrom != "" {
+			overrides, err := contextEnvOverrides(reg, projectIdentifier, createCopyContextFrom)
+			if err != nil {
+				return err
+			}
+			copiedOverrides = overrides
+		}
+
+		// Determine worktree path
+		path, err := prepareWorktreePath(cfg, worktreesBasePath, branchName)
+		if err != nil {
+			return err
+		}
+		worktreePath = path
+
+		// Create git worktree
+		if err := createGitWorktree(projectRoot, branchName, worktreePath); err != nil {
+			return err
+		}
+
+		// Register context
+		if err := registerContext(reg, projectIdentifier, branchName, worktreePath, projectRoot); err != nil {
+			return err
+		}
+
+		logger.Info("Created context: %s", branchName)
+
+		// Pin the context in the worktree, so detection does not depend on HEAD
+		if !createNoContextFile && cfg.GetWriteContextFile() {
+			if err := writeContextFile(worktreePath, branchName); err != nil {
+				logger.Warn("Failed to write %s: %v", context.DualContextFile, err)
+			}
+		}
+
+		// Inherit env overrides before hooks run, so hook overrides take precedence
+		if copiedOverrides != nil {
+			if copiedOverrides.IsEmpty() {
+				logger.Info("Context %s has no env overrides to copy", createCopyContextFrom)
+			} else {
+				applyEnvOverrides(cfg, reg, projectIdentifier, branchName, worktreePath, copiedOverrides)
+				logger.Info("Copied env overrides from context: %s", createCopyContextFrom)
+			}
+		}
+
+		// Execute hooks and apply env overrides
+		return executeHooksAndApplyEnv(cfg, reg, projectRoot, projectIdentifier, branchName, worktreePath)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to load registry: %w", err)
+		return err
 	}
-	defer reg.Close()
 
-	// Validate context doesn't exist
-	if reg.ContextExists(projectIdentifier, branchName) {
-		return fmt.Errorf("context %q already exists\nHint: Use a different branch name or delete the existing context first", branchName)
+	printSuccess(branchName, worktreePath)
+	return nil
+}
+
+// resolveCreateRoot redirects dual create to the main checkout when run from
+// inside a linked worktree, so the new worktree is laid out relative to the
+// main project root instead of nested under the current worktree.
+// Without --from, the new branch starts from the worktree's current HEAD, as
+// it would when run from the main checkout.
+func resolveCreateRoot(cfg *config.Config, projectRoot string) (*config.Config, string, bool, error) {
+	isWT, err := worktree.IsWorktree()
+	if err != nil || !isWT {
+		return cfg, projectRoot, false, nil
 	}
 
-	// Determine worktree path
-	worktreePath, err := prepareWorktreePath(cfg, projectRoot, branchName)
+	mainRoot, err := worktree.GetProjectRoot()
 	if err != nil {
-		return err
+		return nil, "", false, fmt.Errorf("failed to find the main checkout for this worktree: %w\nHint: Run 'dual create' from the main checkout", err)
 	}
 
-	// Create git worktree
-	if err := createGitWorktree(projectRoot, branchName, worktreePath); err != nil {
-		return err
+	mainCfg, _, err := config.LoadConfigFromRoot(mainRoot)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("dual create must be run from the main checkout, not a worktree\nCurrent worktree: %s\nMain checkout: %s\nReason: %v\nHint: cd %s", projectRoot, mainRoot, err, mainRoot)
 	}
 
-	// Register context
-	if err := registerContext(reg, projectIdentifier, branchName, worktreePath, projectRoot); err != nil {
-		return err
+	if createFromRef == "" {
+		ref, err := currentGitRef(projectRoot)
+		if err != nil {
+			return nil, "", false, fmt.Errorf("failed to determine the worktree's current branch: %w\nHint: Pass --from to choose the base explicitly", err)
+		}
+		createFromRef = ref
 	}
 
-	fmt.Fprintf(os.Stderr, "[dual] Created context: %s\n", branchName)
+	logger.Info("Running from worktree %s, creating from main checkout %s", projectRoot, mainRoot)
+	return mainCfg, mainRoot, true, nil
+}
 
-	// Execute hooks and apply env overrides
-	executeHooksAndApplyEnv(cfg, reg, projectRoot, projectIdentifier, branchName, worktreePath)
+// currentGitRef returns the branch checked out in dir, or the commit if HEAD is detached
+func currentGitRef(dir string) (string, error) {
+	// #nosec G204 - Git command with controlled arguments
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	if ref := strings.TrimSpace(string(output)); ref != "HEAD" {
+		return ref, nil
+	}
 
-	printSuccess(branchName, worktreePath)
-	return nil
+	// #nosec G204 - Git command with controlled arguments
+	cmd = exec.Command("git", "rev-parse", "HEAD")
+	cmd.Dir = dir
+	output, err = cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
 }
 
 // validateProjectRoot checks we're running from the project root
@@ -121,9 +273,44 @@ func validateProjectRoot(projectRoot string) error {
 	return nil
 }
 
+// resolveWorktreesBasePath returns the directory new worktrees are created in:
+// the --path override if given, otherwise the configured worktrees path
+func resolveWorktreesBasePath(cfg *config.Config, projectRoot string) (string, error) {
+	if createPath == "" {
+		return cfg.GetWorktreePath(projectRoot), nil
+	}
+
+	if strings.TrimSpace(createPath) == "" {
+		return "", fmt.Errorf("--path cannot be empty")
+	}
+
+	basePath, err := filepath.Abs(createPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve --path %q: %w", createPath, err)
+	}
+
+	if info, err := os.Stat(basePath); err == nil && !info.IsDir() {
+		return "", fmt.Errorf("--path %s is not a directory\nHint: Choose a directory, or a path that does not exist yet", basePath)
+	}
+
+	return basePath, nil
+}
+
+// warnLowDiskSpace warns when the disk new worktrees go on is nearly full, as
+// git then fails partway through the checkout with an unhelpful error
+func warnLowDiskSpace(worktreesBasePath string) {
+	free, err := worktree.FreeSpace(worktreesBasePath)
+	if err != nil {
+		logger.Debug("Could not determine free disk space: %v", err)
+		return
+	}
+	if free < health.LowDiskSpaceThreshold {
+		logger.Warn("Only %s free on the disk holding %s; creating the worktree may fail", worktree.FormatSize(int64(free)), worktreesBasePath)
+	}
+}
+
 // prepareWorktreePath determines and validates the worktree path
-func prepareWorktreePath(cfg *config.Config, projectRoot, branchName string) (string, error) {
-	worktreesBasePath := cfg.GetWorktreePath(projectRoot)
+func prepareWorktreePath(cfg *config.Config, worktreesBasePath, branchName string) (string, error) {
 	worktreeName := cfg.GetWorktreeName(branchName)
 	worktreePath := filepath.Join(worktreesBasePath, worktreeName)
 
@@ -142,15 +329,21 @@ func prepareWorktreePath(cfg *config.Config, projectRoot, branchName string) (st
 
 // createGitWorktree creates the git worktree
 func createGitWorktree(projectRoot, branchName, worktreePath string) error {
+	// A remote branch such as origin/feature is fetched if needed and tracked
+	track, err := prepareRemoteRef(projectRoot, createFromRef)
+	if err != nil {
+		return err
+	}
+
 	// Build git worktree add command
-	gitArgs := buildGitWorktreeArgs(branchName, worktreePath)
+	gitArgs := buildGitWorktreeArgs(branchName, worktreePath, createFromRef, track)
 
-	fmt.Fprintf(os.Stderr, "[dual] Creating git worktree...\n")
-	fmt.Fprintf(os.Stderr, "  Branch: %s\n", branchName)
+	logger.Info("Creating git worktree...")
+	logger.Detail("  Branch: %s", branchName)
 	if createFromRef != "" {
-		fmt.Fprintf(os.Stderr, "  From: %s\n", createFromRef)
+		logger.Detail("  From: %s", createFromRef)
 	}
-	fmt.Fprintf(os.Stderr, "  Path: %s\n", worktreePath)
+	logger.Detail("  Path: %s", worktreePath)
 
 	// Execute git worktree add
 	// #nosec G204 - Git command with controlled arguments
@@ -231,6 +424,13 @@ func createGitWorktree(projectRoot, branchName, worktreePath string) error {
 				"Or clone an existing repository:",
 				"  git clone <repository-url>",
 			)
+		case strings.Contains(stderrStr, "No space left on device"):
+			dualErr = dualErr.WithContext("Issue", "Disk full")
+			dualErr = dualErr.WithFixes(
+				fmt.Sprintf("The disk holding %s is full", filepath.Dir(worktreePath)),
+				"Free up disk space, then remove the partial worktree: git worktree prune",
+				"Or create the worktree on another disk: dual create <branch> --path <dir>",
+			)
 		case strings.Contains(stderrStr, "could not create directory"):
 			dualErr = dualErr.WithContext("Issue", "Permission denied")
 			dualErr = dualErr.WithFixes(
@@ -256,21 +456,95 @@ func createGitWorktree(projectRoot, branchName, worktreePath string) error {
 	return nil
 }
 
-// buildGitWorktreeArgs constructs git worktree add arguments
-func buildGitWorktreeArgs(branchName, worktreePath string) []string {
+// buildGitWorktreeArgs constructs git worktree add arguments. With track, the
+// new branch is set up to track fromRef, which must be a remote branch.
+func buildGitWorktreeArgs(branchName, worktreePath, fromRef string, track bool) []string {
 	gitArgs := []string{"worktree", "add"}
 
-	if createFromRef == "" {
+	switch {
+	case fromRef == "":
 		// Create new branch from current HEAD
 		gitArgs = append(gitArgs, "-b", branchName, worktreePath)
-	} else {
+	case track:
+		// Create new branch tracking a remote branch
+		gitArgs = append(gitArgs, "--track", "-b", branchName, worktreePath, fromRef)
+	default:
 		// Create new branch from specific ref
-		gitArgs = append(gitArgs, "-b", branchName, worktreePath, createFromRef)
+		gitArgs = append(gitArgs, "-b", branchName, worktreePath, fromRef)
 	}
 
 	return gitArgs
 }
 
+// prepareRemoteRef reports whether ref names a branch on a remote, such as
+// origin/feature, fetching it first if it is not known locally yet. Local
+// branches, tags and commits are left alone.
+func prepareRemoteRef(projectRoot, ref string) (bool, error) {
+	if ref == "" {
+		return false, nil
+	}
+
+	remote, branch, ok := splitRemoteRef(ref, gitRemotes(projectRoot))
+	if !ok {
+		return false, nil
+	}
+
+	// A local branch of the same name wins, as it does for git itself
+	if gitRefExists(projectRoot, "refs/heads/"+ref) {
+		return false, nil
+	}
+
+	if !gitRefExists(projectRoot, "refs/remotes/"+remote+"/"+branch) {
+		logger.Info("Fetching %s from %s...", branch, remote)
+
+		// #nosec G204 - Git command with controlled arguments
+		cmd := exec.Command("git", "fetch", remote, branch)
+		cmd.Dir = projectRoot
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return false, fmt.Errorf("failed to fetch %s from %s: %w\n%s\nHint: Check the branch exists on the remote: git ls-remote --heads %s %s", branch, remote, err, strings.TrimSpace(string(output)), remote, branch)
+		}
+	}
+
+	return true, nil
+}
+
+// splitRemoteRef splits a ref such as origin/feature/x into the remote and
+// the branch on it, when the part before the first slash is one of remotes.
+// A refs/remotes/ prefix is accepted too.
+func splitRemoteRef(ref string, remotes []string) (string, string, bool) {
+	ref = strings.TrimPrefix(ref, "refs/remotes/")
+	remote, branch, found := strings.Cut(ref, "/")
+	if !found || branch == "" {
+		return "", "", false
+	}
+	for _, name := range remotes {
+		if name == remote {
+			return remote, branch, true
+		}
+	}
+	return "", "", false
+}
+
+// gitRemotes returns the names of the repository's remotes
+func gitRemotes(dir string) []string {
+	// #nosec G204 - Git command with controlled arguments
+	cmd := exec.Command("git", "remote")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(output))
+}
+
+// gitRefExists reports whether a fully qualified ref exists in dir's repository
+func gitRefExists(dir, ref string) bool {
+	// #nosec G204 - Git command with controlled arguments
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", ref)
+	cmd.Dir = dir
+	return cmd.Run() == nil
+}
+
 // registerContext creates and saves context in registry
 func registerContext(reg *registry.Registry, projectIdentifier, branchName, worktreePath, projectRoot string) error {
 	// Create context in registry
@@ -291,8 +565,64 @@ func registerContext(reg *registry.Registry, projectIdentifier, branchName, work
 	return nil
 }
 
-// executeHooksAndApplyEnv runs hooks and applies environment overrides
-func executeHooksAndApplyEnv(cfg *config.Config, reg *registry.Registry, projectRoot, projectIdentifier, branchName, worktreePath string) {
+// writeContextFile writes a .dual-context file naming the context into the
+// worktree root and adds it to the repository's info/exclude file, unless git
+// already ignores it, so it never shows up as untracked
+func writeContextFile(worktreePath, contextName string) error {
+	contextFile := filepath.Join(worktreePath, context.DualContextFile)
+	// #nosec G306 - the context name is not a secret
+	if err := os.WriteFile(contextFile, []byte(contextName+"\n"), 0o644); err != nil {
+		return err
+	}
+
+	// #nosec G204 - Git command with controlled arguments
+	checkCmd := exec.Command("git", "check-ignore", "-q", context.DualContextFile)
+	checkCmd.Dir = worktreePath
+	if checkCmd.Run() == nil {
+		return nil
+	}
+
+	// info/exclude lives in the main repository's git dir and is shared by
+	// all of its worktrees
+	// #nosec G204 - Git command with controlled arguments
+	pathCmd := exec.Command("git", "rev-parse", "--git-path", "info/exclude")
+	pathCmd.Dir = worktreePath
+	output, err := pathCmd.Output()
+	if err != nil {
+		return fmt.Errorf("failed to locate info/exclude: %w", err)
+	}
+	excludePath := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(excludePath) {
+		excludePath = filepath.Join(worktreePath, excludePath)
+	}
+
+	// #nosec G304 - excludePath comes from git
+	data, err := os.ReadFile(excludePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", excludePath, err)
+	}
+	var b strings.Builder
+	b.Write(data)
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		b.WriteString("\n")
+	}
+	b.WriteString("/" + context.DualContextFile + "\n")
+
+	if err := os.MkdirAll(filepath.Dir(excludePath), 0o750); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(excludePath), err)
+	}
+	// #nosec G306 - info/exclude is a plain git config file
+	if err := os.WriteFile(excludePath, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to update %s: %w", excludePath, err)
+	}
+	logger.Debug("Added /%s to %s", context.DualContextFile, excludePath)
+	return nil
+}
+
+// executeHooksAndApplyEnv runs hooks and applies environment overrides.
+// A hook failure is handled according to the configured onHookFailure policy;
+// an error is returned for the abort and rollback policies.
+func executeHooksAndApplyEnv(cfg *config.Config, reg *registry.Registry, projectRoot, projectIdentifier, branchName, worktreePath string) error {
 	// Prepare hook context
 	hookCtx := hooks.HookContext{
 		Event:       hooks.PostWorktreeCreate,
@@ -307,13 +637,39 @@ func executeHooksAndApplyEnv(cfg *config.Config, reg *registry.Registry, project
 	// Run postWorktreeCreate hooks and capture env overrides
 	envOverrides, err := hookMgr.Execute(hooks.PostWorktreeCreate, hookCtx)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "[dual] Warning: postWorktreeCreate hook failed: %v\n", err)
-		fmt.Fprintf(os.Stderr, "[dual] Worktree created but hooks failed. You may need to run setup manually.\n")
-		return
+		return handleHookFailure(cfg, reg, projectRoot, projectIdentifier, branchName, worktreePath, err)
 	}
 
 	// Apply environment overrides
 	applyEnvOverrides(cfg, reg, projectIdentifier, branchName, worktreePath, envOverrides)
+	return nil
+}
+
+// handleHookFailure applies the onHookFailure policy after a postWorktreeCreate hook failed
+func handleHookFailure(cfg *config.Config, reg *registry.Registry, projectRoot, projectIdentifier, branchName, worktreePath string, hookErr error) error {
+	switch cfg.GetHookFailurePolicy() {
+	case config.HookFailureAbort:
+		logger.Info("Worktree kept at %s for inspection", worktreePath)
+		return fmt.Errorf("postWorktreeCreate hook failed: %w\nHint: Fix the hook and run it manually, or delete the worktree with 'dual delete %s'", hookErr, branchName)
+
+	case config.HookFailureRollback:
+		logger.Info("postWorktreeCreate hook failed, rolling back context %s", branchName)
+		// Same cleanup as registerContext: drop the context, then the worktree
+		if err := reg.DeleteContext(projectIdentifier, branchName); err != nil {
+			logger.Warn("failed to delete context: %v", err)
+		} else if err := reg.SaveRegistry(); err != nil {
+			logger.Warn("failed to save registry: %v", err)
+		}
+		if err := removeGitWorktree(worktreePath, projectRoot); err != nil {
+			logger.Warn("failed to remove worktree %s: %v", worktreePath, err)
+		}
+		return fmt.Errorf("postWorktreeCreate hook failed: %w\nHint: The worktree was removed; branch %q still exists", hookErr, branchName)
+
+	default:
+		logger.Warn("postWorktreeCreate hook failed: %v", hookErr)
+		logger.Info("Worktree created but hooks failed. You may need to run setup manually.")
+		return nil
+	}
 }
 
 // applyEnvOverrides applies environment overrides to registry and generates env files
@@ -325,7 +681,7 @@ func applyEnvOverrides(cfg *config.Config, reg *registry.Registry, projectIdenti
 	// Apply global overrides (serviceName = "")
 	for key, value := range envOverrides.Global {
 		if err := reg.SetEnvOverrideForService(projectIdentifier, branchName, key, value, ""); err != nil {
-			fmt.Fprintf(os.Stderr, "[dual] Warning: failed to set global env override %s: %v\n", key, err)
+			logger.Warn("failed to set global env override %s: %v", key, err)
 		}
 	}
 
@@ -333,29 +689,62 @@ func applyEnvOverrides(cfg *config.Config, reg *registry.Registry, projectIdenti
 	for serviceName, serviceVars := range envOverrides.Services {
 		for key, value := range serviceVars {
 			if err := reg.SetEnvOverrideForService(projectIdentifier, branchName, key, value, serviceName); err != nil {
-				fmt.Fprintf(os.Stderr, "[dual] Warning: failed to set service env override %s.%s: %v\n", serviceName, key, err)
+				logger.Warn("failed to set service env override %s.%s: %v", serviceName, key, err)
 			}
 		}
 	}
 
 	// Save registry with new overrides
 	if err := reg.SaveRegistry(); err != nil {
-		fmt.Fprintf(os.Stderr, "[dual] Warning: failed to save registry with env overrides: %v\n", err)
+		logger.Warn("failed to save registry with env overrides: %v", err)
 	}
 
 	// Generate service env files (overrides go to parent repo, not worktree)
 	if err := env.GenerateServiceEnvFiles(cfg, reg, projectIdentifier, projectIdentifier, branchName); err != nil {
-		fmt.Fprintf(os.Stderr, "[dual] Warning: failed to generate service env files: %v\n", err)
+		logger.Warn("failed to generate service env files: %v", err)
+	}
+}
+
+// contextEnvOverrides returns a copy of the layered env overrides of an
+// existing context, in the form applyEnvOverrides accepts
+func contextEnvOverrides(reg *registry.Registry, projectIdentifier, contextName string) (*hooks.EnvOverrides, error) {
+	ctx, err := reg.GetContext(projectIdentifier, contextName)
+	if err != nil {
+		if errors.Is(err, registry.ErrContextNotFound) || errors.Is(err, registry.ErrProjectNotFound) {
+			return nil, fmt.Errorf("context %q not found\nHint: Run 'dual list' to see available contexts", contextName)
+		}
+		return nil, fmt.Errorf("failed to read context %q: %w", contextName, err)
+	}
+
+	// GetEnvOverrides merges unmigrated legacy overrides into the global layer
+	overrides := &hooks.EnvOverrides{
+		Global:   ctx.GetEnvOverrides(""),
+		Services: make(map[string]map[string]string),
+	}
+	if ctx.EnvOverridesV2 == nil {
+		return overrides, nil
+	}
+	for service, vars := range ctx.EnvOverridesV2.Services {
+		if len(vars) == 0 {
+			continue
+		}
+		overrides.Services[service] = make(map[string]string, len(vars))
+		for key, value := range vars {
+			overrides.Services[service][key] = value
+		}
 	}
+	return overrides, nil
 }
 
 // printSuccess prints success message
 func printSuccess(branchName, worktreePath string) {
-	fmt.Fprintf(os.Stderr, "\n[dual] Worktree created successfully!\n")
-	fmt.Fprintf(os.Stderr, "  Context: %s\n", branchName)
-	fmt.Fprintf(os.Stderr, "  Path: %s\n", worktreePath)
-	fmt.Fprintf(os.Stderr, "\nTo switch to this worktree:\n")
-	fmt.Fprintf(os.Stderr, "  cd %s\n", worktreePath)
+	logger.Detail("")
+	logger.Info("Worktree created successfully!")
+	logger.Detail("  Context: %s", branchName)
+	logger.Detail("  Path: %s", worktreePath)
+	logger.Detail("")
+	logger.Detail("To switch to this worktree:")
+	logger.Detail("  cd %s", worktreePath)
 }
 
 // removeGitWorktree removes a git worktree
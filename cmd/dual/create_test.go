@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lightfastai/dual/internal/registry"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitRemoteRef(t *testing.T) {
+	remotes := []string{"origin", "upstream"}
+
+	tests := []struct {
+		name       string
+		ref        string
+		wantRemote string
+		wantBranch string
+		wantOK     bool
+	}{
+		{name: "remote branch", ref: "origin/feature", wantRemote: "origin", wantBranch: "feature", wantOK: true},
+		{name: "remote branch with slashes", ref: "upstream/feature/auth", wantRemote: "upstream", wantBranch: "feature/auth", wantOK: true},
+		{name: "fully qualified remote ref", ref: "refs/remotes/origin/feature", wantRemote: "origin", wantBranch: "feature", wantOK: true},
+		{name: "local branch", ref: "develop"},
+		{name: "local branch with slashes", ref: "feature/auth"},
+		{name: "commit sha", ref: "3f2c9a1b7e4d"},
+		{name: "remote name only", ref: "origin/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote, branch, ok := splitRemoteRef(tt.ref, remotes)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantRemote, remote)
+			assert.Equal(t, tt.wantBranch, branch)
+		})
+	}
+}
+
+func TestBuildGitWorktreeArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		fromRef string
+		track   bool
+		want    []string
+	}{
+		{name: "current HEAD", want: []string{"worktree", "add", "-b", "feature", "/wt/feature"}},
+		{name: "local branch", fromRef: "develop", want: []string{"worktree", "add", "-b", "feature", "/wt/feature", "develop"}},
+		{name: "commit sha", fromRef: "3f2c9a1b7e4d", want: []string{"worktree", "add", "-b", "feature", "/wt/feature", "3f2c9a1b7e4d"}},
+		{name: "remote branch", fromRef: "origin/feature", track: true, want: []string{"worktree", "add", "--track", "-b", "feature", "/wt/feature", "origin/feature"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildGitWorktreeArgs("feature", "/wt/feature", tt.fromRef, tt.track))
+		})
+	}
+}
+
+func TestContextEnvOverrides(t *testing.T) {
+	reg := &registry.Registry{
+		Projects: map[string]registry.Project{
+			"/project": {Contexts: map[string]registry.Context{
+				"legacy": {
+					EnvOverrides: map[string]string{"DATABASE_URL": "postgres://legacy", "DEBUG": "false"},
+					EnvOverridesV2: &registry.ContextEnvOverrides{
+						Global:   map[string]string{"DEBUG": "true"},
+						Services: map[string]map[string]string{"api": {"PORT": "4001"}, "web": {}},
+					},
+				},
+			}},
+		},
+	}
+
+	overrides, err := contextEnvOverrides(reg, "/project", "legacy")
+	require.NoError(t, err)
+	// Legacy overrides are the lowest-priority global layer
+	assert.Equal(t, map[string]string{"DATABASE_URL": "postgres://legacy", "DEBUG": "true"}, overrides.Global)
+	assert.Equal(t, map[string]map[string]string{"api": {"PORT": "4001"}}, overrides.Services)
+
+	_, err = contextEnvOverrides(reg, "/project", "missing")
+	assert.ErrorContains(t, err, `context "missing" not found`)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -12,11 +13,15 @@ import (
 	"github.com/lightfastai/dual/internal/context"
 	"github.com/lightfastai/dual/internal/env"
 	"github.com/lightfastai/dual/internal/hooks"
+	"github.com/lightfastai/dual/internal/logger"
 	"github.com/lightfastai/dual/internal/registry"
 	"github.com/spf13/cobra"
 )
 
-var deleteForce bool
+var (
+	deleteForce bool
+	deleteJSON  bool
+)
 
 var deleteCmd = &cobra.Command{
 	Use:   "delete <context-name>",
@@ -32,15 +37,23 @@ This command:
 By default, prompts for confirmation before deleting.
 Cannot delete the currently active context.
 
+With --json, what would be deleted is written to stdout as JSON instead: the
+context name and path, whether the worktree still exists, and how many env
+overrides the context holds. Nothing is deleted unless --force is also given,
+so automation can inspect the impact first and then delete non-interactively.
+
 Examples:
   dual delete feature-auth         # Delete worktree with confirmation
-  dual delete feature-api --force  # Delete without confirmation`,
+  dual delete feature-api --force  # Delete without confirmation
+  dual delete feature-api --json   # Show what would be deleted, as JSON
+  dual delete feature-api --json --force   # Report as JSON, then delete`,
 	Args: cobra.ExactArgs(1),
 	RunE: runDelete,
 }
 
 func init() {
 	deleteCmd.Flags().BoolVarP(&deleteForce, "force", "f", false, "Skip confirmation prompt")
+	deleteCmd.Flags().BoolVar(&deleteJSON, "json", false, "Write what would be deleted as JSON; only deletes with --force")
 	rootCmd.AddCommand(deleteCmd)
 }
 
@@ -60,7 +73,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	}
 
 	// Detect current context
-	currentContext, err := context.DetectContext()
+	currentContext, err := context.DetectContextWithSource(cfg.GetContextSource())
 	if err != nil {
 		// Non-fatal: just can't check if deleting current context
 		currentContext = ""
@@ -88,9 +101,18 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	}
 
 	// Show what will be deleted
-	fmt.Fprintf(os.Stderr, "About to delete worktree:\n")
-	fmt.Fprintf(os.Stderr, "  Context: %s\n", contextName)
-	fmt.Fprintf(os.Stderr, "  Path: %s\n", ctx.Path)
+	if deleteJSON {
+		if err := outputDeleteImpactJSON(contextName, ctx); err != nil {
+			return err
+		}
+		if !deleteForce {
+			return nil
+		}
+	} else {
+		fmt.Fprintf(os.Stderr, "About to delete worktree:\n")
+		fmt.Fprintf(os.Stderr, "  Context: %s\n", contextName)
+		fmt.Fprintf(os.Stderr, "  Path: %s\n", ctx.Path)
+	}
 
 	// Confirm deletion unless --force
 	if !deleteForce {
@@ -103,7 +125,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 
 		response = strings.ToLower(strings.TrimSpace(response))
 		if response != "y" && response != "yes" {
-			fmt.Fprintf(os.Stderr, "[dual] Deletion cancelled\n")
+			logger.Info("Deletion cancelled")
 			return nil
 		}
 	}
@@ -129,7 +151,7 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	// Cleanup service env files before deleting context
 	// Use projectIdentifier to clean up from parent repo's .dual/ directory
 	if err := env.CleanupServiceEnvFiles(projectIdentifier); err != nil {
-		fmt.Fprintf(os.Stderr, "[dual] Warning: failed to cleanup service env files: %v\n", err)
+		logger.Warn("failed to cleanup service env files: %v", err)
 		// Don't fail the command - continue with deletion
 	}
 
@@ -143,24 +165,28 @@ func runDelete(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to save registry: %w", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "[dual] Deleted context from registry\n")
+	logger.Info("Deleted context from registry")
 
 	// Remove git worktree
 	if ctx.Path != "" {
-		fmt.Fprintf(os.Stderr, "[dual] Removing git worktree...\n")
+		logger.Info("Removing git worktree...")
 
 		// #nosec G204 - Git command with controlled arguments
 		gitCmd := exec.Command("git", "worktree", "remove", ctx.Path, "--force")
 		gitCmd.Dir = projectRoot
 		gitCmd.Stdout = os.Stdout
+		if deleteJSON {
+			// Keep stdout to the JSON report
+			gitCmd.Stdout = os.Stderr
+		}
 		gitCmd.Stderr = os.Stderr
 
 		if err := gitCmd.Run(); err != nil {
-			fmt.Fprintf(os.Stderr, "[dual] Warning: failed to remove git worktree: %v\n", err)
-			fmt.Fprintf(os.Stderr, "[dual] You may need to remove it manually: %s\n", ctx.Path)
+			logger.Warn("failed to remove git worktree: %v", err)
+			logger.Info("You may need to remove it manually: %s", ctx.Path)
 			// Continue anyway - context is already deleted from registry
 		} else {
-			fmt.Fprintf(os.Stderr, "[dual] Removed git worktree\n")
+			logger.Info("Removed git worktree")
 		}
 	}
 
@@ -168,8 +194,60 @@ func runDelete(cmd *cobra.Command, args []string) error {
 	hookCtx.Event = hooks.PostWorktreeDelete
 	hookMgr.ExecuteWithFallback(hooks.PostWorktreeDelete, hookCtx)
 
-	fmt.Fprintf(os.Stderr, "\n[dual] Worktree deleted successfully!\n")
-	fmt.Fprintf(os.Stderr, "  Context: %s\n", contextName)
+	logger.Detail("")
+	logger.Success("Worktree deleted successfully!")
+	logger.Detail("  Context: %s", contextName)
+
+	return nil
+}
+
+// outputDeleteImpactJSON writes what deleting a context removes for --json
+func outputDeleteImpactJSON(name string, ctx *registry.Context) error {
+	type overridesJSON struct {
+		Count    int            `json:"count"`
+		Global   int            `json:"global"`
+		Services map[string]int `json:"services"`
+	}
+	type deleteImpactJSON struct {
+		Name           string        `json:"name"`
+		Path           string        `json:"path,omitempty"`
+		Created        string        `json:"created"`
+		WorktreeExists bool          `json:"worktreeExists"`
+		Overrides      overridesJSON `json:"overrides"`
+	}
+
+	// Legacy overrides count towards the global layer, as in 'dual context info'
+	global, services := contextOverrideLayers(ctx)
+	overrides := overridesJSON{
+		Count:    len(global),
+		Global:   len(global),
+		Services: make(map[string]int, len(services)),
+	}
+	for service, serviceOverrides := range services {
+		overrides.Services[service] = len(serviceOverrides)
+		overrides.Count += len(serviceOverrides)
+	}
+
+	worktreeExists := false
+	if ctx.Path != "" {
+		if info, err := os.Stat(ctx.Path); err == nil && info.IsDir() {
+			worktreeExists = true
+		}
+	}
+
+	output := deleteImpactJSON{
+		Name:           name,
+		Path:           ctx.Path,
+		Created:        ctx.Created.Format("2006-01-02T15:04:05Z"),
+		WorktreeExists: worktreeExists,
+		Overrides:      overrides,
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
 
+	fmt.Println(string(data))
 	return nil
 }
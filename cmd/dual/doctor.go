@@ -33,7 +33,12 @@ The doctor command performs the following checks:
   - Port conflict detection
   - Worktree validation
   - Orphaned context cleanup
+  - Untracked git worktrees (registered with --fix)
+  - Legacy env override migration
+  - Service env file drift (regenerated with --fix)
   - File permissions check
+  - Disk space for new worktrees
+  - .dual-context file (flags a name that is not a registered context)
 
 Exit codes:
   0 - All checks passed
@@ -62,7 +67,7 @@ func init() {
 	rootCmd.AddCommand(doctorCmd)
 }
 
-//nolint:gocyclo // Health check function naturally has high complexity due to 10 sequential checks
+//nolint:gocyclo // Health check function naturally has high complexity due to its many sequential checks
 func runDoctor(cmd *cobra.Command, args []string) error {
 	// Initialize logger
 	logger.Init(doctorVerbose, false)
@@ -137,7 +142,11 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		logger.Verbose("Checking current context...")
 	}
 
-	currentContext, err := context.DetectContext()
+	contextSource := context.SourceBranch
+	if ctx.Config != nil {
+		contextSource = ctx.Config.GetContextSource()
+	}
+	currentContext, err := context.DetectContextWithSource(contextSource)
 	if err != nil {
 		logger.Verbose("Warning: failed to detect context: %v", err)
 		ctx.CurrentContext = ""
@@ -170,18 +179,48 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	}
 	result.AddCheck(health.CheckOrphanedContexts(ctx))
 
-	// === Check 9: Permissions ===
+	// === Check 9: Untracked Worktrees ===
+	if doctorVerbose {
+		logger.Verbose("Checking git worktrees against contexts...")
+	}
+	result.AddCheck(health.CheckUntrackedWorktrees(ctx))
+
+	// === Check 10: Legacy Env Overrides ===
+	if doctorVerbose {
+		logger.Verbose("Checking for legacy env overrides...")
+	}
+	result.AddCheck(health.CheckLegacyEnvOverrides(ctx))
+
+	// === Check 11: Service Env Files ===
+	if doctorVerbose {
+		logger.Verbose("Checking service env files...")
+	}
+	result.AddCheck(health.CheckServiceEnvFiles(ctx))
+
+	// === Check 12: Permissions ===
 	if doctorVerbose {
 		logger.Verbose("Checking file permissions...")
 	}
 	result.AddCheck(health.CheckPermissions(ctx))
 
-	// === Check 10: Service Detection ===
+	// === Check 13: Service Detection ===
 	if doctorVerbose {
 		logger.Verbose("Checking service detection...")
 	}
 	result.AddCheck(health.CheckServiceDetection(ctx))
 
+	// === Check 14: Disk Space ===
+	if doctorVerbose {
+		logger.Verbose("Checking free disk space for worktrees...")
+	}
+	result.AddCheck(health.CheckDiskSpace(ctx))
+
+	// === Check 15: Context File ===
+	if doctorVerbose {
+		logger.Verbose("Checking .dual-context file...")
+	}
+	result.AddCheck(health.CheckContextFile(ctx))
+
 	// Close registry before exiting
 	if ctx.Registry != nil {
 		if err := ctx.Registry.Close(); err != nil {
@@ -1,32 +1,101 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"unicode/utf16"
 
 	"github.com/lightfastai/dual/internal/config"
 	"github.com/lightfastai/dual/internal/context"
 	"github.com/lightfastai/dual/internal/env"
 	"github.com/lightfastai/dual/internal/logger"
 	"github.com/lightfastai/dual/internal/registry"
+	"github.com/lightfastai/dual/internal/service"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 var (
 	// Flags for env commands
-	envShowValues       bool
-	envShowBaseOnly     bool
-	envShowOverrideOnly bool
-	envShowJSON         bool
-	envExportFormat     string
-	envServiceFlag      string // --service flag for service-specific overrides
-	envVerbose          bool
-	envDebug            bool
+	envShowValues         bool
+	envShowBaseOnly       bool
+	envShowExportUnset    bool
+	envShowOverrideOnly   bool
+	envShowTree           bool
+	envShowJSON           bool
+	envShowDiffBase       bool
+	envSetIfNotExists     bool
+	envSetNote            string
+	envSetJSON            bool
+	envSetFromJSONFile    string
+	envSetJSONSeparator   string
+	envNoGenerate         bool
+	envExportFormat       string
+	envExportOutput       string
+	envExportForce        bool
+	envExportSort         string
+	envExportName         string
+	envExportWatch        bool
+	envExportKeyTransform string
+	envExportPrefixes     []string
+	envExportMatches      []string
+	envExportOverrideOnly bool
+	envExportMergeFile    string
+	envExportFilePriority bool
+	envExportOnlySecrets  bool
+	envExportNoSecrets    bool
+	envExportAll          bool
+	envExportDir          string
+	envExportTemplate     string
+	envExportTemplateDef  string
+	envExportGrouped      bool
+	envExportProfile      string
+	envCheckStrict        bool
+	envCheckUndefined     bool // --check-undefined flag for export and check
+	envDiffBase           string
+	envDiffJSON           bool
+	envServiceFlag        string // --service flag for service-specific overrides
+	envContextFlag        string // --context flag for read-only commands
+	envEnvironmentFlag    string // --env flag for environment overlays (e.g. staging)
+	envDryRun             bool   // --dry-run flag for set, unset and remap
+	envVerbose            bool
+	envDebug              bool
+	// Flags for import-shell command
+	envImportShellKeys   []string
+	envImportShellPrefix string
+	envImportShellDryRun bool
+	// Flags for import-example command
+	envImportExampleDefaults bool
 )
 
+// standardShellVars are variables set by the shell or OS that are never
+// captured by 'dual env import-shell --prefix' (they can still be listed
+// explicitly with --keys)
+var standardShellVars = map[string]bool{
+	"PATH":    true,
+	"HOME":    true,
+	"SHELL":   true,
+	"USER":    true,
+	"LOGNAME": true,
+	"PWD":     true,
+	"OLDPWD":  true,
+	"SHLVL":   true,
+	"TERM":    true,
+	"TMPDIR":  true,
+	"LANG":    true,
+	"_":       true,
+}
+
 // getServiceNames returns a sorted list of service names from config
 func getServiceNames(cfg *config.Config) []string {
 	names := make([]string, 0, len(cfg.Services))
@@ -37,6 +106,52 @@ func getServiceNames(cfg *config.Config) []string {
 	return names
 }
 
+// applyServiceEnvVar falls back to DUAL_SERVICE when --service was not given.
+// Only read-only env commands use it; set, unset and import-shell require an
+// explicit --service so writes are never scoped by an ambient variable.
+// Service aliases are resolved to the canonical service name.
+func applyServiceEnvVar(cfg *config.Config) error {
+	if envServiceFlag != "" {
+		if canonical, exists := cfg.ResolveServiceName(envServiceFlag); exists {
+			envServiceFlag = canonical
+		}
+		return nil
+	}
+	name := os.Getenv(service.EnvVar)
+	if name == "" {
+		return nil
+	}
+	canonical, exists := cfg.ResolveServiceName(name)
+	if !exists {
+		return fmt.Errorf("service %q from %s not found in config\nAvailable services: %v", name, service.EnvVar, getServiceNames(cfg))
+	}
+	envServiceFlag = canonical
+	return nil
+}
+
+// resolveServiceFlag validates --service, if given, and replaces an alias
+// with the canonical service name
+func resolveServiceFlag(cfg *config.Config) error {
+	if envServiceFlag == "" {
+		return nil
+	}
+	canonical, exists := cfg.ResolveServiceName(envServiceFlag)
+	if !exists {
+		return fmt.Errorf("service %q not found in config\nAvailable services: %v", envServiceFlag, getServiceNames(cfg))
+	}
+	envServiceFlag = canonical
+	return nil
+}
+
+// envContextName returns the context named by --context, or else the detected
+// context. A --context is checked against the registry by the caller.
+func envContextName(cfg *config.Config) (string, error) {
+	if envContextFlag != "" {
+		return envContextFlag, nil
+	}
+	return context.DetectContextWithSource(cfg.GetContextSource())
+}
+
 var envCmd = &cobra.Command{
 	Use:   "env",
 	Short: "Manage context-specific environment variables",
@@ -47,7 +162,15 @@ Environment variables are layered in the following priority (highest to lowest):
   2. Service-specific environment
   3. Base environment file
 
-Use 'dual env set' to override variables for the current context.`,
+Use 'dual env set' to override variables for the current context.
+
+Overrides set with --env <name> form an overlay for that environment (e.g.
+staging) on top of the context overrides. 'dual env show' and 'dual env export'
+apply it when given the same --env; everything else, including 'dual run',
+uses the default environment without overlays.
+
+'dual env show', 'export' and 'check' use $DUAL_SERVICE as the service when
+--service is not given. Commands that write overrides always require --service.`,
 	RunE: runEnvShow, // Default to show command
 }
 
@@ -63,8 +186,30 @@ Examples:
   dual env show              # Show summary
   dual env show --values     # Show all variable values
   dual env show --base-only  # Show only base variables
+  dual env show --export-unset  # Show base variables no layer overrides
   dual env show --overrides-only  # Show only overrides
-  dual env show --json       # Output as JSON`,
+  dual env show --tree       # Show every layer's value per variable
+  dual env show --diff-base  # Show what this context changes relative to base
+  dual env show --json       # Output as JSON
+
+The --tree view lists, for each variable, the value found in each layer and
+marks the one that wins. "runtime" is the value inherited from the current
+shell; 'dual run' replaces it with any value set in base, service or override.
+
+The --diff-base view lists only variables defined in the base file that the
+service or override layer replaces, with the base value next to the effective
+one. Combined with --json it outputs that list as JSON.
+
+The --export-unset view lists the base variables that no service or override
+layer sets: the defaults this context silently inherits. Combined with --json
+it outputs them as JSON; the full --json output includes them as
+"inheritedBase".
+
+Use --context to inspect another context's environment without switching
+branches, e.g. 'dual env show --context feature-x --values'.
+
+Use --env to include an environment overlay set with 'dual env set --env',
+e.g. 'dual env show --env staging --values'.`,
 	RunE: runEnvShow,
 }
 
@@ -78,11 +223,58 @@ The override takes precedence over service and base environment files.
 
 Use --service to set a service-specific override that only applies to that service.
 
+Use --if-not-exists in provisioning scripts to seed defaults without clobbering
+values a user has already set. Only an override in the target layer (the
+--service overrides, or else the global ones) counts as set.
+
+Use --note to record why the override is set, so teammates see it in
+'dual env show'. Without --note, an existing note is kept; --note "" removes it.
+
+Use --json for values holding JSON-encoded config: the value must parse as JSON
+and is stored exactly as given, so a typo fails here instead of at startup.
+
+Use --from-json-file instead of <key> <value> to set one override per leaf of a
+JSON object. Nested keys are joined with --json-separator (default "__"), so
+{"DB": {"HOST": "x"}} sets DB__HOST=x. Strings are stored as-is; numbers,
+booleans and arrays as their JSON text; null as an empty value. The other flags
+apply to every key.
+
+Use --env to set the override in an environment overlay instead, e.g. staging.
+It applies to every service, wins over the other overrides, and is only used
+by 'dual env show' and 'dual env export' with the same --env. Overlays do not
+change the generated service env files and are not covered by
+'dual env rollback'. --env cannot be combined with --service or --note.
+
+Use --dry-run to preview a change: it lists the overrides that would be added,
+changed or removed and the service env files that would be created or
+rewritten, without saving anything. This is most useful with --from-json-file.
+
+When setting many variables in a script, pass --no-generate to skip rewriting
+the service env files each time and run 'dual env remap' once at the end. The
+registry is still updated immediately ('dual env show' and 'dual env export'
+see the change), but 'dual run' reads the generated files and only picks it
+up after the remap.
+
 Examples:
   dual env set DATABASE_URL "mysql://localhost/mydb"
   dual env set DEBUG "true"
-  dual env set --service api DATABASE_URL "mysql://localhost/api_db"`,
-	Args: cobra.ExactArgs(2),
+  dual env set --service api DATABASE_URL "mysql://localhost/api_db"
+  dual env set --env staging API_URL "https://staging.example.com"
+  dual env set --if-not-exists LOG_LEVEL "info"
+  dual env set DEBUG true --note "investigating #123"
+  dual env set --no-generate A 1 && dual env set --no-generate B 2 && dual env remap
+  dual env set --json FEATURE_FLAGS '{"beta": true}'
+  dual env set --from-json-file config.json --service api
+  dual env set --from-json-file config.json --dry-run`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if envSetFromJSONFile != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("--from-json-file takes no <key> <value> arguments")
+			}
+			return nil
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
 	RunE: runEnvSet,
 }
 
@@ -93,16 +285,43 @@ var envUnsetCmd = &cobra.Command{
 
 If the variable exists in the base environment file, it will show the fallback value.
 
-Use --service to remove a service-specific override.
+Use --service to remove a service-specific override, or --env to remove one
+from an environment overlay.
+
+Use --no-generate to defer rewriting the service env files to a later
+'dual env remap', and --dry-run to preview the change, as with 'dual env set'.
 
 Examples:
   dual env unset DATABASE_URL
   dual env unset DEBUG
-  dual env unset --service api DATABASE_URL`,
+  dual env unset --service api DATABASE_URL
+  dual env unset --env staging API_URL
+  dual env unset --dry-run DATABASE_URL
+  dual env unset --no-generate DEBUG && dual env remap`,
 	Args: cobra.ExactArgs(1),
 	RunE: runEnvUnset,
 }
 
+var envRollbackCmd = &cobra.Command{
+	Use:   "rollback <key>",
+	Short: "Restore an override's previous value",
+	Long: `Undo the last 'dual env set' or 'dual env unset' of a variable in the
+current context.
+
+The override gets back the value it had before that change, or is removed if
+it was not set before. Running rollback again goes one change further back.
+Each context keeps its last 50 changes.
+
+Use --service to roll back a service-specific override.
+
+Examples:
+  dual env set DATABASE_URL postgres://typo   # oops
+  dual env rollback DATABASE_URL              # previous value is back
+  dual env rollback --service api PORT`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEnvRollback,
+}
+
 var envExportCmd = &cobra.Command{
 	Use:   "export",
 	Short: "Export merged environment to stdout",
@@ -110,11 +329,92 @@ var envExportCmd = &cobra.Command{
 
 The output includes all layers merged together (base, service, overrides).
 
+Secret references are resolved at export time, so only references are ever
+stored in the registry and generated env files:
+  op://vault/item/field          # resolved with 'op read'
+  ${vault:secret/app#password}   # resolved with 'vault kv get -field=password'
+Additional schemes can be configured under env.secrets in dual.config.yml.
+
+Keys are sorted by name by default. Use --sort=off to keep the order in which
+variables were loaded (base file, then service files, then overrides), which
+matters when values reference earlier variables.
+
+--prefix and --match limit the export to matching variable names; a variable
+is kept if it matches any of them. Filtering happens before secret references
+are resolved, so secrets outside the filter are never fetched or written.
+
+--overrides-only exports just the variables that are missing from the base
+env or differ from it, for a minimal per-context file.
+
+--all writes every service's environment to its own file in --dir
+(<service>.env for dotenv, .json, .sh or .yaml for the other formats),
+e.g. for docker-compose env_file entries or a process manager in CI.
+
+--merge-file overlays the export onto an existing dotenv file instead of
+replacing it. Keys that only the file defines are kept, so hand-maintained
+local variables survive regeneration. dual's values win for keys in both,
+unless --file-priority is given. When --output names the same file, it is
+rewritten without needing --force.
+
+--context exports another context's environment instead of the current one.
+
+--check-undefined fails, listing them, if the env files being exported
+reference variables that are not defined earlier in the same file. godotenv
+expands those to nothing, so API_URL=${BASE_URL}/api would export "/api".
+
+--env applies an environment overlay set with 'dual env set --env' on top of
+the context overrides, so the merge order is base, service, overrides, overlay.
+
+--profile applies a named override set from the profiles section of
+dual.config.yml on top of the context overrides (and --env overlay) for this
+export only. Profiles are never saved to the registry.
+
+--template renders a Go text/template file instead of a --format, with the
+merged environment available as .Env, e.g. {"db": "{{ .Env.DATABASE_URL }}"}.
+Referencing a variable that is not set is an error, unless --template-default
+gives the value to use for it.
+
+--format=properties writes a Java .properties file, e.g. for Spring's
+application.properties, escaping values as java.util.Properties does. Add
+--key-transform=dotted to turn DATABASE_URL into database.url.
+
+--grouped, with --format=json, outputs every layer next to the merged result
+instead of only the merged variables:
+{"base": {...}, "serviceBase": {...}, "service": {...}, "overrides": {...},
+"merged": {...}}. Layer values are shown as written; secret references are only
+resolved in "merged".
+
+--only-secrets and --exclude-secrets split the environment into secrets and
+everything else, e.g. to populate a secret store or to write a public env file
+that is safe to commit. A variable counts as a secret if its value is a secret
+reference or its name looks like a credential (*_KEY, *SECRET*, *PASSWORD*,
+*TOKEN*, ...).
+
 Examples:
   dual env export              # dotenv format
   dual env export --format=json    # JSON format
   dual env export --format=shell   # Shell export format
-  dual env export > .env.local     # Save to file`,
+  dual env export --format=json --grouped      # Every layer plus the merged result
+  dual env export --format=properties --key-transform=dotted -o application.properties   # Spring config
+  dual env export --format=envrc -o .envrc     # direnv .envrc with watch_file directives
+  dual env export --output .env.local          # Save to file atomically
+  dual env export --output .env.local --force  # Replace an existing file
+  dual env export --sort=off                   # Keep load order
+  dual env export --prefix NEXT_PUBLIC_        # Only NEXT_PUBLIC_* variables
+  dual env export --prefix VITE_ --match '*_URL'   # Prefix or glob (both repeatable)
+  dual env export --overrides-only -o .env.local   # Only what differs from the base env
+  dual env export --exclude-secrets -o .env        # Public config, safe to commit
+  dual env export --only-secrets -o .env.secret    # Secrets only
+  dual env export --all --dir .env.d               # One file per service
+  dual env export --merge-file .env.local -o .env.local   # Keep local-only variables
+  dual env export --format=k8s-configmap --name web-env   # Kubernetes ConfigMap
+  dual env export --format=k8s-secret --service api       # Kubernetes Secret (base64 values)
+  dual env export --context feature-x -o /tmp/feature-x.env   # Another context's environment
+  dual env export --env staging -o .env.staging               # Apply the staging overlay
+  dual env export --profile debug                             # Apply the debug profile
+  dual env export --check-undefined -o .env                   # Fail instead of exporting "/api"
+  dual env export --template config.json.tmpl -o config.json  # Render a config file
+  dual env export --template app.yaml.tmpl --template-default ""   # Unset variables render empty`,
 	RunE: runEnvExport,
 }
 
@@ -128,9 +428,31 @@ Checks:
   - All required variables are present
   - No conflicts or issues
 
+With --service, additionally checks that service's own environment:
+  - The service's env file exists (if one is configured)
+  - No variable is left empty once all layers are merged
+    (e.g. API_KEY= declared in a file but never given a value)
+
+With --strict, also checks the base file and every service's env files for
+circular variable expansion (e.g. A=${B} and B=${A}), which otherwise
+silently expands to empty or partial values.
+
+With --check-undefined, also checks the base file and every service's env
+files for references to undefined variables. godotenv only expands variables
+defined earlier in the same file, so API_URL=${BASE_URL}/api becomes "/api"
+when BASE_URL is set elsewhere or not at all.
+
+With --context, checks that context instead of the current one.
+
 Exit code:
   0 - Environment is valid
-  1 - Issues found`,
+  1 - Issues found
+
+Examples:
+  dual env check                 # Check project-wide configuration
+  dual env check --service api   # Also check the api service's environment
+  dual env check --strict        # Also detect circular variable expansion
+  dual env check --check-undefined   # Also detect references that expand to nothing`,
 	RunE: runEnvCheck,
 }
 
@@ -144,9 +466,18 @@ Shows variables that are:
   - Added (only in context2)
   - Removed (only in context1)
 
+With --base, compares both contexts against a common base context instead, to
+reconcile two branches. Each variable changed on either side is shown with its
+base value and both sides' values, classified as:
+  - Conflict (both changed it, differently)
+  - Changed in only one context
+  - Same change in both
+
 Examples:
   dual env diff main feature-auth
-  dual env diff feature-a feature-b`,
+  dual env diff feature-a feature-b
+  dual env diff --base main feature-a feature-b
+  dual env diff --base main feature-a feature-b --json`,
 	Args: cobra.ExactArgs(2),
 	RunE: runEnvDiff,
 }
@@ -161,13 +492,64 @@ registry state. This is useful if you've manually edited the registry or if the
 files are out of sync.
 
 The files are automatically generated when you use 'dual env set' or 'dual env unset',
-so you typically don't need to run this command manually.
+so you typically don't need to run this command manually, unless they were run
+with --no-generate.
+
+Use --dry-run to list the files that are missing or out of date without
+writing them.
 
 Examples:
-  dual env remap    # Regenerate all service env files`,
+  dual env remap              # Regenerate all service env files
+  dual env remap --dry-run    # Show which files would be rewritten`,
 	RunE: runEnvRemap,
 }
 
+var envImportShellCmd = &cobra.Command{
+	Use:   "import-shell",
+	Short: "Capture variables from the current shell as overrides",
+	Long: `Snapshot environment variables exported in the current shell into
+context overrides for the current context.
+
+To avoid capturing the entire environment, you must name the variables to
+import with --keys, or select them by prefix with --prefix. Standard shell
+variables (PATH, HOME, SHELL, ...) are never matched by --prefix.
+
+Use --service to store the values as service-specific overrides, and
+--dry-run to preview what would be imported without changing anything.
+--no-generate skips rewriting the service env files, as with 'dual env set'.
+
+Examples:
+  dual env import-shell --keys DATABASE_URL,REDIS_URL
+  dual env import-shell --prefix STRIPE_
+  dual env import-shell --prefix API_ --service api
+  dual env import-shell --prefix NEXT_PUBLIC_ --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runEnvImportShell,
+}
+
+var envImportExampleCmd = &cobra.Command{
+	Use:   "import-example <file>",
+	Short: "Seed missing overrides from a .env.example template",
+	Long: `Seed the current context with the variables listed in an example env
+file such as .env.example.
+
+Every key in the file that is neither set as an override nor defined by the
+base or service env files is prompted for, showing the example value as the
+default; press Enter to accept it. With --defaults, the example values are
+stored without prompting (keys with an empty example value are left unset).
+Keys that are already present are reported and never changed.
+
+Use --service to store the values as service-specific overrides.
+--no-generate skips rewriting the service env files, as with 'dual env set'.
+
+Examples:
+  dual env import-example .env.example
+  dual env import-example .env.example --defaults
+  dual env import-example apps/api/.env.example --service api`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEnvImportExample,
+}
+
 func init() {
 	rootCmd.AddCommand(envCmd)
 
@@ -175,41 +557,120 @@ func init() {
 	envCmd.AddCommand(envShowCmd)
 	envCmd.AddCommand(envSetCmd)
 	envCmd.AddCommand(envUnsetCmd)
+	envCmd.AddCommand(envRollbackCmd)
 	envCmd.AddCommand(envExportCmd)
 	envCmd.AddCommand(envCheckCmd)
 	envCmd.AddCommand(envDiffCmd)
 	envCmd.AddCommand(envRemapCmd)
+	envCmd.AddCommand(envImportShellCmd)
+	envCmd.AddCommand(envImportExampleCmd)
 
 	// Flags for show command
 	envShowCmd.Flags().BoolVar(&envShowValues, "values", false, "show all variable values")
 	envShowCmd.Flags().BoolVar(&envShowBaseOnly, "base-only", false, "show only base variables")
 	envShowCmd.Flags().BoolVar(&envShowOverrideOnly, "overrides-only", false, "show only overrides")
+	envShowCmd.Flags().BoolVar(&envShowExportUnset, "export-unset", false, "show only base variables that no service or override layer sets")
 	envShowCmd.Flags().BoolVar(&envShowJSON, "json", false, "output as JSON")
+	envShowCmd.Flags().BoolVar(&envShowTree, "tree", false, "show each variable's value per layer and which one wins")
+	envShowCmd.Flags().BoolVar(&envShowDiffBase, "diff-base", false, "show base variables replaced by service or override values")
 	envShowCmd.Flags().StringVar(&envServiceFlag, "service", "", "show overrides for specific service")
+	envShowCmd.Flags().StringVar(&envContextFlag, "context", "", "show this context instead of the current one")
+	envShowCmd.Flags().StringVar(&envEnvironmentFlag, "env", "", "apply this environment's overlay (e.g. staging)")
+	_ = envShowCmd.RegisterFlagCompletionFunc("context", contextCompletion)
 
 	// Flags for set command
 	envSetCmd.Flags().StringVar(&envServiceFlag, "service", "", "set service-specific override")
+	envSetCmd.Flags().BoolVar(&envSetIfNotExists, "if-not-exists", false, "only set the override if the key has none yet (skips instead of overwriting)")
+	envSetCmd.Flags().StringVar(&envEnvironmentFlag, "env", "", "set the override in this environment's overlay (e.g. staging)")
+	envSetCmd.Flags().StringVar(&envSetNote, "note", "", "explain why the override is set, shown by 'dual env show' (empty removes the note)")
+	envSetCmd.Flags().BoolVar(&envNoGenerate, "no-generate", false, "update the registry only; run 'dual env remap' later to write service env files")
+	envSetCmd.Flags().BoolVar(&envDryRun, "dry-run", false, "show which overrides and service env files would change without saving anything")
+	envSetCmd.Flags().BoolVar(&envSetJSON, "json", false, "require the value to be valid JSON (stored as given)")
+	envSetCmd.Flags().StringVar(&envSetFromJSONFile, "from-json-file", "", "set one override per leaf of the JSON object in this file")
+	envSetCmd.Flags().StringVar(&envSetJSONSeparator, "json-separator", "__", "join nested keys from --from-json-file with this separator")
+	envSetCmd.MarkFlagsMutuallyExclusive("json", "from-json-file")
 
 	// Flags for unset command
 	envUnsetCmd.Flags().StringVar(&envServiceFlag, "service", "", "unset service-specific override")
+	envUnsetCmd.Flags().StringVar(&envEnvironmentFlag, "env", "", "unset the override from this environment's overlay")
+	envUnsetCmd.Flags().BoolVar(&envNoGenerate, "no-generate", false, "update the registry only; run 'dual env remap' later to write service env files")
+	envUnsetCmd.Flags().BoolVar(&envDryRun, "dry-run", false, "show which overrides and service env files would change without saving anything")
+
+	// Flags for rollback command
+	envRollbackCmd.Flags().StringVar(&envServiceFlag, "service", "", "roll back a service-specific override")
+	envRollbackCmd.Flags().BoolVar(&envNoGenerate, "no-generate", false, "update the registry only; run 'dual env remap' later to write service env files")
 
 	// Flags for export command
-	envExportCmd.Flags().StringVar(&envExportFormat, "format", "dotenv", "output format (dotenv, json, shell)")
+	envExportCmd.Flags().StringVar(&envExportFormat, "format", "dotenv", "output format (dotenv, json, shell, envrc, properties, k8s-configmap, k8s-secret)")
+	envExportCmd.Flags().BoolVar(&envExportWatch, "watch", true, "emit watch_file directives for the source env files (envrc format)")
+	envExportCmd.Flags().StringVar(&envExportKeyTransform, "key-transform", "none", "key names for the properties format (none, dotted: DATABASE_URL becomes database.url)")
+	envExportCmd.Flags().StringVar(&envExportName, "name", "", "metadata.name for k8s formats (default: <context>[-<service>]-env)")
 	envExportCmd.Flags().StringVar(&envServiceFlag, "service", "", "export for specific service")
+	envExportCmd.Flags().StringVar(&envContextFlag, "context", "", "export this context instead of the current one")
+	envExportCmd.Flags().StringVar(&envEnvironmentFlag, "env", "", "apply this environment's overlay (e.g. staging)")
+	envExportCmd.Flags().BoolVar(&envCheckUndefined, "check-undefined", false, "fail if the env files reference variables that expand to nothing")
+	_ = envExportCmd.RegisterFlagCompletionFunc("context", contextCompletion)
+	envCheckCmd.Flags().StringVar(&envServiceFlag, "service", "", "also validate a specific service's environment")
+	envCheckCmd.Flags().BoolVar(&envCheckStrict, "strict", false, "also check env files for circular variable expansion")
+	envCheckCmd.Flags().BoolVar(&envCheckUndefined, "check-undefined", false, "also check env files for references to undefined variables")
+	envCheckCmd.Flags().StringVar(&envContextFlag, "context", "", "check this context instead of the current one")
+	_ = envCheckCmd.RegisterFlagCompletionFunc("context", contextCompletion)
+	envDiffCmd.Flags().StringVar(&envDiffBase, "base", "", "three-way diff: compare both contexts against this common base context")
+	envDiffCmd.Flags().BoolVar(&envDiffJSON, "json", false, "output as JSON")
+
+	envRemapCmd.Flags().BoolVar(&envDryRun, "dry-run", false, "show which service env files would be written without writing them")
+	_ = envDiffCmd.RegisterFlagCompletionFunc("base", contextCompletion)
+	envExportCmd.Flags().StringVarP(&envExportOutput, "output", "o", "", "write to file atomically instead of stdout (mode 0600)")
+	envExportCmd.Flags().BoolVar(&envExportForce, "force", false, "overwrite the --output file if it exists and differs")
+	envExportCmd.Flags().StringVar(&envExportSort, "sort", "name", "key order (name, off)")
+	envExportCmd.Flags().StringArrayVar(&envExportPrefixes, "prefix", nil, "only export variables whose name starts with this prefix (repeatable)")
+	envExportCmd.Flags().StringArrayVar(&envExportMatches, "match", nil, "only export variables whose name matches this glob, e.g. '*_URL' (repeatable)")
+	envExportCmd.Flags().BoolVar(&envExportOverrideOnly, "overrides-only", false, "only export variables that are not in the base env or differ from it")
+	envExportCmd.Flags().StringVar(&envExportMergeFile, "merge-file", "", "overlay the export onto this dotenv file, keeping keys only it defines")
+	envExportCmd.Flags().BoolVar(&envExportFilePriority, "file-priority", false, "let values in the --merge-file win over dual's values")
+	envExportCmd.Flags().BoolVar(&envExportOnlySecrets, "only-secrets", false, "only export variables that look like secrets")
+	envExportCmd.Flags().BoolVar(&envExportNoSecrets, "exclude-secrets", false, "leave out variables that look like secrets")
+	envExportCmd.Flags().BoolVar(&envExportAll, "all", false, "export every service to its own file in --dir")
+	envExportCmd.Flags().StringVar(&envExportDir, "dir", "", "directory for --all, created if needed")
+	envExportCmd.Flags().StringVar(&envExportTemplate, "template", "", "render this Go text/template file with the environment as .Env instead of a --format")
+	envExportCmd.Flags().StringVar(&envExportTemplateDef, "template-default", "", "value for variables the --template references but are not set (default: error)")
+	envExportCmd.Flags().BoolVar(&envExportGrouped, "grouped", false, "with --format=json, group variables by layer next to the merged result")
+	envExportCmd.Flags().StringVar(&envExportProfile, "profile", "", "apply this profile from dual.config.yml above the context overrides (not saved)")
+
+	// Flags for import-shell command
+	envImportShellCmd.Flags().StringSliceVar(&envImportShellKeys, "keys", nil, "comma-separated list of variables to import")
+	envImportShellCmd.Flags().StringVar(&envImportShellPrefix, "prefix", "", "import all variables starting with this prefix")
+	envImportShellCmd.Flags().StringVar(&envServiceFlag, "service", "", "import as service-specific overrides")
+	envImportShellCmd.Flags().BoolVar(&envImportShellDryRun, "dry-run", false, "show what would be imported without saving")
+	envImportShellCmd.Flags().BoolVar(&envNoGenerate, "no-generate", false, "update the registry only; run 'dual env remap' later to write service env files")
+
+	// Flags for import-example command
+	envImportExampleCmd.Flags().BoolVar(&envImportExampleDefaults, "defaults", false, "use the example values without prompting")
+	envImportExampleCmd.Flags().StringVar(&envServiceFlag, "service", "", "import as service-specific overrides")
+	envImportExampleCmd.Flags().BoolVar(&envNoGenerate, "no-generate", false, "update the registry only; run 'dual env remap' later to write service env files")
 }
 
 func runEnvShow(cmd *cobra.Command, args []string) error {
 	// Initialize logger
 	logger.Init(envVerbose, envDebug)
 
+	if err := validateEnvironmentFlag(cmd, false); err != nil {
+		return err
+	}
+
 	// Load config
 	cfg, projectRoot, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
 	}
 
-	// Detect context
-	contextName, err := context.DetectContext()
+	// Fall back to DUAL_SERVICE when --service is not given
+	if err := applyServiceEnvVar(cfg); err != nil {
+		return err
+	}
+
+	// Detect context, unless --context names one
+	contextName, err := envContextName(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to detect context: %w", err)
 	}
@@ -221,23 +682,32 @@ func runEnvShow(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load registry (use projectIdentifier which points to parent repo for worktrees)
-	reg, err := registry.LoadRegistry(projectIdentifier)
+	reg, err := registry.LoadRegistryReadOnly(projectIdentifier)
 	if err != nil {
 		return fmt.Errorf("failed to load registry: %w", err)
 	}
 	defer reg.Close()
 
 	// Get context from registry - gracefully handle when not found
-	var overrides map[string]string
+	var overrides, notes map[string]string
 	ctx, err := reg.GetContext(projectIdentifier, contextName)
-	if err != nil {
+	if err != nil && envContextFlag != "" {
+		return fmt.Errorf("context %q not found in registry\nHint: Run 'dual list' to see available contexts", contextName)
+	} else if err != nil {
 		// Context not in registry - this is OK for read-only commands
 		// We can still show base and service layers, just without overrides
 		logger.Debug("Context not in registry, proceeding without overrides: %v", err)
 		overrides = nil
 	} else {
 		// Get environment overrides for the specified service (or global if no service specified)
-		overrides = ctx.GetEnvOverrides(envServiceFlag)
+		overrides = ctx.GetEnvOverridesForEnvironment(envServiceFlag, envEnvironmentFlag)
+		notes = ctx.GetEnvOverrideNotes(envServiceFlag)
+
+		// An overlay value replaces the override, and with it the note
+		for k := range ctx.GetEnvironmentOverrides(envEnvironmentFlag) {
+			delete(notes, k)
+		}
+		warnEmptyEnvironment(ctx, contextName)
 	}
 
 	// Load layered environment with the updated signature
@@ -251,25 +721,43 @@ func runEnvShow(cmd *cobra.Command, args []string) error {
 	// Get stats
 	stats := layeredEnv.Stats()
 
+	if envShowDiffBase {
+		return showEnvDiffBase(layeredEnv, cfg, contextName)
+	}
+
+	if envShowExportUnset {
+		return showInheritedBase(layeredEnv, cfg, projectRoot, contextName)
+	}
+
 	// Handle JSON output
 	if envShowJSON {
-		return outputEnvJSON(layeredEnv, cfg, contextName, stats)
+		return outputEnvJSON(layeredEnv, cfg, contextName, stats, notes)
 	}
 
 	// Handle different display modes
 	if envShowBaseOnly {
-		return showBaseOnly(layeredEnv, cfg)
+		return showBaseOnly(layeredEnv, cfg, projectRoot)
 	}
 
 	if envShowOverrideOnly {
-		return showOverridesOnly(layeredEnv, contextName)
+		return showOverridesOnly(layeredEnv, contextName, notes)
+	}
+
+	if envShowTree {
+		return showEnvTree(layeredEnv, contextName)
 	}
 
 	// Default: show summary
-	return showEnvSummary(layeredEnv, cfg, contextName, stats)
+	return showEnvSummary(layeredEnv, cfg, projectRoot, contextName, stats, notes)
 }
 
-func showEnvSummary(layeredEnv *env.LayeredEnv, cfg *config.Config, contextName string, stats env.EnvStats) error {
+func showEnvSummary(layeredEnv *env.LayeredEnv, cfg *config.Config, projectRoot, contextName string, stats env.EnvStats, notes map[string]string) error {
+	// A missing file loads as an empty layer, so say so rather than show 0 vars
+	warnMissingEnvFile(projectRoot, cfg.Env.BaseFile, "Base file")
+	if service, ok := cfg.Services[envServiceFlag]; ok {
+		warnMissingEnvFile(projectRoot, service.BaseFile, "Service base file")
+	}
+
 	// Show base file info
 	if cfg.Env.BaseFile != "" {
 		fmt.Printf("Base:      %s (%d vars)\n", cfg.Env.BaseFile, stats.BaseVars)
@@ -277,6 +765,11 @@ func showEnvSummary(layeredEnv *env.LayeredEnv, cfg *config.Config, contextName
 		fmt.Println("Base:      (none configured)")
 	}
 
+	// Show service base layer info, only when the service declares one
+	if service, ok := cfg.Services[envServiceFlag]; ok && service.BaseFile != "" {
+		fmt.Printf("Svc base:  %s (%d vars)\n", service.BaseFile, stats.ServiceBaseVars)
+	}
+
 	// Show service layer info
 	if stats.ServiceVars > 0 {
 		fmt.Printf("Service:   %d vars\n", stats.ServiceVars)
@@ -287,7 +780,7 @@ func showEnvSummary(layeredEnv *env.LayeredEnv, cfg *config.Config, contextName
 	// Show overrides count
 	fmt.Printf("Overrides: %d vars\n", stats.OverrideVars)
 
-	// Show total (now correctly includes all three layers)
+	// Show total across all layers
 	fmt.Printf("Effective: %d vars total\n", stats.TotalVars)
 
 	// Show overrides if any
@@ -302,28 +795,177 @@ func showEnvSummary(layeredEnv *env.LayeredEnv, cfg *config.Config, contextName
 		sort.Strings(keys)
 
 		for _, k := range keys {
-			v := layeredEnv.Overrides[k]
-			if envShowValues {
-				fmt.Printf("  %s=%s\n", k, v)
-			} else {
-				// Show truncated value for security
-				displayValue := v
-				if len(v) > 40 {
-					displayValue = v[:37] + "..."
-				}
-				fmt.Printf("  %s=%s\n", k, displayValue)
+			fmt.Printf("  %s=%s%s\n", k, displayEnvValue(layeredEnv.Overrides[k]), noteSuffix(notes[k]))
+		}
+	}
+
+	return nil
+}
+
+// warnMissingEnvFile warns when a configured env file does not exist. Missing
+// env files are allowed, so this is only a hint for an unexpectedly empty layer.
+func warnMissingEnvFile(projectRoot, file, label string) {
+	if file == "" {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(projectRoot, file)); os.IsNotExist(err) {
+		logger.Warn("%s %s is configured but does not exist; its layer is empty", label, file)
+	}
+}
+
+// noteSuffix formats an override note for display after its variable
+func noteSuffix(note string) string {
+	if note == "" {
+		return ""
+	}
+	return "  # " + note
+}
+
+// displayEnvValue returns a value for display, truncated for security unless --values is set
+func displayEnvValue(v string) string {
+	if envShowValues || len(v) <= 40 {
+		return v
+	}
+	return v[:37] + "..."
+}
+
+// showEnvTree prints, per variable, the value at each layer and the winning layer.
+// Layers are listed from lowest to highest priority.
+func showEnvTree(layeredEnv *env.LayeredEnv, contextName string) error {
+	layers := []struct {
+		name string
+		vars map[string]string
+	}{
+		{"base", layeredEnv.Base},
+		{"serviceBase", layeredEnv.ServiceBase},
+		{"service", layeredEnv.Service},
+		{"override", layeredEnv.Overrides},
+	}
+
+	merged := layeredEnv.Merge()
+	if len(merged) == 0 {
+		fmt.Println("No environment variables in any layer")
+		return nil
+	}
+
+	keys := make([]string, 0, len(merged))
+	for k := range merged {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fmt.Printf("Environment layers for context '%s' (lowest → highest priority):\n\n", contextName)
+	for _, k := range keys {
+		var parts []string
+		winner := ""
+
+		if v, ok := os.LookupEnv(k); ok {
+			parts = append(parts, fmt.Sprintf("runtime=%s", displayEnvValue(v)))
+		}
+		for _, layer := range layers {
+			if v, ok := layer.vars[k]; ok {
+				parts = append(parts, fmt.Sprintf("%s=%s", layer.name, displayEnvValue(v)))
+				winner = layer.name
 			}
 		}
+
+		fmt.Printf("%s: %s (→ %s)\n", k, strings.Join(parts, " "), winner)
+	}
+
+	return nil
+}
+
+// showEnvDiffBase prints base variables that a higher layer replaces
+func showEnvDiffBase(layeredEnv *env.LayeredEnv, cfg *config.Config, contextName string) error {
+	diffs := layeredEnv.OverriddenBase()
+
+	if envShowJSON {
+		if diffs == nil {
+			diffs = []env.BaseOverride{}
+		}
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"context":        contextName,
+			"baseFile":       cfg.Env.BaseFile,
+			"overridingBase": diffs,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if cfg.Env.BaseFile == "" {
+		fmt.Println("No base environment file configured")
+		return nil
+	}
+
+	if len(diffs) == 0 {
+		fmt.Printf("No base variables are overridden in context '%s'\n", contextName)
+		return nil
+	}
+
+	fmt.Printf("Variables overriding base (%s) in context '%s':\n\n", cfg.Env.BaseFile, contextName)
+	for _, d := range diffs {
+		fmt.Printf("%s: base=%s → %s=%s\n", d.Key, displayEnvValue(d.Base), d.Layer, displayEnvValue(d.Value))
+	}
+
+	return nil
+}
+
+// showInheritedBase prints base variables that no higher layer sets
+func showInheritedBase(layeredEnv *env.LayeredEnv, cfg *config.Config, projectRoot, contextName string) error {
+	inherited := layeredEnv.InheritedBase()
+
+	if envShowJSON {
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"context":       contextName,
+			"baseFile":      cfg.Env.BaseFile,
+			"inheritedBase": inherited,
+		}, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if cfg.Env.BaseFile == "" {
+		fmt.Println("No base environment file configured")
+		return nil
+	}
+	warnMissingEnvFile(projectRoot, cfg.Env.BaseFile, "Base file")
+
+	if len(inherited) == 0 {
+		fmt.Printf("Every base variable is overridden in context '%s'\n", contextName)
+		return nil
+	}
+
+	fmt.Printf("Base variables (%s) in effect for context '%s':\n", cfg.Env.BaseFile, contextName)
+
+	keys := make([]string, 0, len(inherited))
+	for k := range inherited {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if envShowValues {
+			fmt.Printf("%s=%s\n", k, inherited[k])
+		} else {
+			fmt.Printf("%s\n", k)
+		}
 	}
 
 	return nil
 }
 
-func showBaseOnly(layeredEnv *env.LayeredEnv, cfg *config.Config) error {
+func showBaseOnly(layeredEnv *env.LayeredEnv, cfg *config.Config, projectRoot string) error {
 	if cfg.Env.BaseFile == "" {
 		fmt.Println("No base environment file configured")
 		return nil
 	}
+	warnMissingEnvFile(projectRoot, cfg.Env.BaseFile, "Base file")
 
 	if len(layeredEnv.Base) == 0 {
 		fmt.Printf("Base file %s has no variables\n", cfg.Env.BaseFile)
@@ -351,7 +993,7 @@ func showBaseOnly(layeredEnv *env.LayeredEnv, cfg *config.Config) error {
 	return nil
 }
 
-func showOverridesOnly(layeredEnv *env.LayeredEnv, contextName string) error {
+func showOverridesOnly(layeredEnv *env.LayeredEnv, contextName string, notes map[string]string) error {
 	if len(layeredEnv.Overrides) == 0 {
 		fmt.Printf("No overrides for context '%s'\n", contextName)
 		return nil
@@ -368,28 +1010,48 @@ func showOverridesOnly(layeredEnv *env.LayeredEnv, contextName string) error {
 
 	for _, k := range keys {
 		if envShowValues {
-			fmt.Printf("%s=%s\n", k, layeredEnv.Overrides[k])
+			fmt.Printf("%s=%s%s\n", k, layeredEnv.Overrides[k], noteSuffix(notes[k]))
 		} else {
-			fmt.Printf("%s\n", k)
+			fmt.Printf("%s%s\n", k, noteSuffix(notes[k]))
 		}
 	}
 
 	return nil
 }
 
-func outputEnvJSON(layeredEnv *env.LayeredEnv, cfg *config.Config, contextName string, stats env.EnvStats) error {
+func outputEnvJSON(layeredEnv *env.LayeredEnv, cfg *config.Config, contextName string, stats env.EnvStats, notes map[string]string) error {
+	// Variables already set in the process environment, as in the --tree view.
+	// dual run replaces them with the merged values.
+	runtime := make(map[string]string)
+	for k := range layeredEnv.Merge() {
+		if v, ok := os.LookupEnv(k); ok {
+			runtime[k] = v
+		}
+	}
+
 	output := map[string]interface{}{
 		"context":  contextName,
 		"baseFile": cfg.Env.BaseFile,
 		"stats": map[string]int{
-			"baseVars":     stats.BaseVars,
-			"serviceVars":  stats.ServiceVars,
-			"overrideVars": stats.OverrideVars,
-			"totalVars":    stats.TotalVars,
+			"baseVars":        stats.BaseVars,
+			"serviceBaseVars": stats.ServiceBaseVars,
+			"serviceVars":     stats.ServiceVars,
+			"overrideVars":    stats.OverrideVars,
+			"runtimeVars":     len(runtime),
+			"totalVars":       stats.TotalVars,
 		},
-		"base":      layeredEnv.Base,
-		"service":   layeredEnv.Service,
-		"overrides": layeredEnv.Overrides,
+		"base":          layeredEnv.Base,
+		"serviceBase":   layeredEnv.ServiceBase,
+		"service":       layeredEnv.Service,
+		"overrides":     layeredEnv.Overrides,
+		"inheritedBase": layeredEnv.InheritedBase(),
+		"runtime":       runtime,
+	}
+	if service, ok := cfg.Services[envServiceFlag]; ok && service.BaseFile != "" {
+		output["serviceBaseFile"] = service.BaseFile
+	}
+	if len(notes) > 0 {
+		output["overrideNotes"] = notes
 	}
 
 	data, err := json.MarshalIndent(output, "", "  ")
@@ -402,8 +1064,14 @@ func outputEnvJSON(layeredEnv *env.LayeredEnv, cfg *config.Config, contextName s
 }
 
 func runEnvSet(cmd *cobra.Command, args []string) error {
-	key := args[0]
-	value := args[1]
+	if err := validateEnvSetFlags(cmd); err != nil {
+		return err
+	}
+
+	values, keys, err := envSetValues(args)
+	if err != nil {
+		return err
+	}
 
 	// Initialize logger
 	logger.Init(envVerbose, envDebug)
@@ -415,7 +1083,7 @@ func runEnvSet(cmd *cobra.Command, args []string) error {
 	}
 
 	// Detect context
-	contextName, err := context.DetectContext()
+	contextName, err := context.DetectContextWithSource(cfg.GetContextSource())
 	if err != nil {
 		return fmt.Errorf("failed to detect context: %w", err)
 	}
@@ -426,171 +1094,298 @@ func runEnvSet(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get project identifier: %w", err)
 	}
 
-	// Load registry (use projectIdentifier which points to parent repo for worktrees)
-	reg, err := registry.LoadRegistry(projectIdentifier)
-	if err != nil {
-		return fmt.Errorf("failed to load registry: %w", err)
+	// If service is specified, validate it exists in config
+	if err := resolveServiceFlag(cfg); err != nil {
+		return err
 	}
-	defer reg.Close()
 
-	// Check if context exists
-	_, err = reg.GetContext(projectIdentifier, contextName)
-	if err != nil {
-		return fmt.Errorf("context %q not found in registry\nHint: Run 'dual create <branch>' to create a worktree with a context", contextName)
+	// Check if we're overriding base variables
+	var baseEnv map[string]string
+	if cfg.Env.BaseFile != "" {
+		baseEnv, _ = env.NewLoader().LoadEnvFile(projectRoot + "/" + cfg.Env.BaseFile)
 	}
 
-	// If service is specified, validate it exists in config
-	if envServiceFlag != "" {
-		if _, exists := cfg.Services[envServiceFlag]; !exists {
-			return fmt.Errorf("service %q not found in config\nAvailable services: %v", envServiceFlag, getServiceNames(cfg))
+	// Update the registry (use projectIdentifier which points to parent repo for worktrees)
+	var result envSetResult
+	err = updateEnvRegistry(projectIdentifier, func(reg *registry.Registry) error {
+		target, err := newOverrideTarget(reg, projectIdentifier, contextName)
+		if err != nil {
+			return err
 		}
-	}
+		before := overrideSnapshot(target.ctx)
 
-	// Check if we're overriding a base variable
-	if cfg.Env.BaseFile != "" {
-		loader := env.NewLoader()
-		baseEnv, err := loader.LoadEnvFile(projectRoot + "/" + cfg.Env.BaseFile)
-		if err == nil {
-			if _, exists := baseEnv[key]; exists {
-				fmt.Fprintf(os.Stderr, "[dual] Warning: Overriding variable %q from base environment\n", key)
-			}
+		result, err = applyEnvSet(target, keys, values, baseEnv, cmd.Flags().Changed("note"))
+		if err != nil || len(result.set) == 0 {
+			return err
 		}
+		if envDryRun {
+			return printEnvDryRun(cfg, reg, projectIdentifier, contextName, before)
+		}
+		if target.environment == "" {
+			regenerateServiceEnvFiles(cfg, reg, projectIdentifier, contextName)
+			result.countOverrides(reg, projectIdentifier, contextName)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	// Set the override (with service if specified)
-	if err := reg.SetEnvOverrideForService(projectIdentifier, contextName, key, value, envServiceFlag); err != nil {
-		return fmt.Errorf("failed to set environment override: %w", err)
+	for _, key := range result.skipped {
+		fmt.Printf("%s already set in context '%s', skipped\n", key, contextName)
 	}
-
-	// Save registry
-	if err := reg.SaveRegistry(); err != nil {
-		return fmt.Errorf("failed to save registry: %w", err)
+	if len(result.set) == 0 || envDryRun {
+		return nil
 	}
+	printEnvSetSummary(contextName, values, result)
+	return nil
+}
 
-	// Generate service env files
-	if err := env.GenerateServiceEnvFiles(cfg, reg, projectIdentifier, projectIdentifier, contextName); err != nil {
-		fmt.Fprintf(os.Stderr, "[dual] Warning: failed to regenerate service env files: %v\n", err)
-		// Don't fail the command - the override is saved, env files are optional
+// validateEnvSetFlags checks the 'dual env set' flags that cannot be combined
+func validateEnvSetFlags(cmd *cobra.Command) error {
+	if err := validateEnvironmentFlag(cmd, true); err != nil {
+		return err
 	}
-
-	// Show success message
-	if envServiceFlag != "" {
-		fmt.Printf("Set %s=%s for service '%s' in context '%s'\n", key, value, envServiceFlag, contextName)
-	} else {
-		fmt.Printf("Set %s=%s for context '%s' (global)\n", key, value, contextName)
+	if envEnvironmentFlag != "" && cmd.Flags().Changed("note") {
+		return fmt.Errorf("--note cannot be used with --env")
 	}
+	return nil
+}
 
-	// Show current override count
+// envSetResult records what 'dual env set' did, for its summary
+type envSetResult struct {
+	set, skipped []string
+	// total and global count the context's overrides after the change
+	total, global int
+}
+
+// countOverrides records how many overrides the context has now
+func (r *envSetResult) countOverrides(reg *registry.Registry, projectIdentifier, contextName string) {
 	ctx, _ := reg.GetContext(projectIdentifier, contextName)
-	if ctx != nil {
-		globalCount := 0
-		serviceCount := 0
-		if ctx.EnvOverridesV2 != nil {
-			globalCount = len(ctx.EnvOverridesV2.Global)
-			for _, serviceOverrides := range ctx.EnvOverridesV2.Services {
-				serviceCount += len(serviceOverrides)
-			}
-		}
-		totalCount := globalCount + serviceCount
-		if totalCount > 0 {
-			fmt.Printf("Context '%s' now has %d override(s) (%d global, %d service-specific)\n",
-				contextName, totalCount, globalCount, serviceCount)
-		}
+	if ctx == nil || ctx.EnvOverridesV2 == nil {
+		return
+	}
+	r.global = len(ctx.EnvOverridesV2.Global)
+	r.total = r.global
+	for _, serviceOverrides := range ctx.EnvOverridesV2.Services {
+		r.total += len(serviceOverrides)
 	}
-
-	return nil
 }
 
-func runEnvUnset(cmd *cobra.Command, args []string) error {
-	key := args[0]
+// applyEnvSet sets each key in the target layer, skipping keys that already
+// have an override there with --if-not-exists
+func applyEnvSet(target *overrideTarget, keys []string, values, baseEnv map[string]string, setNote bool) (envSetResult, error) {
+	var result envSetResult
+	for _, key := range keys {
+		if envSetIfNotExists && target.has(key) {
+			result.skipped = append(result.skipped, key)
+			continue
+		}
 
-	// Initialize logger
-	logger.Init(envVerbose, envDebug)
+		// Environment overlays apply on top of everything, so only warn for the
+		// layers that replace base variables in the generated env files
+		if _, exists := baseEnv[key]; exists && target.environment == "" {
+			logger.Warn("Overriding variable %q from base environment", key)
+		}
 
-	// Load config
-	cfg, projectRoot, err := config.LoadConfig()
-	if err != nil {
-		return fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
+		if err := target.set(key, values[key]); err != nil {
+			return result, err
+		}
+		if setNote {
+			if err := target.reg.SetEnvOverrideNote(target.projectIdentifier, target.contextName, key, envSetNote, target.service); err != nil {
+				return result, fmt.Errorf("failed to set override note: %w", err)
+			}
+		}
+		result.set = append(result.set, key)
 	}
+	return result, nil
+}
 
-	// Detect context
-	contextName, err := context.DetectContext()
-	if err != nil {
-		return fmt.Errorf("failed to detect context: %w", err)
+// printEnvSetSummary reports the overrides 'dual env set' stored
+func printEnvSetSummary(contextName string, values map[string]string, result envSetResult) {
+	key := result.set[0]
+	switch {
+	case envSetFromJSONFile != "":
+		fmt.Printf("Set %d variable(s) from %s in context '%s' (%s):\n", len(result.set), envSetFromJSONFile, contextName, overrideScope())
+		for _, key := range result.set {
+			fmt.Printf("  %s\n", key)
+		}
+	case envEnvironmentFlag != "":
+		fmt.Printf("Set %s=%s for environment '%s' in context '%s'\n", key, values[key], envEnvironmentFlag, contextName)
+	case envServiceFlag != "":
+		fmt.Printf("Set %s=%s for service '%s' in context '%s'\n", key, values[key], envServiceFlag, contextName)
+	default:
+		fmt.Printf("Set %s=%s for context '%s' (global)\n", key, values[key], contextName)
 	}
 
-	// Get project identifier (normalized project root for worktrees)
-	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
-	if err != nil {
-		return fmt.Errorf("failed to get project identifier: %w", err)
+	// Show current override count
+	if result.total > 0 {
+		fmt.Printf("Context '%s' now has %d override(s) (%d global, %d service-specific)\n",
+			contextName, result.total, result.global, result.total-result.global)
 	}
+}
 
-	// Load registry (use projectIdentifier which points to parent repo for worktrees)
-	reg, err := registry.LoadRegistry(projectIdentifier)
-	if err != nil {
-		return fmt.Errorf("failed to load registry: %w", err)
+// overrideScope describes the layer selected by --service or --env
+func overrideScope() string {
+	switch {
+	case envServiceFlag != "":
+		return fmt.Sprintf("service '%s'", envServiceFlag)
+	case envEnvironmentFlag != "":
+		return fmt.Sprintf("environment '%s'", envEnvironmentFlag)
 	}
-	defer reg.Close()
+	return "global"
+}
 
-	// Check if context exists
+// overrideTarget is the override layer of a context that 'dual env set' and
+// 'dual env unset' change: the --env environment overlay, or else the
+// --service overrides or the global ones. Changes to the latter are recorded
+// for 'dual env rollback'; environment overlays have no history.
+type overrideTarget struct {
+	reg               *registry.Registry
+	ctx               *registry.Context
+	projectIdentifier string
+	contextName       string
+	service           string
+	environment       string
+}
+
+// newOverrideTarget selects the layer named by --service and --env in a
+// registered context
+func newOverrideTarget(reg *registry.Registry, projectIdentifier, contextName string) (*overrideTarget, error) {
 	ctx, err := reg.GetContext(projectIdentifier, contextName)
 	if err != nil {
-		return fmt.Errorf("context %q not found in registry\nHint: Run 'dual create <branch>' to create a worktree with a context", contextName)
+		return nil, fmt.Errorf("context %q not found in registry\nHint: Run 'dual create <branch>' to create a worktree with a context", contextName)
+	}
+	return &overrideTarget{
+		reg:               reg,
+		ctx:               ctx,
+		projectIdentifier: projectIdentifier,
+		contextName:       contextName,
+		service:           envServiceFlag,
+		environment:       envEnvironmentFlag,
+	}, nil
+}
+
+// has reports whether key has an override in the target layer
+func (t *overrideTarget) has(key string) bool {
+	if t.environment != "" {
+		_, exists := t.ctx.GetEnvironmentOverrides(t.environment)[key]
+		return exists
 	}
+	return t.ctx.HasEnvOverrideAt(key, t.service)
+}
 
-	// If service is specified, validate it exists in config
-	if envServiceFlag != "" {
-		if _, exists := cfg.Services[envServiceFlag]; !exists {
-			return fmt.Errorf("service %q not found in config\nAvailable services: %v", envServiceFlag, getServiceNames(cfg))
+// set stores an override in the target layer
+func (t *overrideTarget) set(key, value string) error {
+	if t.environment != "" {
+		if err := t.reg.SetEnvOverrideForEnvironment(t.projectIdentifier, t.contextName, key, value, t.environment); err != nil {
+			return fmt.Errorf("failed to set environment override: %w", err)
+		}
+		return nil
+	}
+
+	// Remember the previous value for 'dual env rollback'
+	if err := t.reg.RecordEnvOverrideChange(t.projectIdentifier, t.contextName, key, t.service, &value); err != nil {
+		return fmt.Errorf("failed to record override history: %w", err)
+	}
+	if err := t.reg.SetEnvOverrideForService(t.projectIdentifier, t.contextName, key, value, t.service); err != nil {
+		return fmt.Errorf("failed to set environment override: %w", err)
+	}
+	return nil
+}
+
+// unset removes an override from the target layer, failing if it has none
+func (t *overrideTarget) unset(key string) error {
+	if !t.has(key) {
+		switch {
+		case t.environment != "":
+			return fmt.Errorf("no override found for %q in environment '%s' for context '%s'", key, t.environment, t.contextName)
+		case t.service != "":
+			if t.ctx.HasEnvOverrideAt(key, "") {
+				return fmt.Errorf("%q is not set for service '%s' in context '%s'\nHint: It is a global override; run without --service to remove it", key, t.service, t.contextName)
+			}
+			return fmt.Errorf("no override found for %q in service '%s' for context '%s'", key, t.service, t.contextName)
 		}
+		return fmt.Errorf("no override found for %q in context '%s'", key, t.contextName)
 	}
 
-	// Check if override exists
-	if !ctx.HasEnvOverride(key, envServiceFlag) {
-		if envServiceFlag != "" {
-			return fmt.Errorf("no override found for %q in service '%s' for context '%s'", key, envServiceFlag, contextName)
+	if t.environment != "" {
+		if err := t.reg.UnsetEnvOverrideForEnvironment(t.projectIdentifier, t.contextName, key, t.environment); err != nil {
+			return fmt.Errorf("failed to unset environment override: %w", err)
 		}
-		return fmt.Errorf("no override found for %q in context '%s'", key, contextName)
+		return nil
 	}
 
-	// Unset the override
-	if err := reg.UnsetEnvOverrideForService(projectIdentifier, contextName, key, envServiceFlag); err != nil {
+	// Remember the previous value for 'dual env rollback'
+	if err := t.reg.RecordEnvOverrideChange(t.projectIdentifier, t.contextName, key, t.service, nil); err != nil {
+		return fmt.Errorf("failed to record override history: %w", err)
+	}
+	if err := t.reg.UnsetEnvOverrideForService(t.projectIdentifier, t.contextName, key, t.service); err != nil {
 		return fmt.Errorf("failed to unset environment override: %w", err)
 	}
+	return nil
+}
 
-	// Save registry
-	if err := reg.SaveRegistry(); err != nil {
-		return fmt.Errorf("failed to save registry: %w", err)
+// regenerateServiceEnvFiles writes the context's service env files after an
+// override change, unless deferred to 'dual env remap' with --no-generate.
+// Failures are only warned about: the overrides are saved, env files are optional.
+func regenerateServiceEnvFiles(cfg *config.Config, reg *registry.Registry, projectIdentifier, contextName string) {
+	if envNoGenerate {
+		logger.Verbose("Skipping service env file generation (--no-generate)")
+		return
 	}
-
-	// Generate service env files
 	if err := env.GenerateServiceEnvFiles(cfg, reg, projectIdentifier, projectIdentifier, contextName); err != nil {
-		fmt.Fprintf(os.Stderr, "[dual] Warning: failed to regenerate service env files: %v\n", err)
-		// Don't fail the command - the override is removed, env files are optional
+		logger.Warn("failed to regenerate service env files: %v", err)
 	}
+}
 
-	// Show success message
-	if envServiceFlag != "" {
-		fmt.Printf("Removed override for %s in service '%s' for context '%s'\n", key, envServiceFlag, contextName)
-	} else {
-		fmt.Printf("Removed override for %s in context '%s'\n", key, contextName)
+// envSetValues returns the overrides 'dual env set' should store, in the order
+// to apply them: the <key> <value> arguments, or the leaves of --from-json-file
+func envSetValues(args []string) (map[string]string, []string, error) {
+	if envSetFromJSONFile != "" {
+		return envSetJSONFileValues()
 	}
 
-	// Check if there's a fallback value in base
-	if cfg.Env.BaseFile != "" {
-		loader := env.NewLoader()
-		baseEnv, err := loader.LoadEnvFile(projectRoot + "/" + cfg.Env.BaseFile)
-		if err == nil {
-			if baseValue, exists := baseEnv[key]; exists {
-				fmt.Printf("Fallback to base value: %s=%s\n", key, baseValue)
-			}
+	key, value := args[0], args[1]
+	if err := env.ValidateKey(key); err != nil {
+		return nil, nil, err
+	}
+	if envSetJSON {
+		if err := env.ValidateJSON(value); err != nil {
+			return nil, nil, fmt.Errorf("value for %s is not valid JSON: %w\nHint: Quote the whole value for your shell, e.g. '{\"key\": \"value\"}'", key, err)
 		}
 	}
+	return map[string]string{key: value}, []string{key}, nil
+}
 
-	return nil
+// envSetJSONFileValues flattens the object in --from-json-file into one
+// override per leaf, joining nested keys with --json-separator
+func envSetJSONFileValues() (map[string]string, []string, error) {
+	if envSetJSONSeparator == "" {
+		return nil, nil, fmt.Errorf("--json-separator cannot be empty")
+	}
+	// #nosec G304 - Reading the file the user passed with --from-json-file is intentional
+	data, err := os.ReadFile(envSetFromJSONFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", envSetFromJSONFile, err)
+	}
+	values, keys, err := env.FlattenJSON(data, envSetJSONSeparator)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", envSetFromJSONFile, err)
+	}
+	if len(keys) == 0 {
+		return nil, nil, fmt.Errorf("%s has no values to set", envSetFromJSONFile)
+	}
+	return values, keys, nil
 }
 
-func runEnvExport(cmd *cobra.Command, args []string) error {
+func runEnvUnset(cmd *cobra.Command, args []string) error {
+	key := args[0]
+
+	if err := validateEnvironmentFlag(cmd, true); err != nil {
+		return err
+	}
+
 	// Initialize logger
 	logger.Init(envVerbose, envDebug)
 
@@ -601,7 +1396,7 @@ func runEnvExport(cmd *cobra.Command, args []string) error {
 	}
 
 	// Detect context
-	contextName, err := context.DetectContext()
+	contextName, err := context.DetectContextWithSource(cfg.GetContextSource())
 	if err != nil {
 		return fmt.Errorf("failed to detect context: %w", err)
 	}
@@ -612,284 +1407,1579 @@ func runEnvExport(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to get project identifier: %w", err)
 	}
 
-	// Load registry (use projectIdentifier which points to parent repo for worktrees)
-	reg, err := registry.LoadRegistry(projectIdentifier)
-	if err != nil {
-		return fmt.Errorf("failed to load registry: %w", err)
-	}
-	defer reg.Close()
-
 	// If service is specified, validate it exists in config
-	if envServiceFlag != "" {
-		if _, exists := cfg.Services[envServiceFlag]; !exists {
-			return fmt.Errorf("service %q not found in config\nAvailable services: %v", envServiceFlag, getServiceNames(cfg))
-		}
+	if err := resolveServiceFlag(cfg); err != nil {
+		return err
 	}
 
-	// Get context from registry - gracefully handle when not found
-	var overrides map[string]string
-	ctx, err := reg.GetContext(projectIdentifier, contextName)
-	if err != nil {
-		// Context not in registry - this is OK for export
-		// We can still export base and service layers, just without overrides
-		logger.Debug("Context not in registry, proceeding without overrides: %v", err)
-		overrides = nil
-	} else {
-		// Get environment overrides for the specified service (or global if no service specified)
-		overrides = ctx.GetEnvOverrides(envServiceFlag)
-	}
+	// Update the registry (use projectIdentifier which points to parent repo for worktrees)
+	err = updateEnvRegistry(projectIdentifier, func(reg *registry.Registry) error {
+		target, err := newOverrideTarget(reg, projectIdentifier, contextName)
+		if err != nil {
+			return err
+		}
+		before := overrideSnapshot(target.ctx)
 
-	// Load layered environment with the updated signature
-	// Pass serviceName to load the service layer properly
-	// LoadLayeredEnv will try to load overrides from filesystem if not provided
-	layeredEnv, err := env.LoadLayeredEnv(projectRoot, cfg, envServiceFlag, contextName, overrides)
-	if err != nil {
-		return fmt.Errorf("failed to load environment: %w", err)
+		if err := target.unset(key); err != nil {
+			return err
+		}
+		if envDryRun {
+			return printEnvDryRun(cfg, reg, projectIdentifier, contextName, before)
+		}
+		// Environment overlays are never generated into env files
+		if target.environment == "" {
+			regenerateServiceEnvFiles(cfg, reg, projectIdentifier, contextName)
+		}
+		return nil
+	})
+	if err != nil || envDryRun {
+		return err
 	}
 
-	// Merge all layers
-	merged := layeredEnv.Merge()
-
-	// Sort keys for consistent output
-	keys := make([]string, 0, len(merged))
-	for k := range merged {
-		keys = append(keys, k)
+	// Show success message
+	switch {
+	case envEnvironmentFlag != "":
+		fmt.Printf("Removed override for %s in environment '%s' for context '%s'\n", key, envEnvironmentFlag, contextName)
+		return nil
+	case envServiceFlag != "":
+		fmt.Printf("Removed override for %s in service '%s' for context '%s'\n", key, envServiceFlag, contextName)
+	default:
+		fmt.Printf("Removed override for %s in context '%s'\n", key, contextName)
 	}
-	sort.Strings(keys)
 
-	// Output in requested format
-	switch envExportFormat {
-	case "dotenv":
-		for _, k := range keys {
-			v := merged[k]
-			// Quote values that contain spaces or special characters
-			if strings.ContainsAny(v, " \t\n\"'") {
-				v = fmt.Sprintf(`"%s"`, strings.ReplaceAll(v, `"`, `\"`))
-			}
-			fmt.Printf("%s=%s\n", k, v)
-		}
-	case "json":
-		data, err := json.MarshalIndent(merged, "", "  ")
-		if err != nil {
-			return fmt.Errorf("failed to marshal JSON: %w", err)
-		}
-		fmt.Println(string(data))
-	case "shell":
-		for _, k := range keys {
-			v := merged[k]
-			// Escape single quotes for shell
-			v = strings.ReplaceAll(v, `'`, `'\''`)
-			fmt.Printf("export %s='%s'\n", k, v)
+	// Check if there's a fallback value in base
+	if cfg.Env.BaseFile != "" {
+		baseEnv, err := env.NewLoader().LoadEnvFile(projectRoot + "/" + cfg.Env.BaseFile)
+		if baseValue, exists := baseEnv[key]; err == nil && exists {
+			fmt.Printf("Fallback to base value: %s=%s\n", key, baseValue)
 		}
-	default:
-		return fmt.Errorf("unsupported format: %s (supported: dotenv, json, shell)", envExportFormat)
 	}
 
 	return nil
 }
 
-func runEnvCheck(cmd *cobra.Command, args []string) error {
+func runEnvRollback(cmd *cobra.Command, args []string) error {
 	// Initialize logger
 	logger.Init(envVerbose, envDebug)
 
+	key := args[0]
+
 	// Load config
 	cfg, projectRoot, err := config.LoadConfig()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to load config: %v\n", err)
-		return fmt.Errorf("configuration check failed")
-	}
-
-	hasIssues := false
-
-	// Check base environment file
-	if cfg.Env.BaseFile != "" {
-		baseFilePath := projectRoot + "/" + cfg.Env.BaseFile
-		loader := env.NewLoader()
-		baseEnv, err := loader.LoadEnvFile(baseFilePath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Base environment file (%s) is not readable: %v\n", cfg.Env.BaseFile, err)
-			hasIssues = true
-		} else {
-			fmt.Printf("✓ Base environment file exists: %s (%d vars)\n", cfg.Env.BaseFile, len(baseEnv))
-		}
-	} else {
-		fmt.Println("ℹ No base environment file configured")
+		return fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
 	}
 
-	// Check context
-	contextName, err := context.DetectContext()
+	// Detect context
+	contextName, err := context.DetectContextWithSource(cfg.GetContextSource())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to detect context: %v\n", err)
-		hasIssues = true
-	} else {
-		fmt.Printf("✓ Context detected: %s\n", contextName)
+		return fmt.Errorf("failed to detect context: %w", err)
 	}
 
-	// Check registry
+	// Get project identifier (normalized project root for worktrees)
 	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: Failed to get project identifier: %v\n", err)
-		hasIssues = true
-	} else {
-		reg, err := registry.LoadRegistry(projectIdentifier)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to load registry: %v\n", err)
-			hasIssues = true
-		} else {
-			defer reg.Close()
-			ctx, err := reg.GetContext(projectIdentifier, contextName)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: Context '%s' not found in registry\n", contextName)
-				hasIssues = true
-			} else {
-				// Count all overrides (global + service-specific)
-				globalCount := 0
-				serviceCount := 0
-				if ctx.EnvOverridesV2 != nil {
-					globalCount = len(ctx.EnvOverridesV2.Global)
-					for _, serviceOverrides := range ctx.EnvOverridesV2.Services {
-						serviceCount += len(serviceOverrides)
-					}
-				}
-				totalCount := globalCount + serviceCount
-				if totalCount > 0 {
-					fmt.Printf("✓ Context has %d environment override(s) (%d global, %d service-specific)\n",
-						totalCount, globalCount, serviceCount)
-				} else {
-					fmt.Println("ℹ Context has no environment overrides")
-				}
-			}
-		}
+		return fmt.Errorf("failed to get project identifier: %w", err)
 	}
 
-	if hasIssues {
-		fmt.Println("\n❌ Environment configuration has issues")
-		return fmt.Errorf("environment configuration has issues")
+	// If service is specified, validate it exists in config
+	if err := resolveServiceFlag(cfg); err != nil {
+		return err
 	}
 
-	fmt.Println("\n✓ Environment configuration is valid")
-	return nil
-}
-
-type envDiff struct {
-	changed map[string][2]string
-	added   map[string]string
-	removed map[string]string
-}
-
-func runEnvDiff(cmd *cobra.Command, args []string) error {
-	context1 := args[0]
-	context2 := args[1]
+	var change registry.EnvOverrideChange
+	err = registry.Update(projectIdentifier, func(reg *registry.Registry) error {
+		if _, err := reg.GetContext(projectIdentifier, contextName); err != nil {
+			return fmt.Errorf("context %q not found in registry\nHint: Run 'dual create <branch>' to create a worktree with a context", contextName)
+		}
 
-	// Initialize logger
-	logger.Init(envVerbose, envDebug)
+		change, err = reg.RollbackEnvOverride(projectIdentifier, contextName, key, envServiceFlag)
+		if errors.Is(err, registry.ErrNoOverrideHistory) {
+			scope := ""
+			if envServiceFlag != "" {
+				scope = fmt.Sprintf(" in service '%s'", envServiceFlag)
+			}
+			return fmt.Errorf("no recorded changes to %s%s in context '%s'\nHint: Only changes made with 'dual env set' and 'dual env unset' can be rolled back", key, scope, contextName)
+		} else if err != nil {
+			return fmt.Errorf("failed to roll back environment override: %w", err)
+		}
 
-	// Load environments for both contexts
-	merged1, merged2, err := loadAndMergeContextEnvs(context1, context2)
+		// Generate service env files, unless deferred to 'dual env remap'
+		if envNoGenerate {
+			logger.Verbose("Skipping service env file generation (--no-generate)")
+		} else if err := env.GenerateServiceEnvFiles(cfg, reg, projectIdentifier, projectIdentifier, contextName); err != nil {
+			logger.Warn("failed to regenerate service env files: %v", err)
+			// Don't fail the command - the override is restored, env files are optional
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	// Calculate differences
-	diff := calculateEnvDiff(merged1, merged2)
-
-	// Display results
-	displayEnvDiff(context1, context2, diff)
+	scope := "global"
+	if envServiceFlag != "" {
+		scope = fmt.Sprintf("service '%s'", envServiceFlag)
+	}
+	if change.Previous == nil {
+		fmt.Printf("Rolled back %s in context '%s' (%s): removed, it was not set before\n", key, contextName, scope)
+	} else {
+		fmt.Printf("Rolled back %s in context '%s' (%s): restored %s=%s\n", key, contextName, scope, key, *change.Previous)
+	}
 
 	return nil
 }
 
-func loadAndMergeContextEnvs(context1, context2 string) (map[string]string, map[string]string, error) {
-	// Load config
-	cfg, projectRoot, err := config.LoadConfig()
+func runEnvExport(cmd *cobra.Command, args []string) error {
+	// Initialize logger
+	logger.Init(envVerbose, envDebug)
+
+	filter, err := env.NewKeyFilter(envExportPrefixes, envExportMatches)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
+		return fmt.Errorf("invalid --match: %w", err)
 	}
 
-	// Get project identifier (normalized project root for worktrees)
-	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get project identifier: %w", err)
+	if err := validateExportFlags(cmd); err != nil {
+		return err
 	}
 
-	// Load registry (use projectIdentifier which points to parent repo for worktrees)
-	reg, err := registry.LoadRegistry(projectIdentifier)
+	tmpl, err := loadExportTemplate(cmd)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load registry: %w", err)
+		return err
 	}
-	defer reg.Close()
 
-	// Get both contexts
-	ctx1, err := reg.GetContext(projectIdentifier, context1)
+	// Load config
+	cfg, projectRoot, err := config.LoadConfig()
 	if err != nil {
-		return nil, nil, fmt.Errorf("context %q not found in registry", context1)
+		return fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
 	}
 
-	ctx2, err := reg.GetContext(projectIdentifier, context2)
-	if err != nil {
-		return nil, nil, fmt.Errorf("context %q not found in registry", context2)
+	if envExportProfile != "" {
+		if _, err := cfg.GetProfile(envExportProfile); err != nil {
+			return err
+		}
 	}
 
-	// Load environments for both contexts (using global overrides)
-	// Note: not passing a service name here as we want to compare global environments
-	env1, err := env.LoadLayeredEnv(projectRoot, cfg, "", context1, ctx1.GetEnvOverrides(""))
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load environment for %q: %w", context1, err)
+	// Fall back to DUAL_SERVICE when --service is not given
+	if err := applyServiceEnvVar(cfg); err != nil {
+		return err
 	}
 
-	env2, err := env.LoadLayeredEnv(projectRoot, cfg, "", context2, ctx2.GetEnvOverrides(""))
+	// Detect context, unless --context names one
+	contextName, err := envContextName(cfg)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load environment for %q: %w", context2, err)
+		return fmt.Errorf("failed to detect context: %w", err)
 	}
 
-	// Merge environments
-	return env1.Merge(), env2.Merge(), nil
+	// Get project identifier (normalized project root for worktrees)
+	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to get project identifier: %w", err)
+	}
+
+	// Load registry (use projectIdentifier which points to parent repo for worktrees)
+	reg, err := registry.LoadRegistryReadOnly(projectIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+	defer reg.Close()
+
+	// If service is specified, validate it exists in config
+	if err := resolveServiceFlag(cfg); err != nil {
+		return err
+	}
+
+	ctx, err := exportContext(reg, projectIdentifier, contextName)
+	if err != nil {
+		return err
+	}
+
+	if envCheckUndefined {
+		if err := checkExportUndefined(cfg, projectRoot, projectIdentifier); err != nil {
+			return err
+		}
+	}
+
+	opts := &exportOptions{
+		cfg:               cfg,
+		projectRoot:       projectRoot,
+		projectIdentifier: projectIdentifier,
+		contextName:       contextName,
+		ctx:               ctx,
+		filter:            filter,
+		resolver:          env.NewCommandSecretResolver(cfg.Env.Secrets),
+		tmpl:              tmpl,
+	}
+	if envExportAll {
+		return exportAllServices(opts)
+	}
+
+	data, count, err := renderEnvExport(opts, envServiceFlag)
+	if err != nil {
+		return err
+	}
+	return writeExportOutput(data, count, tmpl != nil)
+}
+
+// validateExportFlags checks the combinations of export flags that cannot
+// be used together, before anything is loaded
+func validateExportFlags(cmd *cobra.Command) error {
+	if envExportFilePriority && envExportMergeFile == "" {
+		return fmt.Errorf("--file-priority requires --merge-file")
+	}
+
+	if envExportOnlySecrets && envExportNoSecrets {
+		return fmt.Errorf("--only-secrets and --exclude-secrets cannot be used together")
+	}
+
+	if err := validateExportAllFlags(); err != nil {
+		return err
+	}
+
+	if err := validateExportGroupedFlags(); err != nil {
+		return err
+	}
+
+	if cmd.Flags().Changed("key-transform") && envExportFormat != "properties" {
+		return fmt.Errorf("--key-transform requires --format=properties")
+	}
+
+	return validateEnvironmentFlag(cmd, false)
+}
+
+// exportContext returns the context to export from the registry.
+// A context that is not in the registry is OK for export: we can still
+// export base and service layers, just without overrides. It is only an
+// error when --context names it explicitly.
+func exportContext(reg *registry.Registry, projectIdentifier, contextName string) (*registry.Context, error) {
+	ctx, err := reg.GetContext(projectIdentifier, contextName)
+	if err != nil {
+		if envContextFlag != "" {
+			return nil, fmt.Errorf("context %q not found in registry\nHint: Run 'dual list' to see available contexts", contextName)
+		}
+		logger.Debug("Context not in registry, proceeding without overrides: %v", err)
+		return nil, nil
+	}
+	warnEmptyEnvironment(ctx, contextName)
+	return ctx, nil
+}
+
+// checkExportUndefined fails if the env files of the exported service (every
+// service with --all) reference variables that expand to nothing
+func checkExportUndefined(cfg *config.Config, projectRoot, projectIdentifier string) error {
+	services := []string{envServiceFlag}
+	if envExportAll {
+		services = getServiceNames(cfg)
+	}
+	problems, _, err := undefinedReferences(cfg, projectRoot, projectIdentifier, services)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("env files reference undefined variables, which expand to empty values:\n  %s\nHint: Define each variable earlier in the same file; expansion does not see other env files or overrides", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// writeExportOutput writes a single export to stdout, or atomically to the
+// --output file and reports the result
+func writeExportOutput(data []byte, count int, rendered bool) error {
+	if envExportOutput == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	// The merge already carries over the file's contents, so rewriting it is safe
+	force := envExportForce || (envExportMergeFile != "" && samePath(envExportMergeFile, envExportOutput))
+	written, err := writeExportFile(envExportOutput, data, force)
+	if err != nil {
+		return err
+	}
+	switch {
+	case !written:
+		logger.Info("%s is already up to date", envExportOutput)
+	case rendered:
+		logger.Info("Rendered %s to %s", envExportTemplate, envExportOutput)
+	default:
+		logger.Info("Exported %d variable(s) to %s", count, envExportOutput)
+	}
+	return nil
+}
+
+// loadExportTemplate reads and parses the --template file, or returns nil
+// without --template. Template syntax errors are reported before any
+// environment is loaded.
+func loadExportTemplate(cmd *cobra.Command) (*env.Template, error) {
+	if envExportTemplate == "" {
+		if cmd.Flags().Changed("template-default") {
+			return nil, fmt.Errorf("--template-default requires --template")
+		}
+		return nil, nil
+	}
+
+	conflicts := []struct {
+		flag string
+		set  bool
+	}{
+		{"--all", envExportAll},
+		{"--format", cmd.Flags().Changed("format")},
+		{"--merge-file", envExportMergeFile != ""},
+	}
+	for _, c := range conflicts {
+		if c.set {
+			return nil, fmt.Errorf("%s cannot be used with --template", c.flag)
+		}
+	}
+
+	// #nosec G304 - Reading the file the user passed with --template is intentional
+	text, err := os.ReadFile(envExportTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	tmpl, err := env.ParseTemplate(filepath.Base(envExportTemplate), string(text))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", envExportTemplate, err)
+	}
+	if cmd.Flags().Changed("template-default") {
+		tmpl.MissingDefault = &envExportTemplateDef
+	}
+	return tmpl, nil
+}
+
+// exportFileExtensions maps --format to the file extension used by --all
+var exportFileExtensions = map[string]string{
+	"dotenv":        ".env",
+	"json":          ".json",
+	"shell":         ".sh",
+	"properties":    ".properties",
+	"k8s-configmap": ".yaml",
+	"k8s-secret":    ".yaml",
+}
+
+// validateEnvironmentFlag checks --env. Commands that write overrides cannot
+// combine it with --service, as an environment overlay applies to every service.
+func validateEnvironmentFlag(cmd *cobra.Command, writes bool) error {
+	if !cmd.Flags().Changed("env") {
+		return nil
+	}
+	if strings.TrimSpace(envEnvironmentFlag) == "" {
+		return fmt.Errorf("--env cannot be empty")
+	}
+	if writes && envServiceFlag != "" {
+		return fmt.Errorf("--env and --service cannot be used together\nHint: An environment overlay applies to every service")
+	}
+	return nil
+}
+
+// warnEmptyEnvironment warns when --env names an environment the context has
+// no overlay for, which is most likely a typo
+func warnEmptyEnvironment(ctx *registry.Context, contextName string) {
+	if envEnvironmentFlag != "" && len(ctx.GetEnvironmentOverrides(envEnvironmentFlag)) == 0 {
+		logger.Warn("Context '%s' has no overrides for environment '%s'", contextName, envEnvironmentFlag)
+	}
+}
+
+// validateExportAllFlags checks that --all and --dir are used together and
+// without the flags that only make sense for a single output
+func validateExportAllFlags() error {
+	if !envExportAll {
+		if envExportDir != "" {
+			return fmt.Errorf("--dir requires --all")
+		}
+		return nil
+	}
+
+	if envExportDir == "" {
+		return fmt.Errorf("--all requires --dir\nHint: e.g. dual env export --all --dir .env.d")
+	}
+	conflicts := []struct {
+		flag string
+		set  bool
+	}{
+		{"--service", envServiceFlag != ""},
+		{"--output", envExportOutput != ""},
+		{"--merge-file", envExportMergeFile != ""},
+		{"--name", envExportName != ""},
+	}
+	for _, c := range conflicts {
+		if c.set {
+			return fmt.Errorf("%s cannot be used with --all", c.flag)
+		}
+	}
+	if _, ok := exportFileExtensions[envExportFormat]; !ok {
+		return fmt.Errorf("--format=%s is not supported with --all (supported: dotenv, json, shell, properties, k8s-configmap, k8s-secret)", envExportFormat)
+	}
+	return nil
+}
+
+// validateExportGroupedFlags checks that --grouped is only used with the JSON
+// format and without the flags that reshape the merged result
+func validateExportGroupedFlags() error {
+	if !envExportGrouped {
+		return nil
+	}
+
+	if envExportFormat != "json" {
+		return fmt.Errorf("--grouped requires --format=json")
+	}
+	conflicts := []struct {
+		flag string
+		set  bool
+	}{
+		{"--overrides-only", envExportOverrideOnly},
+		{"--merge-file", envExportMergeFile != ""},
+		{"--template", envExportTemplate != ""},
+	}
+	for _, c := range conflicts {
+		if c.set {
+			return fmt.Errorf("%s cannot be used with --grouped", c.flag)
+		}
+	}
+	return nil
+}
+
+// exportOptions holds what every service's export shares: the loaded project
+// and context, the --prefix/--match filter and the secret resolver. ctx is nil
+// when the context is not in the registry, cache is nil outside multi-service
+// exports and tmpl is nil without --template.
+type exportOptions struct {
+	cfg               *config.Config
+	projectRoot       string
+	projectIdentifier string
+	contextName       string
+	ctx               *registry.Context
+	filter            *env.KeyFilter
+	resolver          env.SecretResolver
+	cache             *env.FileCache
+	tmpl              *env.Template
+}
+
+// exportAllServices writes each service's environment to its own file in
+// --dir, named after the service, and reports each file written
+func exportAllServices(opts *exportOptions) error {
+	names := getServiceNames(opts.cfg)
+	if len(names) == 0 {
+		return fmt.Errorf("no services configured\nHint: Add one with 'dual service add <name> --path <path>'")
+	}
+
+	if err := os.MkdirAll(envExportDir, 0o750); err != nil {
+		return fmt.Errorf("failed to create %s: %w", envExportDir, err)
+	}
+
+	// Render everything first so a failing service leaves no partial set,
+	// sharing parsed env files (e.g. the base file) between services
+	opts.cache = env.NewFileCache()
+	files := make([][]byte, len(names))
+	counts := make([]int, len(names))
+	for i, name := range names {
+		data, count, err := renderEnvExport(opts, name)
+		if err != nil {
+			return fmt.Errorf("service %s: %w", name, err)
+		}
+		files[i], counts[i] = data, count
+	}
+
+	for i, name := range names {
+		path := filepath.Join(envExportDir, name+exportFileExtensions[envExportFormat])
+		written, err := writeExportFile(path, files[i], envExportForce)
+		if err != nil {
+			return err
+		}
+		if written {
+			logger.Info("Exported %d variable(s) to %s", counts[i], path)
+		} else {
+			logger.Info("%s is already up to date (%d variable(s))", path, counts[i])
+		}
+	}
+
+	return nil
+}
+
+// renderEnvExport merges, filters and resolves one service's environment (or
+// the project-wide one for an empty serviceName) and renders it in the
+// --format, or with opts.tmpl when it is non-nil. It also returns the number
+// of exported variables.
+func renderEnvExport(opts *exportOptions, serviceName string) ([]byte, int, error) {
+	layeredEnv, err := loadExportEnv(opts, serviceName)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Compare against the base env before resolving, so unchanged secret
+	// references count as unchanged
+	vars := layeredEnv.Merge()
+	if envExportOverrideOnly {
+		vars = layeredEnv.DiffFromBase()
+	}
+
+	// Filter before resolving so secrets outside --prefix/--match are never
+	// fetched, let alone exported. Secret references are only recognisable
+	// before resolution, so classify secrets here too.
+	vars = filterSecrets(opts.filter.Apply(vars))
+	merged, err := env.ResolveSecrets(vars, opts.resolver)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w\nHint: Check that the secret manager CLI is installed and you are signed in", err)
+	}
+
+	// Overlay onto the existing file, keeping keys only it defines
+	var fileOnlyKeys []string
+	if envExportMergeFile != "" {
+		merged, fileOnlyKeys, err = mergeWithEnvFile(merged, envExportMergeFile, envExportFilePriority)
+		if err != nil {
+			return nil, 0, err
+		}
+		fileOnlyKeys = dropFilteredSecrets(merged, fileOnlyKeys)
+	}
+
+	if opts.tmpl != nil {
+		data, err := opts.tmpl.Render(merged)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w\nHint: Set the variables, or use --template-default to render unset ones as a fixed value", err)
+		}
+		return data, len(merged), nil
+	}
+
+	keys, err := exportKeyOrder(layeredEnv, merged, fileOnlyKeys)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	data, err := renderExportFormat(opts, serviceName, layeredEnv, keys, merged)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, len(merged), nil
+}
+
+// loadExportEnv loads the layered environment of a service with the
+// context's overrides for the --env overlay, and the --profile on top
+func loadExportEnv(opts *exportOptions, serviceName string) (*env.LayeredEnv, error) {
+	// Get environment overrides for the service (or global if no service specified)
+	var overrides map[string]string
+	if opts.ctx != nil {
+		overrides = opts.ctx.GetEnvOverridesForEnvironment(serviceName, envEnvironmentFlag)
+	}
+
+	// LoadLayeredEnv will try to load overrides from filesystem if not provided
+	layeredEnv, err := env.LoadLayeredEnvWithCache(opts.projectRoot, opts.cfg, serviceName, opts.contextName, overrides, opts.cache)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load environment: %w", err)
+	}
+	if envExportProfile != "" {
+		profile, err := opts.cfg.GetProfile(envExportProfile)
+		if err != nil {
+			return nil, err
+		}
+		layeredEnv.ApplyProfile(profile)
+	}
+	return layeredEnv, nil
+}
+
+// exportKeyOrder orders the exported keys for --sort: alphabetically for
+// consistent output, or as loaded with keys only the --merge-file defines last
+func exportKeyOrder(layeredEnv *env.LayeredEnv, merged map[string]string, fileOnlyKeys []string) ([]string, error) {
+	switch envExportSort {
+	case "name":
+		return sortedMapKeys(merged), nil
+	case "off":
+		var keys []string
+		for _, k := range layeredEnv.Keys() {
+			if _, ok := merged[k]; ok {
+				keys = append(keys, k)
+			}
+		}
+		return append(keys, fileOnlyKeys...), nil
+	}
+	return nil, fmt.Errorf("unsupported sort order: %s (supported: name, off)", envExportSort)
+}
+
+// renderExportFormat renders the merged variables in the --format, in the
+// given key order
+func renderExportFormat(opts *exportOptions, serviceName string, layeredEnv *env.LayeredEnv, keys []string, merged map[string]string) ([]byte, error) {
+	switch envExportFormat {
+	case "dotenv":
+		return renderDotenv(keys, merged), nil
+	case "json":
+		return renderJSON(opts, layeredEnv, keys, merged), nil
+	case "shell":
+		return renderShell(keys, merged), nil
+	case "envrc":
+		return renderEnvrc(opts, serviceName, keys, merged), nil
+	case "properties":
+		return renderProperties(keys, merged, envExportKeyTransform)
+	case "k8s-configmap", "k8s-secret":
+		return renderK8sExport(opts, serviceName, keys, merged)
+	}
+	return nil, fmt.Errorf("unsupported format: %s (supported: dotenv, json, shell, envrc, properties, k8s-configmap, k8s-secret)", envExportFormat)
+}
+
+// renderDotenv renders the environment as a dotenv file, quoting values that
+// contain spaces or quotes
+func renderDotenv(keys []string, vars map[string]string) []byte {
+	var out bytes.Buffer
+	for _, k := range keys {
+		v := vars[k]
+		if strings.ContainsAny(v, " \t\n\"'") {
+			v = fmt.Sprintf(`"%s"`, strings.ReplaceAll(v, `"`, `\"`))
+		}
+		fmt.Fprintf(&out, "%s=%s\n", k, v)
+	}
+	return out.Bytes()
+}
+
+// renderJSON renders the environment as a JSON object, or with --grouped
+// every layer next to the merged result
+func renderJSON(opts *exportOptions, layeredEnv *env.LayeredEnv, keys []string, vars map[string]string) []byte {
+	var out bytes.Buffer
+	if envExportGrouped {
+		writeGroupedJSON(&out, layeredEnv, keys, vars, opts.filter)
+	} else {
+		writeJSONObject(&out, keys, vars, "")
+	}
+	out.WriteString("\n")
+	return out.Bytes()
+}
+
+// renderShell renders the environment as single-quoted export statements
+func renderShell(keys []string, vars map[string]string) []byte {
+	var out bytes.Buffer
+	for _, k := range keys {
+		v := strings.ReplaceAll(vars[k], `'`, `'\''`)
+		fmt.Fprintf(&out, "export %s='%s'\n", k, v)
+	}
+	return out.Bytes()
+}
+
+// renderEnvrc renders the environment as a direnv .envrc, preceded with
+// --watch by watch_file directives for every file feeding the environment
+func renderEnvrc(opts *exportOptions, serviceName string, keys []string, vars map[string]string) []byte {
+	var out bytes.Buffer
+	if envExportWatch {
+		watchFiles := env.LayeredEnvFiles(opts.projectRoot, opts.cfg, serviceName, opts.contextName)
+		if registryPath, err := registry.GetRegistryPath(opts.projectIdentifier); err == nil {
+			watchFiles = append(watchFiles, registryPath)
+		}
+		for _, f := range watchFiles {
+			fmt.Fprintf(&out, "watch_file %s\n", quoteEnvrcValue(f))
+		}
+	}
+	for _, k := range keys {
+		fmt.Fprintf(&out, "export %s=%s\n", k, quoteEnvrcValue(vars[k]))
+	}
+	return out.Bytes()
+}
+
+// renderK8sExport renders the environment as a ConfigMap or Secret named by
+// --name, or after the context and service
+func renderK8sExport(opts *exportOptions, serviceName string, keys []string, vars map[string]string) ([]byte, error) {
+	name := envExportName
+	if name == "" {
+		name = defaultK8sName(opts.contextName, serviceName)
+	}
+	if err := validateDNS1123Name(name); err != nil {
+		return nil, fmt.Errorf("invalid resource name %q: %w\nHint: Use --name with lowercase letters, digits, '-' and '.'", name, err)
+	}
+	data, err := renderK8sManifest(envExportFormat == "k8s-secret", name, keys, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render manifest: %w", err)
+	}
+	return data, nil
+}
+
+// writeJSONObject writes vars as a JSON object with its keys in the given
+// order, indenting nested lines by indent. encoding/json always sorts map
+// keys, so the object is written by hand.
+func writeJSONObject(out *bytes.Buffer, keys []string, vars map[string]string, indent string) {
+	out.WriteString("{")
+	for i, k := range keys {
+		keyJSON, _ := json.Marshal(k)
+		valueJSON, _ := json.Marshal(vars[k])
+		if i > 0 {
+			out.WriteString(",")
+		}
+		fmt.Fprintf(out, "\n%s  %s: %s", indent, keyJSON, valueJSON)
+	}
+	if len(keys) > 0 {
+		out.WriteString("\n" + indent)
+	}
+	out.WriteString("}")
+}
+
+// writeGroupedJSON writes each layer of layeredEnv, passed through the same
+// --prefix, --match and secret filters as the export, followed by the merged
+// result. Keys follow the export's order; layer keys the merged result
+// leaves out come last, alphabetically.
+func writeGroupedJSON(out *bytes.Buffer, layeredEnv *env.LayeredEnv, keys []string, merged map[string]string, filter *env.KeyFilter) {
+	groups := []struct {
+		name string
+		vars map[string]string
+	}{
+		{"base", layeredEnv.Base},
+		{"serviceBase", layeredEnv.ServiceBase},
+		{"service", layeredEnv.Service},
+		{"overrides", layeredEnv.Overrides},
+	}
+
+	out.WriteString("{")
+	for _, group := range groups {
+		vars := filterSecrets(filter.Apply(group.vars))
+
+		var groupKeys []string
+		for _, k := range keys {
+			if _, ok := vars[k]; ok {
+				groupKeys = append(groupKeys, k)
+			}
+		}
+		var rest []string
+		for k := range vars {
+			if _, ok := merged[k]; !ok {
+				rest = append(rest, k)
+			}
+		}
+		sort.Strings(rest)
+		groupKeys = append(groupKeys, rest...)
+
+		fmt.Fprintf(out, "\n  %q: ", group.name)
+		writeJSONObject(out, groupKeys, vars, "  ")
+		out.WriteString(",")
+	}
+	out.WriteString("\n  \"merged\": ")
+	writeJSONObject(out, keys, merged, "  ")
+	out.WriteString("\n}")
+}
+
+// quoteEnvrcValue double-quotes a value for a bash .envrc, escaping the
+// characters that are special inside double quotes
+func quoteEnvrcValue(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(value)
+	return `"` + escaped + `"`
+}
+
+var (
+	// dns1123Label matches one label of a DNS-1123 subdomain
+	dns1123Label = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	// dns1123Invalid matches runs of characters not allowed in a DNS-1123 subdomain
+	dns1123Invalid = regexp.MustCompile(`[^a-z0-9.-]+`)
+)
+
+// validateDNS1123Name checks a Kubernetes resource name is a valid DNS-1123 subdomain
+func validateDNS1123Name(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is empty")
+	}
+	if len(name) > 253 {
+		return fmt.Errorf("must be no more than 253 characters")
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !dns1123Label.MatchString(label) {
+			return fmt.Errorf("must consist of lowercase alphanumeric characters, '-' or '.', and start and end with an alphanumeric character")
+		}
+	}
+	return nil
+}
+
+// defaultK8sName derives a resource name from the context and service, e.g.
+// "feature-auth-api-env", replacing characters Kubernetes does not allow
+func defaultK8sName(contextName, serviceName string) string {
+	parts := []string{contextName}
+	if serviceName != "" {
+		parts = append(parts, serviceName)
+	}
+	parts = append(parts, "env")
+
+	name := strings.ToLower(strings.Join(parts, "-"))
+	name = dns1123Invalid.ReplaceAllString(name, "-")
+	name = strings.Trim(name, "-.")
+	if len(name) > 253 {
+		name = strings.TrimRight(name[:253], "-.")
+	}
+	return name
+}
+
+// renderK8sManifest renders the environment as a ConfigMap, or as an Opaque
+// Secret with base64-encoded values, keeping data keys in the given order
+func renderK8sManifest(secret bool, name string, keys []string, vars map[string]string) ([]byte, error) {
+	type metadata struct {
+		Name string `yaml:"name"`
+	}
+	type manifest struct {
+		APIVersion string    `yaml:"apiVersion"`
+		Kind       string    `yaml:"kind"`
+		Metadata   metadata  `yaml:"metadata"`
+		Type       string    `yaml:"type,omitempty"`
+		Data       yaml.Node `yaml:"data"`
+	}
+
+	m := manifest{
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Metadata:   metadata{Name: name},
+		Data:       yaml.Node{Kind: yaml.MappingNode},
+	}
+	if secret {
+		m.Kind = "Secret"
+		m.Type = "Opaque"
+	}
+
+	for _, k := range keys {
+		value := vars[k]
+		if secret {
+			value = base64.StdEncoding.EncodeToString([]byte(value))
+		}
+		m.Data.Content = append(m.Data.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: k},
+			// Force string style so values like "true" or "8080" stay strings
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+		)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(m); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderProperties renders the environment as a Java .properties file in the
+// given key order, with names changed by the --key-transform
+func renderProperties(keys []string, vars map[string]string, transform string) ([]byte, error) {
+	var out bytes.Buffer
+	seen := make(map[string]string, len(keys))
+	for _, k := range keys {
+		name, err := propertiesKey(k, transform)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("%s and %s both become %q with --key-transform=%s", other, k, name, transform)
+		}
+		seen[name] = k
+		fmt.Fprintf(&out, "%s=%s\n", escapeProperty(name, true), escapeProperty(vars[k], false))
+	}
+	return out.Bytes(), nil
+}
+
+// propertiesKey applies a --key-transform to a variable name: "none" keeps
+// it, "dotted" lowercases it and turns underscores into dots
+func propertiesKey(key, transform string) (string, error) {
+	switch transform {
+	case "none":
+		return key, nil
+	case "dotted":
+		return strings.ReplaceAll(strings.ToLower(key), "_", "."), nil
+	}
+	return "", fmt.Errorf("unsupported key transform: %s (supported: none, dotted)", transform)
+}
+
+// escapeProperty escapes a .properties key or value the way
+// java.util.Properties.store does: backslashes, the separators '=' and ':',
+// the comment characters '#' and '!', control characters, and spaces (in
+// keys, or leading in values). Characters outside printable ASCII are written
+// as \uXXXX escapes, so the file reads the same in any encoding.
+func escapeProperty(s string, isKey bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case ' ':
+			if isKey || i == 0 {
+				b.WriteString(`\ `)
+			} else {
+				b.WriteRune(r)
+			}
+		case '\\', '=', ':', '#', '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		default:
+			if r < 0x20 || r > 0x7e {
+				for _, unit := range utf16.Encode([]rune{r}) {
+					fmt.Fprintf(&b, `\u%04X`, unit)
+				}
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	return b.String()
+}
+
+// writeExportFile atomically writes exported environment data to path with
+// owner-only permissions, since the output usually contains secrets.
+// An existing file with different content is only replaced when force is set.
+// It reports whether the file was written.
+func writeExportFile(path string, data []byte, force bool) (bool, error) {
+	// #nosec G304 - path is the --output file or a file in the --dir the user chose
+	existing, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if bytes.Equal(existing, data) {
+			return false, nil
+		}
+		if !force {
+			return false, fmt.Errorf("%s already exists and differs from the exported environment\nHint: Use --force to overwrite it", path)
+		}
+	case !os.IsNotExist(err):
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	// Write to temporary file next to the destination so the rename is atomic
+	tempFile := path + ".tmp"
+	if err := os.WriteFile(tempFile, data, 0o600); err != nil {
+		return false, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	// WriteFile keeps the mode of a stale temp file, so enforce 0600 explicitly
+	if err := os.Chmod(tempFile, 0o600); err != nil {
+		_ = os.Remove(tempFile)
+		return false, fmt.Errorf("failed to set permissions on temporary file: %w", err)
+	}
+
+	// Atomic rename
+	if err := os.Rename(tempFile, path); err != nil {
+		_ = os.Remove(tempFile) // Clean up temp file on error
+		return false, fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return true, nil
+}
+
+// mergeWithEnvFile overlays vars onto the dotenv file at path. Values from vars
+// win unless filePriority is set. It also returns the keys only the file
+// defines, in file order. A missing file is treated as empty.
+func mergeWithEnvFile(vars map[string]string, path string, filePriority bool) (map[string]string, []string, error) {
+	fileVars, fileKeys, err := env.LoadEnvFileOrdered(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load --merge-file %s: %w", path, err)
+	}
+
+	merged := make(map[string]string, len(vars)+len(fileVars))
+	for k, v := range vars {
+		merged[k] = v
+	}
+
+	var fileOnlyKeys []string
+	for _, k := range fileKeys {
+		if _, ok := vars[k]; !ok {
+			fileOnlyKeys = append(fileOnlyKeys, k)
+			merged[k] = fileVars[k]
+		} else if filePriority {
+			merged[k] = fileVars[k]
+		}
+	}
+
+	return merged, fileOnlyKeys, nil
+}
+
+// samePath reports whether two paths refer to the same location
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
+
+// keepSecretClass reports whether a variable passes --only-secrets or
+// --exclude-secrets; with neither flag every variable is kept
+func keepSecretClass(key, value string) bool {
+	switch {
+	case envExportOnlySecrets:
+		return env.IsSecret(key, value)
+	case envExportNoSecrets:
+		return !env.IsSecret(key, value)
+	default:
+		return true
+	}
+}
+
+// filterSecrets returns the variables that pass keepSecretClass
+func filterSecrets(vars map[string]string) map[string]string {
+	kept := make(map[string]string, len(vars))
+	for k, v := range vars {
+		if keepSecretClass(k, v) {
+			kept[k] = v
+		}
+	}
+	return kept
+}
+
+// dropFilteredSecrets removes --merge-file keys that fail keepSecretClass from
+// merged, so a merge cannot reintroduce the variables the flags left out. It
+// returns the remaining keys.
+func dropFilteredSecrets(merged map[string]string, fileOnlyKeys []string) []string {
+	kept := fileOnlyKeys[:0]
+	for _, k := range fileOnlyKeys {
+		if keepSecretClass(k, merged[k]) {
+			kept = append(kept, k)
+		} else {
+			delete(merged, k)
+		}
+	}
+	return kept
+}
+
+func runEnvCheck(cmd *cobra.Command, args []string) error {
+	// Initialize logger
+	logger.Init(envVerbose, envDebug)
+
+	// Load config
+	cfg, projectRoot, err := config.LoadConfig()
+	if err != nil {
+		logger.Error("Failed to load config: %v", err)
+		return fmt.Errorf("configuration check failed")
+	}
+
+	// Fall back to DUAL_SERVICE when --service is not given
+	if err := applyServiceEnvVar(cfg); err != nil {
+		return err
+	}
+
+	// Validate service flag before running any checks
+	if err := resolveServiceFlag(cfg); err != nil {
+		return err
+	}
+
+	hasIssues := false
+
+	// Check base environment file
+	if cfg.Env.BaseFile != "" {
+		baseFilePath := projectRoot + "/" + cfg.Env.BaseFile
+		loader := env.NewLoader()
+		baseEnv, err := loader.LoadEnvFile(baseFilePath)
+		if err != nil {
+			logger.Error("Base environment file (%s) is not readable: %v", cfg.Env.BaseFile, err)
+			hasIssues = true
+		} else {
+			fmt.Printf("✓ Base environment file exists: %s (%d vars)\n", cfg.Env.BaseFile, len(baseEnv))
+		}
+	} else {
+		fmt.Println("ℹ No base environment file configured")
+	}
+
+	// Check context
+	contextName, err := envContextName(cfg)
+	if err != nil {
+		logger.Error("Failed to detect context: %v", err)
+		hasIssues = true
+	} else if envContextFlag != "" {
+		fmt.Printf("✓ Context selected: %s\n", contextName)
+	} else {
+		fmt.Printf("✓ Context detected: %s\n", contextName)
+	}
+
+	// Check registry
+	var ctx *registry.Context
+	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
+	if err != nil {
+		logger.Error("Failed to get project identifier: %v", err)
+		hasIssues = true
+	} else {
+		reg, err := registry.LoadRegistryReadOnly(projectIdentifier)
+		if err != nil {
+			logger.Error("Failed to load registry: %v", err)
+			hasIssues = true
+		} else {
+			defer reg.Close()
+			ctx, err = reg.GetContext(projectIdentifier, contextName)
+			if err != nil {
+				logger.Error("Context '%s' not found in registry", contextName)
+				hasIssues = true
+			} else {
+				// Count all overrides (global + service-specific)
+				globalCount := 0
+				serviceCount := 0
+				if ctx.EnvOverridesV2 != nil {
+					globalCount = len(ctx.EnvOverridesV2.Global)
+					for _, serviceOverrides := range ctx.EnvOverridesV2.Services {
+						serviceCount += len(serviceOverrides)
+					}
+				}
+				totalCount := globalCount + serviceCount
+				if totalCount > 0 {
+					fmt.Printf("✓ Context has %d environment override(s) (%d global, %d service-specific)\n",
+						totalCount, globalCount, serviceCount)
+				} else {
+					fmt.Println("ℹ Context has no environment overrides")
+				}
+			}
+		}
+	}
+
+	// Check service-specific environment
+	if envServiceFlag != "" {
+		if !checkServiceEnv(cfg, projectRoot, projectIdentifier, contextName, envServiceFlag, ctx) {
+			hasIssues = true
+		}
+	}
+
+	// Check env files for circular expansion
+	if envCheckStrict {
+		if !checkExpansionCycles(cfg, projectRoot, projectIdentifier) {
+			hasIssues = true
+		}
+	}
+
+	// Check env files for references that expand to nothing
+	if envCheckUndefined {
+		problems, checked, err := undefinedReferences(cfg, projectRoot, projectIdentifier, getServiceNames(cfg))
+		if err != nil {
+			logger.Error("%v", err)
+			hasIssues = true
+		}
+		for _, problem := range problems {
+			logger.Error("Undefined variable reference in %s", problem)
+			hasIssues = true
+		}
+		if err == nil && len(problems) == 0 {
+			fmt.Printf("✓ No undefined variable references (%d env file(s) checked)\n", checked)
+		}
+	}
+
+	if hasIssues {
+		fmt.Println("\n❌ Environment configuration has issues")
+		return fmt.Errorf("environment configuration has issues")
+	}
+
+	fmt.Println("\n✓ Environment configuration is valid")
+	return nil
+}
+
+// checkExpansionCycles reports circular variable expansion in the base file and
+// every service's base and env files. Service env files fall back to the parent
+// repo's copy in worktrees, like the loader. Returns false if any was found.
+func checkExpansionCycles(cfg *config.Config, projectRoot, projectIdentifier string) bool {
+	ok := true
+	checked := 0
+	for _, file := range serviceEnvFiles(cfg, getServiceNames(cfg)) {
+		path, exists := resolveEnvFilePath(projectRoot, projectIdentifier, file)
+		if !exists {
+			continue
+		}
+		checked++
+
+		cycles, err := env.ExpansionCycles(path)
+		if err != nil {
+			logger.Error("Failed to check %s for circular expansion: %v", file, err)
+			ok = false
+			continue
+		}
+		for _, cycle := range cycles {
+			logger.Error("Circular variable expansion in %s: %s", file, env.FormatCycle(cycle))
+			ok = false
+		}
+	}
+
+	if ok {
+		fmt.Printf("✓ No circular variable expansion (%d env file(s) checked)\n", checked)
+	}
+	return ok
+}
+
+// undefinedReferences checks the base file and the given services' env files
+// for references that expand to nothing, returning one "file: KEY references
+// NAME" line per reference and the number of files checked. An empty service
+// name checks only the base file.
+func undefinedReferences(cfg *config.Config, projectRoot, projectIdentifier string, services []string) ([]string, int, error) {
+	var problems []string
+	checked := 0
+	for _, file := range serviceEnvFiles(cfg, services) {
+		path, exists := resolveEnvFilePath(projectRoot, projectIdentifier, file)
+		if !exists {
+			continue
+		}
+		checked++
+
+		refs, err := env.UndefinedReferences(path)
+		if err != nil {
+			return nil, checked, fmt.Errorf("failed to check %s for undefined variables: %w", file, err)
+		}
+		for _, ref := range refs {
+			problems = append(problems, fmt.Sprintf("%s: %s references %s", file, ref.Key, ref.Name))
+		}
+	}
+	return problems, checked, nil
+}
+
+// serviceEnvFiles returns the env files the given services load, relative to
+// the project root: the base file, then each service's base and env files.
+// Empty service names are skipped and each file is listed once.
+func serviceEnvFiles(cfg *config.Config, services []string) []string {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(file string) {
+		if file != "" && !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	add(cfg.Env.BaseFile)
+	for _, name := range services {
+		service, ok := cfg.Services[name]
+		if !ok {
+			continue
+		}
+		add(service.BaseFile)
+		envFile := service.EnvFile
+		if envFile == "" {
+			envFile = filepath.Join(service.Path, ".env")
+		}
+		add(envFile)
+	}
+	return files
+}
+
+// resolveEnvFilePath finds an env file in the worktree, falling back to the
+// parent repo's copy like the loader. Reports false if neither exists.
+func resolveEnvFilePath(projectRoot, projectIdentifier, file string) (string, bool) {
+	path := filepath.Join(projectRoot, file)
+	if _, err := os.Stat(path); err != nil && projectIdentifier != "" {
+		path = filepath.Join(projectIdentifier, file)
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// checkServiceEnv validates a single service's environment: its env file and
+// the merged variables including its context overrides. Issues are reported
+// with the service name so they stand apart from project-wide checks.
+// Returns false if any issue was found.
+func checkServiceEnv(cfg *config.Config, projectRoot, projectIdentifier, contextName, serviceName string, ctx *registry.Context) bool {
+	ok := true
+	service := cfg.Services[serviceName]
+	fmt.Printf("\nService '%s':\n", serviceName)
+
+	// Check the service's env file (worktrees fall back to the parent repo's copy)
+	envFile := service.EnvFile
+	if envFile == "" {
+		envFile = filepath.Join(service.Path, ".env")
+	}
+	envFileFound := false
+	for _, root := range []string{projectRoot, projectIdentifier} {
+		if root == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(root, envFile)); err == nil {
+			envFileFound = true
+			break
+		}
+	}
+	switch {
+	case envFileFound:
+		fmt.Printf("  ✓ Env file exists: %s\n", envFile)
+	case service.EnvFile != "":
+		logger.Error("[%s] Env file not found: %s", serviceName, service.EnvFile)
+		ok = false
+	default:
+		fmt.Printf("  ℹ No env file at %s\n", envFile)
+	}
+
+	// Load the merged environment with this service's overrides
+	var overrides map[string]string
+	if ctx != nil {
+		overrides = ctx.GetEnvOverrides(serviceName)
+	}
+	layeredEnv, err := env.LoadLayeredEnv(projectRoot, cfg, serviceName, contextName, overrides)
+	if err != nil {
+		logger.Error("[%s] Failed to load environment: %v", serviceName, err)
+		return false
+	}
+	merged := layeredEnv.Merge()
+	stats := layeredEnv.Stats()
+	fmt.Printf("  ✓ Merged environment: %d vars (%d base, %d service base, %d service, %d overrides)\n",
+		stats.TotalVars, stats.BaseVars, stats.ServiceBaseVars, stats.ServiceVars, stats.OverrideVars)
+
+	// Variables declared without a value are required but unset
+	var empty []string
+	for k, v := range merged {
+		if v == "" {
+			empty = append(empty, k)
+		}
+	}
+	sort.Strings(empty)
+	if len(empty) > 0 {
+		logger.Error("[%s] %d variable(s) have no value: %s", serviceName, len(empty), strings.Join(empty, ", "))
+		logger.Detail("  Hint: Set them with 'dual env set --service %s <key> <value>'", serviceName)
+		ok = false
+	}
+
+	return ok
+}
+
+func runEnvDiff(cmd *cobra.Command, args []string) error {
+	context1 := args[0]
+	context2 := args[1]
+
+	// Initialize logger
+	logger.Init(envVerbose, envDebug)
+
+	if envDiffBase != "" {
+		return runEnvDiffThreeWay(envDiffBase, context1, context2)
+	}
+
+	// Load environments for both contexts
+	envs, err := loadContextEnvs(context1, context2)
+	if err != nil {
+		return err
+	}
+
+	// Calculate differences
+	diff := envs[0].Diff(envs[1])
+
+	// Display results
+	if envDiffJSON {
+		return outputEnvDiffJSON(context1, context2, diff)
+	}
+	displayEnvDiff(context1, context2, diff)
+
+	return nil
+}
+
+// runEnvDiffThreeWay compares two contexts against their common base context
+func runEnvDiffThreeWay(baseContext, context1, context2 string) error {
+	envs, err := loadContextEnvs(baseContext, context1, context2)
+	if err != nil {
+		return err
+	}
+
+	baseVars, vars1, vars2 := envs[0].Merge(), envs[1].Merge(), envs[2].Merge()
+	entries := env.ThreeWayDiff(baseVars, vars1, vars2)
+
+	// Count the variables neither side changed
+	allKeys := make(map[string]bool)
+	for _, vars := range []map[string]string{baseVars, vars1, vars2} {
+		for k := range vars {
+			allKeys[k] = true
+		}
+	}
+	unchanged := len(allKeys) - len(entries)
+
+	if envDiffJSON {
+		return outputThreeWayDiffJSON(baseContext, context1, context2, entries, unchanged)
+	}
+	displayThreeWayDiff(baseContext, context1, context2, entries, unchanged)
+	return nil
+}
+
+// loadContextEnvs loads the global merged environment of each named context
+func loadContextEnvs(contextNames ...string) ([]*env.LayeredEnv, error) {
+	// Load config
+	cfg, projectRoot, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
+	}
+
+	// Get project identifier (normalized project root for worktrees)
+	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project identifier: %w", err)
+	}
+
+	// Load registry (use projectIdentifier which points to parent repo for worktrees)
+	reg, err := registry.LoadRegistryReadOnly(projectIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry: %w", err)
+	}
+	defer reg.Close()
+
+	// Get all contexts before loading anything
+	contexts := make([]*registry.Context, len(contextNames))
+	for i, name := range contextNames {
+		ctx, err := reg.GetContext(projectIdentifier, name)
+		if err != nil {
+			return nil, fmt.Errorf("context %q not found in registry", name)
+		}
+		contexts[i] = ctx
+	}
+
+	// Load environments for all contexts (using global overrides)
+	// Note: not passing a service name here as we want to compare global environments
+	// They share the base file, so it is only read once
+	cache := env.NewFileCache()
+	envs := make([]*env.LayeredEnv, len(contextNames))
+	for i, name := range contextNames {
+		layeredEnv, err := env.LoadLayeredEnvWithCache(projectRoot, cfg, "", name, contexts[i].GetEnvOverrides(""), cache)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load environment for %q: %w", name, err)
+		}
+		envs[i] = layeredEnv
+	}
+
+	return envs, nil
+}
+
+// outputEnvDiffJSON prints a two-way diff as JSON
+func outputEnvDiffJSON(context1, context2 string, diff env.EnvDiff) error {
+	type changeJSON struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	changed := make(map[string]changeJSON, len(diff.Changed))
+	for k, vals := range diff.Changed {
+		changed[k] = changeJSON{From: vals[0], To: vals[1]}
+	}
+
+	output := map[string]interface{}{
+		"from":    context1,
+		"to":      context2,
+		"changed": changed,
+		"added":   diff.Added,
+		"removed": diff.Removed,
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// outputThreeWayDiffJSON prints a three-way diff as JSON. Values are null
+// where the variable is not set.
+func outputThreeWayDiffJSON(baseContext, context1, context2 string, entries []env.ThreeWayEntry, unchanged int) error {
+	type variableJSON struct {
+		Key       string  `json:"key"`
+		Status    string  `json:"status"`
+		ChangedIn string  `json:"changedIn,omitempty"`
+		Base      *string `json:"base"`
+		Left      *string `json:"left"`
+		Right     *string `json:"right"`
+	}
+
+	optional := func(value string, set bool) *string {
+		if !set {
+			return nil
+		}
+		return &value
+	}
+
+	variables := make([]variableJSON, 0, len(entries))
+	for _, entry := range entries {
+		v := variableJSON{
+			Key:    entry.Key,
+			Status: string(entry.Status),
+			Base:   optional(entry.Base, entry.InBase),
+			Left:   optional(entry.Left, entry.InLeft),
+			Right:  optional(entry.Right, entry.InRight),
+		}
+		switch entry.Status {
+		case env.ThreeWayLeft:
+			v.ChangedIn = context1
+		case env.ThreeWayRight:
+			v.ChangedIn = context2
+		}
+		variables = append(variables, v)
+	}
+
+	output := map[string]interface{}{
+		"base":      baseContext,
+		"left":      context1,
+		"right":     context2,
+		"variables": variables,
+		"unchanged": unchanged,
+	}
+
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
 }
 
-func calculateEnvDiff(merged1, merged2 map[string]string) envDiff {
-	diff := envDiff{
-		changed: make(map[string][2]string),
-		added:   make(map[string]string),
-		removed: make(map[string]string),
+// displayThreeWayDiff prints a three-way diff grouped by classification
+func displayThreeWayDiff(baseContext, context1, context2 string, entries []env.ThreeWayEntry, unchanged int) {
+	fmt.Printf("Comparing environments: %s ↔ %s (base: %s)\n\n", context1, context2, baseContext)
+
+	if len(entries) == 0 {
+		fmt.Printf("Neither context changed anything from %s\n", baseContext)
+		return
 	}
 
-	// Find changed and removed
-	for k, v1 := range merged1 {
-		if v2, exists := merged2[k]; exists {
-			if v1 != v2 {
-				diff.changed[k] = [2]string{v1, v2}
-			}
-		} else {
-			diff.removed[k] = v1
+	byStatus := make(map[env.ThreeWayStatus][]env.ThreeWayEntry)
+	for _, entry := range entries {
+		byStatus[entry.Status] = append(byStatus[entry.Status], entry)
+	}
+
+	if conflicts := byStatus[env.ThreeWayConflict]; len(conflicts) > 0 {
+		width := max(len(baseContext), len(context1), len(context2)) + 1
+		fmt.Println("Conflicts (changed differently in both):")
+		for _, entry := range conflicts {
+			fmt.Printf("  %s\n", entry.Key)
+			fmt.Printf("    %-*s %s\n", width, baseContext+":", diffValue(entry.Base, entry.InBase))
+			fmt.Printf("    %-*s %s\n", width, context1+":", diffValue(entry.Left, entry.InLeft))
+			fmt.Printf("    %-*s %s\n", width, context2+":", diffValue(entry.Right, entry.InRight))
 		}
+		fmt.Println()
 	}
 
-	// Find added
-	for k, v2 := range merged2 {
-		if _, exists := merged1[k]; !exists {
-			diff.added[k] = v2
+	groups := []struct {
+		status env.ThreeWayStatus
+		title  string
+	}{
+		{env.ThreeWayLeft, fmt.Sprintf("Changed in %s only:", context1)},
+		{env.ThreeWayRight, fmt.Sprintf("Changed in %s only:", context2)},
+		{env.ThreeWaySame, "Same change in both:"},
+	}
+	for _, group := range groups {
+		if len(byStatus[group.status]) == 0 {
+			continue
+		}
+		fmt.Println(group.title)
+		for _, entry := range byStatus[group.status] {
+			value, set := entry.Left, entry.InLeft
+			if group.status == env.ThreeWayRight {
+				value, set = entry.Right, entry.InRight
+			}
+			fmt.Printf("  %s: %s → %s\n", entry.Key, diffValue(entry.Base, entry.InBase), diffValue(value, set))
 		}
+		fmt.Println()
 	}
 
-	return diff
+	fmt.Printf("%d conflict(s), %d variable(s) unchanged\n", len(byStatus[env.ThreeWayConflict]), unchanged)
 }
 
-func displayEnvDiff(context1, context2 string, diff envDiff) {
+// diffValue shows a value in a diff, marking variables that are not set
+func diffValue(value string, set bool) string {
+	if !set {
+		return "(unset)"
+	}
+	return value
+}
+
+func displayEnvDiff(context1, context2 string, diff env.EnvDiff) {
 	fmt.Printf("Comparing environments: %s → %s\n\n", context1, context2)
 
-	if len(diff.changed) > 0 {
-		displayChangedVars(diff.changed)
+	if len(diff.Changed) > 0 {
+		displayChangedVars(diff.Changed)
 	}
 
-	if len(diff.added) > 0 {
-		displayAddedVars(diff.added)
+	if len(diff.Added) > 0 {
+		displayAddedVars(diff.Added)
 	}
 
-	if len(diff.removed) > 0 {
-		displayRemovedVars(diff.removed)
+	if len(diff.Removed) > 0 {
+		displayRemovedVars(diff.Removed)
 	}
 
-	if len(diff.changed) == 0 && len(diff.added) == 0 && len(diff.removed) == 0 {
+	if diff.IsEmpty() {
 		fmt.Println("No differences found")
 	}
 }
@@ -945,7 +3035,7 @@ func runEnvRemap(cmd *cobra.Command, args []string) error {
 	}
 
 	// Detect context
-	contextName, err := context.DetectContext()
+	contextName, err := context.DetectContextWithSource(cfg.GetContextSource())
 	if err != nil {
 		return fmt.Errorf("failed to detect context: %w", err)
 	}
@@ -957,27 +3047,514 @@ func runEnvRemap(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load registry (use projectIdentifier which points to parent repo for worktrees)
-	reg, err := registry.LoadRegistry(projectIdentifier)
+	load := registry.LoadRegistry
+	if envDryRun {
+		load = registry.LoadRegistryReadOnly
+	}
+	reg, err := load(projectIdentifier)
 	if err != nil {
 		return fmt.Errorf("failed to load registry: %w", err)
 	}
 	defer reg.Close()
 
 	// Check if context exists
-	_, err = reg.GetContext(projectIdentifier, contextName)
+	ctx, err := reg.GetContext(projectIdentifier, contextName)
 	if err != nil {
 		return fmt.Errorf("context %q not found in registry\nHint: Run 'dual create <branch>' to create a worktree with a context", contextName)
 	}
 
-	fmt.Fprintf(os.Stderr, "[dual] Regenerating service env files for context '%s'...\n", contextName)
+	if envDryRun {
+		return printEnvDryRun(cfg, reg, projectIdentifier, contextName, overrideSnapshot(ctx))
+	}
+
+	logger.Info("Regenerating service env files for context '%s'...", contextName)
 
 	// Generate service env files
 	if err := env.GenerateServiceEnvFiles(cfg, reg, projectIdentifier, projectIdentifier, contextName); err != nil {
 		return fmt.Errorf("failed to generate service env files: %w", err)
 	}
 
-	fmt.Fprintf(os.Stderr, "[dual] Service env files regenerated successfully\n")
-	fmt.Fprintf(os.Stderr, "  Files written to: %s/.dual/.local/service/<service>/.env\n", projectIdentifier)
+	logger.Info("Service env files regenerated successfully")
+	logger.Detail("  Files written to: %s/.dual/.local/service/<service>/.env", projectIdentifier)
+
+	return nil
+}
+
+// updateEnvRegistry runs fn like registry.Update, or with --dry-run against an
+// in-memory copy of the registry that is never saved
+func updateEnvRegistry(projectIdentifier string, fn func(*registry.Registry) error) error {
+	if !envDryRun {
+		return registry.Update(projectIdentifier, fn)
+	}
+
+	reg, err := registry.LoadRegistryReadOnly(projectIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+	defer reg.Close()
+	return fn(reg)
+}
+
+// overrideEntry identifies one override in a context: its key and the layer
+// it is set in ("global", "service <name>" or "environment <name>")
+type overrideEntry struct {
+	scope string
+	key   string
+}
+
+// overrideSnapshot copies a context's overrides, for diffOverrides to compare
+// against after a change
+func overrideSnapshot(ctx *registry.Context) map[overrideEntry]string {
+	snapshot := make(map[overrideEntry]string)
+	if ctx.EnvOverridesV2 == nil {
+		return snapshot
+	}
+	for k, v := range ctx.EnvOverridesV2.Global {
+		snapshot[overrideEntry{"global", k}] = v
+	}
+	for service, overrides := range ctx.EnvOverridesV2.Services {
+		for k, v := range overrides {
+			snapshot[overrideEntry{"service " + service, k}] = v
+		}
+	}
+	for environment, overrides := range ctx.EnvOverridesV2.Environments {
+		for k, v := range overrides {
+			snapshot[overrideEntry{"environment " + environment, k}] = v
+		}
+	}
+	return snapshot
+}
+
+// diffOverrides lists the overrides added (+), changed (~) and removed (-)
+// between two snapshots, ordered by layer and key
+func diffOverrides(before, after map[overrideEntry]string) []string {
+	entries := make([]overrideEntry, 0, len(before)+len(after))
+	for entry := range before {
+		entries = append(entries, entry)
+	}
+	for entry := range after {
+		if _, ok := before[entry]; !ok {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].scope != entries[j].scope {
+			return entries[i].scope < entries[j].scope
+		}
+		return entries[i].key < entries[j].key
+	})
+
+	var changes []string
+	for _, entry := range entries {
+		old, hadOld := before[entry]
+		value, hasNew := after[entry]
+		switch {
+		case !hadOld:
+			changes = append(changes, fmt.Sprintf("+ %s=%s (%s)", entry.key, value, entry.scope))
+		case !hasNew:
+			changes = append(changes, fmt.Sprintf("- %s (%s, was %s)", entry.key, entry.scope, old))
+		case old != value:
+			changes = append(changes, fmt.Sprintf("~ %s=%s (%s, was %s)", entry.key, value, entry.scope, old))
+		}
+	}
+	return changes
+}
+
+// printEnvDryRun shows what an env command run with --dry-run would change:
+// the context's overrides compared with before, and the service env files
+// that would be created or rewritten
+func printEnvDryRun(cfg *config.Config, reg *registry.Registry, projectIdentifier, contextName string, before map[overrideEntry]string) error {
+	ctx, err := reg.GetContext(projectIdentifier, contextName)
+	if err != nil {
+		return fmt.Errorf("failed to get context: %w", err)
+	}
+
+	fmt.Printf("Dry run for context '%s', nothing was saved\n", contextName)
+
+	changes := diffOverrides(before, overrideSnapshot(ctx))
+	if len(changes) == 0 {
+		fmt.Println("Registry: no changes")
+	} else {
+		fmt.Println("Registry changes:")
+		for _, change := range changes {
+			fmt.Printf("  %s\n", change)
+		}
+	}
+
+	switch {
+	case envEnvironmentFlag != "":
+		fmt.Println("Service env files: unchanged (environment overlays are not written to them)")
+		return nil
+	case envNoGenerate:
+		fmt.Println("Service env files: not written (--no-generate)")
+		return nil
+	}
+
+	drift, err := env.ServiceEnvFileDrift(cfg, reg, projectIdentifier, contextName)
+	if err != nil {
+		return fmt.Errorf("failed to compare service env files: %w", err)
+	}
+	if len(drift) == 0 {
+		fmt.Println("Service env files: up to date")
+		return nil
+	}
+
+	services := make([]string, 0, len(drift))
+	for service := range drift {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	fmt.Println("Service env files that would be written:")
+	for _, service := range services {
+		action := "update"
+		if drift[service] == "missing" {
+			action = "create"
+		}
+		fmt.Printf("  %s %s\n", action, filepath.Join(".dual", ".local", "service", service, ".env"))
+	}
+	return nil
+}
+
+func runEnvImportShell(cmd *cobra.Command, args []string) error {
+	// Initialize logger
+	logger.Init(envVerbose, envDebug)
+
+	// Require an explicit selection to avoid capturing the entire environment
+	if len(envImportShellKeys) == 0 && envImportShellPrefix == "" {
+		return fmt.Errorf("no variables selected\nHint: Use --keys KEY1,KEY2 or --prefix PREFIX_ to choose which variables to import")
+	}
+
+	// Load config
+	cfg, projectRoot, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
+	}
+
+	// If service is specified, validate it exists in config
+	if err := resolveServiceFlag(cfg); err != nil {
+		return err
+	}
+
+	// Select variables from the current environment
+	imported := selectShellVars(os.Environ(), envImportShellKeys, envImportShellPrefix)
+	for _, key := range envImportShellKeys {
+		if _, exists := imported[key]; !exists {
+			logger.Warn("%s is not set in the current environment, skipping", key)
+		}
+	}
+
+	if len(imported) == 0 {
+		fmt.Println("No matching variables found in the current environment")
+		return nil
+	}
+
+	// Detect context
+	contextName, err := context.DetectContextWithSource(cfg.GetContextSource())
+	if err != nil {
+		return fmt.Errorf("failed to detect context: %w", err)
+	}
+
+	// Sort keys for consistent output
+	keys := sortedMapKeys(imported)
+
+	scope := "global"
+	if envServiceFlag != "" {
+		scope = fmt.Sprintf("service '%s'", envServiceFlag)
+	}
+
+	if envImportShellDryRun {
+		fmt.Printf("Would import %d variable(s) into context '%s' (%s):\n", len(keys), contextName, scope)
+		for _, k := range keys {
+			fmt.Printf("  %s\n", k)
+		}
+		return nil
+	}
+
+	if err := saveShellImport(cfg, projectRoot, contextName, imported); err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d variable(s) into context '%s' (%s):\n", len(keys), contextName, scope)
+	for _, k := range keys {
+		fmt.Printf("  %s\n", k)
+	}
+
+	return nil
+}
+
+// saveShellImport stores imported variables as overrides of the context
+// (service-specific with --service) and regenerates the service env files
+func saveShellImport(cfg *config.Config, projectRoot, contextName string, imported map[string]string) error {
+	// Get project identifier (normalized project root for worktrees)
+	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to get project identifier: %w", err)
+	}
+
+	// Load registry (use projectIdentifier which points to parent repo for worktrees)
+	reg, err := registry.LoadRegistry(projectIdentifier)
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+	defer reg.Close()
+
+	// Check if context exists
+	if _, err := reg.GetContext(projectIdentifier, contextName); err != nil {
+		return fmt.Errorf("context %q not found in registry\nHint: Run 'dual create <branch>' to create a worktree with a context", contextName)
+	}
+
+	for _, k := range sortedMapKeys(imported) {
+		if err := reg.SetEnvOverrideForService(projectIdentifier, contextName, k, imported[k], envServiceFlag); err != nil {
+			return fmt.Errorf("failed to set environment override %s: %w", k, err)
+		}
+	}
+
+	// Save registry
+	if err := reg.SaveRegistry(); err != nil {
+		return fmt.Errorf("failed to save registry: %w", err)
+	}
+
+	// Generate service env files, unless deferred to 'dual env remap'
+	if envNoGenerate {
+		logger.Verbose("Skipping service env file generation (--no-generate)")
+	} else if err := env.GenerateServiceEnvFiles(cfg, reg, projectIdentifier, projectIdentifier, contextName); err != nil {
+		logger.Warn("failed to regenerate service env files: %v", err)
+		// Don't fail the command - the overrides are saved, env files are optional
+	}
+	return nil
+}
+
+// selectShellVars picks variables from an os.Environ()-style slice that are
+// either listed in keys or start with prefix (excluding standard shell variables)
+func selectShellVars(environ []string, keys []string, prefix string) map[string]string {
+	wanted := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		wanted[k] = true
+	}
+
+	selected := make(map[string]string)
+	for _, entry := range environ {
+		key, value, ok := parseEnvironEntry(entry)
+		if !ok {
+			continue
+		}
+		if wanted[key] || (prefix != "" && strings.HasPrefix(key, prefix) && !standardShellVars[key]) {
+			selected[key] = value
+		}
+	}
+
+	return selected
+}
+
+// parseEnvironEntry splits a KEY=value entry of os.Environ()
+func parseEnvironEntry(entry string) (key, value string, ok bool) {
+	key, value, found := strings.Cut(entry, "=")
+	if !found || key == "" {
+		return "", "", false
+	}
+	return key, value, true
+}
+
+func runEnvImportExample(cmd *cobra.Command, args []string) error {
+	exampleFile := args[0]
+
+	// Initialize logger
+	logger.Init(envVerbose, envDebug)
+
+	// The loader treats missing files as empty, so check explicitly
+	if _, err := os.Stat(exampleFile); err != nil {
+		return fmt.Errorf("failed to read example file: %w", err)
+	}
+	example, keys, err := env.NewLoader().LoadEnvFileOrdered(exampleFile)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", exampleFile, err)
+	}
+	if len(keys) == 0 {
+		fmt.Printf("No variables found in %s\n", exampleFile)
+		return nil
+	}
+
+	// Load config
+	cfg, projectRoot, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w\nHint: Run 'dual init' to create a configuration file", err)
+	}
+
+	// If service is specified, validate it exists in config
+	if err := resolveServiceFlag(cfg); err != nil {
+		return err
+	}
+
+	// Detect context
+	contextName, err := context.DetectContextWithSource(cfg.GetContextSource())
+	if err != nil {
+		return fmt.Errorf("failed to detect context: %w", err)
+	}
+
+	// Get project identifier (normalized project root for worktrees)
+	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to get project identifier: %w", err)
+	}
+
+	// Find the keys that are already present without holding the registry
+	// lock, which must not wait on the user typing values
+	present, err := presentExampleKeys(cfg, projectRoot, projectIdentifier, contextName, keys)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]string)
+	var missing, unset []string
+	var reader *bufio.Reader
+	for _, key := range keys {
+		if _, ok := present[key]; ok {
+			continue
+		}
+		missing = append(missing, key)
+
+		value := example[key]
+		if !envImportExampleDefaults {
+			if reader == nil {
+				reader = bufio.NewReader(os.Stdin)
+			}
+			value, err = promptExampleValue(reader, key, example[key])
+			if err != nil {
+				return err
+			}
+		}
+		if value == "" {
+			unset = append(unset, key)
+			continue
+		}
+		values[key] = value
+	}
+
+	// Store the values, skipping any key set by someone else in the meantime
+	var seeded []string
+	if len(values) > 0 {
+		err = registry.Update(projectIdentifier, func(reg *registry.Registry) error {
+			ctx, err := reg.GetContext(projectIdentifier, contextName)
+			if err != nil {
+				return fmt.Errorf("context %q not found in registry\nHint: Run 'dual create <branch>' to create a worktree with a context", contextName)
+			}
+
+			for _, key := range missing {
+				value, ok := values[key]
+				if !ok {
+					continue
+				}
+				if ctx.HasEnvOverride(key, envServiceFlag) {
+					present[key] = "override"
+					continue
+				}
+				if err := reg.SetEnvOverrideForService(projectIdentifier, contextName, key, value, envServiceFlag); err != nil {
+					return fmt.Errorf("failed to set environment override %s: %w", key, err)
+				}
+				seeded = append(seeded, key)
+			}
+			if len(seeded) == 0 {
+				return nil
+			}
+
+			// Generate service env files, unless deferred to 'dual env remap'
+			if envNoGenerate {
+				logger.Verbose("Skipping service env file generation (--no-generate)")
+			} else if err := env.GenerateServiceEnvFiles(cfg, reg, projectIdentifier, projectIdentifier, contextName); err != nil {
+				logger.Warn("failed to regenerate service env files: %v", err)
+				// Don't fail the command - the overrides are saved, env files are optional
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	scope := "global"
+	if envServiceFlag != "" {
+		scope = fmt.Sprintf("service '%s'", envServiceFlag)
+	}
+	if len(seeded) == 0 {
+		fmt.Printf("No variables seeded from %s into context '%s' (%s)\n", exampleFile, contextName, scope)
+	} else {
+		fmt.Printf("Seeded %d variable(s) from %s into context '%s' (%s):\n", len(seeded), exampleFile, contextName, scope)
+		for _, key := range seeded {
+			fmt.Printf("  %s\n", key)
+		}
+	}
+	if len(present) > 0 {
+		fmt.Printf("Already present (%d):\n", len(present))
+		for _, key := range keys {
+			if source, ok := present[key]; ok {
+				fmt.Printf("  %s (%s)\n", key, source)
+			}
+		}
+	}
+	if len(unset) > 0 {
+		fmt.Printf("Left unset, no value given (%d):\n", len(unset))
+		for _, key := range unset {
+			fmt.Printf("  %s\n", key)
+		}
+	}
 
 	return nil
 }
+
+// presentExampleKeys returns the keys that already have a value in the
+// context, mapped to where it comes from: "override" or "env file" (the base,
+// service base or service env file)
+func presentExampleKeys(cfg *config.Config, projectRoot, projectIdentifier, contextName string, keys []string) (map[string]string, error) {
+	reg, err := registry.LoadRegistryReadOnly(projectIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry: %w", err)
+	}
+	defer reg.Close()
+
+	ctx, err := reg.GetContext(projectIdentifier, contextName)
+	if err != nil {
+		return nil, fmt.Errorf("context %q not found in registry\nHint: Run 'dual create <branch>' to create a worktree with a context", contextName)
+	}
+
+	overrides := ctx.GetEnvOverrides(envServiceFlag)
+	layeredEnv, err := env.LoadLayeredEnv(projectRoot, cfg, envServiceFlag, contextName, overrides)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load environment: %w", err)
+	}
+
+	present := make(map[string]string)
+	for _, key := range keys {
+		if _, ok := overrides[key]; ok {
+			present[key] = "override"
+			continue
+		}
+		for _, layer := range []map[string]string{layeredEnv.Base, layeredEnv.ServiceBase, layeredEnv.Service} {
+			if _, ok := layer[key]; ok {
+				present[key] = "env file"
+				break
+			}
+		}
+	}
+	return present, nil
+}
+
+// promptExampleValue asks for a value for key on stderr, returning
+// defaultValue when the answer is empty
+func promptExampleValue(reader *bufio.Reader, key, defaultValue string) (string, error) {
+	if defaultValue != "" {
+		fmt.Fprintf(os.Stderr, "%s [%s]: ", key, defaultValue)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s: ", key)
+	}
+
+	answer, err := reader.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || answer == "") {
+		return "", fmt.Errorf("failed to read value for %s: %w\nHint: Use --defaults to accept the example values without prompting", key, err)
+	}
+
+	if answer = strings.TrimRight(answer, "\r\n"); answer == "" {
+		return defaultValue, nil
+	}
+	return answer, nil
+}
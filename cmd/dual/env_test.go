@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeProperty(t *testing.T) {
+	tests := []struct {
+		name  string
+		in    string
+		isKey bool
+		want  string
+	}{
+		{name: "plain value", in: "postgres://localhost/app", want: `postgres\://localhost/app`},
+		{name: "separators and comments", in: "a=b:c#d!e", want: `a\=b\:c\#d\!e`},
+		{name: "backslash", in: `C:\data`, want: `C\:\\data`},
+		{name: "control characters", in: "line1\nline2\ttab\r\f", want: `line1\nline2\ttab\r\f`},
+		{name: "spaces in value", in: " leading and inner", want: `\ leading and inner`},
+		{name: "spaces in key", in: "my key", isKey: true, want: `my\ key`},
+		{name: "non-ASCII", in: "café", want: `caf\u00E9`},
+		{name: "supplementary character", in: "🚀", want: `\uD83D\uDE80`},
+		{name: "empty", in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, escapeProperty(tt.in, tt.isKey))
+		})
+	}
+}
+
+func TestRenderProperties(t *testing.T) {
+	vars := map[string]string{"DATABASE_URL": "postgres://db", "SERVER_PORT": "8080"}
+	keys := []string{"DATABASE_URL", "SERVER_PORT"}
+
+	got, err := renderProperties(keys, vars, "none")
+	assert.NoError(t, err)
+	assert.Equal(t, "DATABASE_URL=postgres\\://db\nSERVER_PORT=8080\n", string(got))
+
+	got, err = renderProperties(keys, vars, "dotted")
+	assert.NoError(t, err)
+	assert.Equal(t, "database.url=postgres\\://db\nserver.port=8080\n", string(got))
+
+	_, err = renderProperties([]string{"A_B", "a_b"}, map[string]string{"A_B": "1", "a_b": "2"}, "dotted")
+	assert.ErrorContains(t, err, `A_B and a_b both become "a.b"`)
+
+	_, err = renderProperties(keys, vars, "camel")
+	assert.ErrorContains(t, err, "unsupported key transform: camel")
+}
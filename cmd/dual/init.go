@@ -4,24 +4,39 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/lightfastai/dual/internal/config"
+	"github.com/lightfastai/dual/internal/logger"
 	"github.com/spf13/cobra"
 )
 
-var forceInit bool
+var (
+	forceInit       bool
+	initNoGitignore bool
+)
+
+// gitignoreEntry is the .gitignore line that keeps dual's local state
+// (registry, locks, generated env files) out of git
+const gitignoreEntry = "/.dual/.local/"
 
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize a new dual configuration",
 	Long: `Creates a new dual.config.yml file in the current directory with an empty services configuration.
 
-If a configuration file already exists, use --force to overwrite it.`,
+If a configuration file already exists, use --force to overwrite it.
+
+Also adds /.dual/.local/ to .gitignore, so the registry (which holds absolute
+paths and env overrides), lock files and generated env files are not
+committed. Nothing is added if .gitignore already covers it. Use
+--no-gitignore to leave .gitignore alone.`,
 	RunE: runInit,
 }
 
 func init() {
 	initCmd.Flags().BoolVar(&forceInit, "force", false, "Overwrite existing configuration file")
+	initCmd.Flags().BoolVar(&initNoGitignore, "no-gitignore", false, "Don't add .dual/.local/ to .gitignore")
 	rootCmd.AddCommand(initCmd)
 }
 
@@ -54,6 +69,12 @@ func runInit(cmd *cobra.Command, args []string) error {
 	}
 
 	fmt.Printf("[dual] Initialized configuration at %s\n", configPath)
+
+	if !initNoGitignore {
+		if err := ensureGitignored(cwd); err != nil {
+			logger.Warn("%v", err)
+		}
+	}
 	fmt.Println("\nNext steps:")
 	fmt.Println("  1. Add services with: dual service add <name> --path <path>")
 	fmt.Println("  2. Create a worktree with: dual create <branch>")
@@ -61,3 +82,43 @@ func runInit(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// ensureGitignored appends gitignoreEntry to dir/.gitignore unless an existing
+// line already ignores .dual/.local/. It warns when a line ignores all of
+// .dual/, since that also hides hook scripts meant to be committed.
+func ensureGitignored(dir string) error {
+	gitignorePath := filepath.Join(dir, ".gitignore")
+
+	// #nosec G304 - gitignorePath is in the directory being initialized
+	data, err := os.ReadFile(gitignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", gitignorePath, err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		switch strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(line), "/"), "/") {
+		case ".dual/.local", ".dual/.local/*":
+			fmt.Println("[dual] .gitignore already ignores .dual/.local/")
+			return nil
+		case ".dual", ".dual/*":
+			logger.Warn(".gitignore ignores all of .dual/ (%q), including hook scripts in .dual/hooks/", strings.TrimSpace(line))
+			logger.Info("Consider ignoring only %s instead", gitignoreEntry)
+			return nil
+		}
+	}
+
+	var b strings.Builder
+	b.Write(data)
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		b.WriteString("\n")
+	}
+	b.WriteString("# dual local state (registry, locks, generated env files)\n")
+	b.WriteString(gitignoreEntry + "\n")
+
+	// #nosec G306 - .gitignore is a committed project file, not a secret
+	if err := os.WriteFile(gitignorePath, []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to update %s: %w", gitignorePath, err)
+	}
+	fmt.Printf("[dual] Added %s to %s\n", gitignoreEntry, gitignorePath)
+	return nil
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/lightfastai/dual/internal/config"
@@ -18,6 +20,7 @@ import (
 var (
 	listOutputJSON bool
 	listAll        bool
+	listSizes      bool
 )
 
 var listCmd = &cobra.Command{
@@ -27,18 +30,24 @@ var listCmd = &cobra.Command{
 
 By default, lists contexts with their creation dates.
 Use --json for machine-readable output.
-Use --all to show contexts from all projects.
+Use --all to show contexts from all projects, including whether each
+context's worktree still exists on disk.
+Use --sizes to show how much disk space each worktree takes, to find large
+ones worth deleting. Worktrees are measured in parallel; contexts without a
+worktree path (the main checkout) or whose worktree is gone show "-".
 
 Examples:
   dual list              # List contexts for current project
   dual list --json       # Output as JSON
-  dual list --all        # Show contexts from all projects`,
+  dual list --all        # Show contexts from all projects
+  dual list --sizes      # Include each worktree's disk usage`,
 	RunE: runList,
 }
 
 func init() {
 	listCmd.Flags().BoolVar(&listOutputJSON, "json", false, "Output as JSON")
 	listCmd.Flags().BoolVar(&listAll, "all", false, "Include contexts from all projects")
+	listCmd.Flags().BoolVar(&listSizes, "sizes", false, "Show the disk usage of each context's worktree")
 	rootCmd.AddCommand(listCmd)
 }
 
@@ -56,7 +65,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Load registry (using projectIdentifier to ensure worktrees access parent repo's registry)
-	reg, err := registry.LoadRegistry(projectIdentifier)
+	reg, err := registry.LoadRegistryReadOnly(projectIdentifier)
 	if err != nil {
 		return fmt.Errorf("failed to load registry: %w", err)
 	}
@@ -72,7 +81,7 @@ func runList(cmd *cobra.Command, args []string) error {
 }
 
 func listAllProjectContexts(reg *registry.Registry) error {
-	projects := reg.GetAllProjects()
+	projects := dedupeProjectPaths(reg.GetAllProjects())
 
 	if len(projects) == 0 {
 		fmt.Println("No projects found in registry")
@@ -85,26 +94,75 @@ func listAllProjectContexts(reg *registry.Registry) error {
 
 	// Human-readable output for all projects
 	totalContexts := 0
+	missingContexts := 0
+	var totalSize int64
 	for _, projectPath := range projects {
 		contexts, err := reg.ListContexts(projectPath)
 		if err != nil {
 			continue
 		}
 
+		sizes := contextSizes(contexts)
 		fmt.Printf("\nProject: %s\n", projectPath)
-		if err := outputContextsTable(reg, projectPath, contexts, ""); err != nil {
+		if err := outputContextsTable(reg, projectPath, contexts, "", true, sizes); err != nil {
 			return err
 		}
+		for _, size := range sizes {
+			totalSize += size
+		}
 		totalContexts += len(contexts)
+		for _, ctx := range contexts {
+			if !contextPathExists(projectPath, ctx) {
+				missingContexts++
+			}
+		}
 	}
 
-	fmt.Printf("\nTotal: %d contexts across %d projects\n", totalContexts, len(projects))
+	fmt.Printf("\nTotal: %d contexts across %d projects%s\n", totalContexts, len(projects), totalSizeSuffix(totalSize))
+	if missingContexts > 0 {
+		fmt.Printf("%d context(s) point to worktrees that no longer exist (run 'dual doctor --fix' to clean up)\n", missingContexts)
+	}
 	return nil
 }
 
+// dedupeProjectPaths removes registry entries that refer to the same project
+// directory under different spellings (trailing slashes, symlinks), keeping
+// the first path in sorted order
+func dedupeProjectPaths(projects []string) []string {
+	seen := make(map[string]bool, len(projects))
+	result := make([]string, 0, len(projects))
+	for _, projectPath := range projects {
+		key := filepath.Clean(projectPath)
+		if resolved, err := filepath.EvalSymlinks(key); err == nil {
+			key = resolved
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, projectPath)
+	}
+	return result
+}
+
+// contextPathExists reports whether a context's worktree is still on disk.
+// Contexts without a path belong to the project root itself.
+func contextPathExists(projectPath string, ctx registry.Context) bool {
+	path := ctx.Path
+	if path == "" {
+		path = projectPath
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
 func listCurrentProjectContexts(reg *registry.Registry, projectIdentifier string) error {
-	// Detect current context
-	currentContext, err := context.DetectContext()
+	// Detect current context, honoring the configured precedence if a config loads
+	contextSource := context.SourceBranch
+	if cfg, _, err := config.LoadConfig(); err == nil {
+		contextSource = cfg.GetContextSource()
+	}
+	currentContext, err := context.DetectContextWithSource(contextSource)
 	if err != nil {
 		currentContext = "" // Ignore error, just won't mark as current
 	}
@@ -126,21 +184,28 @@ func listCurrentProjectContexts(reg *registry.Registry, projectIdentifier string
 		return nil
 	}
 
+	sizes := contextSizes(contexts)
 	if listOutputJSON {
-		return outputContextsJSON(reg, projectIdentifier, currentContext, contexts)
+		return outputContextsJSON(reg, projectIdentifier, currentContext, contexts, sizes)
 	}
 
 	// Human-readable output
 	fmt.Printf("Contexts for %s:\n", projectIdentifier)
-	if err := outputContextsTable(reg, projectIdentifier, contexts, currentContext); err != nil {
+	if err := outputContextsTable(reg, projectIdentifier, contexts, currentContext, false, sizes); err != nil {
 		return err
 	}
 
-	fmt.Printf("\nTotal: %d contexts\n", len(contexts))
+	var totalSize int64
+	for _, size := range sizes {
+		totalSize += size
+	}
+	fmt.Printf("\nTotal: %d contexts%s\n", len(contexts), totalSizeSuffix(totalSize))
 	return nil
 }
 
-func outputContextsTable(reg *registry.Registry, projectIdentifier string, contexts map[string]registry.Context, currentContext string) error {
+// outputContextsTable prints contexts as a table. sizes is nil unless --sizes
+// was given, in which case a SIZE column is added.
+func outputContextsTable(reg *registry.Registry, projectIdentifier string, contexts map[string]registry.Context, currentContext string, showExists bool, sizes map[string]int64) error {
 	// Sort context names
 	names := make([]string, 0, len(contexts))
 	for name := range contexts {
@@ -152,7 +217,14 @@ func outputContextsTable(reg *registry.Registry, projectIdentifier string, conte
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
 	// Print header
-	fmt.Fprintln(w, "NAME\tCREATED\tCURRENT")
+	header := []string{"NAME", "CREATED"}
+	if showExists {
+		header = append(header, "EXISTS")
+	}
+	if sizes != nil {
+		header = append(header, "SIZE")
+	}
+	fmt.Fprintln(w, strings.Join(append(header, "CURRENT"), "\t"))
 
 	// Print each context
 	for _, name := range names {
@@ -162,18 +234,66 @@ func outputContextsTable(reg *registry.Registry, projectIdentifier string, conte
 			currentMarker = "(current)"
 		}
 
-		createdDate := ctx.Created.Format("2006-01-02")
-		fmt.Fprintf(w, "%s\t%s\t%s\n", name, createdDate, currentMarker)
+		row := []string{name, ctx.Created.Format("2006-01-02")}
+		if showExists {
+			existsMarker := "yes"
+			if !contextPathExists(projectIdentifier, ctx) {
+				existsMarker = "missing"
+			}
+			row = append(row, existsMarker)
+		}
+		if sizes != nil {
+			sizeDisplay := "-"
+			if size, ok := sizes[name]; ok {
+				sizeDisplay = worktree.FormatSize(size)
+			}
+			row = append(row, sizeDisplay)
+		}
+		fmt.Fprintln(w, strings.Join(append(row, currentMarker), "\t"))
 	}
 
 	return w.Flush()
 }
 
-func outputContextsJSON(reg *registry.Registry, projectIdentifier, currentContext string, contexts map[string]registry.Context) error {
+// contextSizes measures the worktree of every context that has a path on disk,
+// keyed by context name. It returns nil unless --sizes was given.
+func contextSizes(contexts map[string]registry.Context) map[string]int64 {
+	if !listSizes {
+		return nil
+	}
+
+	var paths []string
+	for _, ctx := range contexts {
+		if ctx.Path != "" {
+			paths = append(paths, ctx.Path)
+		}
+	}
+	pathSizes := worktree.DirSizes(paths)
+
+	sizes := make(map[string]int64, len(pathSizes))
+	for name, ctx := range contexts {
+		if size, ok := pathSizes[ctx.Path]; ok {
+			sizes[name] = size
+		}
+	}
+	return sizes
+}
+
+// totalSizeSuffix describes the combined size for the summary line, or is
+// empty without --sizes
+func totalSizeSuffix(total int64) string {
+	if !listSizes {
+		return ""
+	}
+	return fmt.Sprintf(" (%s on disk)", worktree.FormatSize(total))
+}
+
+func outputContextsJSON(reg *registry.Registry, projectIdentifier, currentContext string, contexts map[string]registry.Context, sizes map[string]int64) error {
 	type contextJSON struct {
-		Name    string `json:"name"`
-		Created string `json:"created"`
-		Path    string `json:"path,omitempty"`
+		Name      string `json:"name"`
+		Created   string `json:"created"`
+		Path      string `json:"path,omitempty"`
+		SizeBytes *int64 `json:"sizeBytes,omitempty"`
 	}
 
 	output := map[string]interface{}{
@@ -200,6 +320,9 @@ func outputContextsJSON(reg *registry.Registry, projectIdentifier, currentContex
 		if ctx.Path != "" {
 			ctxJSON.Path = ctx.Path
 		}
+		if size, ok := sizes[name]; ok {
+			ctxJSON.SizeBytes = &size
+		}
 
 		contextList = append(contextList, ctxJSON)
 	}
@@ -217,9 +340,11 @@ func outputContextsJSON(reg *registry.Registry, projectIdentifier, currentContex
 
 func outputAllProjectsJSON(reg *registry.Registry, projects []string) error {
 	type contextJSON struct {
-		Name    string `json:"name"`
-		Created string `json:"created"`
-		Path    string `json:"path,omitempty"`
+		Name      string `json:"name"`
+		Created   string `json:"created"`
+		Path      string `json:"path,omitempty"`
+		Exists    bool   `json:"exists"`
+		SizeBytes *int64 `json:"sizeBytes,omitempty"`
 	}
 
 	type projectJSON struct {
@@ -246,16 +371,21 @@ func outputAllProjectsJSON(reg *registry.Registry, projects []string) error {
 		sort.Strings(names)
 
 		// Build context list
+		sizes := contextSizes(contexts)
 		contextList := make([]contextJSON, 0, len(contexts))
 		for _, name := range names {
 			ctx := contexts[name]
 			ctxJSON := contextJSON{
 				Name:    name,
 				Created: ctx.Created.Format("2006-01-02T15:04:05Z"),
+				Exists:  contextPathExists(projectPath, ctx),
 			}
 			if ctx.Path != "" {
 				ctxJSON.Path = ctx.Path
 			}
+			if size, ok := sizes[name]; ok {
+				ctxJSON.SizeBytes = &size
+			}
 			contextList = append(contextList, ctxJSON)
 		}
 
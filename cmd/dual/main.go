@@ -1,9 +1,16 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/lightfastai/dual/internal/config"
+	dualerrors "github.com/lightfastai/dual/internal/errors"
+	"github.com/lightfastai/dual/internal/logger"
+	"github.com/lightfastai/dual/internal/registry"
 	"github.com/spf13/cobra"
 )
 
@@ -16,6 +23,26 @@ var (
 	date    = "unknown"
 )
 
+// projectFlag is the --project global flag
+var projectFlag string
+
+// logJSONFlag is the --log-json global flag
+var logJSONFlag bool
+
+// errorJSONFlag is the --error-json global flag
+var errorJSONFlag bool
+
+// quietFlag is the --quiet global flag
+var quietFlag bool
+
+// colorFlag is the --color global flag; --no-color and --force-color are
+// shorthands for never and always
+var (
+	colorFlag      string
+	noColorFlag    bool
+	forceColorFlag bool
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "dual",
 	Short: "Manage worktree lifecycle with environment remapping",
@@ -24,6 +51,24 @@ with environment remapping via hooks. It enables flexible development workflows
 across multiple branches and worktrees, allowing users to implement custom
 environment management logic through hooks.`,
 	Version: version,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		logger.SetJSON(logJSONFlag)
+		logger.SetQuiet(quietFlag)
+		if err := applyColorFlags(cmd); err != nil {
+			return err
+		}
+		// main reports the error as JSON instead of cobra's text and usage
+		cmd.Root().SilenceErrors = errorJSONFlag
+		cmd.Root().SilenceUsage = errorJSONFlag
+		if err := applyProjectFlag(); err != nil {
+			return err
+		}
+		// An out-of-date binary must still be able to report its version
+		if cmd == versionCmd {
+			return nil
+		}
+		return checkVersionCompatibility()
+	},
 }
 
 func init() {
@@ -42,11 +87,115 @@ Built: {{.Annotations.date}}
 
 	// Add version flag (cobra adds this automatically, but we ensure it's there)
 	rootCmd.Flags().BoolP("version", "v", false, "version for dual")
+
+	rootCmd.PersistentFlags().StringVar(&projectFlag, "project", "", "Operate on the project at this path instead of the current directory")
+	rootCmd.PersistentFlags().BoolVar(&logJSONFlag, "log-json", false, "Write log messages to stderr as JSON, one object per line")
+	rootCmd.PersistentFlags().BoolVar(&errorJSONFlag, "error-json", false, "On failure, write the error to stderr as a JSON object with its code and suggested fixes")
+	rootCmd.PersistentFlags().BoolVarP(&quietFlag, "quiet", "q", false, "Suppress informational and warning messages on stderr (errors are still shown)")
+	rootCmd.PersistentFlags().StringVar(&colorFlag, "color", "auto", "Color output: auto (only on a terminal, unless NO_COLOR is set), always or never")
+	rootCmd.PersistentFlags().BoolVar(&noColorFlag, "no-color", false, "Disable color output (same as --color=never)")
+	rootCmd.PersistentFlags().BoolVar(&forceColorFlag, "force-color", false, "Color output even when it is piped (same as --color=always)")
+}
+
+// applyColorFlags resolves --color, --no-color and --force-color and applies
+// the result to log messages and colored command output
+func applyColorFlags(cmd *cobra.Command) error {
+	mode, err := logger.ParseColorMode(colorFlag)
+	if err != nil {
+		return err
+	}
+
+	colorSet := cmd.Flags().Changed("color")
+	switch {
+	case noColorFlag && forceColorFlag:
+		return fmt.Errorf("--no-color and --force-color cannot be used together")
+	case (noColorFlag || forceColorFlag) && colorSet:
+		return fmt.Errorf("--color cannot be used with --no-color or --force-color")
+	case noColorFlag:
+		mode = logger.ColorNever
+	case forceColorFlag:
+		mode = logger.ColorAlways
+	}
+
+	logger.SetColor(mode)
+	return nil
+}
+
+// applyProjectFlag validates the --project path and switches into it, so that
+// config loading, context detection and service detection all resolve against
+// the given project exactly as if dual had been started there
+func applyProjectFlag() error {
+	if projectFlag == "" {
+		return nil
+	}
+
+	projectPath := projectFlag
+	if projectPath == "~" || strings.HasPrefix(projectPath, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to expand --project path: %w", err)
+		}
+		projectPath = filepath.Join(home, strings.TrimPrefix(projectPath, "~"))
+	}
+
+	_, projectRoot, err := config.LoadConfigFromRoot(projectPath)
+	if err != nil {
+		return fmt.Errorf("invalid --project %q: %w", projectFlag, err)
+	}
+
+	if err := os.Chdir(projectRoot); err != nil {
+		return fmt.Errorf("failed to switch to project %s: %w", projectRoot, err)
+	}
+
+	return nil
+}
+
+// checkVersionCompatibility fails early, with upgrade advice, when the
+// project's config or registry was written for a newer dual than this one.
+// Anything it cannot read is left to the command's own loading.
+func checkVersionCompatibility() error {
+	configPath, err := config.FindConfigPath()
+	if err != nil {
+		return nil
+	}
+
+	if v, err := config.ReadConfigVersion(configPath); err == nil && v > config.SupportedVersion {
+		return fmt.Errorf("dual %s is out of date: %s uses config version %d, but this build supports up to version %d\nHint: Upgrade dual to the latest release (e.g. 'brew upgrade dual'), then check with 'dual --version'",
+			version, configPath, v, config.SupportedVersion)
+	}
+
+	projectIdentifier, err := config.GetProjectIdentifier(filepath.Dir(configPath))
+	if err != nil {
+		return nil
+	}
+	if v, err := registry.ReadSchemaVersion(projectIdentifier); err == nil && v > registry.SchemaVersion {
+		registryPath, _ := registry.GetRegistryPath(projectIdentifier)
+		return fmt.Errorf("dual %s is out of date: %s uses registry schema version %d, but this build supports up to version %d\nHint: Upgrade dual to the latest release (e.g. 'brew upgrade dual'), then check with 'dual --version'",
+			version, registryPath, v, registry.SchemaVersion)
+	}
+
+	return nil
+}
+
+// writeErrorJSON reports err on stderr as {"error": {...}} for --error-json
+func writeErrorJSON(err error) {
+	output := struct {
+		Error dualerrors.JSONError `json:"error"`
+	}{Error: dualerrors.ToJSON(err)}
+	encoder := json.NewEncoder(os.Stderr)
+	encoder.SetEscapeHTML(false)
+	if encodeErr := encoder.Encode(output); encodeErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
 }
 
 func main() {
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if errorJSONFlag {
+			writeErrorJSON(err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
 		os.Exit(1)
 	}
 }
@@ -16,8 +16,8 @@ var openCmd = &cobra.Command{
 	Short: "Open a service directory in VS Code",
 	Long: `Opens the service directory in VS Code.
 
-If no service is specified, dual will attempt to auto-detect the service
-based on your current working directory.
+If no service is specified, dual uses $DUAL_SERVICE if set, and otherwise
+attempts to auto-detect the service based on your current working directory.
 
 Examples:
   dual open www    # Open www service directory in VS Code
@@ -41,17 +41,21 @@ func runOpen(cmd *cobra.Command, args []string) error {
 	var serviceName string
 	if len(args) > 0 {
 		// Service specified explicitly
-		serviceName = args[0]
-		// Validate service exists in config
-		if _, exists := cfg.Services[serviceName]; !exists {
-			return fmt.Errorf("service %q not found in config\nAvailable services: %v", serviceName, getServiceNames(cfg))
+		// Validate service exists in config, resolving aliases
+		canonical, exists := cfg.ResolveServiceName(args[0])
+		if !exists {
+			return fmt.Errorf("service %q not found in config\nAvailable services: %v", args[0], getServiceNames(cfg))
 		}
+		serviceName = canonical
 	} else {
-		// Auto-detect service
-		serviceName, err = service.DetectService(cfg, projectRoot)
+		// Use DUAL_SERVICE, falling back to auto-detection
+		serviceName, err = service.ResolveService(cfg, projectRoot, "")
 		if err != nil {
 			if errors.Is(err, service.ErrServiceNotDetected) {
-				return fmt.Errorf("could not auto-detect service from current directory\nAvailable services: %v\nHint: Run this command from within a service directory or specify the service name", getServiceNames(cfg))
+				return fmt.Errorf("could not auto-detect service from current directory\nAvailable services: %v\nHint: Run this command from within a service directory, specify the service name or set %s", getServiceNames(cfg), service.EnvVar)
+			}
+			if errors.Is(err, service.ErrUnknownService) {
+				return err
 			}
 			return fmt.Errorf("failed to detect service: %w", err)
 		}
@@ -5,11 +5,17 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/lightfastai/dual/internal/config"
 	"github.com/lightfastai/dual/internal/context"
 	"github.com/lightfastai/dual/internal/env"
+	"github.com/lightfastai/dual/internal/logger"
+	"github.com/lightfastai/dual/internal/registry"
 	"github.com/lightfastai/dual/internal/service"
+	"github.com/mattn/go-isatty"
 	"github.com/spf13/cobra"
 )
 
@@ -24,10 +30,18 @@ into the command execution in priority order (lowest to highest):
   1. Base environment (.env.base if configured)
   2. Service-specific environment (<service-path>/.env)
   3. Context-specific overrides (.dual/.local/service/<service>/.env)
+  4. The --profile override set from dual.config.yml, if given
+  5. --env-override values
 
 This enables running services with isolated environments per worktree without
 requiring applications to load dotenv files manually.
 
+Signals (SIGINT, SIGTERM, SIGHUP, SIGQUIT) are relayed to the command so it can
+shut down gracefully, and dual exits with the command's exit code. If the
+command is still running --grace-period after the first signal, it is killed.
+When not attached to a terminal, the command runs in its own process group and
+signals are relayed to the whole group, so processes it spawns are not orphaned.
+
 Examples:
   # Run Node.js server with environment
   dual run node server.js
@@ -39,45 +53,93 @@ Examples:
   dual run python app.py
 
   # Explicitly specify service
-  dual run --service api node server.js`,
+  dual run --service api node server.js
+
+  # Select the service from the environment (e.g. in CI, from the repo root)
+  DUAL_SERVICE=api dual run node server.js
+
+  # One-off overrides for this invocation only (not saved to the registry)
+  dual run --env-override PORT=4001 --env-override DEBUG=1 npm start
+
+  # Apply a named override set from the profiles section of dual.config.yml
+  dual run --profile debug npm start
+
+  # Run from a service directory without cd'ing (e.g. from a root process manager)
+  dual run --cwd apps/api -- npm run dev`,
 	RunE:               runCommand,
 	Args:               cobra.MinimumNArgs(1),
 	DisableFlagParsing: false,
 }
 
-var runServiceName string
+var (
+	runServiceName  string
+	runEnvOverrides []string
+	runGracePeriod  time.Duration
+	runCwd          string
+	runProfile      string
+)
 
 func init() {
 	rootCmd.AddCommand(runCmd)
 
-	runCmd.Flags().StringVar(&runServiceName, "service", "", "Explicitly specify service name (auto-detected if not provided)")
+	runCmd.Flags().StringVar(&runServiceName, "service", "", "Explicitly specify service name (defaults to $DUAL_SERVICE, then auto-detected)")
+	runCmd.Flags().DurationVar(&runGracePeriod, "grace-period", 10*time.Second, "Time to wait after relaying a signal before killing the command (0 waits forever)")
+	runCmd.Flags().StringArrayVar(&runEnvOverrides, "env-override", nil, "Set KEY=VALUE for this run only, above all other layers (repeatable)")
+	runCmd.Flags().StringVar(&runProfile, "profile", "", "Apply this profile from dual.config.yml above the context overrides for this run only")
+	runCmd.Flags().StringVar(&runCwd, "cwd", "", "Directory to detect the service from and run the command in (defaults to the current directory)")
 }
 
 func runCommand(cmd *cobra.Command, args []string) error {
+	// Parse one-off overrides before doing any work
+	oneOffOverrides := make(map[string]string, len(runEnvOverrides))
+	for _, assignment := range runEnvOverrides {
+		key, value, err := env.ParseAssignment(assignment)
+		if err != nil {
+			return fmt.Errorf("invalid --env-override: %w", err)
+		}
+		oneOffOverrides[key] = value
+	}
+
+	if runCwd != "" {
+		info, err := os.Stat(runCwd)
+		if err != nil {
+			return fmt.Errorf("invalid --cwd: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("invalid --cwd: %s is not a directory", runCwd)
+		}
+	}
+
 	// Load config (finds project root automatically)
 	cfg, projectRoot, err := config.LoadConfig()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Detect current service if not explicitly specified
-	serviceName := runServiceName
-	if serviceName == "" {
-		detector := service.NewDetector()
-		detectedService, err := detector.DetectService(cfg, projectRoot)
-		if err != nil {
-			return fmt.Errorf("failed to detect service (use --service flag to specify): %w", err)
+	var profile map[string]string
+	if runProfile != "" {
+		if profile, err = cfg.GetProfile(runProfile); err != nil {
+			return err
 		}
-		serviceName = detectedService
 	}
 
-	// Validate service exists in config
-	if _, exists := cfg.Services[serviceName]; !exists {
-		return fmt.Errorf("service %q not found in config", serviceName)
+	// Use --service or DUAL_SERVICE, falling back to detection from cwd
+	// (or --cwd when given)
+	var serviceName string
+	if runCwd != "" {
+		serviceName, err = service.ResolveServiceInDir(cfg, projectRoot, runServiceName, runCwd)
+	} else {
+		serviceName, err = service.ResolveService(cfg, projectRoot, runServiceName)
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrUnknownService) {
+			return err
+		}
+		return fmt.Errorf("failed to detect service (use --service or %s to specify): %w", service.EnvVar, err)
 	}
 
 	// Detect current context
-	ctxDetector := context.NewDetector()
+	ctxDetector := context.NewDetectorWithSource(cfg.GetContextSource())
 	ctxName, err := ctxDetector.DetectContext()
 	if err != nil {
 		return fmt.Errorf("failed to detect context: %w", err)
@@ -91,8 +153,34 @@ func runCommand(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load layered environment: %w", err)
 	}
 
-	// Merge all layers
-	mergedEnv := layeredEnv.Merge()
+	// Overrides matching env.exclude are never written to the generated
+	// file, so read them from the registry instead
+	if len(cfg.Env.Exclude) > 0 {
+		excluded, err := loadExcludedOverrides(cfg, projectRoot, serviceName, ctxName)
+		if err != nil {
+			logger.Warn("failed to load excluded overrides: %v", err)
+		}
+		if len(excluded) > 0 && layeredEnv.Overrides == nil {
+			layeredEnv.Overrides = make(map[string]string)
+		}
+		for key, value := range excluded {
+			layeredEnv.Overrides[key] = value
+		}
+	}
+
+	// The profile sits above the context overrides and is never persisted
+	layeredEnv.ApplyProfile(profile)
+
+	// Merge all layers, resolving secret references only now so they never hit disk
+	mergedEnv, err := layeredEnv.MergeResolved(env.NewCommandSecretResolver(cfg.Env.Secrets))
+	if err != nil {
+		return fmt.Errorf("%w\nHint: Check that the secret manager CLI is installed and you are signed in", err)
+	}
+
+	// One-off overrides take precedence over every layer and are never persisted
+	for key, value := range oneOffOverrides {
+		mergedEnv[key] = value
+	}
 
 	// Build environment for exec
 	execEnv := buildExecEnv(mergedEnv)
@@ -104,27 +192,122 @@ func runCommand(cmd *cobra.Command, args []string) error {
 	// Execute command with injected environment
 	execCmd := exec.Command(command, commandArgs...)
 	execCmd.Env = execEnv
+	execCmd.Dir = runCwd
 	execCmd.Stdout = os.Stdout
 	execCmd.Stderr = os.Stderr
 	execCmd.Stdin = os.Stdin
 
-	fmt.Fprintf(os.Stderr, "[dual] Running: %s %v\n", command, commandArgs)
-	fmt.Fprintf(os.Stderr, "[dual] Service: %s\n", serviceName)
-	fmt.Fprintf(os.Stderr, "[dual] Context: %s\n", ctxName)
-	fmt.Fprintf(os.Stderr, "[dual] Environment variables loaded: %d\n\n", len(mergedEnv))
+	logger.Info("Running: %s %v", command, commandArgs)
+	logger.Info("Service: %s", serviceName)
+	logger.Info("Context: %s", ctxName)
+	logger.Info("Environment variables loaded: %d", len(mergedEnv))
+	logger.Detail("")
+
+	// In a terminal the command shares our foreground process group so it keeps
+	// access to stdin and receives Ctrl-C directly. Otherwise give it its own
+	// group so relayed signals also reach any processes it spawns.
+	ownGroup := !isatty.IsTerminal(os.Stdin.Fd()) && setProcessGroup(execCmd)
 
 	// Run command and return exit code
-	if err := execCmd.Run(); err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			os.Exit(exitErr.ExitCode())
-		}
+	exitCode, err := runWithSignalRelay(execCmd, ownGroup, runGracePeriod)
+	if err != nil {
 		return fmt.Errorf("command execution failed: %w", err)
 	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 
 	return nil
 }
 
+// loadExcludedOverrides returns the context's overrides for the service that
+// match env.exclude. A context that is not registered has none.
+func loadExcludedOverrides(cfg *config.Config, projectRoot, serviceName, ctxName string) (map[string]string, error) {
+	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project identifier: %w", err)
+	}
+
+	reg, err := registry.LoadRegistryReadOnly(projectIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load registry: %w", err)
+	}
+	defer reg.Close()
+
+	ctx, err := reg.GetContext(projectIdentifier, ctxName)
+	if err != nil {
+		return nil, nil
+	}
+	return env.ExcludedOverrides(ctx, serviceName, cfg.Env.Exclude), nil
+}
+
+// runWithSignalRelay starts a command and waits for it, relaying termination
+// signals received by dual to it. Signals are caught before the command starts,
+// so one arriving early cannot kill dual and leave the command running. If the
+// command has not exited within grace after the first signal, it is sent
+// SIGKILL. Returns the command's exit code, using the shell convention 128+n
+// when it was terminated by signal n.
+func runWithSignalRelay(cmd *exec.Cmd, ownGroup bool, grace time.Duration) (int, error) {
+	sigCh := make(chan os.Signal, 4)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(sigCh)
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	var killTimer <-chan time.Time
+	for {
+		select {
+		case err := <-done:
+			return exitCodeFromWait(err)
+		case sig := <-sigCh:
+			// Terminal-generated Ctrl-C and Ctrl-\ already reached the command
+			// through the shared foreground process group
+			if ownGroup || (sig != syscall.SIGINT && sig != syscall.SIGQUIT) {
+				signalChild(cmd, ownGroup, sig.(syscall.Signal))
+			}
+			if killTimer == nil && grace > 0 {
+				killTimer = time.After(grace)
+			}
+		case <-killTimer:
+			logger.Info("Command did not exit within %s, killing it", grace)
+			signalChild(cmd, ownGroup, syscall.SIGKILL)
+		}
+	}
+}
+
+// signalChild sends sig to the command, or to its whole process group if it has one
+func signalChild(cmd *exec.Cmd, ownGroup bool, sig syscall.Signal) {
+	if ownGroup {
+		_ = signalProcessGroup(cmd.Process.Pid, sig)
+		return
+	}
+	_ = cmd.Process.Signal(sig)
+}
+
+// exitCodeFromWait converts the result of cmd.Wait into an exit code
+func exitCodeFromWait(err error) (int, error) {
+	if err == nil {
+		return 0, nil
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return 0, err
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+	return exitErr.ExitCode(), nil
+}
+
 // buildExecEnv creates the environment slice for exec.Command
 func buildExecEnv(mergedEnv map[string]string) []string {
 	// Start with current process environment
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup reports false: process groups are only used on Unix, so
+// signals are relayed to the command alone
+func setProcessGroup(cmd *exec.Cmd) bool {
+	return false
+}
+
+// signalProcessGroup is never called when setProcessGroup reports false
+func signalProcessGroup(pid int, sig syscall.Signal) error {
+	return errors.New("process groups are not supported on this platform")
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/lightfastai/dual/internal/config"
 	"github.com/spf13/cobra"
@@ -14,6 +15,7 @@ import (
 var (
 	servicePath    string
 	serviceEnvFile string
+	serviceCreate  bool
 	// list command flags
 	listJSON     bool
 	listAbsPaths bool
@@ -32,8 +34,14 @@ var serviceAddCmd = &cobra.Command{
 	Short: "Add a new service to the configuration",
 	Long: `Add a new service to the dual configuration with the specified name and path.
 
-The path should be relative to the project root (where dual.config.yml is located).
-Optionally, you can specify an env file for the service using --env-file.`,
+The path should be relative to the project root (where dual.config.yml is located)
+and must already exist, unless --create-dir is given to create it first.
+Optionally, you can specify an env file for the service using --env-file.
+
+Examples:
+  dual service add api --path apps/api                      # Existing directory
+  dual service add web --path apps/web --create-dir         # Create apps/web first
+  dual service add api --path apps/api --env-file apps/api/.env.local`,
 	Args: cobra.ExactArgs(1),
 	RunE: runServiceAdd,
 }
@@ -63,6 +71,7 @@ This command does NOT delete any files or directories.`,
 func init() {
 	serviceAddCmd.Flags().StringVar(&servicePath, "path", "", "Relative path to the service directory (required)")
 	serviceAddCmd.Flags().StringVar(&serviceEnvFile, "env-file", "", "Relative path to the env file for the service (optional)")
+	serviceAddCmd.Flags().BoolVar(&serviceCreate, "create-dir", false, "Create the service directory if it does not exist")
 	_ = serviceAddCmd.MarkFlagRequired("path")
 
 	serviceListCmd.Flags().BoolVar(&listJSON, "json", false, "Output in JSON format")
@@ -103,12 +112,19 @@ func runServiceAdd(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("path must be relative to project root, got absolute path: %s", servicePath)
 	}
 
-	// Validate that the path exists
+	// Validate that the path exists, creating it if asked to
 	fullPath := filepath.Join(projectRoot, servicePath)
 	info, err := os.Stat(fullPath)
+	if os.IsNotExist(err) && serviceCreate {
+		if err := os.MkdirAll(fullPath, 0o755); err != nil {
+			return fmt.Errorf("failed to create service directory: %w", err)
+		}
+		fmt.Printf("[dual] Created directory %s\n", servicePath)
+		info, err = os.Stat(fullPath)
+	}
 	if err != nil {
 		if os.IsNotExist(err) {
-			return fmt.Errorf("path does not exist: %s (resolved to %s)", servicePath, fullPath)
+			return fmt.Errorf("path does not exist: %s (resolved to %s)\nHint: Create it with 'mkdir -p %s' or re-run with --create-dir", servicePath, fullPath, servicePath)
 		}
 		return fmt.Errorf("failed to check path: %w", err)
 	}
@@ -191,10 +207,11 @@ func runServiceList(cmd *cobra.Command, args []string) error {
 
 func outputListJSON(cfg *config.Config, projectRoot string, serviceNames []string) error {
 	type serviceOutput struct {
-		Name         string `json:"name"`
-		Path         string `json:"path"`
-		EnvFile      string `json:"envFile,omitempty"`
-		AbsolutePath string `json:"absolutePath,omitempty"`
+		Name         string   `json:"name"`
+		Path         string   `json:"path"`
+		EnvFile      string   `json:"envFile,omitempty"`
+		AbsolutePath string   `json:"absolutePath,omitempty"`
+		Aliases      []string `json:"aliases,omitempty"`
 	}
 
 	output := struct {
@@ -209,6 +226,7 @@ func outputListJSON(cfg *config.Config, projectRoot string, serviceNames []strin
 			Name:    name,
 			Path:    svc.Path,
 			EnvFile: svc.EnvFile,
+			Aliases: svc.Aliases,
 		}
 
 		if listAbsPaths {
@@ -261,6 +279,9 @@ func outputListHuman(cfg *config.Config, projectRoot string, serviceNames []stri
 		if svc.EnvFile != "" {
 			fmt.Printf("  %s", svc.EnvFile)
 		}
+		if len(svc.Aliases) > 0 {
+			fmt.Printf("  (aliases: %s)", strings.Join(svc.Aliases, ", "))
+		}
 		fmt.Println()
 	}
 
@@ -287,6 +308,11 @@ func runServiceRemove(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("service %q not found in configuration", serviceName)
 	}
 
+	// Services expanded from a glob would reappear on the next load
+	if globName := cfg.ServiceGlobSource(serviceName); globName != "" {
+		return fmt.Errorf("service %q is generated by the glob service %q\nHint: Add it to the 'exclude' list of %q in %s instead", serviceName, globName, globName, config.ConfigFileName)
+	}
+
 	// Remove service from config
 	delete(cfg.Services, serviceName)
 
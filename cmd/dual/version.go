@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/lightfastai/dual/internal/logger"
+	"github.com/lightfastai/dual/internal/update"
+	"github.com/spf13/cobra"
+)
+
+var versionCheck bool
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the version and optionally check for updates",
+	Long: `Print the version, commit and build date of this dual binary.
+
+With --check, also ask GitHub for the latest release and report whether a
+newer one is available. The answer is cached for a day in the user cache
+directory ($XDG_CACHE_HOME/dual on Linux) to stay clear of API rate limits.
+The check is opt-in and only runs for this command. When GitHub cannot be
+reached, a warning is printed and the command still succeeds.
+
+Examples:
+  dual version           # Version, commit and build date
+  dual version --check   # Also check for a newer release`,
+	Args: cobra.NoArgs,
+	RunE: runVersion,
+}
+
+func init() {
+	versionCmd.Flags().BoolVar(&versionCheck, "check", false, "Check GitHub for a newer release")
+	rootCmd.AddCommand(versionCmd)
+}
+
+func runVersion(cmd *cobra.Command, args []string) error {
+	fmt.Printf("dual version %s\n", version)
+	fmt.Printf("Commit: %s\n", commit)
+	fmt.Printf("Built: %s\n", date)
+
+	if !versionCheck {
+		return nil
+	}
+
+	result, err := update.NewChecker().Check(version)
+	if err != nil {
+		logger.Warn("could not check for updates: %v", err)
+		return nil
+	}
+
+	fmt.Println()
+	switch {
+	case !result.Comparable:
+		fmt.Printf("Latest release is %s (this is a development build)\n", result.Latest.Version)
+	case result.UpdateAvailable:
+		fmt.Printf("A newer version is available: %s (you have %s)\n", result.Latest.Version, version)
+		if result.Latest.URL != "" {
+			fmt.Printf("  %s\n", result.Latest.URL)
+		}
+		fmt.Println("Hint: Upgrade with your package manager, e.g. 'brew upgrade dual'")
+	default:
+		fmt.Printf("✓ dual %s is up to date\n", version)
+	}
+
+	return nil
+}
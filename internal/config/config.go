@@ -3,10 +3,15 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 
+	"github.com/lightfastai/dual/internal/context"
 	dualerrors "github.com/lightfastai/dual/internal/errors"
+	"github.com/lightfastai/dual/internal/logger"
 	"github.com/lightfastai/dual/internal/worktree"
 	"gopkg.in/yaml.v3"
 )
@@ -18,19 +23,96 @@ const (
 	SupportedVersion = 1
 )
 
+const (
+	// HookDirWorktree runs hook scripts from the context's worktree directory
+	HookDirWorktree = "worktree"
+	// HookDirProject runs hook scripts from the main project root
+	HookDirProject = "project"
+)
+
+const (
+	// HookFailureWarn keeps the new worktree and prints a warning
+	HookFailureWarn = "warn"
+	// HookFailureAbort keeps the new worktree but fails the command
+	HookFailureAbort = "abort"
+	// HookFailureRollback removes the new worktree and context and fails the command
+	HookFailureRollback = "rollback"
+)
+
+// validHookEvents lists the lifecycle events hooks can be attached to
+var validHookEvents = map[string]bool{
+	"postWorktreeCreate": true,
+	"preWorktreeDelete":  true,
+	"postWorktreeDelete": true,
+	"preContextDelete":   true,
+	"postContextDelete":  true,
+}
+
+// validHookEventNames is validHookEvents in display order, for error messages
+const validHookEventNames = "postWorktreeCreate, preWorktreeDelete, postWorktreeDelete, preContextDelete, postContextDelete"
+
+// defaultHookWorkingDirs are used for events without a hookWorkingDir entry.
+// postWorktreeDelete runs after the worktree is removed, and context events
+// fire for contexts whose worktree may not exist, so they cannot run there.
+var defaultHookWorkingDirs = map[string]string{
+	"postWorktreeCreate": HookDirWorktree,
+	"preWorktreeDelete":  HookDirWorktree,
+	"postWorktreeDelete": HookDirProject,
+	"preContextDelete":   HookDirProject,
+	"postContextDelete":  HookDirProject,
+}
+
 // Config represents the dual.config.yml structure
 type Config struct {
 	Services  map[string]Service  `yaml:"services"`
 	Version   int                 `yaml:"version"`
 	Env       EnvConfig           `yaml:"env,omitempty"`
 	Worktrees WorktreeConfig      `yaml:"worktrees,omitempty"`
+	Context   ContextConfig       `yaml:"context,omitempty"`
 	Hooks     map[string][]string `yaml:"hooks,omitempty"`
+
+	// HookWorkingDir sets, per hook event, the directory hook scripts run in:
+	// HookDirWorktree or HookDirProject. See GetHookWorkingDir for defaults.
+	HookWorkingDir map[string]string `yaml:"hookWorkingDir,omitempty"`
+
+	// OnHookFailure is what 'dual create' does when a postWorktreeCreate hook
+	// fails: HookFailureWarn (default), HookFailureAbort or HookFailureRollback
+	OnHookFailure string `yaml:"onHookFailure,omitempty"`
+
+	// ServiceDefaults fills in the fields a service leaves unset
+	// (see applyServiceDefaults)
+	ServiceDefaults ServiceDefaults `yaml:"serviceDefaults,omitempty"`
+
+	// Profiles are named override sets applied for a single invocation with
+	// --profile, above the context overrides. They are never saved to the registry.
+	Profiles map[string]map[string]string `yaml:"profiles,omitempty"`
+
+	// Glob expansion bookkeeping (see expandServiceGlobs), used by SaveConfig
+	// to write glob entries back instead of the services they expanded into
+	serviceGlobs      map[string]Service
+	expandedFrom      map[string]string
+	expanded          map[string]Service
+	explicitOverrides map[string]Service
+
+	// Service defaults bookkeeping (see applyServiceDefaults), used by
+	// SaveConfig to write services without the applied defaults
+	beforeDefaults map[string]Service
+	withDefaults   map[string]Service
 }
 
 // EnvConfig contains environment-related configuration
 type EnvConfig struct {
 	// BaseFile is the path to the base environment file (relative to project root)
 	BaseFile string `yaml:"baseFile,omitempty"`
+
+	// Secrets maps a secret reference scheme to the command that resolves it
+	// Example: vault: "vault kv get -field={field} {path}"
+	Secrets map[string]string `yaml:"secrets,omitempty"`
+
+	// Exclude lists variable names or glob patterns (e.g. "GITHUB_*") that are
+	// never written to the generated service env files. Matching overrides are
+	// only applied by 'dual run'.
+	Exclude []string `yaml:"exclude,omitempty"`
 }
 
 // WorktreeConfig contains worktree-related configuration
@@ -43,12 +125,51 @@ type WorktreeConfig struct {
 	// Supports: "branch" (use branch name as-is), "prefix-{branch}", etc.
 	// Default: "branch"
 	Naming string `yaml:"naming,omitempty"`
+
+	// WriteContextFile controls whether 'dual create' writes a .dual-context
+	// file naming the context into each new worktree. Default: true
+	WriteContextFile *bool `yaml:"writeContextFile,omitempty"`
+}
+
+// ContextConfig contains context detection configuration
+type ContextConfig struct {
+	// Source sets the detection precedence: "branch" (git branch, then
+	// .dual-context; default), "fileFirst" (.dual-context, then git branch)
+	// or "file" (.dual-context only)
+	Source string `yaml:"source,omitempty"`
 }
 
 // Service represents a single service configuration
 type Service struct {
+	// Path is the service directory relative to the project root.
+	// It may be a glob pattern (e.g. "apps/*") that expands into one service
+	// per matched directory, named after the directory.
 	Path    string `yaml:"path"`
 	EnvFile string `yaml:"envFile"`
+
+	// BaseFile is an optional env file, relative to the project root, with
+	// shared defaults for this service. It is layered above env.baseFile and
+	// below the service's env file.
+	BaseFile string `yaml:"baseFile,omitempty"`
+
+	// Exclude lists patterns to skip when Path is a glob. Patterns are matched
+	// against the directory name and its path relative to the project root.
+	Exclude []string `yaml:"exclude,omitempty"`
+
+	// Ignore lists glob patterns, relative to the service path, for
+	// subdirectories where service detection should not pick this service
+	// (e.g. "node_modules" or "packages/*/dist")
+	Ignore []string `yaml:"ignore,omitempty"`
+
+	// Aliases are alternative names that resolve to this service wherever a
+	// service name is accepted (e.g. --service frontend for "web"). The
+	// canonical name is still used for registry and generated file keys.
+	Aliases []string `yaml:"aliases,omitempty"`
+
+	// Hooks lists scripts in .dual/hooks/ to run for lifecycle events, like
+	// the project-level hooks. They run after the project-level hooks for the
+	// same event, with DUAL_SERVICE set to the service name.
+	Hooks map[string][]string `yaml:"hooks,omitempty"`
 }
 
 // LoadConfig searches for dual.config.yml starting from the current directory
@@ -58,22 +179,80 @@ type Service struct {
 // (which will be the worktree directory for worktrees sharing the config).
 // Use GetProjectIdentifier() to get the normalized identifier for the registry.
 func LoadConfig() (*Config, string, error) {
+	// Find the config file by walking up from the current directory
+	configPath, err := FindConfigPath()
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Parse the config
+	config, err := parseConfig(configPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+
+	// The project root is the directory where the config was found
+	// This allows service paths to be resolved correctly in both main repo and worktrees
+	projectRoot := filepath.Dir(configPath)
+
+	// Validate the config against the project root
+	if err := validateConfig(config, projectRoot); err != nil {
+		return nil, "", fmt.Errorf("invalid config in %s: %w", configPath, err)
+	}
+
+	return config, projectRoot, nil
+}
+
+// LoadConfigFromRoot loads the config located directly in projectRoot without
+// searching parent directories. It is used when the project is given explicitly
+// (e.g. via --project) rather than derived from the current directory.
+// Returns the config and the absolute project root.
+func LoadConfigFromRoot(projectRoot string) (*Config, string, error) {
+	absRoot, err := filepath.Abs(projectRoot)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to resolve project path: %w", err)
+	}
+
+	configPath := filepath.Join(absRoot, ConfigFileName)
+	if _, err := os.Stat(configPath); err != nil {
+		dualErr := dualerrors.New(dualerrors.ErrConfigNotFound, fmt.Sprintf("No %s found in project directory", ConfigFileName))
+		dualErr = dualErr.WithContext("Project", absRoot)
+		dualErr = dualErr.WithFixes(
+			"Check that the path points at the project root (where dual.config.yml lives)",
+			fmt.Sprintf("Or initialize dual there: cd %s && dual init", absRoot),
+		)
+		return nil, "", dualErr
+	}
+
+	config, err := parseConfig(configPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+
+	if err := validateConfig(config, absRoot); err != nil {
+		return nil, "", fmt.Errorf("invalid config in %s: %w", configPath, err)
+	}
+
+	return config, absRoot, nil
+}
+
+// FindConfigPath searches for dual.config.yml starting from the current directory
+// and walking up the directory tree. It returns the absolute path of the config file.
+func FindConfigPath() (string, error) {
 	// Start from current directory
 	currentDir, err := os.Getwd()
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get current directory: %w", err)
+		return "", fmt.Errorf("failed to get current directory: %w", err)
 	}
 
 	// Walk up the directory tree
 	searchDir := currentDir
-	var configDir string
 	for {
 		configPath := filepath.Join(searchDir, ConfigFileName)
 
 		// Check if config file exists
 		if _, err := os.Stat(configPath); err == nil {
-			configDir = searchDir
-			break
+			return configPath, nil
 		}
 
 		// Move up one directory
@@ -95,31 +274,11 @@ func LoadConfig() (*Config, string, error) {
 				"    api:",
 				"      path: ./apps/api",
 			)
-			return nil, "", err
+			return "", err
 		}
 
 		searchDir = parentDir
 	}
-
-	// Found the config file at configDir
-	configPath := filepath.Join(configDir, ConfigFileName)
-
-	// Parse the config
-	config, err := parseConfig(configPath)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to parse %s: %w", configPath, err)
-	}
-
-	// The project root is the directory where the config was found
-	// This allows service paths to be resolved correctly in both main repo and worktrees
-	projectRoot := configDir
-
-	// Validate the config against the project root
-	if err := validateConfig(config, projectRoot); err != nil {
-		return nil, "", fmt.Errorf("invalid config in %s: %w", configPath, err)
-	}
-
-	return config, projectRoot, nil
 }
 
 // parseConfig reads and parses a YAML config file
@@ -224,8 +383,84 @@ func parseConfig(path string) (*Config, error) {
 	return &config, nil
 }
 
-// validateConfig checks that the config has valid structure and values
+// ValidationError describes a single problem found while validating a config.
+// Field identifies the offending setting using dotted YAML notation
+// (e.g. "services.web.path"), and Err carries the message, context and
+// suggested fixes.
+type ValidationError struct {
+	Field string
+	Err   *dualerrors.Error
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Err.Error())
+}
+
+// Unwrap returns the underlying structured error
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Message returns the human-readable description of the problem
+func (e *ValidationError) Message() string {
+	return e.Err.Message
+}
+
+// Fixes returns the suggested fixes for the problem
+func (e *ValidationError) Fixes() []string {
+	return e.Err.Fixes
+}
+
+// newValidationError creates a ValidationError for the given field
+func newValidationError(field string, err *dualerrors.Error) *ValidationError {
+	return &ValidationError{Field: field, Err: err}
+}
+
+// ValidationErrors holds every problem found while validating a config.
+// Validation does not stop at the first problem so that a config with several
+// mistakes can be fixed in a single pass.
+type ValidationErrors []*ValidationError
+
+// Error implements the error interface
+func (v ValidationErrors) Error() string {
+	if len(v) == 1 {
+		return v[0].Error()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d configuration problems found:", len(v)))
+	for _, err := range v {
+		sb.WriteString("\n  - ")
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap returns the individual validation errors for errors.Is and errors.As
+func (v ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(v))
+	for i, err := range v {
+		errs[i] = err
+	}
+	return errs
+}
+
+// validateConfig checks that the config has valid structure and values.
+// All problems are collected and returned together as ValidationErrors.
 func validateConfig(config *Config, projectRoot string) error {
+	// Expand glob service paths first so the expanded services are validated
+	// like any other service
+	errs := expandServiceGlobs(config, projectRoot)
+
+	// Then fill in unset fields from serviceDefaults, so the merged services
+	// are validated below
+	errs = append(errs, validateServiceDefaults(config.ServiceDefaults)...)
+	applyServiceDefaults(config)
+
 	// Check version
 	if config.Version == 0 {
 		err := dualerrors.New(dualerrors.ErrConfigInvalid, "Missing required 'version' field in configuration")
@@ -239,9 +474,8 @@ func validateConfig(config *Config, projectRoot string) error {
 			"    web:",
 			"      path: ./web",
 		)
-		return err
-	}
-	if config.Version != SupportedVersion {
+		errs = append(errs, newValidationError("version", err))
+	} else if config.Version != SupportedVersion {
 		err := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("Unsupported config version %d", config.Version))
 		err = err.WithContext("Current version", fmt.Sprintf("%d", config.Version))
 		err = err.WithContext("Required version", fmt.Sprintf("%d", SupportedVersion))
@@ -250,41 +484,252 @@ func validateConfig(config *Config, projectRoot string) error {
 			"This version of dual only supports config version 1",
 			"Check if you need to update dual: dual --version",
 		)
-		return err
+		errs = append(errs, newValidationError("version", err))
 	}
 
 	// Services can be empty (for initial setup), but if present, validate them
-	for name, service := range config.Services {
-		if err := validateService(name, service, projectRoot); err != nil {
-			return fmt.Errorf("service %q: %w", name, err)
-		}
+	// Iterate in sorted order so problems are reported deterministically
+	names := make([]string, 0, len(config.Services))
+	for name := range config.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		errs = append(errs, validateService(name, config.Services[name], projectRoot)...)
+	}
+
+	errs = append(errs, validateServiceAliases(config.Services, names)...)
+
+	// Nested service paths are allowed, but service detection picks the
+	// deepest match, which can be surprising
+	for _, warning := range overlappingServicePaths(config.Services) {
+		logger.Warn("%s", warning)
 	}
 
 	// Validate worktree configuration if present
 	if config.Worktrees.Path != "" {
 		if filepath.IsAbs(config.Worktrees.Path) {
-			return fmt.Errorf("worktrees.path must be relative to project root, got absolute path: %s", config.Worktrees.Path)
+			err := dualerrors.New(dualerrors.ErrConfigInvalid, "Worktrees path must be relative to project root")
+			err = err.WithContext("Absolute path", config.Worktrees.Path)
+			err = err.WithFixes(
+				"Use a path relative to where dual.config.yml is located",
+				"  Example: path: ../worktrees",
+			)
+			errs = append(errs, newValidationError("worktrees.path", err))
 		}
 		// Note: We don't check if the worktrees directory exists because it may not exist yet
 		// It will be created by the 'dual create' command
 	}
 
-	// Validate hooks if present
-	if len(config.Hooks) > 0 {
-		if err := validateHooks(config.Hooks, projectRoot); err != nil {
-			return fmt.Errorf("hooks: %w", err)
+	for _, pattern := range config.Env.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			dualErr := dualerrors.New(dualerrors.ErrConfigInvalid, "Invalid env exclude pattern")
+			dualErr = dualErr.WithContext("Pattern", pattern)
+			dualErr = dualErr.WithCause(err)
+			dualErr = dualErr.WithFixes(
+				"Use a variable name or glob, e.g. CI or GITHUB_*",
+				"Supported syntax: *, ?, [...]",
+			)
+			errs = append(errs, newValidationError("env.exclude", dualErr))
 		}
 	}
 
+	errs = append(errs, validateProfiles(config.Profiles)...)
+
+	// Validate hooks if present
+	errs = append(errs, validateHooks("hooks", config.Hooks, projectRoot)...)
+	errs = append(errs, validateHookWorkingDirs(config.HookWorkingDir)...)
+
+	if err := ValidateHookFailurePolicy(config.OnHookFailure); err != nil {
+		errs = append(errs, newValidationError("onHookFailure", err))
+	}
+
+	if _, err := context.ParseSource(config.Context.Source); err != nil {
+		err := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("Invalid context source: %s", config.Context.Source))
+		err = err.WithContext("Valid values", "branch, file, fileFirst")
+		err = err.WithFixes(
+			"'branch' uses the git branch, then .dual-context (default)",
+			"'fileFirst' uses .dual-context, then the git branch",
+			"'file' uses .dual-context only",
+		)
+		errs = append(errs, newValidationError("context.source", err))
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
 
-// validateService checks that a service configuration is valid
-func validateService(name string, service Service, projectRoot string) error {
+// validateService checks that a service configuration is valid and returns every problem found
+func validateService(name string, service Service, projectRoot string) ValidationErrors {
+	field := fmt.Sprintf("services.%s", name)
+
 	if name == "" {
-		return fmt.Errorf("service name cannot be empty")
+		err := dualerrors.New(dualerrors.ErrConfigInvalid, "Service name cannot be empty")
+		err = err.WithFixes("Give every entry under 'services' a non-empty name")
+		return ValidationErrors{newValidationError("services", err)}
 	}
 
+	var errs ValidationErrors
+
+	// Exclude only makes sense for glob paths, which are expanded before validation
+	if len(service.Exclude) > 0 {
+		err := dualerrors.New(dualerrors.ErrConfigInvalid, "Service exclude is only supported when path is a glob pattern")
+		err = err.WithContext("Service", name)
+		err = err.WithContext("Path", service.Path)
+		err = err.WithFixes(
+			"Remove the exclude list from this service",
+			"Or use a glob path, e.g. path: apps/*",
+		)
+		errs = append(errs, newValidationError(field+".exclude", err))
+	}
+
+	for _, pattern := range service.Ignore {
+		if err := validateIgnorePattern(pattern); err != nil {
+			dualErr := dualerrors.New(dualerrors.ErrConfigInvalid, "Invalid service ignore pattern")
+			dualErr = dualErr.WithContext("Service", name)
+			dualErr = dualErr.WithContext("Pattern", pattern)
+			dualErr = dualErr.WithCause(err)
+			dualErr = dualErr.WithFixes(
+				"Use a glob relative to the service path, e.g. node_modules or packages/*/dist",
+				"Supported syntax: *, ?, [...]",
+			)
+			errs = append(errs, newValidationError(field+".ignore", dualErr))
+		}
+	}
+
+	// EnvFile is optional, but if provided, validate it's a relative path
+	// Note: We don't validate that the file or directory exists because:
+	// - Files may not exist yet (fresh worktrees, gitignored directories)
+	// - The env layer gracefully handles missing files by returning empty maps
+	// - Validation happens at runtime via 'dual doctor' or 'dual env check'
+	if service.EnvFile != "" && filepath.IsAbs(service.EnvFile) {
+		err := dualerrors.New(dualerrors.ErrConfigInvalid, "Service envFile must be relative to project root")
+		err = err.WithContext("Service", name)
+		err = err.WithContext("Absolute path", service.EnvFile)
+		err = err.WithFixes(
+			"Use a path relative to where dual.config.yml is located",
+			fmt.Sprintf("  Example: envFile: %s", filepath.Join(service.Path, ".env")),
+		)
+		errs = append(errs, newValidationError(field+".envFile", err))
+	}
+
+	if service.BaseFile != "" && filepath.IsAbs(service.BaseFile) {
+		err := dualerrors.New(dualerrors.ErrConfigInvalid, "Service baseFile must be relative to project root")
+		err = err.WithContext("Service", name)
+		err = err.WithContext("Absolute path", service.BaseFile)
+		err = err.WithFixes(
+			"Use a path relative to where dual.config.yml is located",
+			fmt.Sprintf("  Example: baseFile: .env.%s.base", name),
+		)
+		errs = append(errs, newValidationError(field+".baseFile", err))
+	}
+
+	errs = append(errs, validateHooks(field+".hooks", service.Hooks, projectRoot)...)
+
+	if pathErr := validateServicePath(name, service, projectRoot); pathErr != nil {
+		// Report path problems first, as they are usually the root cause
+		errs = append(ValidationErrors{newValidationError(field+".path", pathErr)}, errs...)
+	}
+
+	return errs
+}
+
+// validateServiceAliases checks that every alias is a usable name that does
+// not clash with a service name or another alias. names must be sorted.
+func validateServiceAliases(services map[string]Service, names []string) ValidationErrors {
+	var errs ValidationErrors
+	owners := make(map[string]string)
+	for _, name := range names {
+		for _, alias := range services[name].Aliases {
+			field := fmt.Sprintf("services.%s.aliases", name)
+
+			if strings.TrimSpace(alias) == "" {
+				err := dualerrors.New(dualerrors.ErrConfigInvalid, "Service alias cannot be empty")
+				err = err.WithContext("Service", name)
+				err = err.WithFixes("Remove the empty entry from the aliases list")
+				errs = append(errs, newValidationError(field, err))
+				continue
+			}
+
+			owner := owners[alias]
+			if _, isService := services[alias]; isService {
+				owner = alias
+			}
+			if owner != "" && owner != name {
+				err := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("Service alias %q is already used by service %q", alias, owner))
+				err = err.WithContext("Service", name)
+				err = err.WithFixes("Service names and aliases must all be unique; rename or remove one of them")
+				errs = append(errs, newValidationError(field, err))
+				continue
+			}
+			owners[alias] = name
+		}
+	}
+	return errs
+}
+
+// overlappingServicePaths describes every pair of services where one path is
+// the same as or nested inside the other. Paths are compared the way service
+// detection compares them: cleaned, and only on whole path components.
+func overlappingServicePaths(services map[string]Service) []string {
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var warnings []string
+	for i, outer := range names {
+		outerPath := filepath.Clean(services[outer].Path)
+		for _, inner := range names[i+1:] {
+			innerPath := filepath.Clean(services[inner].Path)
+			switch {
+			case outerPath == innerPath:
+				warnings = append(warnings, fmt.Sprintf("services %q and %q share the path %s; service detection cannot tell them apart (use --service)", outer, inner, outerPath))
+			case isNestedPath(innerPath, outerPath):
+				warnings = append(warnings, fmt.Sprintf("service %q (%s) is nested inside service %q (%s); inside it, %q is detected", inner, innerPath, outer, outerPath, inner))
+			case isNestedPath(outerPath, innerPath):
+				warnings = append(warnings, fmt.Sprintf("service %q (%s) is nested inside service %q (%s); inside it, %q is detected", outer, outerPath, inner, innerPath, outer))
+			}
+		}
+	}
+	return warnings
+}
+
+// isNestedPath reports whether path is strictly inside base. The project root
+// (".") contains every other path.
+func isNestedPath(path, base string) bool {
+	if path == base {
+		return false
+	}
+	if base == "." {
+		return true
+	}
+	return strings.HasPrefix(path, base+string(filepath.Separator))
+}
+
+// validateIgnorePattern checks a service ignore pattern is a relative glob
+// that stays inside the service directory
+func validateIgnorePattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("pattern is empty")
+	}
+	if filepath.IsAbs(pattern) {
+		return fmt.Errorf("pattern must be relative to the service path")
+	}
+	if cleaned := filepath.Clean(pattern); cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("pattern must not leave the service directory")
+	}
+	if _, err := path.Match(filepath.ToSlash(pattern), ""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateServicePath checks that a service path is set, relative and points to a directory
+func validateServicePath(name string, service Service, projectRoot string) *dualerrors.Error {
 	if service.Path == "" {
 		err := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("Service '%s' missing required 'path' field", name))
 		err = err.WithContext("Service", name)
@@ -332,7 +777,10 @@ func validateService(name string, service Service, projectRoot string) error {
 			)
 			return dualErr
 		}
-		return fmt.Errorf("failed to check path: %w", err)
+		return dualerrors.New(dualerrors.ErrConfigInvalid, "Failed to check service path").
+			WithContext("Service", name).
+			WithContext("Resolved to", fullPath).
+			WithCause(err)
 	}
 
 	// Path should be a directory
@@ -350,51 +798,130 @@ func validateService(name string, service Service, projectRoot string) error {
 		return dualErr
 	}
 
-	// EnvFile is optional, but if provided, validate it's a relative path
-	// Note: We don't validate that the file or directory exists because:
-	// - Files may not exist yet (fresh worktrees, gitignored directories)
-	// - The env layer gracefully handles missing files by returning empty maps
-	// - Validation happens at runtime via 'dual doctor' or 'dual env check'
-	if service.EnvFile != "" {
-		if filepath.IsAbs(service.EnvFile) {
-			return fmt.Errorf("envFile must be relative to project root, got absolute path: %s", service.EnvFile)
-		}
-	}
-
 	return nil
 }
 
-// validateHooks checks that hook definitions are valid
-func validateHooks(hooks map[string][]string, projectRoot string) error {
-	validEvents := map[string]bool{
-		"postWorktreeCreate": true,
-		"preWorktreeDelete":  true,
-		"postWorktreeDelete": true,
+// validateHooks checks that hook definitions are valid and returns every problem
+// found. field is the config key holding them, e.g. hooks or services.api.hooks.
+func validateHooks(field string, hooks map[string][]string, projectRoot string) ValidationErrors {
+	// Iterate in sorted order so problems are reported deterministically
+	events := make([]string, 0, len(hooks))
+	for event := range hooks {
+		events = append(events, event)
 	}
+	sort.Strings(events)
 
-	for event, scripts := range hooks {
-		if !validEvents[event] {
-			return fmt.Errorf("invalid hook event: %s (valid events: postWorktreeCreate, preWorktreeDelete, postWorktreeDelete)", event)
+	var errs ValidationErrors
+	for _, event := range events {
+		if !validHookEvents[event] {
+			err := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("Invalid hook event: %s", event))
+			err = err.WithContext("Valid events", validHookEventNames)
+			err = err.WithFixes(
+				fmt.Sprintf("Rename '%s' to one of the valid hook events", event),
+				fmt.Sprintf("Or remove it from %s", field),
+			)
+			errs = append(errs, newValidationError(field+"."+event, err))
+			continue
 		}
 
-		for _, script := range scripts {
+		for _, script := range hooks[event] {
 			// Hook scripts are relative to .dual/hooks/ directory
 			hookPath := filepath.Join(projectRoot, ".dual", "hooks", script)
 
-			// Check if hook script exists (warning if missing, not error)
-			if _, err := os.Stat(hookPath); os.IsNotExist(err) {
-				fmt.Fprintf(os.Stderr, "[dual] Warning: hook script not found: %s\n", hookPath)
+			// Check if hook script exists and is executable (warnings, not errors)
+			info, err := os.Stat(hookPath)
+			if os.IsNotExist(err) {
+				logger.Warn("hook script not found: %s", hookPath)
+			} else if err == nil && !info.IsDir() && info.Mode()&0o111 == 0 {
+				logger.Warn("hook script is not executable: %s (run 'chmod +x %s' or 'dual doctor --fix')", hookPath, hookPath)
 			}
 		}
 	}
 
-	return nil
+	return errs
+}
+
+// validateHookWorkingDirs checks the per-event hook working directory settings
+// profileKeyPattern matches the variable names a profile may set, as
+// env.ValidateKey accepts them
+var profileKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateProfiles checks that every profile variable has a valid name
+func validateProfiles(profiles map[string]map[string]string) ValidationErrors {
+	var errs ValidationErrors
+	for _, name := range sortedKeys(profiles) {
+		for _, key := range sortedKeys(profiles[name]) {
+			if profileKeyPattern.MatchString(key) {
+				continue
+			}
+			err := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("Invalid variable name in profile %s: %q", name, key))
+			err = err.WithFixes("Use letters, digits and underscores, not starting with a digit")
+			errs = append(errs, newValidationError("profiles."+name, err))
+		}
+	}
+	return errs
+}
+
+func validateHookWorkingDirs(dirs map[string]string) ValidationErrors {
+	events := make([]string, 0, len(dirs))
+	for event := range dirs {
+		events = append(events, event)
+	}
+	sort.Strings(events)
+
+	var errs ValidationErrors
+	for _, event := range events {
+		field := "hookWorkingDir." + event
+		if !validHookEvents[event] {
+			err := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("Invalid hook event: %s", event))
+			err = err.WithContext("Valid events", validHookEventNames)
+			err = err.WithFixes(fmt.Sprintf("Rename '%s' to one of the valid hook events", event))
+			errs = append(errs, newValidationError(field, err))
+			continue
+		}
+
+		dir := dirs[event]
+		if dir != HookDirWorktree && dir != HookDirProject {
+			err := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("Invalid hook working directory: %s", dir))
+			err = err.WithContext("Valid values", HookDirWorktree+", "+HookDirProject)
+			err = err.WithFixes(fmt.Sprintf("Use '%s' to run in the worktree or '%s' to run in the project root", HookDirWorktree, HookDirProject))
+			errs = append(errs, newValidationError(field, err))
+			continue
+		}
+
+		if defaultHookWorkingDirs[event] == HookDirProject && dir == HookDirWorktree {
+			err := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("%s hooks cannot run in the worktree", event))
+			reason := "the worktree has already been removed when these hooks run"
+			if event != "postWorktreeDelete" {
+				reason = "context hooks may run for contexts whose worktree no longer exists"
+			}
+			err = err.WithContext("Reason", reason)
+			err = err.WithFixes(fmt.Sprintf("Use '%s' or remove the setting", HookDirProject))
+			errs = append(errs, newValidationError(field, err))
+		}
+	}
+
+	return errs
 }
 
 // SaveConfig writes a config to the specified path atomically
 func SaveConfig(config *Config, path string) error {
+	// Write glob service entries back in their original form
+	fileConfig := *config
+	fileConfig.Services = config.fileServices()
+
 	// Marshal to YAML
-	data, err := yaml.Marshal(config)
+	data, err := yaml.Marshal(&fileConfig)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -435,6 +962,25 @@ func LoadConfigFrom(path string) (*Config, error) {
 	return config, nil
 }
 
+// ReadConfigVersion returns the version field of the config file at path
+// without parsing or validating the rest, so it works for configs written for
+// newer versions of dual.
+func ReadConfigVersion(path string) (int, error) {
+	// #nosec G304 - path is from trusted source (config file search)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var header struct {
+		Version int `yaml:"version"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return header.Version, nil
+}
+
 // GetProjectIdentifier returns the normalized project identifier for the registry.
 // For worktrees, this returns the parent repository path so all worktrees share
 // the same project entry in the registry. For normal repos, returns the projectRoot.
@@ -458,6 +1004,22 @@ func GetProjectIdentifier(projectRoot string) (string, error) {
 	return normalizedRoot, nil
 }
 
+// ResolveServiceName returns the canonical service name for a service name or
+// one of its aliases, and false if neither matches
+func (c *Config) ResolveServiceName(name string) (string, bool) {
+	if _, exists := c.Services[name]; exists {
+		return name, true
+	}
+	for canonical, service := range c.Services {
+		for _, alias := range service.Aliases {
+			if alias == name {
+				return canonical, true
+			}
+		}
+	}
+	return "", false
+}
+
 // GetWorktreePath returns the absolute path to the worktrees directory
 func (c *Config) GetWorktreePath(projectRoot string) string {
 	if c.Worktrees.Path == "" {
@@ -477,6 +1039,72 @@ func (c *Config) GetWorktreeName(branchName string) string {
 	return strings.ReplaceAll(c.Worktrees.Naming, "{branch}", branchName)
 }
 
+// GetWriteContextFile reports whether 'dual create' writes a .dual-context
+// file into new worktrees, defaulting to true
+func (c *Config) GetWriteContextFile() bool {
+	return c.Worktrees.WriteContextFile == nil || *c.Worktrees.WriteContextFile
+}
+
+// ValidateHookFailurePolicy checks an onHookFailure value; empty means the default
+func ValidateHookFailurePolicy(policy string) *dualerrors.Error {
+	switch policy {
+	case "", HookFailureWarn, HookFailureAbort, HookFailureRollback:
+		return nil
+	}
+
+	err := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("Invalid hook failure policy: %s", policy))
+	err = err.WithContext("Valid values", strings.Join([]string{HookFailureWarn, HookFailureAbort, HookFailureRollback}, ", "))
+	err = err.WithFixes(
+		fmt.Sprintf("'%s' keeps the worktree and warns (default)", HookFailureWarn),
+		fmt.Sprintf("'%s' keeps the worktree and fails the command", HookFailureAbort),
+		fmt.Sprintf("'%s' removes the worktree and context and fails the command", HookFailureRollback),
+	)
+	return err
+}
+
+// GetHookFailurePolicy returns the configured onHookFailure policy, defaulting to HookFailureWarn
+func (c *Config) GetHookFailurePolicy() string {
+	if c.OnHookFailure == "" {
+		return HookFailureWarn
+	}
+	return c.OnHookFailure
+}
+
+// GetContextSource returns the configured context detection precedence,
+// defaulting to context.SourceBranch
+func (c *Config) GetContextSource() context.Source {
+	source, err := context.ParseSource(c.Context.Source)
+	if err != nil {
+		return context.SourceBranch
+	}
+	return source
+}
+
+// GetHookWorkingDir returns where hook scripts for an event run: HookDirWorktree
+// or HookDirProject. Unless configured, hooks run in the worktree, except
+// postWorktreeDelete and the context events which run in the project root.
+func (c *Config) GetHookWorkingDir(event string) string {
+	if dir, exists := c.HookWorkingDir[event]; exists && dir != "" {
+		return dir
+	}
+	if dir, exists := defaultHookWorkingDirs[event]; exists {
+		return dir
+	}
+	return HookDirWorktree
+}
+
+// GetProfile returns the variables of a profile, or an error listing the
+// defined profiles if there is none by that name
+func (c *Config) GetProfile(name string) (map[string]string, error) {
+	if profile, ok := c.Profiles[name]; ok {
+		return profile, nil
+	}
+	if len(c.Profiles) == 0 {
+		return nil, fmt.Errorf("profile %q not found: no profiles are defined\nHint: Add it under 'profiles' in %s", name, ConfigFileName)
+	}
+	return nil, fmt.Errorf("profile %q not found (available: %s)", name, strings.Join(sortedKeys(c.Profiles), ", "))
+}
+
 // GetHookScripts returns the list of hook scripts for a given event
 func (c *Config) GetHookScripts(event string) []string {
 	if scripts, exists := c.Hooks[event]; exists {
@@ -484,3 +1112,29 @@ func (c *Config) GetHookScripts(event string) []string {
 	}
 	return nil
 }
+
+// ServiceHook is a hook script configured under a service's hooks
+type ServiceHook struct {
+	Service string
+	Script  string
+}
+
+// GetServiceHookScripts returns the hook scripts services configure for a
+// given event, ordered by service name and then as listed
+func (c *Config) GetServiceHookScripts(event string) []ServiceHook {
+	names := make([]string, 0, len(c.Services))
+	for name, service := range c.Services {
+		if len(service.Hooks[event]) > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var hooks []ServiceHook
+	for _, name := range names {
+		for _, script := range c.Services[name].Hooks[event] {
+			hooks = append(hooks, ServiceHook{Service: name, Script: script})
+		}
+	}
+	return hooks
+}
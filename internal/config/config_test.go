@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -157,6 +159,150 @@ func TestValidateConfig(t *testing.T) {
 			wantErr: true,
 			errMsg:  "path does not exist",
 		},
+		{
+			name: "valid hook working dir",
+			config: &Config{
+				Version:        1,
+				HookWorkingDir: map[string]string{"postWorktreeCreate": HookDirProject},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid hook working dir value",
+			config: &Config{
+				Version:        1,
+				HookWorkingDir: map[string]string{"postWorktreeCreate": "home"},
+			},
+			wantErr: true,
+			errMsg:  "Invalid hook working directory: home",
+		},
+		{
+			name: "postWorktreeDelete cannot run in worktree",
+			config: &Config{
+				Version:        1,
+				HookWorkingDir: map[string]string{"postWorktreeDelete": HookDirWorktree},
+			},
+			wantErr: true,
+			errMsg:  "postWorktreeDelete hooks cannot run in the worktree",
+		},
+		{
+			name: "valid service aliases",
+			config: &Config{
+				Version: 1,
+				Services: map[string]Service{
+					"web": {Path: "apps/web", Aliases: []string{"frontend", "www"}},
+					"api": {Path: "apps/api", Aliases: []string{"backend"}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "alias clashes with service name",
+			config: &Config{
+				Version: 1,
+				Services: map[string]Service{
+					"web": {Path: "apps/web", Aliases: []string{"api"}},
+					"api": {Path: "apps/api"},
+				},
+			},
+			wantErr: true,
+			errMsg:  `Service alias "api" is already used by service "api"`,
+		},
+		{
+			name: "alias used by two services",
+			config: &Config{
+				Version: 1,
+				Services: map[string]Service{
+					"web": {Path: "apps/web", Aliases: []string{"app"}},
+					"api": {Path: "apps/api", Aliases: []string{"app"}},
+				},
+			},
+			wantErr: true,
+			errMsg:  `Service alias "app" is already used by service "api"`,
+		},
+		{
+			name: "context hooks are valid events",
+			config: &Config{
+				Version: 1,
+				Hooks: map[string][]string{
+					"preContextDelete":  {"a.sh"},
+					"postContextDelete": {"b.sh"},
+				},
+				HookWorkingDir: map[string]string{"postContextDelete": HookDirProject},
+			},
+			wantErr: false,
+		},
+		{
+			name: "preContextDelete cannot run in worktree",
+			config: &Config{
+				Version:        1,
+				HookWorkingDir: map[string]string{"preContextDelete": HookDirWorktree},
+			},
+			wantErr: true,
+			errMsg:  "preContextDelete hooks cannot run in the worktree",
+		},
+		{
+			name: "valid hook failure policy",
+			config: &Config{
+				Version:       1,
+				OnHookFailure: HookFailureRollback,
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid hook failure policy",
+			config: &Config{
+				Version:       1,
+				OnHookFailure: "ignore",
+			},
+			wantErr: true,
+			errMsg:  "Invalid hook failure policy: ignore",
+		},
+		{
+			name: "invalid service ignore pattern",
+			config: &Config{
+				Version: 1,
+				Services: map[string]Service{
+					"web": {Path: ".", Ignore: []string{"../outside"}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "Invalid service ignore pattern",
+		},
+		{
+			name: "valid context source",
+			config: &Config{
+				Version: 1,
+				Context: ContextConfig{Source: "fileFirst"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid context source",
+			config: &Config{
+				Version: 1,
+				Context: ContextConfig{Source: "git"},
+			},
+			wantErr: true,
+			errMsg:  "Invalid context source: git",
+		},
+		{
+			name: "valid env exclude patterns",
+			config: &Config{
+				Version: 1,
+				Env:     EnvConfig{Exclude: []string{"CI", "GITHUB_*"}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid env exclude pattern",
+			config: &Config{
+				Version: 1,
+				Env:     EnvConfig{Exclude: []string{"[unclosed"}},
+			},
+			wantErr: true,
+			errMsg:  "Invalid env exclude pattern",
+		},
 	}
 
 	for _, tt := range tests {
@@ -175,6 +321,149 @@ func TestValidateConfig(t *testing.T) {
 	}
 }
 
+func TestValidateConfig_AccumulatesErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cfg := &Config{
+		Version: 2,
+		Services: map[string]Service{
+			"api": {Path: "nonexistent"},
+			"web": {Path: "/absolute/path"},
+		},
+		Hooks: map[string][]string{
+			"invalidEvent": {"setup.sh"},
+		},
+	}
+
+	err := validateConfig(cfg, tmpDir)
+	if err == nil {
+		t.Fatal("validateConfig() expected error, got nil")
+	}
+
+	validationErrs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("validateConfig() error type = %T, want ValidationErrors", err)
+	}
+
+	if len(validationErrs) != 4 {
+		t.Fatalf("validateConfig() returned %d errors, want 4: %v", len(validationErrs), err)
+	}
+
+	expected := []string{
+		"version: Unsupported config version",
+		"services.api.path: Service path does not exist",
+		"services.web.path: Service path must be relative",
+		"hooks.invalidEvent: Invalid hook event",
+	}
+	for i, want := range expected {
+		if !contains(validationErrs[i].Error(), want) {
+			t.Errorf("error[%d] = %v, want error containing %q", i, validationErrs[i], want)
+		}
+	}
+
+	if !contains(err.Error(), "4 configuration problems found") {
+		t.Errorf("validateConfig() error = %v, want summary header", err)
+	}
+
+	// Each problem keeps its structured fixes
+	for _, validationErr := range validationErrs {
+		if len(validationErr.Fixes()) == 0 {
+			t.Errorf("error %q has no fixes", validationErr.Field)
+		}
+	}
+}
+
+func TestValidateService_ReportsPathAndEnvFileTogether(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	errs := validateService("web", Service{Path: "/absolute/path", EnvFile: "/absolute/.env"}, tmpDir)
+	if len(errs) != 2 {
+		t.Fatalf("validateService() returned %d errors, want 2: %v", len(errs), errs)
+	}
+
+	if errs[0].Field != "services.web.path" {
+		t.Errorf("errs[0].Field = %q, want %q", errs[0].Field, "services.web.path")
+	}
+	if errs[1].Field != "services.web.envFile" {
+		t.Errorf("errs[1].Field = %q, want %q", errs[1].Field, "services.web.envFile")
+	}
+}
+
+func TestServiceHooks(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, dir := range []string{"apps/api", "apps/web"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0o755); err != nil {
+			t.Fatalf("failed to create test directory: %v", err)
+		}
+	}
+
+	errs := validateService("api", Service{Path: "apps/api", Hooks: map[string][]string{"postCreate": {"migrate.sh"}}}, tmpDir)
+	if len(errs) != 1 || errs[0].Field != "services.api.hooks.postCreate" {
+		t.Fatalf("validateService() = %v, want one services.api.hooks.postCreate error", errs)
+	}
+
+	cfg := &Config{
+		Services: map[string]Service{
+			"web": {Path: "apps/web", Hooks: map[string][]string{"postWorktreeCreate": {"build.sh"}}},
+			"api": {Path: "apps/api", Hooks: map[string][]string{"postWorktreeCreate": {"migrate.sh", "seed.sh"}}},
+		},
+	}
+	want := []ServiceHook{
+		{Service: "api", Script: "migrate.sh"},
+		{Service: "api", Script: "seed.sh"},
+		{Service: "web", Script: "build.sh"},
+	}
+	if got := cfg.GetServiceHookScripts("postWorktreeCreate"); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetServiceHookScripts() = %v, want %v", got, want)
+	}
+	if got := cfg.GetServiceHookScripts("preWorktreeDelete"); got != nil {
+		t.Errorf("GetServiceHookScripts() = %v, want nil", got)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ConfigFileName)
+	content := `version: 1
+services:
+  api:
+    path: apps/api
+profiles:
+  debug:
+    LOG_LEVEL: debug
+    VERBOSE: "1"
+  perf:
+    PROFILE: "true"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := parseConfig(path)
+	if err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+
+	got, err := cfg.GetProfile("debug")
+	if err != nil {
+		t.Fatalf("GetProfile() error = %v", err)
+	}
+	if want := map[string]string{"LOG_LEVEL": "debug", "VERBOSE": "1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetProfile() = %v, want %v", got, want)
+	}
+
+	if _, err := cfg.GetProfile("trace"); err == nil || !strings.Contains(err.Error(), "available: debug, perf") {
+		t.Errorf("GetProfile() error = %v, want the available profiles", err)
+	}
+	if _, err := (&Config{}).GetProfile("debug"); err == nil || !strings.Contains(err.Error(), "no profiles are defined") {
+		t.Errorf("GetProfile() error = %v, want no profiles defined", err)
+	}
+
+	errs := validateProfiles(map[string]map[string]string{"debug": {"LOG_LEVEL": "debug", "1BAD": "x"}})
+	if len(errs) != 1 || errs[0].Field != "profiles.debug" {
+		t.Errorf("validateProfiles() = %v, want one profiles.debug error", errs)
+	}
+}
+
 func TestValidateService(t *testing.T) {
 	// Create test directory structure
 	tmpDir := t.TempDir()
@@ -254,6 +543,15 @@ func TestValidateService(t *testing.T) {
 			wantErr: true,
 			errMsg:  "envFile must be relative",
 		},
+		{
+			name: "absolute baseFile",
+			service: Service{
+				Path:     "valid",
+				BaseFile: "/absolute/.env.base",
+			},
+			wantErr: true,
+			errMsg:  "baseFile must be relative",
+		},
 		{
 			name: "envFile with non-existent directory (allowed)",
 			service: Service{
@@ -458,6 +756,39 @@ func TestLoadConfigFrom_NonExistentFile(t *testing.T) {
 	}
 }
 
+func TestLoadConfigFromRoot(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "apps", "web"), 0o755); err != nil {
+		t.Fatalf("failed to create test directory: %v", err)
+	}
+
+	// A subdirectory is not searched upwards from
+	_, _, err := LoadConfigFromRoot(filepath.Join(tmpDir, "apps"))
+	if err == nil {
+		t.Fatal("LoadConfigFromRoot() expected error when config is missing, got nil")
+	}
+
+	content := `version: 1
+services:
+  web:
+    path: apps/web
+`
+	if err := os.WriteFile(filepath.Join(tmpDir, ConfigFileName), []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, projectRoot, err := LoadConfigFromRoot(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadConfigFromRoot() unexpected error: %v", err)
+	}
+	if projectRoot != tmpDir {
+		t.Errorf("LoadConfigFromRoot() projectRoot = %q, want %q", projectRoot, tmpDir)
+	}
+	if _, exists := cfg.Services["web"]; !exists {
+		t.Error("LoadConfigFromRoot() config missing service 'web'")
+	}
+}
+
 func TestConfigConstants(t *testing.T) {
 	if ConfigFileName != "dual.config.yml" {
 		t.Errorf("ConfigFileName = %q, want %q", ConfigFileName, "dual.config.yml")
@@ -482,3 +813,91 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestOverlappingServicePaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		services map[string]Service
+		want     []string
+	}{
+		{
+			name: "disjoint paths",
+			services: map[string]Service{
+				"web": {Path: "apps/web"},
+				"api": {Path: "apps/api"},
+			},
+		},
+		{
+			name: "shared prefix without nesting",
+			services: map[string]Service{
+				"app":  {Path: "app"},
+				"app2": {Path: "application"},
+			},
+		},
+		{
+			name: "nested service",
+			services: map[string]Service{
+				"web":   {Path: "./apps/web"},
+				"admin": {Path: "apps/web/admin"},
+			},
+			want: []string{`service "admin" (apps/web/admin) is nested inside service "web" (apps/web); inside it, "admin" is detected`},
+		},
+		{
+			name: "same path",
+			services: map[string]Service{
+				"api":    {Path: "apps/api"},
+				"worker": {Path: "apps/api/"},
+			},
+			want: []string{`services "api" and "worker" share the path apps/api; service detection cannot tell them apart (use --service)`},
+		},
+		{
+			name: "root service contains everything",
+			services: map[string]Service{
+				"root": {Path: "."},
+				"web":  {Path: "apps/web"},
+			},
+			want: []string{`service "web" (apps/web) is nested inside service "root" (.); inside it, "web" is detected`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := overlappingServicePaths(tt.services)
+			if len(got) != len(tt.want) {
+				t.Fatalf("overlappingServicePaths() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("overlappingServicePaths()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResolveServiceName(t *testing.T) {
+	cfg := &Config{
+		Services: map[string]Service{
+			"web": {Path: "apps/web", Aliases: []string{"frontend"}},
+			"api": {Path: "apps/api"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		want   string
+		wantOK bool
+	}{
+		{name: "web", want: "web", wantOK: true},
+		{name: "frontend", want: "web", wantOK: true},
+		{name: "api", want: "api", wantOK: true},
+		{name: "backend", want: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := cfg.ResolveServiceName(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ResolveServiceName(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
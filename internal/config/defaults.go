@@ -0,0 +1,112 @@
+package config
+
+import (
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	dualerrors "github.com/lightfastai/dual/internal/errors"
+)
+
+// ServiceDefaults holds values applied to every service that does not set
+// them itself, so conventions shared by all services are written once
+type ServiceDefaults struct {
+	// EnvFile is the service env file, relative to each service's path
+	// (e.g. ".env.local" gives apps/web/.env.local for path apps/web)
+	EnvFile string `yaml:"envFile,omitempty"`
+
+	// BaseFile is the service base file, relative to the project root
+	BaseFile string `yaml:"baseFile,omitempty"`
+
+	// Ignore lists the service ignore patterns, relative to each service's path
+	Ignore []string `yaml:"ignore,omitempty"`
+}
+
+// applyServiceDefaults fills in each service's unset fields from
+// serviceDefaults. "{name}" in EnvFile and BaseFile is replaced with the
+// service name. It runs after glob expansion, so expanded services get
+// defaults too, while fields set by the glob entry or an explicit entry win.
+//
+// The services as they were before are remembered so SaveConfig does not
+// write the defaults into every service entry.
+func applyServiceDefaults(config *Config) {
+	defaults := config.ServiceDefaults
+	if defaults.EnvFile == "" && defaults.BaseFile == "" && len(defaults.Ignore) == 0 {
+		return
+	}
+
+	config.beforeDefaults = make(map[string]Service)
+	config.withDefaults = make(map[string]Service)
+
+	for name, svc := range config.Services {
+		merged := svc
+		if merged.EnvFile == "" && defaults.EnvFile != "" {
+			merged.EnvFile = path.Join(filepath.ToSlash(svc.Path), strings.ReplaceAll(defaults.EnvFile, "{name}", name))
+		}
+		if merged.BaseFile == "" && defaults.BaseFile != "" {
+			merged.BaseFile = strings.ReplaceAll(defaults.BaseFile, "{name}", name)
+		}
+		if len(merged.Ignore) == 0 && len(defaults.Ignore) > 0 {
+			merged.Ignore = defaults.Ignore
+		}
+
+		if reflect.DeepEqual(merged, svc) {
+			continue
+		}
+		config.beforeDefaults[name] = svc
+		config.withDefaults[name] = merged
+		config.Services[name] = merged
+	}
+}
+
+// withoutDefaults returns a copy of services in which services unchanged
+// since loading no longer have the fields filled in by applyServiceDefaults
+func (c *Config) withoutDefaults(services map[string]Service) map[string]Service {
+	result := make(map[string]Service, len(services))
+	for name, svc := range services {
+		if merged, ok := c.withDefaults[name]; ok && reflect.DeepEqual(svc, merged) {
+			svc = c.beforeDefaults[name]
+		}
+		result[name] = svc
+	}
+	return result
+}
+
+// validateServiceDefaults checks the serviceDefaults block. The merged services
+// are validated by validateService like any other.
+func validateServiceDefaults(defaults ServiceDefaults) ValidationErrors {
+	var errs ValidationErrors
+
+	if defaults.EnvFile != "" && filepath.IsAbs(defaults.EnvFile) {
+		err := dualerrors.New(dualerrors.ErrConfigInvalid, "Default envFile must be relative to each service's path")
+		err = err.WithContext("Absolute path", defaults.EnvFile)
+		err = err.WithFixes("Use a path relative to the service directory, e.g. envFile: .env.local")
+		errs = append(errs, newValidationError("serviceDefaults.envFile", err))
+	}
+
+	if defaults.BaseFile != "" && filepath.IsAbs(defaults.BaseFile) {
+		err := dualerrors.New(dualerrors.ErrConfigInvalid, "Default baseFile must be relative to project root")
+		err = err.WithContext("Absolute path", defaults.BaseFile)
+		err = err.WithFixes(
+			"Use a path relative to where dual.config.yml is located",
+			"  Example: baseFile: .env.{name}.base",
+		)
+		errs = append(errs, newValidationError("serviceDefaults.baseFile", err))
+	}
+
+	for _, pattern := range defaults.Ignore {
+		if err := validateIgnorePattern(pattern); err != nil {
+			dualErr := dualerrors.New(dualerrors.ErrConfigInvalid, "Invalid default ignore pattern")
+			dualErr = dualErr.WithContext("Pattern", pattern)
+			dualErr = dualErr.WithCause(err)
+			dualErr = dualErr.WithFixes(
+				"Use a glob relative to the service path, e.g. node_modules or packages/*/dist",
+				"Supported syntax: *, ?, [...]",
+			)
+			errs = append(errs, newValidationError("serviceDefaults.ignore", dualErr))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestApplyServiceDefaults(t *testing.T) {
+	tmpDir := setupGlobProject(t, "apps/web", "apps/api", "tools/cli")
+
+	cfg := &Config{
+		Version: 1,
+		ServiceDefaults: ServiceDefaults{
+			EnvFile:  ".env.local",
+			BaseFile: ".env.{name}.base",
+			Ignore:   []string{"node_modules"},
+		},
+		Services: map[string]Service{
+			"apps": {Path: "apps/*"},
+			"api":  {EnvFile: "config/api.env"},
+			"cli":  {Path: "./tools/cli", Ignore: []string{"dist"}},
+			"root": {Path: "."},
+		},
+	}
+
+	if err := validateConfig(cfg, tmpDir); err != nil {
+		t.Fatalf("validateConfig() error = %v", err)
+	}
+
+	want := map[string]Service{
+		"api":  {Path: "apps/api", EnvFile: "config/api.env", BaseFile: ".env.api.base", Ignore: []string{"node_modules"}},
+		"web":  {Path: "apps/web", EnvFile: "apps/web/.env.local", BaseFile: ".env.web.base", Ignore: []string{"node_modules"}},
+		"cli":  {Path: "./tools/cli", EnvFile: "tools/cli/.env.local", BaseFile: ".env.cli.base", Ignore: []string{"dist"}},
+		"root": {Path: ".", EnvFile: ".env.local", BaseFile: ".env.root.base", Ignore: []string{"node_modules"}},
+	}
+	if !reflect.DeepEqual(cfg.Services, want) {
+		t.Errorf("services = %v, want %v", cfg.Services, want)
+	}
+}
+
+func TestValidateConfig_ServiceDefaults(t *testing.T) {
+	tmpDir := setupGlobProject(t, "web")
+
+	cfg := &Config{
+		Version: 1,
+		ServiceDefaults: ServiceDefaults{
+			EnvFile:  "/etc/app.env",
+			BaseFile: "/etc/base.env",
+			Ignore:   []string{"[unclosed"},
+		},
+		Services: map[string]Service{"web": {Path: "web"}},
+	}
+
+	err := validateConfig(cfg, tmpDir)
+	if err == nil {
+		t.Fatal("validateConfig() expected errors for invalid defaults")
+	}
+	for _, field := range []string{"serviceDefaults.envFile", "serviceDefaults.baseFile", "serviceDefaults.ignore"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("validateConfig() error = %v, want %s error", err, field)
+		}
+	}
+}
+
+func TestSaveConfig_PreservesServiceDefaults(t *testing.T) {
+	tmpDir := setupGlobProject(t, "apps/web", "apps/api", "tools/cli")
+	configPath := filepath.Join(tmpDir, ConfigFileName)
+
+	content := `version: 1
+serviceDefaults:
+  envFile: .env.local
+services:
+  apps:
+    path: apps/*
+  api:
+    path: ""
+    envFile: config/api.env
+  docs:
+    path: .
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfigFrom(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfigFrom() error = %v", err)
+	}
+	if got := cfg.Services["web"].EnvFile; got != "apps/web/.env.local" {
+		t.Errorf("web envFile = %q, want apps/web/.env.local", got)
+	}
+
+	// Add an explicit service and save
+	cfg.Services["cli"] = Service{Path: "tools/cli"}
+	if err := SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	reloaded, err := parseConfig(configPath)
+	if err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+
+	// Defaults stay in the serviceDefaults block instead of every service
+	want := map[string]Service{
+		"apps": {Path: "apps/*"},
+		"api":  {EnvFile: "config/api.env"},
+		"docs": {Path: "."},
+		"cli":  {Path: "tools/cli"},
+	}
+	if !reflect.DeepEqual(reloaded.Services, want) {
+		t.Errorf("saved services = %v, want %v", reloaded.Services, want)
+	}
+	if reloaded.ServiceDefaults.EnvFile != ".env.local" {
+		t.Errorf("saved serviceDefaults = %+v, want envFile .env.local", reloaded.ServiceDefaults)
+	}
+}
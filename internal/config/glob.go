@@ -0,0 +1,210 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	dualerrors "github.com/lightfastai/dual/internal/errors"
+	"github.com/lightfastai/dual/internal/logger"
+)
+
+// IsGlobPath reports whether a service path contains glob metacharacters
+func IsGlobPath(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// expandServiceGlobs replaces service entries whose path is a glob pattern
+// (e.g. "apps/*") with one concrete service per matched directory, named after
+// the directory. Matches are expanded in sorted order so the resulting service
+// set is deterministic.
+//
+// A glob entry may list Exclude patterns, matched against both the directory
+// name and its path relative to the project root. An explicit service entry
+// with the same name as an expanded service overrides the expanded fields that
+// it sets, e.g. to give a single app a different envFile.
+//
+// The original glob entries are remembered so SaveConfig writes them back
+// instead of the expanded services.
+func expandServiceGlobs(config *Config, projectRoot string) ValidationErrors {
+	// Collect glob entries in sorted order
+	var globKeys []string
+	for name, svc := range config.Services {
+		if IsGlobPath(svc.Path) {
+			globKeys = append(globKeys, name)
+		}
+	}
+	if len(globKeys) == 0 {
+		return nil
+	}
+	sort.Strings(globKeys)
+
+	config.serviceGlobs = make(map[string]Service)
+	config.expandedFrom = make(map[string]string)
+	config.expanded = make(map[string]Service)
+	config.explicitOverrides = make(map[string]Service)
+
+	for _, key := range globKeys {
+		config.serviceGlobs[key] = config.Services[key]
+		delete(config.Services, key)
+	}
+
+	var errs ValidationErrors
+	for _, key := range globKeys {
+		glob := config.serviceGlobs[key]
+		field := fmt.Sprintf("services.%s.path", key)
+
+		if filepath.IsAbs(glob.Path) {
+			err := dualerrors.New(dualerrors.ErrConfigInvalid, "Service glob must be relative to project root")
+			err = err.WithContext("Service", key)
+			err = err.WithContext("Pattern", glob.Path)
+			err = err.WithFixes("Use a pattern relative to where dual.config.yml is located, e.g. apps/*")
+			errs = append(errs, newValidationError(field, err))
+			continue
+		}
+
+		if len(glob.Aliases) > 0 {
+			err := dualerrors.New(dualerrors.ErrConfigInvalid, "Service aliases are not supported when path is a glob pattern")
+			err = err.WithContext("Service", key)
+			err = err.WithContext("Pattern", glob.Path)
+			err = err.WithFixes("Add an explicit entry named after one expanded service and give it the aliases instead")
+			errs = append(errs, newValidationError(fmt.Sprintf("services.%s.aliases", key), err))
+		}
+
+		matches, err := filepath.Glob(filepath.Join(projectRoot, glob.Path))
+		if err != nil {
+			dualErr := dualerrors.New(dualerrors.ErrConfigInvalid, "Invalid service glob pattern")
+			dualErr = dualErr.WithContext("Service", key)
+			dualErr = dualErr.WithContext("Pattern", glob.Path)
+			dualErr = dualErr.WithCause(err)
+			dualErr = dualErr.WithFixes("Check the pattern syntax (supported: *, ?, [...])")
+			errs = append(errs, newValidationError(field, dualErr))
+			continue
+		}
+		sort.Strings(matches)
+
+		expandedCount := 0
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil || !info.IsDir() {
+				continue
+			}
+
+			relPath, err := filepath.Rel(projectRoot, match)
+			if err != nil {
+				continue
+			}
+			relPath = filepath.ToSlash(relPath)
+			name := filepath.Base(match)
+
+			if isExcluded(glob.Exclude, name, relPath) {
+				continue
+			}
+
+			if source, exists := config.expandedFrom[name]; exists {
+				dualErr := dualerrors.New(dualerrors.ErrConfigInvalid, fmt.Sprintf("Service glob produces duplicate service name %q", name))
+				dualErr = dualErr.WithContext("Pattern", glob.Path)
+				dualErr = dualErr.WithContext("Also produced by", config.serviceGlobs[source].Path)
+				dualErr = dualErr.WithFixes(
+					fmt.Sprintf("Exclude one of the matches, e.g. exclude: [%s]", relPath),
+					"Or declare the services explicitly instead of using a glob",
+				)
+				errs = append(errs, newValidationError(field, dualErr))
+				continue
+			}
+
+			expandedSvc := Service{
+				Path:     relPath,
+				EnvFile:  strings.ReplaceAll(glob.EnvFile, "{name}", name),
+				BaseFile: strings.ReplaceAll(glob.BaseFile, "{name}", name),
+				Ignore:   glob.Ignore,
+				Hooks:    glob.Hooks,
+			}
+
+			// Explicit entries with the same name override individual fields
+			if explicit, exists := config.Services[name]; exists {
+				config.explicitOverrides[name] = explicit
+				if explicit.Path != "" {
+					expandedSvc.Path = explicit.Path
+				}
+				if explicit.EnvFile != "" {
+					expandedSvc.EnvFile = explicit.EnvFile
+				}
+				if explicit.BaseFile != "" {
+					expandedSvc.BaseFile = explicit.BaseFile
+				}
+				if len(explicit.Ignore) > 0 {
+					expandedSvc.Ignore = explicit.Ignore
+				}
+				if len(explicit.Aliases) > 0 {
+					expandedSvc.Aliases = explicit.Aliases
+				}
+				if len(explicit.Hooks) > 0 {
+					expandedSvc.Hooks = explicit.Hooks
+				}
+			}
+
+			config.Services[name] = expandedSvc
+			config.expandedFrom[name] = key
+			config.expanded[name] = expandedSvc
+			expandedCount++
+		}
+
+		if expandedCount == 0 {
+			logger.Warn("service glob %q (%s) matched no directories", key, glob.Path)
+		}
+	}
+
+	return errs
+}
+
+// isExcluded reports whether an expanded service matches any exclude pattern
+func isExcluded(patterns []string, name, relPath string) bool {
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(filepath.Clean(pattern))
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, relPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ServiceGlobSource returns the name of the glob entry a service was expanded
+// from, or an empty string if the service is declared explicitly.
+func (c *Config) ServiceGlobSource(name string) string {
+	return c.expandedFrom[name]
+}
+
+// fileServices returns the services as they should be written to disk:
+// expanded services are collapsed back into their glob entries, while any
+// explicit overrides and services changed since loading are kept.
+func (c *Config) fileServices() map[string]Service {
+	// Defaults were applied after expansion, so take them out first
+	services := c.withoutDefaults(c.Services)
+	if len(c.serviceGlobs) == 0 {
+		return services
+	}
+
+	for name := range c.expandedFrom {
+		current, exists := services[name]
+		if !exists || !reflect.DeepEqual(current, c.expanded[name]) {
+			continue
+		}
+		if explicit, hasOverride := c.explicitOverrides[name]; hasOverride {
+			services[name] = explicit
+		} else {
+			delete(services, name)
+		}
+	}
+
+	for key, glob := range c.serviceGlobs {
+		services[key] = glob
+	}
+
+	return services
+}
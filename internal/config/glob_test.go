@@ -0,0 +1,150 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func setupGlobProject(t *testing.T, dirs ...string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(tmpDir, dir), 0o755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+	return tmpDir
+}
+
+func TestIsGlobPath(t *testing.T) {
+	tests := map[string]bool{
+		"apps/*":     true,
+		"apps/web?":  true,
+		"apps/[ab]*": true,
+		"apps/web":   false,
+		".":          false,
+	}
+	for path, want := range tests {
+		if got := IsGlobPath(path); got != want {
+			t.Errorf("IsGlobPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestExpandServiceGlobs(t *testing.T) {
+	tmpDir := setupGlobProject(t, "apps/web", "apps/api", "apps/legacy", "apps/worker")
+	// Files are not expanded into services
+	if err := os.WriteFile(filepath.Join(tmpDir, "apps", "README.md"), []byte("docs"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{
+		Version: 1,
+		Services: map[string]Service{
+			"apps": {Path: "apps/*", EnvFile: "apps/{name}/.env", Exclude: []string{"legacy"}},
+			"api":  {EnvFile: "config/api.env"},
+		},
+	}
+
+	if errs := expandServiceGlobs(cfg, tmpDir); len(errs) > 0 {
+		t.Fatalf("expandServiceGlobs() errors = %v", errs)
+	}
+
+	want := map[string]Service{
+		"api":    {Path: "apps/api", EnvFile: "config/api.env"},
+		"web":    {Path: "apps/web", EnvFile: "apps/web/.env"},
+		"worker": {Path: "apps/worker", EnvFile: "apps/worker/.env"},
+	}
+	if !reflect.DeepEqual(cfg.Services, want) {
+		t.Errorf("expanded services = %v, want %v", cfg.Services, want)
+	}
+
+	if got := cfg.ServiceGlobSource("web"); got != "apps" {
+		t.Errorf("ServiceGlobSource(web) = %q, want %q", got, "apps")
+	}
+	if got := cfg.ServiceGlobSource("missing"); got != "" {
+		t.Errorf("ServiceGlobSource(missing) = %q, want empty", got)
+	}
+}
+
+func TestExpandServiceGlobs_DuplicateNames(t *testing.T) {
+	tmpDir := setupGlobProject(t, "apps/web", "packages/web")
+
+	cfg := &Config{
+		Version: 1,
+		Services: map[string]Service{
+			"apps":     {Path: "apps/*"},
+			"packages": {Path: "packages/*"},
+		},
+	}
+
+	errs := expandServiceGlobs(cfg, tmpDir)
+	if len(errs) != 1 {
+		t.Fatalf("expandServiceGlobs() returned %d errors, want 1: %v", len(errs), errs)
+	}
+	if !strings.Contains(errs[0].Error(), "duplicate service name") {
+		t.Errorf("error = %v, want duplicate service name", errs[0])
+	}
+}
+
+func TestValidateConfig_ExcludeRequiresGlob(t *testing.T) {
+	tmpDir := setupGlobProject(t, "apps/web")
+
+	cfg := &Config{
+		Version: 1,
+		Services: map[string]Service{
+			"web": {Path: "apps/web", Exclude: []string{"legacy"}},
+		},
+	}
+
+	err := validateConfig(cfg, tmpDir)
+	if err == nil || !strings.Contains(err.Error(), "services.web.exclude") {
+		t.Errorf("validateConfig() error = %v, want exclude error", err)
+	}
+}
+
+func TestSaveConfig_PreservesGlobs(t *testing.T) {
+	tmpDir := setupGlobProject(t, "apps/web", "apps/api", "tools/cli")
+	configPath := filepath.Join(tmpDir, ConfigFileName)
+
+	content := `version: 1
+services:
+  apps:
+    path: apps/*
+    envFile: ""
+  api:
+    path: ""
+    envFile: config/api.env
+`
+	if err := os.WriteFile(configPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfigFrom(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfigFrom() error = %v", err)
+	}
+
+	// Add an explicit service and save
+	cfg.Services["cli"] = Service{Path: "tools/cli"}
+	if err := SaveConfig(cfg, configPath); err != nil {
+		t.Fatalf("SaveConfig() error = %v", err)
+	}
+
+	reloaded, err := parseConfig(configPath)
+	if err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+
+	want := map[string]Service{
+		"apps": {Path: "apps/*"},
+		"api":  {EnvFile: "config/api.env"},
+		"cli":  {Path: "tools/cli"},
+	}
+	if !reflect.DeepEqual(reloaded.Services, want) {
+		t.Errorf("saved services = %v, want %v", reloaded.Services, want)
+	}
+}
@@ -17,6 +17,35 @@ const (
 	DefaultContext = "default"
 )
 
+// Source selects which signal DetectContext prefers when both a git branch
+// and a .dual-context file are available
+type Source string
+
+const (
+	// SourceBranch prefers the git branch, then the .dual-context file (default)
+	SourceBranch Source = "branch"
+	// SourceFile uses only the .dual-context file and ignores the git branch
+	SourceFile Source = "file"
+	// SourceFileFirst prefers the .dual-context file, then the git branch
+	SourceFileFirst Source = "fileFirst"
+)
+
+// ValidSources lists the accepted Source values
+var ValidSources = []Source{SourceBranch, SourceFile, SourceFileFirst}
+
+// ParseSource converts a config value into a Source. Empty means SourceBranch.
+func ParseSource(value string) (Source, error) {
+	if value == "" {
+		return SourceBranch, nil
+	}
+	for _, source := range ValidSources {
+		if Source(value) == source {
+			return source, nil
+		}
+	}
+	return "", fmt.Errorf("invalid context source %q (valid: branch, file, fileFirst)", value)
+}
+
 // Detector is responsible for detecting the current development context
 type Detector struct {
 	// gitCommand allows for dependency injection in tests
@@ -25,6 +54,8 @@ type Detector struct {
 	readFile func(path string) ([]byte, error)
 	// getwd allows for dependency injection in tests
 	getwd func() (string, error)
+	// source controls the detection precedence
+	source Source
 }
 
 // NewDetector creates a new Detector with default implementations
@@ -33,35 +64,73 @@ func NewDetector() *Detector {
 		gitCommand: execGitCommand,
 		readFile:   os.ReadFile,
 		getwd:      os.Getwd,
+		source:     SourceBranch,
 	}
 }
 
-// DetectContext detects the current development context with priority:
+// NewDetectorWithSource creates a new Detector that uses the given precedence
+func NewDetectorWithSource(source Source) *Detector {
+	d := NewDetector()
+	if source != "" {
+		d.source = source
+	}
+	return d
+}
+
+// DetectContext detects the current development context. With the default
+// SourceBranch the priority is:
 // 1. Git branch name (if in a git repository)
 // 2. .dual-context file (walks up directory tree)
 // 3. "default" (fallback)
+// SourceFileFirst swaps 1 and 2; SourceFile skips the git branch entirely.
 func (d *Detector) DetectContext() (string, error) {
-	// Priority 1: Try git branch
+	context := d.detect()
+	logger.Success("Context: %s", context)
+	return context, nil
+}
+
+// detect returns the detected context without reporting it
+func (d *Detector) detect() string {
+	var detectors []func() (string, bool)
+	switch d.source {
+	case SourceFile:
+		detectors = append(detectors, d.contextFromFile)
+	case SourceFileFirst:
+		detectors = append(detectors, d.contextFromFile, d.contextFromBranch)
+	default:
+		detectors = append(detectors, d.contextFromBranch, d.contextFromFile)
+	}
+
+	for _, detect := range detectors {
+		if context, ok := detect(); ok {
+			return context
+		}
+	}
+
+	// Last resort: Return default
+	return DefaultContext
+}
+
+// contextFromBranch returns the current git branch, if any
+func (d *Detector) contextFromBranch() (string, bool) {
 	logger.Debug("Checking for git branch...")
 	if branch, err := d.detectGitBranch(); err == nil && branch != "" {
 		logger.Debug("Git branch: %s", branch)
-		logger.Success("Context: %s", branch)
-		return branch, nil
+		return branch, true
 	}
 	logger.Debug("Git branch: not found")
+	return "", false
+}
 
-	// Priority 2: Look for .dual-context file
+// contextFromFile returns the context named by the nearest .dual-context file, if any
+func (d *Detector) contextFromFile() (string, bool) {
 	logger.Debug("Checking for .dual-context file...")
 	if context, err := d.findDualContextFile(); err == nil && context != "" {
 		logger.Debug(".dual-context file: %s", context)
-		logger.Success("Context: %s", context)
-		return context, nil
+		return context, true
 	}
 	logger.Debug(".dual-context file: not found")
-
-	// Priority 3: Return default
-	logger.Success("Context: %s", DefaultContext)
-	return DefaultContext, nil
+	return "", false
 }
 
 // detectGitBranch attempts to detect the current git branch
@@ -115,12 +184,53 @@ func (d *Detector) findDualContextFile() (string, error) {
 	return "", fmt.Errorf("no .dual-context file found")
 }
 
+// FindDualContextFile returns the path of the .dual-context file that
+// detection from dir reads, the nearest one in dir or its parents
+func FindDualContextFile(dir string) (string, bool) {
+	currentDir := dir
+	for {
+		contextPath := filepath.Join(currentDir, DualContextFile)
+		if info, err := os.Stat(contextPath); err == nil && !info.IsDir() {
+			return contextPath, true
+		}
+
+		parent := filepath.Dir(currentDir)
+		if parent == currentDir {
+			return "", false
+		}
+		currentDir = parent
+	}
+}
+
 // DetectContext is a convenience function that creates a new detector and detects the context
 func DetectContext() (string, error) {
 	detector := NewDetector()
 	return detector.DetectContext()
 }
 
+// DetectContextWithSource is a convenience function that detects the context
+// using the given precedence (see Config.GetContextSource)
+func DetectContextWithSource(source Source) (string, error) {
+	return NewDetectorWithSource(source).DetectContext()
+}
+
+// DetectContextInDir returns the context that detection with the given
+// precedence finds when run from dir, without reporting it
+func DetectContextInDir(dir string, source Source) string {
+	d := NewDetectorWithSource(source)
+	d.getwd = func() (string, error) { return dir, nil }
+	d.gitCommand = func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		output, err := cmd.Output()
+		if err != nil {
+			return "", err
+		}
+		return string(output), nil
+	}
+	return d.detect()
+}
+
 // execGitCommand executes a git command and returns the output
 func execGitCommand(args ...string) (string, error) {
 	cmd := exec.Command("git", args...)
@@ -211,6 +211,65 @@ func TestDetectContext_Priority(t *testing.T) {
 	}
 }
 
+// TestDetectContext_Source tests that the configured source reorders detection
+func TestDetectContext_Source(t *testing.T) {
+	tests := []struct { //nolint:govet // Test struct optimization not critical
+		name     string
+		source   Source
+		branch   string
+		files    map[string]string
+		expected string
+	}{
+		{"branch prefers branch", SourceBranch, "git-branch\n", map[string]string{"/project/.dual-context": "file-context"}, "git-branch"},
+		{"empty source prefers branch", "", "git-branch\n", map[string]string{"/project/.dual-context": "file-context"}, "git-branch"},
+		{"fileFirst prefers file", SourceFileFirst, "git-branch\n", map[string]string{"/project/.dual-context": "file-context"}, "file-context"},
+		{"fileFirst falls back to branch", SourceFileFirst, "git-branch\n", map[string]string{}, "git-branch"},
+		{"file prefers file", SourceFile, "git-branch\n", map[string]string{"/project/.dual-context": "file-context"}, "file-context"},
+		{"file ignores branch", SourceFile, "git-branch\n", map[string]string{}, DefaultContext},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := &Detector{
+				gitCommand: mockGitCommand(tt.branch, nil),
+				readFile:   mockReadFile(tt.files),
+				getwd:      mockGetwd("/project/sub", nil),
+				source:     tt.source,
+			}
+
+			result, err := detector.DetectContext()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+// TestParseSource tests parsing of context source config values
+func TestParseSource(t *testing.T) {
+	for value, expected := range map[string]Source{
+		"":          SourceBranch,
+		"branch":    SourceBranch,
+		"file":      SourceFile,
+		"fileFirst": SourceFileFirst,
+	} {
+		source, err := ParseSource(value)
+		if err != nil {
+			t.Errorf("ParseSource(%q) unexpected error: %v", value, err)
+		}
+		if source != expected {
+			t.Errorf("ParseSource(%q) = %q, want %q", value, source, expected)
+		}
+	}
+
+	if _, err := ParseSource("filefirst"); err == nil {
+		t.Error("expected error for invalid source")
+	}
+}
+
 // TestDetectContext_DetachedHEAD tests behavior in detached HEAD state
 func TestDetectContext_DetachedHEAD(t *testing.T) {
 	detector := &Detector{
@@ -484,3 +543,43 @@ func TestDetectContext_Integration(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, result)
 	}
 }
+
+func TestFindDualContextFile(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "apps", "web")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if path, found := FindDualContextFile(sub); found {
+		t.Errorf("FindDualContextFile() = %s, want none", path)
+	}
+
+	want := filepath.Join(dir, DualContextFile)
+	if err := os.WriteFile(want, []byte("feature-x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if path, found := FindDualContextFile(sub); !found || path != want {
+		t.Errorf("FindDualContextFile() = %s, %v, want %s", path, found, want)
+	}
+}
+
+func TestDetectContextInDir(t *testing.T) {
+	// Not a git repository, so only the .dual-context file can be detected
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "apps", "web")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := DetectContextInDir(sub, SourceBranch); got != DefaultContext {
+		t.Errorf("DetectContextInDir() = %q, want %q", got, DefaultContext)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, DualContextFile), []byte("feature-x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := DetectContextInDir(sub, SourceFile); got != "feature-x" {
+		t.Errorf("DetectContextInDir() = %q, want feature-x", got)
+	}
+}
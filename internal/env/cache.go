@@ -0,0 +1,70 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileCache keeps parsed env files in memory for the duration of a single
+// command, so loading the layered environment of several services reads shared
+// files such as the base file once. Entries are keyed by absolute path and
+// revalidated against the file's modification time and size on every lookup.
+// A FileCache is not safe for concurrent use and should not outlive the
+// command that created it.
+type FileCache struct {
+	entries map[string]cachedEnvFile
+
+	// reads counts the files read from disk, for tests
+	reads int
+}
+
+// cachedEnvFile is a parsed env file and the file state it was parsed from
+type cachedEnvFile struct {
+	modTime time.Time
+	size    int64
+	env     map[string]string
+	order   []string
+}
+
+// NewFileCache creates an empty FileCache
+func NewFileCache() *FileCache {
+	return &FileCache{entries: make(map[string]cachedEnvFile)}
+}
+
+// cacheKey returns the absolute form of path, falling back to the cleaned path
+func cacheKey(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// get returns copies of the cached env and key order for path if the file has
+// not changed since it was cached
+func (c *FileCache) get(path string, info os.FileInfo) (map[string]string, []string, bool) {
+	entry, ok := c.entries[cacheKey(path)]
+	if !ok || !entry.modTime.Equal(info.ModTime()) || entry.size != info.Size() {
+		return nil, nil, false
+	}
+
+	env := make(map[string]string, len(entry.env))
+	for k, v := range entry.env {
+		env[k] = v
+	}
+	return env, append([]string(nil), entry.order...), true
+}
+
+// put caches the parsed contents of path as of info
+func (c *FileCache) put(path string, info os.FileInfo, env map[string]string, order []string) {
+	entry := cachedEnvFile{
+		modTime: info.ModTime(),
+		size:    info.Size(),
+		env:     make(map[string]string, len(env)),
+		order:   append([]string(nil), order...),
+	}
+	for k, v := range env {
+		entry.env[k] = v
+	}
+	c.entries[cacheKey(path)] = entry
+}
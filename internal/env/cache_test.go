@@ -0,0 +1,106 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lightfastai/dual/internal/config"
+)
+
+// TestLoadLayeredEnvWithCache tests that an N-service load reads the shared
+// base file once instead of once per service, with the same result
+func TestLoadLayeredEnvWithCache(t *testing.T) {
+	const serviceCount = 5
+
+	repo := t.TempDir()
+	cfg := &config.Config{
+		Env:      config.EnvConfig{BaseFile: ".env.base"},
+		Services: map[string]config.Service{},
+	}
+	if err := os.WriteFile(filepath.Join(repo, ".env.base"), []byte("SHARED=base\nAPI_URL=http://api\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < serviceCount; i++ {
+		name := fmt.Sprintf("svc%d", i)
+		dir := filepath.Join(repo, "apps", name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, ".env"), []byte(fmt.Sprintf("NAME=%s\nSHARED=%s\n", name, name)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg.Services[name] = config.Service{Path: filepath.Join("apps", name)}
+	}
+
+	cache := NewFileCache()
+	loadAll := func() {
+		t.Helper()
+		for name := range cfg.Services {
+			cached, err := LoadLayeredEnvWithCache(repo, cfg, name, "", nil, cache)
+			if err != nil {
+				t.Fatalf("LoadLayeredEnvWithCache(%s) failed: %v", name, err)
+			}
+			uncached, err := LoadLayeredEnv(repo, cfg, name, "", nil)
+			if err != nil {
+				t.Fatalf("LoadLayeredEnv(%s) failed: %v", name, err)
+			}
+			if !reflect.DeepEqual(cached, uncached) {
+				t.Errorf("%s: cached env %+v differs from uncached %+v", name, cached, uncached)
+			}
+		}
+	}
+
+	// The base file is read once rather than once per service
+	loadAll()
+	if cache.reads != serviceCount+1 {
+		t.Errorf("expected %d file reads (base file once, one per service), got %d", serviceCount+1, cache.reads)
+	}
+
+	// A second pass is served entirely from memory
+	loadAll()
+	if cache.reads != serviceCount+1 {
+		t.Errorf("expected no further reads, got %d total", cache.reads)
+	}
+
+	// Changing a file invalidates only that entry
+	if err := os.WriteFile(filepath.Join(repo, ".env.base"), []byte("SHARED=changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loadAll()
+	if cache.reads != serviceCount+2 {
+		t.Errorf("expected the changed base file to be re-read once, got %d total reads", cache.reads)
+	}
+}
+
+// TestCachedLoaderReturnsCopies tests that callers cannot modify cached entries
+func TestCachedLoaderReturnsCopies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("FOO=bar\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewCachedLoader(NewFileCache())
+	env, order, err := loader.LoadEnvFileOrdered(path)
+	if err != nil {
+		t.Fatalf("LoadEnvFileOrdered failed: %v", err)
+	}
+	env["FOO"] = "mutated"
+	order[0] = "MUTATED"
+
+	env, order, err = loader.LoadEnvFileOrdered(path)
+	if err != nil {
+		t.Fatalf("LoadEnvFileOrdered failed: %v", err)
+	}
+	if env["FOO"] != "bar" || !reflect.DeepEqual(order, []string{"FOO"}) {
+		t.Errorf("cached entry was modified: env=%v order=%v", env, order)
+	}
+
+	// Missing files are not an error, as with the uncached loader
+	env, _, err = loader.LoadEnvFileOrdered(filepath.Join(t.TempDir(), "missing.env"))
+	if err != nil || len(env) != 0 {
+		t.Errorf("expected an empty env for a missing file, got %v, %v", env, err)
+	}
+}
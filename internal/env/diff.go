@@ -0,0 +1,125 @@
+package env
+
+import (
+	"sort"
+)
+
+// EnvDiff describes how one environment differs from another
+type EnvDiff struct {
+	Changed map[string][2]string // Keys in both with different values: [from, to]
+	Added   map[string]string    // Keys only in the target environment
+	Removed map[string]string    // Keys only in the source environment
+}
+
+// IsEmpty reports whether the two environments are identical
+func (d EnvDiff) IsEmpty() bool {
+	return len(d.Changed) == 0 && len(d.Added) == 0 && len(d.Removed) == 0
+}
+
+// Diff compares the merged environment of e against other.
+// Added and Removed are relative to e, i.e. Added holds keys only other has.
+func (e *LayeredEnv) Diff(other *LayeredEnv) EnvDiff {
+	return DiffMaps(e.Merge(), other.Merge())
+}
+
+// DiffMaps compares two flat environment maps
+func DiffMaps(from, to map[string]string) EnvDiff {
+	diff := EnvDiff{
+		Changed: make(map[string][2]string),
+		Added:   make(map[string]string),
+		Removed: make(map[string]string),
+	}
+
+	// Find changed and removed
+	for k, v1 := range from {
+		if v2, exists := to[k]; exists {
+			if v1 != v2 {
+				diff.Changed[k] = [2]string{v1, v2}
+			}
+		} else {
+			diff.Removed[k] = v1
+		}
+	}
+
+	// Find added
+	for k, v2 := range to {
+		if _, exists := from[k]; !exists {
+			diff.Added[k] = v2
+		}
+	}
+
+	return diff
+}
+
+// ThreeWayStatus classifies how a variable changed on two sides of a common base
+type ThreeWayStatus string
+
+const (
+	// ThreeWayLeft means only the left side changed the variable
+	ThreeWayLeft ThreeWayStatus = "left"
+	// ThreeWayRight means only the right side changed the variable
+	ThreeWayRight ThreeWayStatus = "right"
+	// ThreeWaySame means both sides made the same change
+	ThreeWaySame ThreeWayStatus = "same"
+	// ThreeWayConflict means both sides changed the variable differently
+	ThreeWayConflict ThreeWayStatus = "conflict"
+)
+
+// ThreeWayEntry is one variable changed on at least one side of a three-way
+// diff. The In* fields report whether the variable is set in each environment.
+type ThreeWayEntry struct {
+	Key     string
+	Status  ThreeWayStatus
+	Base    string
+	Left    string
+	Right   string
+	InBase  bool
+	InLeft  bool
+	InRight bool
+}
+
+// ThreeWayDiff compares left and right against their common base by diffing
+// each side against base. Variables neither side changed are left out; the
+// entries are sorted by key.
+func ThreeWayDiff(base, left, right map[string]string) []ThreeWayEntry {
+	leftDiff := DiffMaps(base, left)
+	rightDiff := DiffMaps(base, right)
+
+	changed := make(map[string]bool)
+	for _, diff := range []EnvDiff{leftDiff, rightDiff} {
+		for k := range diff.Changed {
+			changed[k] = true
+		}
+		for k := range diff.Added {
+			changed[k] = true
+		}
+		for k := range diff.Removed {
+			changed[k] = true
+		}
+	}
+
+	entries := make([]ThreeWayEntry, 0, len(changed))
+	for key := range changed {
+		entry := ThreeWayEntry{Key: key}
+		entry.Base, entry.InBase = base[key]
+		entry.Left, entry.InLeft = left[key]
+		entry.Right, entry.InRight = right[key]
+
+		leftChanged := entry.InLeft != entry.InBase || entry.Left != entry.Base
+		rightChanged := entry.InRight != entry.InBase || entry.Right != entry.Base
+		switch {
+		case leftChanged && rightChanged && entry.InLeft == entry.InRight && entry.Left == entry.Right:
+			entry.Status = ThreeWaySame
+		case leftChanged && rightChanged:
+			entry.Status = ThreeWayConflict
+		case leftChanged:
+			entry.Status = ThreeWayLeft
+		default:
+			entry.Status = ThreeWayRight
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
@@ -0,0 +1,83 @@
+package env
+
+import (
+	"testing"
+)
+
+func TestLayeredEnv_Diff(t *testing.T) {
+	from := &LayeredEnv{
+		Base:      map[string]string{"A": "1", "B": "2", "C": "3"},
+		Overrides: map[string]string{"B": "override"},
+	}
+	to := &LayeredEnv{
+		Base:    map[string]string{"A": "1", "B": "2"},
+		Service: map[string]string{"D": "4"},
+	}
+
+	diff := from.Diff(to)
+
+	if len(diff.Changed) != 1 || diff.Changed["B"] != [2]string{"override", "2"} {
+		t.Errorf("Changed = %v, want B: [override 2]", diff.Changed)
+	}
+	if len(diff.Added) != 1 || diff.Added["D"] != "4" {
+		t.Errorf("Added = %v, want D=4", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed["C"] != "3" {
+		t.Errorf("Removed = %v, want C=3", diff.Removed)
+	}
+	if diff.IsEmpty() {
+		t.Error("IsEmpty() = true, want false")
+	}
+}
+
+func TestDiffMaps_Identical(t *testing.T) {
+	vars := map[string]string{"A": "1", "B": "2"}
+
+	diff := DiffMaps(vars, vars)
+	if !diff.IsEmpty() {
+		t.Errorf("DiffMaps() of identical maps = %+v, want empty", diff)
+	}
+
+	if !DiffMaps(nil, nil).IsEmpty() {
+		t.Error("DiffMaps(nil, nil) should be empty")
+	}
+}
+
+func TestThreeWayDiff(t *testing.T) {
+	base := map[string]string{"SAME": "1", "LEFT": "a", "RIGHT": "b", "BOTH": "x", "AGREE": "old", "GONE": "z"}
+	left := map[string]string{"SAME": "1", "LEFT": "a2", "RIGHT": "b", "BOTH": "x-left", "AGREE": "new", "GONE": "z", "NEW": "l"}
+	right := map[string]string{"SAME": "1", "LEFT": "a", "RIGHT": "b2", "BOTH": "x-right", "AGREE": "new", "NEW": "r"}
+
+	entries := ThreeWayDiff(base, left, right)
+
+	want := map[string]ThreeWayStatus{
+		"AGREE": ThreeWaySame,
+		"BOTH":  ThreeWayConflict,
+		"GONE":  ThreeWayRight,
+		"LEFT":  ThreeWayLeft,
+		"NEW":   ThreeWayConflict,
+		"RIGHT": ThreeWayRight,
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("expected %d entries, got %+v", len(want), entries)
+	}
+	for i, entry := range entries {
+		if i > 0 && entries[i-1].Key >= entry.Key {
+			t.Errorf("entries not sorted: %s before %s", entries[i-1].Key, entry.Key)
+		}
+		if entry.Status != want[entry.Key] {
+			t.Errorf("%s: status = %s, want %s", entry.Key, entry.Status, want[entry.Key])
+		}
+	}
+
+	// Removal is distinguished from an empty value
+	for _, entry := range entries {
+		if entry.Key == "GONE" && (entry.InRight || !entry.InLeft || !entry.InBase) {
+			t.Errorf("GONE presence = base %v, left %v, right %v", entry.InBase, entry.InLeft, entry.InRight)
+		}
+	}
+
+	if entries := ThreeWayDiff(base, base, base); len(entries) != 0 {
+		t.Errorf("expected no entries for identical environments, got %+v", entries)
+	}
+}
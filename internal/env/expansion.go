@@ -0,0 +1,252 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// referencePattern matches variable references the way godotenv expands them:
+// $VAR or ${VAR} with upper-case names, skipping \$VAR and $(...)
+var referencePattern = regexp.MustCompile(`(\\)?\$(\()?\{?([A-Z0-9_]+)?`)
+
+// rawAssignment is a KEY=value line before godotenv expands its value
+type rawAssignment struct {
+	key   string
+	value string
+	quote byte
+}
+
+// ExpansionCycles reports variables in a dotenv file whose expansions refer to
+// each other in a loop, e.g. A=${B} and B=${A}. godotenv expands references
+// in file order, so a cycle silently produces empty or partial values instead
+// of an error.
+//
+// Each cycle found is returned once, starting at its alphabetically first key. A
+// variable that references itself only counts when it has no earlier
+// definition, since PATH=$PATH:/bin after PATH=/usr/bin is a valid append.
+// A missing file has no cycles.
+func (l *Loader) ExpansionCycles(path string) ([][]string, error) {
+	data, err := l.readFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	// Build the reference graph from each key's final definition
+	refs := make(map[string][]string)
+	defined := make(map[string]bool)
+	for _, a := range parseRawAssignments(string(data)) {
+		var keyRefs []string
+		if a.quote != '\'' && !IsSecretRef(a.value) {
+			for _, ref := range variableReferences(a.value) {
+				if ref == a.key && defined[a.key] {
+					continue
+				}
+				keyRefs = append(keyRefs, ref)
+			}
+		}
+		refs[a.key] = keyRefs
+		defined[a.key] = true
+	}
+
+	return findCycles(refs), nil
+}
+
+// ExpansionCycles is a convenience function that creates a loader and checks
+// a file for circular variable references
+func ExpansionCycles(path string) ([][]string, error) {
+	loader := NewLoader()
+	return loader.ExpansionCycles(path)
+}
+
+// UndefinedReference is a variable reference that godotenv expands to an empty
+// string because the name is not defined before it in the same file
+type UndefinedReference struct {
+	Key  string // The variable whose value holds the reference
+	Name string // The referenced variable
+}
+
+// UndefinedReferences reports references in a dotenv file that expand to
+// nothing, e.g. API_URL=${BASE_URL}/api becoming "/api". godotenv only
+// expands names defined earlier in the same file, so a variable defined later,
+// in another env file or only as an override counts as undefined too.
+// Each reference is reported once per key, in file order. A missing file has
+// no references.
+func (l *Loader) UndefinedReferences(path string) ([]UndefinedReference, error) {
+	data, err := l.readFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	var undefined []UndefinedReference
+	seen := make(map[UndefinedReference]bool)
+	defined := make(map[string]bool)
+	for _, a := range parseRawAssignments(string(data)) {
+		if a.quote != '\'' && !IsSecretRef(a.value) {
+			for _, name := range variableReferences(a.value) {
+				ref := UndefinedReference{Key: a.key, Name: name}
+				if !defined[name] && !seen[ref] {
+					seen[ref] = true
+					undefined = append(undefined, ref)
+				}
+			}
+		}
+		defined[a.key] = true
+	}
+
+	return undefined, nil
+}
+
+// UndefinedReferences is a convenience function that creates a loader and
+// checks a file for references to undefined variables
+func UndefinedReferences(path string) ([]UndefinedReference, error) {
+	loader := NewLoader()
+	return loader.UndefinedReferences(path)
+}
+
+// FormatCycle renders a cycle as "A -> B -> A"
+func FormatCycle(cycle []string) string {
+	if len(cycle) == 0 {
+		return ""
+	}
+	return strings.Join(append(append([]string{}, cycle...), cycle[0]), " -> ")
+}
+
+// parseRawAssignments scans dotenv content for assignments without expanding
+// them. Quoted values may span lines; unquoted values lose inline comments.
+func parseRawAssignments(content string) []rawAssignment {
+	var assignments []rawAssignment
+	var current *rawAssignment
+
+	for _, line := range strings.Split(content, "\n") {
+		if current != nil {
+			// Inside a multiline value; look for the closing quote
+			if i := strings.IndexByte(line, current.quote); i >= 0 {
+				current.value += "\n" + line[:i]
+				assignments = append(assignments, *current)
+				current = nil
+			} else {
+				current.value += "\n" + line
+			}
+			continue
+		}
+
+		match := assignmentPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		a := rawAssignment{key: match[1], value: strings.TrimSpace(match[2])}
+
+		if a.value != "" && (a.value[0] == '"' || a.value[0] == '\'') {
+			a.quote = a.value[0]
+			rest := a.value[1:]
+			if i := strings.IndexByte(rest, a.quote); i >= 0 {
+				a.value = rest[:i]
+			} else {
+				a.value = rest
+				current = &a
+				continue
+			}
+		} else if i := strings.Index(a.value, " #"); i >= 0 {
+			a.value = strings.TrimSpace(a.value[:i])
+		}
+
+		assignments = append(assignments, a)
+	}
+
+	// An unterminated quote runs to the end of the file
+	if current != nil {
+		assignments = append(assignments, *current)
+	}
+
+	return assignments
+}
+
+// variableReferences returns the names a value references, in order
+func variableReferences(value string) []string {
+	var names []string
+	for _, m := range referencePattern.FindAllStringSubmatch(value, -1) {
+		if m[1] == `\` || m[2] == "(" || m[3] == "" {
+			continue
+		}
+		names = append(names, m[3])
+	}
+	return names
+}
+
+// findCycles returns the cycles in a reference graph. Edges to keys that are
+// not in the graph (undefined variables) are ignored.
+func findCycles(refs map[string][]string) [][]string {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+	state := make(map[string]int, len(refs))
+	seen := make(map[string]bool)
+	var cycles [][]string
+	var stack []string
+
+	var visit func(key string)
+	visit = func(key string) {
+		state[key] = inProgress
+		stack = append(stack, key)
+		for _, ref := range refs[key] {
+			if _, ok := refs[ref]; !ok {
+				continue
+			}
+			switch state[ref] {
+			case unvisited:
+				visit(ref)
+			case inProgress:
+				// The stack from ref onwards is a cycle
+				start := len(stack) - 1
+				for stack[start] != ref {
+					start--
+				}
+				cycle := normalizeCycle(stack[start:])
+				if id := strings.Join(cycle, "\x00"); !seen[id] {
+					seen[id] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[key] = done
+	}
+
+	keys := make([]string, 0, len(refs))
+	for key := range refs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if state[key] == unvisited {
+			visit(key)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return strings.Join(cycles[i], " ") < strings.Join(cycles[j], " ")
+	})
+	return cycles
+}
+
+// normalizeCycle rotates a cycle to start at its alphabetically first key
+func normalizeCycle(cycle []string) []string {
+	first := 0
+	for i, key := range cycle {
+		if key < cycle[first] {
+			first = i
+		}
+	}
+	return append(append([]string{}, cycle[first:]...), cycle[:first]...)
+}
@@ -0,0 +1,143 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpansionCycles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    [][]string
+	}{
+		{
+			name:    "two-variable cycle",
+			content: "A=${B}\nB=${A}\n",
+			want:    [][]string{{"A", "B"}},
+		},
+		{
+			name:    "three-variable cycle through bare references",
+			content: "X=$Z/x\nY=\"${X}-y\"\nZ=$Y\n",
+			want:    [][]string{{"X", "Z", "Y"}},
+		},
+		{
+			name:    "self reference without earlier definition",
+			content: "LOOP=${LOOP}\n",
+			want:    [][]string{{"LOOP"}},
+		},
+		{
+			name:    "append to earlier definition",
+			content: "PATH=/usr/bin\nPATH=$PATH:/bin\n",
+			want:    nil,
+		},
+		{
+			name:    "chain without cycle",
+			content: "BASE=http://localhost\nAPI=${BASE}/api\nWS=$API/ws\n",
+			want:    nil,
+		},
+		{
+			name:    "single quotes, escapes and comments are not expanded",
+			content: "A='${B}'\nB=\\$A\nC=plain # see $C\n",
+			want:    nil,
+		},
+		{
+			name:    "multiline value",
+			content: "A=\"first\n${B}\"\nB=$A\n",
+			want:    [][]string{{"A", "B"}},
+		},
+		{
+			name:    "undefined references are ignored",
+			content: "A=${MISSING}\n",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write env file: %v", err)
+			}
+
+			got, err := ExpansionCycles(path)
+			if err != nil {
+				t.Fatalf("ExpansionCycles() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpansionCycles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpansionCycles_MissingFile(t *testing.T) {
+	got, err := ExpansionCycles(filepath.Join(t.TempDir(), "missing.env"))
+	if err != nil || got != nil {
+		t.Errorf("ExpansionCycles() = %v, %v, want nil, nil", got, err)
+	}
+}
+
+func TestUndefinedReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []UndefinedReference
+	}{
+		{
+			name:    "reference to unset variable",
+			content: "API_URL=${BASE_URL}/api\nWS_URL=$BASE_URL/ws\n",
+			want:    []UndefinedReference{{"API_URL", "BASE_URL"}, {"WS_URL", "BASE_URL"}},
+		},
+		{
+			name:    "defined later in the file",
+			content: "API_URL=${BASE_URL}/api\nBASE_URL=http://localhost\n",
+			want:    []UndefinedReference{{"API_URL", "BASE_URL"}},
+		},
+		{
+			name:    "defined earlier in the file",
+			content: "BASE_URL=http://localhost\nAPI_URL=\"${BASE_URL}/api\"\nPATH=/bin\nPATH=$PATH:/usr/bin\n",
+			want:    nil,
+		},
+		{
+			name:    "self reference without earlier definition",
+			content: "PATH=$PATH:/usr/bin\n",
+			want:    []UndefinedReference{{"PATH", "PATH"}},
+		},
+		{
+			name:    "single quotes, escapes, comments and secret references are not expanded",
+			content: "A='${B}'\nC=\\$D\nE=plain # see $F\nG=${vault:secret/app#password}\n",
+			want:    nil,
+		},
+		{
+			name:    "repeated reference in one value",
+			content: "URL=$HOST:$PORT/$HOST\n",
+			want:    []UndefinedReference{{"URL", "HOST"}, {"URL", "PORT"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("failed to write env file: %v", err)
+			}
+
+			got, err := UndefinedReferences(path)
+			if err != nil {
+				t.Fatalf("UndefinedReferences() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UndefinedReferences() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatCycle(t *testing.T) {
+	if got := FormatCycle([]string{"A", "B"}); got != "A -> B -> A" {
+		t.Errorf("FormatCycle() = %q, want %q", got, "A -> B -> A")
+	}
+}
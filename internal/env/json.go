@@ -0,0 +1,88 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ValidateJSON checks that value is well-formed JSON
+func ValidateJSON(value string) error {
+	var v any
+	if err := json.Unmarshal([]byte(value), &v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return nil
+}
+
+// FlattenJSON turns a JSON object into environment variables. Nested object
+// keys are joined with separator ({"db": {"host": "x"}} becomes db__host=x
+// with "__"). Strings are stored as-is; numbers, booleans and arrays keep
+// their compact JSON text, and null becomes an empty value. Every resulting
+// name must be a valid environment variable name. The keys are returned in
+// alphabetical order.
+func FlattenJSON(data []byte, separator string) (map[string]string, []string, error) {
+	var root map[string]json.RawMessage
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, nil, fmt.Errorf("expected a JSON object: %w", err)
+	}
+	if root == nil {
+		return nil, nil, fmt.Errorf("expected a JSON object, got null")
+	}
+
+	vars := make(map[string]string)
+	if err := flattenJSONObject(root, "", separator, vars); err != nil {
+		return nil, nil, err
+	}
+	return vars, sortedKeys(vars), nil
+}
+
+// flattenJSONObject adds the leaves of obj to vars, prefixing keys with prefix
+func flattenJSONObject(obj map[string]json.RawMessage, prefix, separator string, vars map[string]string) error {
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := name
+		if prefix != "" {
+			key = prefix + separator + name
+		}
+		raw := bytes.TrimSpace(obj[name])
+
+		switch {
+		case len(raw) > 0 && raw[0] == '{':
+			var nested map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &nested); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			if err := flattenJSONObject(nested, key, separator, vars); err != nil {
+				return err
+			}
+			continue
+		case len(raw) > 0 && raw[0] == '"':
+			var s string
+			if err := json.Unmarshal(raw, &s); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			vars[key] = s
+		case string(raw) == "null":
+			vars[key] = ""
+		default:
+			var compact bytes.Buffer
+			if err := json.Compact(&compact, raw); err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			vars[key] = compact.String()
+		}
+
+		if err := ValidateKey(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
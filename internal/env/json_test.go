@@ -0,0 +1,85 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateJSON(t *testing.T) {
+	valid := []string{`{"a": 1}`, `[1, 2]`, `"text"`, `42`, `true`, `null`}
+	for _, value := range valid {
+		if err := ValidateJSON(value); err != nil {
+			t.Errorf("ValidateJSON(%q) unexpected error: %v", value, err)
+		}
+	}
+
+	invalid := []string{``, `{a: 1}`, `[1, 2`, `{"a": 1} extra`, `'single'`}
+	for _, value := range invalid {
+		if err := ValidateJSON(value); err == nil {
+			t.Errorf("ValidateJSON(%q) expected error, got nil", value)
+		}
+	}
+}
+
+func TestFlattenJSON(t *testing.T) {
+	data := []byte(`{
+  "PORT": 3000,
+  "DEBUG": true,
+  "NAME": "api",
+  "EMPTY": null,
+  "HOSTS": ["a", "b"],
+  "DB": {"HOST": "localhost", "POOL": {"MAX": 10}},
+  "BIG": 12345678901234567890
+}`)
+
+	vars, keys, err := FlattenJSON(data, "__")
+	if err != nil {
+		t.Fatalf("FlattenJSON() error = %v", err)
+	}
+
+	want := map[string]string{
+		"PORT":          "3000",
+		"DEBUG":         "true",
+		"NAME":          "api",
+		"EMPTY":         "",
+		"HOSTS":         `["a","b"]`,
+		"DB__HOST":      "localhost",
+		"DB__POOL__MAX": "10",
+		"BIG":           "12345678901234567890",
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("FlattenJSON() = %v, want %v", vars, want)
+	}
+
+	wantKeys := []string{"BIG", "DB__HOST", "DB__POOL__MAX", "DEBUG", "EMPTY", "HOSTS", "NAME", "PORT"}
+	if !reflect.DeepEqual(keys, wantKeys) {
+		t.Errorf("FlattenJSON() keys = %v, want %v", keys, wantKeys)
+	}
+
+	// The separator is configurable
+	vars, _, err = FlattenJSON([]byte(`{"DB": {"HOST": "x"}}`), "_")
+	if err != nil || vars["DB_HOST"] != "x" {
+		t.Errorf("FlattenJSON() with '_' = %v, %v", vars, err)
+	}
+}
+
+func TestFlattenJSON_Errors(t *testing.T) {
+	inputs := map[string]string{
+		"not an object":     `[1, 2]`,
+		"null":              `null`,
+		"malformed":         `{"A": }`,
+		"invalid name":      `{"MY-KEY": 1}`,
+		"invalid nested":    `{"DB": {"bad key": 1}}`,
+		"separator in name": `{"A": {"B": 1}}`,
+	}
+
+	for name, input := range inputs {
+		separator := "__"
+		if name == "separator in name" {
+			separator = "."
+		}
+		if _, _, err := FlattenJSON([]byte(input), separator); err == nil {
+			t.Errorf("%s: expected error for %s", name, input)
+		}
+	}
+}
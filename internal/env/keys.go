@@ -0,0 +1,87 @@
+package env
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// validKeyPattern matches portable environment variable names
+var validKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateKey checks that key is a valid environment variable name
+// (letters, digits and underscores, not starting with a digit)
+func ValidateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("environment variable name cannot be empty")
+	}
+	if !validKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid environment variable name %q: use letters, digits and underscores, not starting with a digit", key)
+	}
+	return nil
+}
+
+// ParseAssignment parses a KEY=VALUE string, validating the key.
+// The value may be empty and may itself contain '='.
+func ParseAssignment(assignment string) (string, string, error) {
+	key, value, found := strings.Cut(assignment, "=")
+	if !found {
+		return "", "", fmt.Errorf("invalid assignment %q: expected KEY=VALUE", assignment)
+	}
+	if err := ValidateKey(key); err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+// KeyFilter selects variables by name prefix or glob pattern.
+// A key is kept if it matches any prefix or any pattern; an empty filter keeps everything.
+type KeyFilter struct {
+	Prefixes []string
+	Patterns []string
+}
+
+// NewKeyFilter creates a KeyFilter, rejecting malformed glob patterns
+func NewKeyFilter(prefixes, patterns []string) (*KeyFilter, error) {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return &KeyFilter{Prefixes: prefixes, Patterns: patterns}, nil
+}
+
+// IsEmpty reports whether the filter keeps every key
+func (f *KeyFilter) IsEmpty() bool {
+	return len(f.Prefixes) == 0 && len(f.Patterns) == 0
+}
+
+// Match reports whether key passes the filter
+func (f *KeyFilter) Match(key string) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	for _, prefix := range f.Prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	for _, pattern := range f.Patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns a copy of vars containing only the keys that pass the filter
+func (f *KeyFilter) Apply(vars map[string]string) map[string]string {
+	result := make(map[string]string, len(vars))
+	for key, value := range vars {
+		if f.Match(key) {
+			result[key] = value
+		}
+	}
+	return result
+}
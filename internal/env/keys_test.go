@@ -0,0 +1,100 @@
+package env
+
+import (
+	"testing"
+)
+
+func TestValidateKey(t *testing.T) {
+	valid := []string{"PORT", "_PRIVATE", "db_url", "API_KEY_2"}
+	for _, key := range valid {
+		if err := ValidateKey(key); err != nil {
+			t.Errorf("ValidateKey(%q) unexpected error: %v", key, err)
+		}
+	}
+
+	invalid := []string{"", "2FA", "MY-KEY", "KEY=VALUE", "WITH SPACE"}
+	for _, key := range invalid {
+		if err := ValidateKey(key); err == nil {
+			t.Errorf("ValidateKey(%q) expected error, got nil", key)
+		}
+	}
+}
+
+func TestParseAssignment(t *testing.T) {
+	tests := []struct {
+		input     string
+		wantKey   string
+		wantValue string
+		wantErr   bool
+	}{
+		{input: "PORT=3000", wantKey: "PORT", wantValue: "3000"},
+		{input: "URL=postgres://host/db?a=b", wantKey: "URL", wantValue: "postgres://host/db?a=b"},
+		{input: "EMPTY=", wantKey: "EMPTY", wantValue: ""},
+		{input: "NO_EQUALS", wantErr: true},
+		{input: "=value", wantErr: true},
+		{input: "BAD-KEY=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		key, value, err := ParseAssignment(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAssignment(%q) expected error, got nil", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseAssignment(%q) unexpected error: %v", tt.input, err)
+			continue
+		}
+		if key != tt.wantKey || value != tt.wantValue {
+			t.Errorf("ParseAssignment(%q) = %q, %q; want %q, %q", tt.input, key, value, tt.wantKey, tt.wantValue)
+		}
+	}
+}
+
+func TestKeyFilter(t *testing.T) {
+	vars := map[string]string{
+		"NEXT_PUBLIC_API_URL": "https://api",
+		"NEXT_PUBLIC_SITE":    "site",
+		"VITE_KEY":            "vite",
+		"DATABASE_URL":        "postgres://",
+		"API_SECRET":          "secret",
+	}
+
+	tests := []struct {
+		name     string
+		prefixes []string
+		patterns []string
+		want     []string
+	}{
+		{name: "empty keeps everything", want: []string{"API_SECRET", "DATABASE_URL", "NEXT_PUBLIC_API_URL", "NEXT_PUBLIC_SITE", "VITE_KEY"}},
+		{name: "single prefix", prefixes: []string{"NEXT_PUBLIC_"}, want: []string{"NEXT_PUBLIC_API_URL", "NEXT_PUBLIC_SITE"}},
+		{name: "multiple prefixes", prefixes: []string{"NEXT_PUBLIC_", "VITE_"}, want: []string{"NEXT_PUBLIC_API_URL", "NEXT_PUBLIC_SITE", "VITE_KEY"}},
+		{name: "glob", patterns: []string{"*_URL"}, want: []string{"DATABASE_URL", "NEXT_PUBLIC_API_URL"}},
+		{name: "prefix or glob", prefixes: []string{"VITE_"}, patterns: []string{"DATABASE_*"}, want: []string{"DATABASE_URL", "VITE_KEY"}},
+		{name: "no match", prefixes: []string{"NOPE_"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := NewKeyFilter(tt.prefixes, tt.patterns)
+			if err != nil {
+				t.Fatalf("NewKeyFilter() unexpected error: %v", err)
+			}
+			got := sortedKeys(filter.Apply(vars))
+			if len(got) != len(tt.want) {
+				t.Fatalf("Apply() keys = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Apply() keys = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if _, err := NewKeyFilter(nil, []string{"[A-"}); err == nil {
+		t.Error("NewKeyFilter() expected error for malformed pattern")
+	}
+}
@@ -3,16 +3,23 @@ package env
 import (
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// assignmentPattern matches the start of a KEY=value line, with optional export prefix
+var assignmentPattern = regexp.MustCompile(`^\s*(?:export\s+)?([A-Za-z_][A-Za-z0-9_.]*)\s*[=:]\s*(.*)$`)
+
 // Loader handles loading environment variables from files
 type Loader struct {
 	// readFile allows for dependency injection in tests
 	readFile func(path string) ([]byte, error)
 	// stat allows for dependency injection in tests
 	stat func(path string) (os.FileInfo, error)
+	// cache, when set, serves repeated LoadEnvFileOrdered calls from memory
+	cache *FileCache
 }
 
 // NewLoader creates a new Loader with default implementations
@@ -23,6 +30,14 @@ func NewLoader() *Loader {
 	}
 }
 
+// NewCachedLoader creates a Loader whose LoadEnvFileOrdered reuses files
+// already parsed into cache. A nil cache behaves like NewLoader.
+func NewCachedLoader(cache *FileCache) *Loader {
+	loader := NewLoader()
+	loader.cache = cache
+	return loader
+}
+
 // LoadEnvFile loads environment variables from a file into a map
 // Returns an empty map if the file doesn't exist (non-fatal)
 // Returns an error only for read failures or parse errors
@@ -57,8 +72,120 @@ func (l *Loader) LoadEnvFile(path string) (map[string]string, error) {
 	return env, nil
 }
 
+// LoadEnvFileOrdered loads environment variables like LoadEnvFile and also
+// returns the keys in the order they first appear in the file.
+// Maps do not preserve order, so this is for consumers that care about it
+// (e.g. exports where later values reference earlier ones).
+func (l *Loader) LoadEnvFileOrdered(path string) (map[string]string, []string, error) {
+	if l.cache != nil {
+		return l.loadEnvFileCached(path)
+	}
+
+	env, err := l.LoadEnvFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(env) == 0 {
+		return env, nil, nil
+	}
+
+	data, err := l.readFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	return env, orderedKeys(string(data), env), nil
+}
+
+// loadEnvFileCached is LoadEnvFileOrdered backed by the loader's cache. The
+// file is read once and parsed from memory rather than read again for
+// ordering; godotenv.Read parses the same bytes the same way.
+func (l *Loader) loadEnvFileCached(path string) (map[string]string, []string, error) {
+	info, err := l.stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(map[string]string), nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to stat env file: %w", err)
+	}
+
+	if env, order, ok := l.cache.get(path, info); ok {
+		return env, order, nil
+	}
+
+	data, err := l.readFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	l.cache.reads++
+
+	env, err := godotenv.UnmarshalBytes(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse env file: %w", err)
+	}
+
+	var order []string
+	if len(env) > 0 {
+		order = orderedKeys(string(data), env)
+	}
+
+	l.cache.put(path, info, env, order)
+	return env, order, nil
+}
+
+// orderedKeys scans dotenv content for assignments and returns parsed keys in
+// first-appearance order. Lines inside multiline quoted values are skipped.
+func orderedKeys(content string, env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	seen := make(map[string]bool, len(env))
+	openQuote := byte(0)
+
+	for _, line := range strings.Split(content, "\n") {
+		if openQuote != 0 {
+			// Inside a multiline value; look for the closing quote
+			if strings.IndexByte(line, openQuote) >= 0 {
+				openQuote = 0
+			}
+			continue
+		}
+
+		match := assignmentPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		key, value := match[1], match[2]
+
+		if value != "" && (value[0] == '"' || value[0] == '\'') && strings.IndexByte(value[1:], value[0]) < 0 {
+			openQuote = value[0]
+		}
+
+		if _, ok := env[key]; ok && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	// Anything the scanner missed keeps a stable position at the end
+	if len(keys) < len(env) {
+		for _, key := range sortedKeys(env) {
+			if !seen[key] {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return keys
+}
+
 // LoadEnvFile is a convenience function that creates a loader and loads a file
 func LoadEnvFile(path string) (map[string]string, error) {
 	loader := NewLoader()
 	return loader.LoadEnvFile(path)
 }
+
+// LoadEnvFileOrdered is a convenience function that creates a loader and loads
+// a file along with its keys in declaration order
+func LoadEnvFileOrdered(path string) (map[string]string, []string, error) {
+	loader := NewLoader()
+	return loader.LoadEnvFileOrdered(path)
+}
@@ -3,6 +3,7 @@ package env
 import (
 	"errors"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -202,6 +203,75 @@ func TestLoadEnvFile_ReadError(t *testing.T) {
 	}
 }
 
+func TestLoadEnvFileOrdered(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "file order is preserved",
+			content:  "ZED=1\nALPHA=2\nMIDDLE=3\n",
+			expected: []string{"ZED", "ALPHA", "MIDDLE"},
+		},
+		{
+			name:     "duplicate key keeps first position",
+			content:  "B=1\nA=2\nB=3\n",
+			expected: []string{"B", "A"},
+		},
+		{
+			name:     "export prefix and comments",
+			content:  "# comment\nexport HOST=localhost\nURL=http://${HOST}\n",
+			expected: []string{"HOST", "URL"},
+		},
+		{
+			name:     "commented-out assignments are skipped",
+			content:  "# B=old\nA=1\n  # C=disabled\nB=2 # inline comment\nC=3\n",
+			expected: []string{"A", "B", "C"},
+		},
+		{
+			name:     "single-quoted multiline value",
+			content:  "FIRST='a\nSECOND=b'\nSECOND=real\n",
+			expected: []string{"FIRST", "SECOND"},
+		},
+		{
+			name:     "multiline value lines are not keys",
+			content:  "KEY=\"line1\nFAKE=inside\nline3\"\nAFTER=1\n",
+			expected: []string{"KEY", "AFTER"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.env")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			env, keys, err := NewLoader().LoadEnvFileOrdered(path)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(keys) != len(env) {
+				t.Errorf("got %d ordered keys for %d vars", len(keys), len(env))
+			}
+			if strings.Join(keys, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("keys = %v, want %v", keys, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadEnvFileOrdered_FileNotFound(t *testing.T) {
+	env, keys, err := LoadEnvFileOrdered(filepath.Join(t.TempDir(), "missing.env"))
+	if err != nil {
+		t.Fatalf("expected no error for nonexistent file, got: %v", err)
+	}
+	if len(env) != 0 || len(keys) != 0 {
+		t.Errorf("expected no vars and no keys, got %v and %v", env, keys)
+	}
+}
+
 func TestNewLoader(t *testing.T) {
 	loader := NewLoader()
 
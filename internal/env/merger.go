@@ -3,19 +3,26 @@ package env
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/lightfastai/dual/internal/config"
 )
 
 // LayeredEnv represents a layered environment with multiple sources
 type LayeredEnv struct {
-	Base      map[string]string // Base environment from file
-	Service   map[string]string // Service-specific environment from <service-path>/.env
-	Overrides map[string]string // Context-specific overrides
+	Base        map[string]string // Base environment from file
+	ServiceBase map[string]string // Service base environment from services.<name>.baseFile
+	Service     map[string]string // Service-specific environment from <service-path>/.env
+	Overrides   map[string]string // Context-specific overrides
+
+	// Order records keys in load order (first appearance across layers).
+	// It is optional; keys missing from it are treated as unordered.
+	Order []string
 }
 
 // Merge merges all layers into a single environment map
-// Priority (lowest to highest): Base → Service → Overrides
+// Priority (lowest to highest): Base → ServiceBase → Service → Overrides
 func (e *LayeredEnv) Merge() map[string]string {
 	result := make(map[string]string)
 
@@ -24,12 +31,17 @@ func (e *LayeredEnv) Merge() map[string]string {
 		result[k] = v
 	}
 
-	// Layer 2: Service-specific environment
+	// Layer 2: Service base environment
+	for k, v := range e.ServiceBase {
+		result[k] = v
+	}
+
+	// Layer 3: Service-specific environment
 	for k, v := range e.Service {
 		result[k] = v
 	}
 
-	// Layer 3: Context overrides
+	// Layer 4: Context overrides
 	for k, v := range e.Overrides {
 		result[k] = v
 	}
@@ -37,6 +49,98 @@ func (e *LayeredEnv) Merge() map[string]string {
 	return result
 }
 
+// ApplyProfile sets the variables of a config profile in the Overrides layer,
+// replacing context overrides for the same keys. The registry is not touched,
+// so the profile only applies to this LayeredEnv.
+func (e *LayeredEnv) ApplyProfile(profile map[string]string) {
+	if len(profile) == 0 {
+		return
+	}
+	overrides := make(map[string]string, len(e.Overrides)+len(profile))
+	for k, v := range e.Overrides {
+		overrides[k] = v
+	}
+	for k, v := range profile {
+		overrides[k] = v
+	}
+	e.Overrides = overrides
+}
+
+// Keys returns the merged keys in load order: each key at the position it was
+// first loaded, regardless of which layer supplied the final value.
+// Keys not recorded in Order follow in alphabetical order.
+func (e *LayeredEnv) Keys() []string {
+	merged := e.Merge()
+	keys := make([]string, 0, len(merged))
+	seen := make(map[string]bool, len(merged))
+
+	for _, k := range e.Order {
+		if _, ok := merged[k]; ok && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	for _, k := range sortedKeys(merged) {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// BaseOverride describes a base variable replaced by a higher layer
+type BaseOverride struct {
+	Key   string `json:"key"`
+	Base  string `json:"base"`
+	Value string `json:"value"`
+	Layer string `json:"layer"` // "service" or "override", whichever wins
+}
+
+// OverriddenBase returns, sorted by key, every base variable that the service
+// or override layer also sets, with the base value and the effective value
+func (e *LayeredEnv) OverriddenBase() []BaseOverride {
+	var result []BaseOverride
+	for _, k := range sortedKeys(e.Base) {
+		if v, ok := e.Overrides[k]; ok {
+			result = append(result, BaseOverride{Key: k, Base: e.Base[k], Value: v, Layer: "override"})
+		} else if v, ok := e.Service[k]; ok {
+			result = append(result, BaseOverride{Key: k, Base: e.Base[k], Value: v, Layer: "service"})
+		} else if v, ok := e.ServiceBase[k]; ok {
+			result = append(result, BaseOverride{Key: k, Base: e.Base[k], Value: v, Layer: "serviceBase"})
+		}
+	}
+	return result
+}
+
+// InheritedBase returns the base variables that no service base, service or
+// override layer sets, i.e. the defaults in effect for this context
+func (e *LayeredEnv) InheritedBase() map[string]string {
+	result := make(map[string]string)
+	for k, v := range e.Base {
+		_, inServiceBase := e.ServiceBase[k]
+		_, inService := e.Service[k]
+		_, inOverrides := e.Overrides[k]
+		if !inServiceBase && !inService && !inOverrides {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// DiffFromBase returns the merged variables that are not in the base layer or
+// whose merged value differs from the base value
+func (e *LayeredEnv) DiffFromBase() map[string]string {
+	result := make(map[string]string)
+	for k, v := range e.Merge() {
+		if base, ok := e.Base[k]; !ok || base != v {
+			result[k] = v
+		}
+	}
+	return result
+}
+
 // ToSlice converts the merged environment to a slice of KEY=value strings
 func (e *LayeredEnv) ToSlice() []string {
 	merged := e.Merge()
@@ -52,25 +156,92 @@ func (e *LayeredEnv) ToSlice() []string {
 // Stats returns statistics about the environment layers
 func (e *LayeredEnv) Stats() EnvStats {
 	return EnvStats{
-		BaseVars:     len(e.Base),
-		ServiceVars:  len(e.Service),
-		OverrideVars: len(e.Overrides),
-		TotalVars:    len(e.Merge()),
+		BaseVars:        len(e.Base),
+		ServiceBaseVars: len(e.ServiceBase),
+		ServiceVars:     len(e.Service),
+		OverrideVars:    len(e.Overrides),
+		TotalVars:       len(e.Merge()),
+	}
+}
+
+// serviceEnvFilePath returns a service's env file path relative to the project root
+func serviceEnvFilePath(service config.Service) string {
+	if service.EnvFile != "" {
+		return service.EnvFile
 	}
+	return filepath.Join(service.Path, ".env")
+}
+
+// contextEnvFilePath returns a service's per-context env file path relative to
+// the project root: its env file with ".<context>" appended, e.g.
+// apps/api/.env.feature-x. Slashes in the context name become dashes so the
+// file stays next to the service env file.
+func contextEnvFilePath(service config.Service, contextName string) string {
+	return serviceEnvFilePath(service) + "." + strings.ReplaceAll(contextName, "/", "-")
+}
+
+// LayeredEnvFiles returns the files LoadLayeredEnv may read for a service in
+// a context, in layer order, whether or not they currently exist: the base
+// file, the service base file, the service env file and per-context env file
+// (parent repo first when in a worktree), and the generated overrides file.
+// It is meant for tools that reload when these change.
+func LayeredEnvFiles(projectRoot string, cfg *config.Config, serviceName, contextName string) []string {
+	var files []string
+
+	if cfg.Env.BaseFile != "" {
+		files = append(files, filepath.Join(projectRoot, cfg.Env.BaseFile))
+	}
+
+	projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
+	if err != nil {
+		projectIdentifier = projectRoot
+	}
+
+	if service, ok := cfg.Services[serviceName]; ok {
+		if service.BaseFile != "" {
+			files = append(files, filepath.Join(projectRoot, service.BaseFile))
+		}
+		relativeEnvPaths := []string{serviceEnvFilePath(service)}
+		if contextName != "" {
+			relativeEnvPaths = append(relativeEnvPaths, contextEnvFilePath(service, contextName))
+		}
+		for _, relativeEnvPath := range relativeEnvPaths {
+			if projectIdentifier != projectRoot {
+				files = append(files, filepath.Join(projectIdentifier, relativeEnvPath))
+			}
+			files = append(files, filepath.Join(projectRoot, relativeEnvPath))
+		}
+		files = append(files, filepath.Join(projectIdentifier, ".dual", ".local", "service", serviceName, ".env"))
+	}
+
+	return files
+}
+
+// sortedKeys returns the keys of a map in alphabetical order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // EnvStats contains statistics about environment layers
 type EnvStats struct {
-	BaseVars     int
-	ServiceVars  int
-	OverrideVars int
-	TotalVars    int
+	BaseVars        int
+	ServiceBaseVars int
+	ServiceVars     int
+	OverrideVars    int
+	TotalVars       int
 }
 
-// LoadLayeredEnv loads a layered environment for a given context with all three layers:
+// LoadLayeredEnv loads a layered environment for a given context with all four layers:
 // 1. Base environment from the configured base file
-// 2. Service-specific environment from the service's .env file
-// 3. Context-specific overrides (from registry or filesystem)
+// 2. Service base environment from the service's baseFile, if configured
+// 3. Service-specific environment from the service's .env file, then its
+// per-context file (<env file>.<context>) if one exists
+// 4. Context-specific overrides (from registry or filesystem)
 //
 // Parameters:
 //   - projectRoot: The root directory of the project
@@ -79,60 +250,82 @@ type EnvStats struct {
 //   - contextName: The name of the current context (empty string for no context)
 //   - overrides: Context-specific overrides from registry (can be nil)
 func LoadLayeredEnv(projectRoot string, cfg *config.Config, serviceName string, contextName string, overrides map[string]string) (*LayeredEnv, error) {
-	loader := NewLoader()
+	return LoadLayeredEnvWithCache(projectRoot, cfg, serviceName, contextName, overrides, nil)
+}
+
+// LoadLayeredEnvWithCache is LoadLayeredEnv reading env files through cache,
+// for commands that load several layered environments in one invocation. A
+// nil cache reads every file from disk.
+func LoadLayeredEnvWithCache(projectRoot string, cfg *config.Config, serviceName string, contextName string, overrides map[string]string, cache *FileCache) (*LayeredEnv, error) {
+	loader := NewCachedLoader(cache)
 	env := &LayeredEnv{
-		Base:      make(map[string]string),
-		Service:   make(map[string]string),
-		Overrides: make(map[string]string),
+		Base:        make(map[string]string),
+		ServiceBase: make(map[string]string),
+		Service:     make(map[string]string),
+		Overrides:   make(map[string]string),
 	}
 
 	// Layer 1: Load base environment file if configured
 	if cfg.Env.BaseFile != "" {
 		baseFilePath := filepath.Join(projectRoot, cfg.Env.BaseFile)
-		baseEnv, err := loader.LoadEnvFile(baseFilePath)
+		baseEnv, baseOrder, err := loader.LoadEnvFileOrdered(baseFilePath)
 		if err != nil {
 			// Non-fatal: The file might not exist yet, which is OK
 			// Just continue with empty base environment
 		} else {
 			env.Base = baseEnv
+			env.Order = append(env.Order, baseOrder...)
+		}
+	}
+
+	// Layer 2: Load the service base file if configured (non-fatal if missing)
+	if service, ok := cfg.Services[serviceName]; ok && serviceName != "" && service.BaseFile != "" {
+		serviceBaseEnv, serviceBaseOrder, err := loader.LoadEnvFileOrdered(filepath.Join(projectRoot, service.BaseFile))
+		if err == nil {
+			env.ServiceBase = serviceBaseEnv
+			env.Order = append(env.Order, serviceBaseOrder...)
 		}
 	}
 
-	// Layer 2: Load service-specific environment file
-	// In worktrees, load from both parent repo and worktree, with worktree overriding
+	// Layer 3: Load service-specific environment file, then the optional
+	// per-context file (e.g. apps/api/.env.feature-x) on top of it.
+	// In worktrees, load each from both parent repo and worktree, with worktree overriding
 	if serviceName != "" {
 		if service, ok := cfg.Services[serviceName]; ok {
 			serviceEnv := make(map[string]string)
 
-			// Determine relative env file path
-			var relativeEnvPath string
-			if service.EnvFile != "" {
-				relativeEnvPath = service.EnvFile
-			} else {
-				relativeEnvPath = filepath.Join(service.Path, ".env")
+			// Determine relative env file paths
+			relativeEnvPaths := []string{serviceEnvFilePath(service)}
+			if contextName != "" {
+				relativeEnvPaths = append(relativeEnvPaths, contextEnvFilePath(service, contextName))
 			}
 
-			// First, try to load from parent repo (if we're in a worktree)
 			projectIdentifier, err := config.GetProjectIdentifier(projectRoot)
-			if err == nil && projectIdentifier != projectRoot {
-				// We're in a worktree, load parent repo's service env first
-				parentEnvPath := filepath.Join(projectIdentifier, relativeEnvPath)
-				parentEnv, err := loader.LoadEnvFile(parentEnvPath)
-				if err == nil {
-					// Merge parent repo env into service env (lowest priority)
-					for k, v := range parentEnv {
-						serviceEnv[k] = v
+			inWorktree := err == nil && projectIdentifier != projectRoot
+
+			for _, relativeEnvPath := range relativeEnvPaths {
+				// First, try to load from parent repo (if we're in a worktree)
+				if inWorktree {
+					parentEnvPath := filepath.Join(projectIdentifier, relativeEnvPath)
+					parentEnv, parentOrder, err := loader.LoadEnvFileOrdered(parentEnvPath)
+					if err == nil {
+						env.Order = append(env.Order, parentOrder...)
+						// Merge parent repo env into service env (lower priority)
+						for k, v := range parentEnv {
+							serviceEnv[k] = v
+						}
 					}
 				}
-			}
 
-			// Then, load from worktree (overrides parent repo)
-			worktreeEnvPath := filepath.Join(projectRoot, relativeEnvPath)
-			worktreeEnv, err := loader.LoadEnvFile(worktreeEnvPath)
-			if err == nil {
-				// Merge worktree env into service env (higher priority, overrides parent)
-				for k, v := range worktreeEnv {
-					serviceEnv[k] = v
+				// Then, load from worktree (overrides parent repo)
+				worktreeEnvPath := filepath.Join(projectRoot, relativeEnvPath)
+				worktreeEnv, worktreeOrder, err := loader.LoadEnvFileOrdered(worktreeEnvPath)
+				if err == nil {
+					env.Order = append(env.Order, worktreeOrder...)
+					// Merge worktree env into service env (higher priority, overrides parent)
+					for k, v := range worktreeEnv {
+						serviceEnv[k] = v
+					}
 				}
 			}
 
@@ -140,9 +333,10 @@ func LoadLayeredEnv(projectRoot string, cfg *config.Config, serviceName string,
 		}
 	}
 
-	// Layer 3: Add context-specific overrides
+	// Layer 4: Add context-specific overrides
 	// First try to use provided overrides (from registry)
 	if overrides != nil {
+		// Registry overrides are unordered; Keys() places new ones alphabetically
 		env.Overrides = overrides
 	} else if contextName != "" && serviceName != "" {
 		// If no overrides provided but we have context and service,
@@ -155,9 +349,10 @@ func LoadLayeredEnv(projectRoot string, cfg *config.Config, serviceName string,
 		}
 
 		overridesPath := filepath.Join(projectIdentifier, ".dual", ".local", "service", serviceName, ".env")
-		overridesEnv, err := loader.LoadEnvFile(overridesPath)
+		overridesEnv, overridesOrder, err := loader.LoadEnvFileOrdered(overridesPath)
 		if err == nil {
 			env.Overrides = overridesEnv
+			env.Order = append(env.Order, overridesOrder...)
 		}
 		// Non-fatal: if overrides file doesn't exist, continue with empty overrides
 	}
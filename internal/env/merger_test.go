@@ -3,6 +3,8 @@ package env
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"testing"
 
 	"github.com/lightfastai/dual/internal/config"
@@ -341,6 +343,145 @@ SHARED_VAR=service_value
 	}
 }
 
+// TestLoadLayeredEnv_ServiceBaseFile tests that a service baseFile is layered
+// between the project base file and the service .env file
+func TestLoadLayeredEnv_ServiceBaseFile(t *testing.T) {
+	repo := t.TempDir()
+	for _, dir := range []string{"apps/api", "apps/web"} {
+		if err := os.MkdirAll(filepath.Join(repo, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	configContent := `version: 1
+services:
+  api:
+    path: apps/api
+    baseFile: .env.api.base
+  web:
+    path: apps/web
+env:
+  baseFile: .env.base
+`
+	configPath := filepath.Join(repo, "dual.config.yml")
+	files := map[string]string{
+		configPath:                                 configContent,
+		filepath.Join(repo, ".env.base"):           "BASE_ONLY=base\nLOG_LEVEL=info\nPORT=3000\nDEBUG=false\n",
+		filepath.Join(repo, ".env.api.base"):       "LOG_LEVEL=warn\nPORT=4000\nAPI_TIMEOUT=30\n",
+		filepath.Join(repo, "apps", "api", ".env"): "PORT=4001\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := config.LoadConfigFrom(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	layeredEnv, err := LoadLayeredEnv(repo, cfg, "api", "", map[string]string{"DEBUG": "true"})
+	if err != nil {
+		t.Fatalf("LoadLayeredEnv failed: %v", err)
+	}
+
+	want := map[string]string{
+		"BASE_ONLY":   "base",
+		"LOG_LEVEL":   "warn",
+		"PORT":        "4001",
+		"API_TIMEOUT": "30",
+		"DEBUG":       "true",
+	}
+	if got := layeredEnv.Merge(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %v, want %v", got, want)
+	}
+
+	stats := layeredEnv.Stats()
+	if stats.ServiceBaseVars != 3 {
+		t.Errorf("expected 3 service base vars, got %d", stats.ServiceBaseVars)
+	}
+
+	// Services without a baseFile only see the project base
+	layeredEnv, err = LoadLayeredEnv(repo, cfg, "web", "", nil)
+	if err != nil {
+		t.Fatalf("LoadLayeredEnv failed: %v", err)
+	}
+	if len(layeredEnv.ServiceBase) != 0 {
+		t.Errorf("expected no service base vars for web, got %v", layeredEnv.ServiceBase)
+	}
+}
+
+// TestLoadLayeredEnv_ContextFile tests that <env file>.<context> is layered
+// over the service env file, below the overrides, only for its own context
+func TestLoadLayeredEnv_ContextFile(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, "apps", "api"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	configPath := filepath.Join(repo, "dual.config.yml")
+	files := map[string]string{
+		configPath: `version: 1
+services:
+  api:
+    path: apps/api
+`,
+		filepath.Join(repo, "apps", "api", ".env"):                 "PORT=4000\nDATABASE_URL=postgres://localhost/app\n",
+		filepath.Join(repo, "apps", "api", ".env.feature-x"):       "DATABASE_URL=postgres://localhost/feature_x\nDEBUG=false\n",
+		filepath.Join(repo, "apps", "api", ".env.feature-auth-v2"): "FEATURE=auth\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg, err := config.LoadConfigFrom(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		context string
+		want    map[string]string
+	}{
+		{
+			name:    "context file present",
+			context: "feature-x",
+			want:    map[string]string{"PORT": "4000", "DATABASE_URL": "postgres://localhost/feature_x", "DEBUG": "true"},
+		},
+		{
+			name:    "context file absent",
+			context: "main",
+			want:    map[string]string{"PORT": "4000", "DATABASE_URL": "postgres://localhost/app", "DEBUG": "true"},
+		},
+		{
+			name:    "slashes in the context name",
+			context: "feature/auth/v2",
+			want:    map[string]string{"PORT": "4000", "DATABASE_URL": "postgres://localhost/app", "FEATURE": "auth", "DEBUG": "true"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layeredEnv, err := LoadLayeredEnv(repo, cfg, "api", tt.context, map[string]string{"DEBUG": "true"})
+			if err != nil {
+				t.Fatalf("LoadLayeredEnv failed: %v", err)
+			}
+			if got := layeredEnv.Merge(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	watched := LayeredEnvFiles(repo, cfg, "api", "feature-x")
+	if want := filepath.Join(repo, "apps", "api", ".env.feature-x"); !slices.Contains(watched, want) {
+		t.Errorf("LayeredEnvFiles() = %v, missing %s", watched, want)
+	}
+}
+
 // TestLayeredEnv_Merge tests the merge priority
 func TestLayeredEnv_Merge(t *testing.T) {
 	env := &LayeredEnv{
@@ -384,6 +525,102 @@ func TestLayeredEnv_Merge(t *testing.T) {
 	}
 }
 
+// TestLayeredEnv_Keys tests that keys come back in load order
+func TestLayeredEnv_Keys(t *testing.T) {
+	env := &LayeredEnv{
+		Base:      map[string]string{"ZED": "base", "ALPHA": "base"},
+		Service:   map[string]string{"MIDDLE": "service", "ZED": "service"},
+		Overrides: map[string]string{"OVERRIDE_B": "o", "OVERRIDE_A": "o"},
+		Order:     []string{"ZED", "ALPHA", "MIDDLE", "ZED", "STALE"},
+	}
+
+	got := env.Keys()
+	want := []string{"ZED", "ALPHA", "MIDDLE", "OVERRIDE_A", "OVERRIDE_B"}
+
+	if len(got) != len(want) {
+		t.Fatalf("Keys() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Keys()[%d] = %q, want %q (full: %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestLayeredEnv_OverriddenBase(t *testing.T) {
+	env := &LayeredEnv{
+		Base:      map[string]string{"PORT": "3000", "DEBUG": "false", "NAME": "app", "LOG": "info"},
+		Service:   map[string]string{"PORT": "4000", "NAME": "api", "EXTRA": "x"},
+		Overrides: map[string]string{"PORT": "4001", "DEBUG": "true", "ONLY_OVERRIDE": "o"},
+	}
+
+	got := env.OverriddenBase()
+	want := []BaseOverride{
+		{Key: "DEBUG", Base: "false", Value: "true", Layer: "override"},
+		{Key: "NAME", Base: "app", Value: "api", Layer: "service"},
+		{Key: "PORT", Base: "3000", Value: "4001", Layer: "override"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("OverriddenBase() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("OverriddenBase()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestLayeredEnv_InheritedBase(t *testing.T) {
+	env := &LayeredEnv{
+		Base:        map[string]string{"PORT": "3000", "DEBUG": "false", "NAME": "app", "LOG": "info", "REGION": "eu"},
+		ServiceBase: map[string]string{"REGION": "us"},
+		Service:     map[string]string{"NAME": "api", "EXTRA": "x"},
+		// An override equal to the base value still replaces it
+		Overrides: map[string]string{"PORT": "4001", "DEBUG": "false"},
+	}
+
+	got := env.InheritedBase()
+	want := map[string]string{"LOG": "info"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("InheritedBase() = %v, want %v", got, want)
+	}
+}
+
+func TestLayeredEnv_ApplyProfile(t *testing.T) {
+	overrides := map[string]string{"PORT": "4001", "LOG_LEVEL": "info"}
+	env := &LayeredEnv{
+		Base:      map[string]string{"LOG_LEVEL": "warn", "NAME": "app"},
+		Overrides: overrides,
+	}
+
+	env.ApplyProfile(map[string]string{"LOG_LEVEL": "debug", "VERBOSE": "1"})
+
+	want := map[string]string{"NAME": "app", "PORT": "4001", "LOG_LEVEL": "debug", "VERBOSE": "1"}
+	if got := env.Merge(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %v, want %v", got, want)
+	}
+	// The context overrides passed in are left untouched
+	if overrides["LOG_LEVEL"] != "info" || len(overrides) != 2 {
+		t.Errorf("ApplyProfile() modified the original overrides: %v", overrides)
+	}
+}
+
+// TestLayeredEnv_DiffFromBase tests that only new or changed variables are returned
+func TestLayeredEnv_DiffFromBase(t *testing.T) {
+	env := &LayeredEnv{
+		Base:      map[string]string{"PORT": "3000", "DEBUG": "false", "NAME": "app", "LOG": "info"},
+		Service:   map[string]string{"NAME": "api", "EXTRA": "x"},
+		Overrides: map[string]string{"PORT": "4001", "DEBUG": "false"},
+	}
+
+	got := env.DiffFromBase()
+	want := map[string]string{"PORT": "4001", "NAME": "api", "EXTRA": "x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffFromBase() = %v, want %v", got, want)
+	}
+}
+
 // TestLayeredEnv_Stats tests the stats calculation
 func TestLayeredEnv_Stats(t *testing.T) {
 	env := &LayeredEnv{
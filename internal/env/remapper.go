@@ -15,7 +15,9 @@ import (
 
 // GenerateServiceEnvFiles generates .env files for each service in .dual/.local/service/<service>/.env
 // It reads environment overrides from the registry and writes only remapped variables (sparse pattern).
-// Only writes files for services that have overrides.
+// Only writes files for services that have overrides. Overrides matching
+// env.exclude are left out of the files; 'dual run' adds them back from the
+// registry (see ExcludedOverrides).
 func GenerateServiceEnvFiles(cfg *config.Config, reg *registry.Registry, projectRoot, projectIdentifier, contextName string) error {
 	// Get context from registry
 	ctx, err := reg.GetContext(projectIdentifier, contextName)
@@ -36,14 +38,15 @@ func GenerateServiceEnvFiles(cfg *config.Config, reg *registry.Registry, project
 
 	// Generate env files for each service
 	for _, serviceName := range serviceNames {
-		remappedVars, err := getRemappedVarsForService(ctx, serviceName)
-		if err != nil {
-			return fmt.Errorf("failed to get remapped vars for service %q: %w", serviceName, err)
+		// Skip if no remapped variables. A service whose overrides are all
+		// excluded still gets a file, so values written before are removed.
+		if len(ctx.GetEnvOverrides(serviceName)) == 0 {
+			continue
 		}
 
-		// Skip if no remapped variables
-		if len(remappedVars) == 0 {
-			continue
+		remappedVars, err := getRemappedVarsForService(ctx, serviceName, cfg.Env.Exclude)
+		if err != nil {
+			return fmt.Errorf("failed to get remapped vars for service %q: %w", serviceName, err)
 		}
 
 		// Write service env file
@@ -58,18 +61,47 @@ func GenerateServiceEnvFiles(cfg *config.Config, reg *registry.Registry, project
 
 // getRemappedVarsForService returns environment variables that have been remapped for a service.
 // It merges global overrides with service-specific overrides (service-specific takes precedence).
-// Returns only variables that are explicitly overridden (sparse pattern).
-func getRemappedVarsForService(ctx *registry.Context, serviceName string) (map[string]string, error) {
+// Returns only variables that are explicitly overridden (sparse pattern),
+// without those matching the exclude patterns.
+func getRemappedVarsForService(ctx *registry.Context, serviceName string, exclude []string) (map[string]string, error) {
 	// Get all overrides for this service (includes global + service-specific)
 	overrides := ctx.GetEnvOverrides(serviceName)
+	if len(exclude) == 0 {
+		return overrides, nil
+	}
 
-	// Return all overrides (no exclusions)
+	excluded := &KeyFilter{Patterns: exclude}
+	for key := range overrides {
+		if excluded.Match(key) {
+			delete(overrides, key)
+		}
+	}
 	return overrides, nil
 }
 
+// ExcludedOverrides returns the overrides of a context for a service that
+// match the exclude patterns (env.exclude) and are therefore missing from the
+// generated service env file
+func ExcludedOverrides(ctx *registry.Context, serviceName string, exclude []string) map[string]string {
+	excluded := make(map[string]string)
+	if len(exclude) == 0 {
+		return excluded
+	}
+
+	filter := &KeyFilter{Patterns: exclude}
+	for key, value := range ctx.GetEnvOverrides(serviceName) {
+		if filter.Match(key) {
+			excluded[key] = value
+		}
+	}
+	return excluded
+}
+
 // writeServiceEnvFile writes a dotenv format file with the remapped variables.
 // Includes a header warning about auto-generation.
 // Creates parent directories if needed.
+// The write is skipped when the existing file only differs by its Generated
+// timestamp, so file watchers are not triggered needlessly.
 func writeServiceEnvFile(serviceName, contextName string, vars map[string]string, outputPath string) error {
 	// Create parent directory
 	dir := filepath.Dir(outputPath)
@@ -77,7 +109,33 @@ func writeServiceEnvFile(serviceName, contextName string, vars map[string]string
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Build file content
+	content := renderServiceEnvFile(serviceName, contextName, vars)
+
+	// Skip the write if nothing but the timestamp would change
+	// #nosec G304 - outputPath is the generated env file under .dual/.local
+	if existing, err := os.ReadFile(outputPath); err == nil {
+		if stripGeneratedTimestamp(string(existing)) == stripGeneratedTimestamp(content) {
+			return nil
+		}
+	}
+
+	// Write file atomically
+	tempFile := outputPath + ".tmp"
+	if err := os.WriteFile(tempFile, []byte(content), 0o600); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	// Atomic rename
+	if err := os.Rename(tempFile, outputPath); err != nil {
+		_ = os.Remove(tempFile) // Clean up temp file on error
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+
+	return nil
+}
+
+// renderServiceEnvFile builds the content of a generated service env file
+func renderServiceEnvFile(serviceName, contextName string, vars map[string]string) string {
 	var builder strings.Builder
 
 	// Header
@@ -89,7 +147,7 @@ func writeServiceEnvFile(serviceName, contextName string, vars map[string]string
 	builder.WriteString(serviceName)
 	builder.WriteString(" <key> <value>\n")
 	builder.WriteString("#\n")
-	builder.WriteString("# Generated: ")
+	builder.WriteString(generatedHeaderPrefix)
 	builder.WriteString(time.Now().UTC().Format(time.RFC3339))
 	builder.WriteString("\n")
 	builder.WriteString("# Context: ")
@@ -125,19 +183,66 @@ func writeServiceEnvFile(serviceName, contextName string, vars map[string]string
 		}
 	}
 
-	// Write file atomically
-	tempFile := outputPath + ".tmp"
-	if err := os.WriteFile(tempFile, []byte(builder.String()), 0o600); err != nil {
-		return fmt.Errorf("failed to write temporary file: %w", err)
+	return builder.String()
+}
+
+// ServiceEnvFileDrift reports the services whose generated env file under
+// .dual/.local/service/<service>/.env is missing or differs from what
+// GenerateServiceEnvFiles would write for the given context.
+// Services without overrides are not generated and therefore never drift.
+// The returned map is service name → reason ("missing" or "stale").
+func ServiceEnvFileDrift(cfg *config.Config, reg *registry.Registry, projectIdentifier, contextName string) (map[string]string, error) {
+	drift := make(map[string]string)
+
+	ctx, err := reg.GetContext(projectIdentifier, contextName)
+	if err != nil {
+		if errors.Is(err, registry.ErrContextNotFound) || errors.Is(err, registry.ErrProjectNotFound) {
+			return drift, nil
+		}
+		return nil, fmt.Errorf("failed to get context: %w", err)
 	}
 
-	// Atomic rename
-	if err := os.Rename(tempFile, outputPath); err != nil {
-		_ = os.Remove(tempFile) // Clean up temp file on error
-		return fmt.Errorf("failed to rename file: %w", err)
+	for serviceName := range cfg.Services {
+		if len(ctx.GetEnvOverrides(serviceName)) == 0 {
+			continue
+		}
+		remappedVars, err := getRemappedVarsForService(ctx, serviceName, cfg.Env.Exclude)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get remapped vars for service %q: %w", serviceName, err)
+		}
+
+		outputPath := filepath.Join(projectIdentifier, ".dual", ".local", "service", serviceName, ".env")
+		// #nosec G304 - outputPath is the generated env file under .dual/.local
+		existing, err := os.ReadFile(outputPath)
+		if err != nil {
+			drift[serviceName] = "missing"
+			continue
+		}
+
+		expected := renderServiceEnvFile(serviceName, contextName, remappedVars)
+		if stripGeneratedTimestamp(string(existing)) != stripGeneratedTimestamp(expected) {
+			drift[serviceName] = "stale"
+		}
 	}
 
-	return nil
+	return drift, nil
+}
+
+// generatedHeaderPrefix marks the timestamp line in generated env file headers
+const generatedHeaderPrefix = "# Generated: "
+
+// stripGeneratedTimestamp removes the Generated timestamp line from generated
+// env file content so two generations can be compared
+func stripGeneratedTimestamp(content string) string {
+	lines := strings.Split(content, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.HasPrefix(line, generatedHeaderPrefix) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
 }
 
 // needsQuoting returns true if a value needs to be quoted in dotenv format
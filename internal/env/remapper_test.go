@@ -198,11 +198,83 @@ func TestGenerateServiceEnvFiles_ContextNotFound(t *testing.T) {
 	}
 }
 
+func TestServiceEnvFileDrift(t *testing.T) {
+	tempDir := t.TempDir()
+
+	cfg := &config.Config{
+		Services: map[string]config.Service{
+			"api":    {Path: "services/api"},
+			"web":    {Path: "services/web"},
+			"worker": {Path: "services/worker"},
+		},
+	}
+
+	reg := &registry.Registry{
+		Projects: map[string]registry.Project{
+			tempDir: {
+				Contexts: map[string]registry.Context{
+					"dev": {
+						Created: time.Now(),
+						EnvOverridesV2: &registry.ContextEnvOverrides{
+							Services: map[string]map[string]string{
+								"api": {"PORT": "4001"},
+								"web": {"PORT": "4002"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// Nothing generated yet: both services with overrides are missing
+	drift, err := ServiceEnvFileDrift(cfg, reg, tempDir, "dev")
+	if err != nil {
+		t.Fatalf("ServiceEnvFileDrift() error = %v", err)
+	}
+	if len(drift) != 2 || drift["api"] != "missing" || drift["web"] != "missing" {
+		t.Errorf("expected api and web missing, got %v", drift)
+	}
+
+	if err := GenerateServiceEnvFiles(cfg, reg, tempDir, tempDir, "dev"); err != nil {
+		t.Fatalf("GenerateServiceEnvFiles() error = %v", err)
+	}
+
+	drift, err = ServiceEnvFileDrift(cfg, reg, tempDir, "dev")
+	if err != nil {
+		t.Fatalf("ServiceEnvFileDrift() error = %v", err)
+	}
+	if len(drift) != 0 {
+		t.Errorf("expected no drift after generation, got %v", drift)
+	}
+
+	// Change an override without regenerating
+	reg.Projects[tempDir].Contexts["dev"].EnvOverridesV2.Services["api"]["PORT"] = "5001"
+
+	drift, err = ServiceEnvFileDrift(cfg, reg, tempDir, "dev")
+	if err != nil {
+		t.Fatalf("ServiceEnvFileDrift() error = %v", err)
+	}
+	if len(drift) != 1 || drift["api"] != "stale" {
+		t.Errorf("expected api stale, got %v", drift)
+	}
+
+	// Unknown context has nothing to compare
+	drift, err = ServiceEnvFileDrift(cfg, reg, tempDir, "missing")
+	if err != nil {
+		t.Fatalf("ServiceEnvFileDrift() error = %v", err)
+	}
+	if len(drift) != 0 {
+		t.Errorf("expected no drift for unknown context, got %v", drift)
+	}
+}
+
 func TestGetRemappedVarsForService(t *testing.T) {
 	tests := []struct {
 		name        string
 		ctx         *registry.Context
 		serviceName string
+		exclude     []string
 		want        map[string]string
 	}{
 		{
@@ -289,11 +361,33 @@ func TestGetRemappedVarsForService(t *testing.T) {
 			serviceName: "api",
 			want:        map[string]string{},
 		},
+		{
+			name: "excluded variables are left out",
+			ctx: &registry.Context{
+				EnvOverridesV2: &registry.ContextEnvOverrides{
+					Global: map[string]string{
+						"CI":           "true",
+						"GITHUB_TOKEN": "ghp_secret",
+						"DEBUG":        "true",
+					},
+					Services: map[string]map[string]string{
+						"api": {
+							"GITHUB_SHA": "abc123",
+						},
+					},
+				},
+			},
+			serviceName: "api",
+			exclude:     []string{"CI", "GITHUB_*"},
+			want: map[string]string{
+				"DEBUG": "true",
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := getRemappedVarsForService(tt.ctx, tt.serviceName)
+			got, err := getRemappedVarsForService(tt.ctx, tt.serviceName, tt.exclude)
 			if err != nil {
 				t.Fatalf("getRemappedVarsForService failed: %v", err)
 			}
@@ -380,6 +474,47 @@ func TestWriteServiceEnvFile(t *testing.T) {
 	}
 }
 
+func TestWriteServiceEnvFile_SkipsUnchanged(t *testing.T) {
+	tempDir := t.TempDir()
+	outputPath := filepath.Join(tempDir, ".dual", ".local", "service", "api", ".env")
+	vars := map[string]string{"DATABASE_URL": "postgres://localhost/db"}
+
+	if err := writeServiceEnvFile("api", "test-context", vars, outputPath); err != nil {
+		t.Fatalf("writeServiceEnvFile failed: %v", err)
+	}
+
+	// Backdate the file so a rewrite would be visible in the mtime
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(outputPath, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same variables: file must not be rewritten
+	if err := writeServiceEnvFile("api", "test-context", vars, outputPath); err != nil {
+		t.Fatalf("writeServiceEnvFile failed: %v", err)
+	}
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(past) {
+		t.Error("file was rewritten although its content did not change")
+	}
+
+	// Changed variables: file must be rewritten
+	vars["DEBUG"] = "true"
+	if err := writeServiceEnvFile("api", "test-context", vars, outputPath); err != nil {
+		t.Fatalf("writeServiceEnvFile failed: %v", err)
+	}
+	content, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "DEBUG=true") {
+		t.Error("file was not rewritten after variables changed")
+	}
+}
+
 func TestWriteServiceEnvFile_SpecialCharacters(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -0,0 +1,194 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// SecretResolver resolves secret references to their real values.
+//
+// Override values such as "op://vault/item/field" or "${vault:secret/app#password}"
+// are stored as-is in the registry and in generated env files. They are only
+// resolved when the environment is handed to a consumer (dual env export, dual run).
+type SecretResolver interface {
+	// Resolve returns the real value for a secret reference
+	Resolve(ref SecretRef) (string, error)
+}
+
+// SecretRef is a parsed secret reference
+type SecretRef struct {
+	// Raw is the reference exactly as it appears in the environment value
+	Raw string
+	// Scheme identifies the secret backend (e.g. "op", "vault")
+	Scheme string
+	// Path is the reference without the ${scheme:...} wrapper.
+	// For op:// references this is the full URI.
+	Path string
+	// Field is the part of the path after '#', if any
+	Field string
+}
+
+// DefaultSecretCommands are the commands used to resolve references for the
+// built-in schemes. Placeholders {ref}, {path} and {field} are substituted
+// per argument; the command is executed directly, not through a shell.
+var DefaultSecretCommands = map[string]string{
+	"op":    "op read {ref}",
+	"vault": "vault kv get -field={field} {path}",
+}
+
+// defaultSecretField is used for {field} when a reference has no '#field' suffix
+const defaultSecretField = "value"
+
+// ParseSecretRef parses a value as a secret reference.
+// Supported forms are "op://..." and "${scheme:path}" spanning the whole value.
+// Shell parameter expansions such as "${HOST:-localhost}" are not references.
+func ParseSecretRef(value string) (SecretRef, bool) {
+	if strings.HasPrefix(value, "op://") && len(value) > len("op://") {
+		return SecretRef{Raw: value, Scheme: "op", Path: value}, true
+	}
+
+	if !strings.HasPrefix(value, "${") || !strings.HasSuffix(value, "}") {
+		return SecretRef{}, false
+	}
+	inner := value[2 : len(value)-1]
+	scheme, path, found := strings.Cut(inner, ":")
+	if !found || scheme == "" || path == "" || strings.ContainsAny(scheme, " ${}") {
+		return SecretRef{}, false
+	}
+	// ${VAR:-default}, ${VAR:=default}, ${VAR:?message} and ${VAR:+alt}
+	if strings.ContainsRune("-=?+", rune(path[0])) {
+		return SecretRef{}, false
+	}
+
+	ref := SecretRef{Raw: value, Scheme: scheme, Path: path}
+	if p, field, hasField := strings.Cut(path, "#"); hasField {
+		ref.Path = p
+		ref.Field = field
+	}
+	return ref, true
+}
+
+// IsSecretRef reports whether a value is a secret reference
+func IsSecretRef(value string) bool {
+	_, ok := ParseSecretRef(value)
+	return ok
+}
+
+// secretKeyPattern matches variable names that conventionally hold credentials
+var secretKeyPattern = regexp.MustCompile(`(?i)(SECRET|PASSWORD|PASSWD|TOKEN|PRIVATE|CREDENTIAL|API_?KEY|ACCESS_?KEY|_KEY$|^KEY$)`)
+
+// IsSecret reports whether a variable looks like it holds a secret: its value
+// is a secret reference, or its name matches a credential naming convention
+// such as API_KEY, DB_PASSWORD or GITHUB_TOKEN.
+func IsSecret(key, value string) bool {
+	return IsSecretRef(value) || secretKeyPattern.MatchString(key)
+}
+
+// CommandSecretResolver resolves secret references by running an external
+// command per scheme (e.g. `op read`, `vault kv get`). Results are cached for
+// the lifetime of the resolver, so a reference used by several variables is
+// only fetched once per invocation.
+type CommandSecretResolver struct {
+	commands map[string][]string
+	cache    map[string]string
+	// runCommand allows for dependency injection in tests
+	runCommand func(name string, args ...string) (string, error)
+}
+
+// NewCommandSecretResolver creates a resolver using DefaultSecretCommands,
+// extended or overridden by the given scheme → command mapping (typically
+// the env.secrets section of dual.config.yml).
+func NewCommandSecretResolver(commands map[string]string) *CommandSecretResolver {
+	merged := make(map[string][]string, len(DefaultSecretCommands)+len(commands))
+	for scheme, command := range DefaultSecretCommands {
+		merged[scheme] = strings.Fields(command)
+	}
+	for scheme, command := range commands {
+		merged[scheme] = strings.Fields(command)
+	}
+
+	return &CommandSecretResolver{
+		commands:   merged,
+		cache:      make(map[string]string),
+		runCommand: execSecretCommand,
+	}
+}
+
+// Resolve runs the command configured for the reference's scheme and returns its output
+func (r *CommandSecretResolver) Resolve(ref SecretRef) (string, error) {
+	if value, ok := r.cache[ref.Raw]; ok {
+		return value, nil
+	}
+
+	command, ok := r.commands[ref.Scheme]
+	if !ok || len(command) == 0 {
+		return "", fmt.Errorf("no resolver configured for secret scheme %q", ref.Scheme)
+	}
+
+	field := ref.Field
+	if field == "" {
+		field = defaultSecretField
+	}
+	replacer := strings.NewReplacer("{ref}", ref.Path, "{path}", ref.Path, "{field}", field)
+
+	args := make([]string, len(command)-1)
+	for i, arg := range command[1:] {
+		args[i] = replacer.Replace(arg)
+	}
+
+	output, err := r.runCommand(command[0], args...)
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w", command[0], err)
+	}
+
+	value := strings.TrimRight(output, "\r\n")
+	r.cache[ref.Raw] = value
+	return value, nil
+}
+
+// ResolveSecrets returns a copy of vars with every secret reference replaced by
+// its resolved value. Values that are not references are copied unchanged.
+// Errors name the variable but never include secret values.
+func ResolveSecrets(vars map[string]string, resolver SecretResolver) (map[string]string, error) {
+	result := make(map[string]string, len(vars))
+
+	// Resolve in sorted order so failures are reported deterministically
+	for _, k := range sortedKeys(vars) {
+		value := vars[k]
+		ref, ok := ParseSecretRef(value)
+		if !ok {
+			result[k] = value
+			continue
+		}
+
+		resolved, err := resolver.Resolve(ref)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve secret for %s (%s): %w", k, ref.Scheme, err)
+		}
+		result[k] = resolved
+	}
+
+	return result, nil
+}
+
+// MergeResolved merges all layers and resolves any secret references in the result
+func (e *LayeredEnv) MergeResolved(resolver SecretResolver) (map[string]string, error) {
+	return ResolveSecrets(e.Merge(), resolver)
+}
+
+// execSecretCommand executes a resolver command and returns its stdout
+func execSecretCommand(name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return string(output), nil
+}
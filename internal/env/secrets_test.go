@@ -0,0 +1,154 @@
+package env
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseSecretRef(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		wantOK bool
+		want   SecretRef
+	}{
+		{
+			name:   "1password reference",
+			value:  "op://dev/db/password",
+			wantOK: true,
+			want:   SecretRef{Raw: "op://dev/db/password", Scheme: "op", Path: "op://dev/db/password"},
+		},
+		{
+			name:   "vault reference with field",
+			value:  "${vault:secret/app#password}",
+			wantOK: true,
+			want:   SecretRef{Raw: "${vault:secret/app#password}", Scheme: "vault", Path: "secret/app", Field: "password"},
+		},
+		{
+			name:   "custom scheme without field",
+			value:  "${aws:prod/db}",
+			wantOK: true,
+			want:   SecretRef{Raw: "${aws:prod/db}", Scheme: "aws", Path: "prod/db"},
+		},
+		{name: "plain value", value: "postgres://localhost/db", wantOK: false},
+		{name: "variable expansion", value: "${HOME}", wantOK: false},
+		{name: "reference embedded in value", value: "prefix-${vault:secret/app}", wantOK: false},
+		{name: "bare op scheme", value: "op://", wantOK: false},
+		{name: "empty path", value: "${vault:}", wantOK: false},
+		{name: "shell default", value: "${HOST:-localhost}", wantOK: false},
+		{name: "shell assign default", value: "${PORT:=3000}", wantOK: false},
+		{name: "shell error if unset", value: "${TOKEN:?required}", wantOK: false},
+		{name: "shell alternate value", value: "${DEBUG:+1}", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseSecretRef(tt.value)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseSecretRef(%q) ok = %v, want %v", tt.value, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("ParseSecretRef(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSecret(t *testing.T) {
+	tests := []struct {
+		key   string
+		value string
+		want  bool
+	}{
+		{key: "API_KEY", value: "abc", want: true},
+		{key: "STRIPE_SECRET", value: "sk_test", want: true},
+		{key: "DB_PASSWORD", value: "hunter2", want: true},
+		{key: "github_token", value: "ghp_x", want: true},
+		{key: "AWS_ACCESS_KEY_ID", value: "AKIA", want: true},
+		{key: "JWT_PRIVATE_KEY", value: "pem", want: true},
+		{key: "DATABASE_URL", value: "op://dev/db/url", want: true},
+		{key: "DATABASE_URL", value: "postgres://localhost/db", want: false},
+		{key: "PORT", value: "3000", want: false},
+		{key: "KEYBOARD_LAYOUT", value: "us", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsSecret(tt.key, tt.value); got != tt.want {
+			t.Errorf("IsSecret(%q, %q) = %v, want %v", tt.key, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCommandSecretResolver_Resolve(t *testing.T) {
+	resolver := NewCommandSecretResolver(map[string]string{
+		"aws": "aws secretsmanager get-secret-value --secret-id {path} --query SecretString",
+	})
+
+	var calls []string
+	resolver.runCommand = func(name string, args ...string) (string, error) {
+		calls = append(calls, name+" "+strings.Join(args, " "))
+		return "s3cret\n", nil
+	}
+
+	vars := map[string]string{
+		"DB_PASSWORD":  "op://dev/db/password",
+		"DB_PASSWORD2": "op://dev/db/password",
+		"API_KEY":      "${vault:secret/app#api_key}",
+		"TOKEN":        "${vault:secret/token}",
+		"AWS_SECRET":   "${aws:prod/app}",
+		"PLAIN":        "value",
+		"HOST":         "${HOST:-localhost}",
+	}
+
+	resolved, err := ResolveSecrets(vars, resolver)
+	if err != nil {
+		t.Fatalf("ResolveSecrets() error = %v", err)
+	}
+
+	for k := range vars {
+		want := "s3cret"
+		switch k {
+		case "PLAIN", "HOST":
+			// Plain values and shell defaults pass through unchanged
+			want = vars[k]
+		}
+		if resolved[k] != want {
+			t.Errorf("resolved[%s] = %q, want %q", k, resolved[k], want)
+		}
+	}
+
+	wantCalls := []string{
+		"vault kv get -field=api_key secret/app",
+		"aws secretsmanager get-secret-value --secret-id prod/app --query SecretString",
+		"op read op://dev/db/password",
+		"vault kv get -field=value secret/token",
+	}
+	if strings.Join(calls, "\n") != strings.Join(wantCalls, "\n") {
+		t.Errorf("commands run:\n%s\nwant:\n%s", strings.Join(calls, "\n"), strings.Join(wantCalls, "\n"))
+	}
+
+	if vars["DB_PASSWORD"] != "op://dev/db/password" {
+		t.Error("ResolveSecrets() should not modify the input map")
+	}
+}
+
+func TestCommandSecretResolver_Errors(t *testing.T) {
+	resolver := NewCommandSecretResolver(nil)
+	resolver.runCommand = func(name string, args ...string) (string, error) {
+		return "", errors.New("not signed in")
+	}
+
+	_, err := ResolveSecrets(map[string]string{"DB_PASSWORD": "op://dev/db/password"}, resolver)
+	if err == nil {
+		t.Fatal("expected error when resolver command fails")
+	}
+	if !strings.Contains(err.Error(), "DB_PASSWORD") || !strings.Contains(err.Error(), "not signed in") {
+		t.Errorf("error should name the variable and cause, got: %v", err)
+	}
+
+	_, err = ResolveSecrets(map[string]string{"KEY": "${unknown:path}"}, resolver)
+	if err == nil || !strings.Contains(err.Error(), `no resolver configured for secret scheme "unknown"`) {
+		t.Errorf("expected unknown scheme error, got: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Template is a text/template that is rendered with an environment exposed
+// as .Env, e.g. {{ .Env.DATABASE_URL }}
+type Template struct {
+	// MissingDefault, when non-nil, is rendered for referenced variables that
+	// are not set instead of failing
+	MissingDefault *string
+
+	name string
+	tmpl *template.Template
+	// keys lists the variables referenced as .Env.KEY, in order of first use
+	keys []string
+}
+
+// templateData is the value a Template is executed with
+type templateData struct {
+	Env map[string]string
+}
+
+// ParseTemplate parses a template and records the variables it references
+func ParseTemplate(name, text string) (*Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	t := &Template{name: name, tmpl: tmpl}
+	seen := make(map[string]bool)
+	for _, associated := range tmpl.Templates() {
+		if associated.Tree == nil {
+			continue
+		}
+		walkTemplateNode(associated.Tree.Root, func(key string) {
+			if !seen[key] {
+				seen[key] = true
+				t.keys = append(t.keys, key)
+			}
+		})
+	}
+	return t, nil
+}
+
+// Keys returns the variables the template references as .Env.KEY
+func (t *Template) Keys() []string {
+	return t.keys
+}
+
+// Render executes the template with vars. Referenced variables missing from
+// vars are an error listing all of them, unless MissingDefault is set.
+func (t *Template) Render(vars map[string]string) ([]byte, error) {
+	var missing []string
+	for _, key := range t.keys {
+		if _, ok := vars[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		if t.MissingDefault == nil {
+			return nil, fmt.Errorf("template %s references undefined variable(s): %s", t.name, strings.Join(missing, ", "))
+		}
+		withDefaults := make(map[string]string, len(vars)+len(missing))
+		for k, v := range vars {
+			withDefaults[k] = v
+		}
+		for _, key := range missing {
+			withDefaults[key] = *t.MissingDefault
+		}
+		vars = withDefaults
+	}
+
+	var out bytes.Buffer
+	if err := t.tmpl.Execute(&out, templateData{Env: vars}); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// walkTemplateNode calls found for every .Env.KEY (or $.Env.KEY) reference
+// below node. References made some other way, such as inside {{ with .Env }},
+// are only caught when the template is executed.
+func walkTemplateNode(node parse.Node, found func(key string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplateNode(child, found)
+		}
+	case *parse.ActionNode:
+		walkTemplateNode(n.Pipe, found)
+	case *parse.IfNode:
+		walkTemplateBranch(&n.BranchNode, found)
+	case *parse.RangeNode:
+		walkTemplateBranch(&n.BranchNode, found)
+	case *parse.WithNode:
+		walkTemplateBranch(&n.BranchNode, found)
+	case *parse.TemplateNode:
+		walkTemplateNode(n.Pipe, found)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkTemplateNode(cmd, found)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkTemplateNode(arg, found)
+		}
+	case *parse.FieldNode:
+		envFieldKey(n.Ident, found)
+	case *parse.VariableNode:
+		if len(n.Ident) > 0 && n.Ident[0] == "$" {
+			envFieldKey(n.Ident[1:], found)
+		}
+	}
+}
+
+// walkTemplateBranch walks the pipeline and both lists of an if, range or with
+func walkTemplateBranch(n *parse.BranchNode, found func(key string)) {
+	walkTemplateNode(n.Pipe, found)
+	walkTemplateNode(n.List, found)
+	walkTemplateNode(n.ElseList, found)
+}
+
+// envFieldKey reports KEY for the field chain Env.KEY
+func envFieldKey(ident []string, found func(key string)) {
+	if len(ident) >= 2 && ident[0] == "Env" {
+		found(ident[1])
+	}
+}
@@ -0,0 +1,88 @@
+package env
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTemplateKeys(t *testing.T) {
+	text := `{"db": "{{ .Env.DATABASE_URL }}", "port": {{ $.Env.PORT }}}
+{{ if .Env.DEBUG }}{{ range $i, $x := .Env }}{{ end }}{{ end }}
+{{ define "extra" }}{{ .Env.API_KEY }}{{ .Env.PORT }}{{ end }}`
+
+	tmpl, err := ParseTemplate("config.json.tmpl", text)
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+
+	want := []string{"DATABASE_URL", "PORT", "DEBUG", "API_KEY"}
+	got := tmpl.Keys()
+	if len(got) != len(want) {
+		t.Fatalf("Keys() = %v, want %v", got, want)
+	}
+	// Associated templates are walked in no particular order
+	seen := make(map[string]bool)
+	for _, key := range got {
+		seen[key] = true
+	}
+	for _, key := range want {
+		if !seen[key] {
+			t.Errorf("Keys() = %v, missing %s", got, key)
+		}
+	}
+}
+
+func TestTemplateRender(t *testing.T) {
+	tmpl, err := ParseTemplate("config.tmpl", "db={{ .Env.DATABASE_URL }} port={{ .Env.PORT }} key={{ .Env.API_KEY }}\n")
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	vars := map[string]string{"DATABASE_URL": "postgres://localhost/db", "PORT": "4001"}
+
+	// Missing variables are reported together
+	_, err = tmpl.Render(vars)
+	if err == nil || !strings.Contains(err.Error(), "undefined variable(s): API_KEY") {
+		t.Errorf("Render() error = %v, want undefined API_KEY", err)
+	}
+
+	empty := ""
+	tmpl.MissingDefault = &empty
+	got, err := tmpl.Render(vars)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "db=postgres://localhost/db port=4001 key=\n"; string(got) != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	// Defaults never leak into the caller's map
+	if _, ok := vars["API_KEY"]; ok {
+		t.Error("Render() modified vars")
+	}
+
+	vars["API_KEY"] = "secret"
+	tmpl.MissingDefault = nil
+	got, err = tmpl.Render(vars)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "db=postgres://localhost/db port=4001 key=secret\n"; string(got) != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestTemplateRender_DynamicMissingKey(t *testing.T) {
+	tmpl, err := ParseTemplate("config.tmpl", "{{ with .Env }}{{ .MISSING }}{{ end }}")
+	if err != nil {
+		t.Fatalf("ParseTemplate() error = %v", err)
+	}
+	if _, err := tmpl.Render(map[string]string{"PORT": "3000"}); err == nil {
+		t.Error("Render() expected error for a missing key outside .Env.KEY")
+	}
+}
+
+func TestParseTemplate_SyntaxError(t *testing.T) {
+	if _, err := ParseTemplate("bad.tmpl", "{{ .Env.PORT "); err == nil {
+		t.Error("ParseTemplate() expected syntax error")
+	}
+}
@@ -0,0 +1,138 @@
+package errors
+
+import (
+	"strings"
+)
+
+// errorTypeCodes are the stable codes ErrorTypes are reported as in JSON
+var errorTypeCodes = map[ErrorType]string{
+	ErrConfigNotFound:          "config_not_found",
+	ErrConfigInvalid:           "config_invalid",
+	ErrConfigExists:            "config_exists",
+	ErrRegistryCorrupted:       "registry_corrupted",
+	ErrContextNotFound:         "context_not_found",
+	ErrServiceNotFound:         "service_not_found",
+	ErrPortConflict:            "port_conflict",
+	ErrServiceNotDetected:      "service_not_detected",
+	ErrContextDetectionFailed:  "context_detection_failed",
+	ErrPortCalculationFailed:   "port_calculation_failed",
+	ErrCommandFailed:           "command_failed",
+	ErrEnvNotFound:             "env_not_found",
+	ErrEnvParseFailed:          "env_parse_failed",
+	ErrEnvConflict:             "env_conflict",
+	ErrPermissionDenied:        "permission_denied",
+	ErrProjectRootNotFound:     "project_root_not_found",
+	ErrWorktreeDetectionFailed: "worktree_detection_failed",
+}
+
+// CodeUnknown is the code of errors that carry no ErrorType
+const CodeUnknown = "error"
+
+// String returns the stable code of the error type, e.g. "config_invalid"
+func (t ErrorType) String() string {
+	if code, ok := errorTypeCodes[t]; ok {
+		return code
+	}
+	return CodeUnknown
+}
+
+// JSONError is the machine-readable form of an error, for tools wrapping dual
+type JSONError struct {
+	Code    string            `json:"code"`
+	Message string            `json:"message"`
+	Context map[string]string `json:"context,omitempty"`
+	Cause   string            `json:"cause,omitempty"`
+	Fixes   []string          `json:"fixes,omitempty"`
+
+	// Problems lists every structured error when err holds more than one
+	// (e.g. all configuration problems found at once)
+	Problems []JSONError `json:"problems,omitempty"`
+}
+
+// ToJSON converts any error into a JSONError. The message is err's text
+// without its "Hint:" lines, which become fixes. Code, context, cause and
+// further fixes come from the first *Error wrapped in err; errors without one
+// get CodeUnknown.
+func ToJSON(err error) JSONError {
+	result := JSONError{Code: CodeUnknown}
+
+	var lines []string
+	for _, line := range strings.Split(err.Error(), "\n") {
+		if hint, ok := strings.CutPrefix(strings.TrimSpace(line), "Hint: "); ok {
+			result.Fixes = append(result.Fixes, hint)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	result.Message = strings.TrimSpace(strings.Join(lines, "\n"))
+
+	structured := collect(err, nil)
+	if len(structured) == 0 {
+		return result
+	}
+
+	first := structured[0]
+	result.Code = first.Type.String()
+	if len(first.Context) > 0 {
+		result.Context = first.Context
+	}
+	if first.Cause != nil {
+		result.Cause = first.Cause.Error()
+	}
+	result.Fixes = appendMissing(result.Fixes, first.Fixes...)
+
+	if len(structured) > 1 {
+		for _, e := range structured {
+			problem := JSONError{Code: e.Type.String(), Message: e.Message, Fixes: appendMissing(nil, e.Fixes...)}
+			if len(e.Context) > 0 {
+				problem.Context = e.Context
+			}
+			if e.Cause != nil {
+				problem.Cause = e.Cause.Error()
+			}
+			result.Problems = append(result.Problems, problem)
+		}
+	}
+
+	return result
+}
+
+// collect appends every *Error in err's tree, depth first, without descending
+// into an *Error's own cause
+func collect(err error, found []*Error) []*Error {
+	if e, ok := err.(*Error); ok {
+		return append(found, e)
+	}
+
+	switch wrapped := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, child := range wrapped.Unwrap() {
+			found = collect(child, found)
+		}
+	case interface{ Unwrap() error }:
+		if child := wrapped.Unwrap(); child != nil {
+			found = collect(child, found)
+		}
+	}
+	return found
+}
+
+// appendMissing appends the non-blank values not already in list
+func appendMissing(list []string, values ...string) []string {
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		seen := false
+		for _, existing := range list {
+			if existing == v {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestErrorType_String(t *testing.T) {
+	if got := ErrConfigInvalid.String(); got != "config_invalid" {
+		t.Errorf("ErrConfigInvalid.String() = %q, want config_invalid", got)
+	}
+	for errType := ErrConfigNotFound; errType <= ErrWorktreeDetectionFailed; errType++ {
+		if errType.String() == CodeUnknown {
+			t.Errorf("ErrorType %d has no code", errType)
+		}
+	}
+	if got := ErrorType(999).String(); got != CodeUnknown {
+		t.Errorf("unknown ErrorType String() = %q, want %q", got, CodeUnknown)
+	}
+}
+
+func TestToJSON_PlainError(t *testing.T) {
+	err := fmt.Errorf("context %q not found\nHint: Run 'dual create <branch>'", "feature")
+
+	got := ToJSON(err)
+	want := JSONError{
+		Code:    CodeUnknown,
+		Message: `context "feature" not found`,
+		Fixes:   []string{"Run 'dual create <branch>'"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToJSON() = %+v, want %+v", got, want)
+	}
+}
+
+func TestToJSON_WrappedError(t *testing.T) {
+	structured := New(ErrConfigInvalid, "Service path does not exist").
+		WithContext("Service", "api").
+		WithCause(errors.New("stat failed")).
+		WithFixes("Create the directory", "", "Create the directory")
+	err := fmt.Errorf("failed to load config: %w\nHint: Run 'dual init'", structured)
+
+	got := ToJSON(err)
+	want := JSONError{
+		Code:    "config_invalid",
+		Message: "failed to load config: Service path does not exist",
+		Context: map[string]string{"Service": "api"},
+		Cause:   "stat failed",
+		Fixes:   []string{"Run 'dual init'", "Create the directory"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ToJSON() = %+v, want %+v", got, want)
+	}
+}
+
+func TestToJSON_MultipleErrors(t *testing.T) {
+	err := errors.Join(
+		New(ErrConfigInvalid, "first problem").WithFix("fix first"),
+		fmt.Errorf("wrapped: %w", New(ErrServiceNotFound, "second problem")),
+	)
+
+	got := ToJSON(err)
+	if got.Code != "config_invalid" {
+		t.Errorf("Code = %q, want the first problem's code", got.Code)
+	}
+	if len(got.Problems) != 2 {
+		t.Fatalf("expected 2 problems, got %+v", got.Problems)
+	}
+	if got.Problems[0].Message != "first problem" || got.Problems[1].Code != "service_not_found" {
+		t.Errorf("unexpected problems: %+v", got.Problems)
+	}
+}
@@ -7,10 +7,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/lightfastai/dual/internal/config"
 	"github.com/lightfastai/dual/internal/context"
+	"github.com/lightfastai/dual/internal/env"
 	"github.com/lightfastai/dual/internal/registry"
 	"github.com/lightfastai/dual/internal/service"
 	"github.com/lightfastai/dual/internal/worktree"
@@ -156,8 +158,12 @@ func CheckCurrentContext(ctx *CheckerContext) Check {
 	check := NewCheck("Current Context", StatusPass, "")
 
 	if ctx.CurrentContext == "" {
-		// Try to detect
-		detectedContext, err := context.DetectContext()
+		// Try to detect, honoring the configured precedence when there is a config
+		source := context.SourceBranch
+		if ctx.Config != nil {
+			source = ctx.Config.GetContextSource()
+		}
+		detectedContext, err := context.DetectContextWithSource(source)
 		if err != nil {
 			return check.
 				WithStatus(StatusError).
@@ -196,6 +202,80 @@ func CheckCurrentContext(ctx *CheckerContext) Check {
 	return check.WithMessage(fmt.Sprintf("Context: %s", ctx.CurrentContext))
 }
 
+// CheckContextFile validates the .dual-context file that context detection
+// reads, if there is one. A stale file silently takes over from branch
+// detection (or is ignored when empty), which is easy to miss.
+func CheckContextFile(ctx *CheckerContext) Check {
+	check := NewCheck("Context File", StatusPass, "")
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return check.
+			WithStatus(StatusWarn).
+			WithMessage("Cannot determine the current directory").
+			WithError(err)
+	}
+
+	path, found := context.FindDualContextFile(cwd)
+	if !found {
+		return check.WithMessage("No .dual-context file")
+	}
+
+	// #nosec G304 - path is the .dual-context file found above the working directory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return check.
+			WithStatus(StatusError).
+			WithMessage("Cannot read .dual-context file").
+			WithDetails(fmt.Sprintf("Path: %s", path)).
+			WithError(err)
+	}
+
+	name := strings.TrimSpace(string(data))
+	var problem string
+	switch {
+	case name == "":
+		problem = "is empty, so it is ignored"
+	case strings.ContainsAny(name, "\r\n"):
+		problem = "has more than one line"
+	case strings.ContainsAny(name, " \t"):
+		problem = "contains whitespace"
+	}
+
+	if problem != "" {
+		if ctx.AutoFix {
+			if err := os.Remove(path); err == nil {
+				return check.
+					WithMessage(fmt.Sprintf("Removed .dual-context file that %s", problem)).
+					WithDetails(fmt.Sprintf("Path: %s", path)).
+					WithFixApplied()
+			}
+		}
+		return check.
+			WithStatus(StatusWarn).
+			WithMessage(fmt.Sprintf(".dual-context file %s", problem)).
+			WithDetails(fmt.Sprintf("Path: %s", path)).
+			WithFixAction("Run 'dual doctor --fix' to remove it, or write a single context name to it")
+	}
+
+	details := []string{fmt.Sprintf("Path: %s", path)}
+	if ctx.Config != nil {
+		details = append(details, fmt.Sprintf("Context source: %s", ctx.Config.GetContextSource()))
+	}
+
+	if ctx.Registry != nil && ctx.ProjectID != "" && !ctx.Registry.ContextExists(ctx.ProjectID, name) {
+		return check.
+			WithStatus(StatusWarn).
+			WithMessage(fmt.Sprintf(".dual-context file names context '%s', which is not in the registry", name)).
+			WithDetails(details...).
+			WithFixAction("Run 'dual list' to see registered contexts, then update or remove the file")
+	}
+
+	return check.
+		WithMessage(fmt.Sprintf(".dual-context file names context '%s'", name)).
+		WithDetails(details...)
+}
+
 // CheckServicePaths validates that all service paths exist
 func CheckServicePaths(ctx *CheckerContext) Check {
 	check := NewCheck("Service Paths", StatusPass, "")
@@ -252,7 +332,9 @@ func CheckServicePaths(ctx *CheckerContext) Check {
 		WithDetails(validPaths...)
 }
 
-// CheckEnvironmentFiles validates environment files
+// CheckEnvironmentFiles validates environment files. A configured file that
+// does not exist yet is only a warning, since dual tolerates missing env files;
+// a path that points at a directory can never be loaded and is an error.
 func CheckEnvironmentFiles(ctx *CheckerContext) Check {
 	check := NewCheck("Environment Files", StatusPass, "")
 
@@ -260,31 +342,40 @@ func CheckEnvironmentFiles(ctx *CheckerContext) Check {
 		return check.WithStatus(StatusWarn).WithMessage("No configuration loaded")
 	}
 
-	var issues []string
+	var missing []string
+	var unreadable []string
 	var validFiles []string
 	hasEnvFiles := false
 
-	// Check base env file
-	if ctx.Config.Env.BaseFile != "" {
+	checkFile := func(label, relPath, fix string) {
 		hasEnvFiles = true
-		baseFilePath := filepath.Join(ctx.ProjectRoot, ctx.Config.Env.BaseFile)
-		if _, err := os.Stat(baseFilePath); os.IsNotExist(err) {
-			issues = append(issues, fmt.Sprintf("Base env file not found: %s", ctx.Config.Env.BaseFile))
-		} else {
-			validFiles = append(validFiles, fmt.Sprintf("Base: %s", ctx.Config.Env.BaseFile))
+		info, err := os.Stat(filepath.Join(ctx.ProjectRoot, relPath))
+		switch {
+		case os.IsNotExist(err):
+			missing = append(missing, fmt.Sprintf("%s: %s (not found; create it, or remove %s if it is not needed)", label, relPath, fix))
+		case err != nil:
+			unreadable = append(unreadable, fmt.Sprintf("%s: %s (error: %v)", label, relPath, err))
+		case info.IsDir():
+			unreadable = append(unreadable, fmt.Sprintf("%s: %s (is a directory; point %s at a file such as %s)", label, relPath, fix, filepath.Join(relPath, ".env")))
+		default:
+			validFiles = append(validFiles, fmt.Sprintf("%s: %s", label, relPath))
 		}
 	}
 
+	// Check base env file
+	if ctx.Config.Env.BaseFile != "" {
+		checkFile("Base", ctx.Config.Env.BaseFile, "env.baseFile")
+	}
+
 	// Check service env files
-	for name, svc := range ctx.Config.Services {
-		if svc.EnvFile != "" {
-			hasEnvFiles = true
-			envFilePath := filepath.Join(ctx.ProjectRoot, svc.EnvFile)
-			if _, err := os.Stat(envFilePath); os.IsNotExist(err) {
-				issues = append(issues, fmt.Sprintf("Service '%s' env file not found: %s", name, svc.EnvFile))
-			} else {
-				validFiles = append(validFiles, fmt.Sprintf("%s: %s", name, svc.EnvFile))
-			}
+	serviceNames := make([]string, 0, len(ctx.Config.Services))
+	for name := range ctx.Config.Services {
+		serviceNames = append(serviceNames, name)
+	}
+	sort.Strings(serviceNames)
+	for _, name := range serviceNames {
+		if envFile := ctx.Config.Services[name].EnvFile; envFile != "" {
+			checkFile(name, envFile, "envFile")
 		}
 	}
 
@@ -295,11 +386,19 @@ func CheckEnvironmentFiles(ctx *CheckerContext) Check {
 			WithFixAction("Add env.baseFile or service envFile in dual.config.yml if needed")
 	}
 
-	if len(issues) > 0 {
+	if len(unreadable) > 0 {
+		return check.
+			WithStatus(StatusError).
+			WithMessage(fmt.Sprintf("%d environment file path(s) cannot be read as files", len(unreadable))).
+			WithDetails(append(unreadable, missing...)...).
+			WithFixAction("Update env.baseFile and service envFile paths in dual.config.yml to point at files")
+	}
+
+	if len(missing) > 0 {
 		return check.
 			WithStatus(StatusWarn).
-			WithMessage(fmt.Sprintf("%d environment file(s) not found", len(issues))).
-			WithDetails(issues...).
+			WithMessage(fmt.Sprintf("%d environment file(s) not found", len(missing))).
+			WithDetails(missing...).
 			WithFixAction("Create missing .env files or update paths in dual.config.yml")
 	}
 
@@ -414,6 +513,238 @@ func CheckOrphanedContexts(ctx *CheckerContext) Check {
 	return check.WithMessage("No orphaned contexts found")
 }
 
+// CheckUntrackedWorktrees compares the worktrees git knows about with the
+// project's contexts. Linked worktrees without a context are reported and,
+// with AutoFix, registered under their branch name. Contexts pointing at an
+// existing directory that is not a git worktree are reported as well; missing
+// paths are left to CheckOrphanedContexts.
+func CheckUntrackedWorktrees(ctx *CheckerContext) Check {
+	check := NewCheck("Worktree Contexts", StatusPass, "")
+
+	if ctx.Registry == nil || ctx.ProjectID == "" {
+		return check.WithStatus(StatusWarn).WithMessage("Cannot check without registry")
+	}
+
+	worktrees, err := worktree.NewDetector().ListWorktrees(ctx.ProjectID)
+	if err != nil {
+		return check.
+			WithStatus(StatusWarn).
+			WithMessage("Cannot list git worktrees").
+			WithError(err)
+	}
+
+	contexts, err := ctx.Registry.ListContexts(ctx.ProjectID)
+	if err != nil && !errors.Is(err, registry.ErrProjectNotFound) {
+		return check.
+			WithStatus(StatusWarn).
+			WithMessage("Cannot list contexts").
+			WithError(err)
+	}
+
+	contextByPath := make(map[string]string, len(contexts))
+	for name, regCtx := range contexts {
+		if regCtx.Path != "" {
+			contextByPath[CanonicalPath(regCtx.Path)] = name
+		}
+	}
+
+	gitPaths := make(map[string]bool, len(worktrees))
+	var untracked, registered []string
+	registerable := 0
+	for i, wt := range worktrees {
+		path := CanonicalPath(wt.Path)
+		gitPaths[path] = true
+
+		// The main checkout and bare repositories don't need a context
+		if i == 0 || wt.Bare {
+			continue
+		}
+		if _, ok := contextByPath[path]; ok {
+			continue
+		}
+
+		entry := fmt.Sprintf("Worktree without context: %s", wt.Path)
+		switch {
+		case wt.Branch == "":
+			untracked = append(untracked, fmt.Sprintf("%s (detached HEAD, adopt it with 'dual adopt --name <context> %s')", entry, wt.Path))
+			continue
+		case ctx.Registry.ContextExists(ctx.ProjectID, wt.Branch):
+			untracked = append(untracked, fmt.Sprintf("%s (context %q already points elsewhere, adopt it with 'dual adopt --name <context> %s')", entry, wt.Branch, wt.Path))
+			continue
+		}
+
+		registerable++
+		if ctx.AutoFix {
+			if err := ctx.Registry.SetContext(ctx.ProjectID, wt.Branch, wt.Path); err == nil {
+				registered = append(registered, fmt.Sprintf("Registered context %s for %s", wt.Branch, wt.Path))
+				continue
+			}
+		}
+		untracked = append(untracked, fmt.Sprintf("%s (branch %s)", entry, wt.Branch))
+	}
+
+	var stale []string
+	for _, name := range sortedContextNames(contexts) {
+		regCtx := contexts[name]
+		if regCtx.Path == "" || gitPaths[CanonicalPath(regCtx.Path)] {
+			continue
+		}
+		if _, err := os.Stat(regCtx.Path); err != nil {
+			continue
+		}
+		stale = append(stale, fmt.Sprintf("Context %s points to %s, which is not a git worktree", name, regCtx.Path))
+	}
+
+	if len(registered) > 0 {
+		if err := ctx.Registry.SaveRegistry(); err != nil {
+			return check.
+				WithStatus(StatusError).
+				WithMessage("Failed to save registry after registering worktrees").
+				WithError(err)
+		}
+	}
+
+	sort.Strings(untracked)
+	if len(untracked) > 0 || len(stale) > 0 {
+		check = check.
+			WithStatus(StatusWarn).
+			WithMessage(fmt.Sprintf("Found %d worktree(s) without a context and %d context(s) without a worktree", len(untracked), len(stale))).
+			WithDetails(append(append(untracked, stale...), registered...)...)
+		if registerable > len(registered) {
+			return check.WithFixAction("Run 'dual doctor --fix' to register worktrees as contexts named after their branch")
+		}
+		return check.WithFixAction("Adopt worktrees with 'dual adopt', or remove stale contexts with 'dual delete'")
+	}
+
+	if len(registered) > 0 {
+		return check.
+			WithMessage(fmt.Sprintf("Registered %d worktree(s) as contexts", len(registered))).
+			WithDetails(registered...).
+			WithFixApplied()
+	}
+
+	return check.WithMessage("Git worktrees and contexts are in sync")
+}
+
+// CanonicalPath cleans a path and resolves symlinks where possible, so paths
+// reported by git and stored in the registry compare equal
+func CanonicalPath(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
+// sortedContextNames returns context names in alphabetical order
+func sortedContextNames(contexts map[string]registry.Context) []string {
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CheckLegacyEnvOverrides finds contexts still storing overrides in the
+// pre-V2 EnvOverrides field and, with AutoFix, migrates them to EnvOverridesV2
+func CheckLegacyEnvOverrides(ctx *CheckerContext) Check {
+	check := NewCheck("Legacy Env Overrides", StatusPass, "")
+
+	if ctx.Registry == nil {
+		return check.WithStatus(StatusWarn).WithMessage("Cannot check without registry")
+	}
+
+	var legacy []string
+	var migrated []string
+
+	for projectPath, project := range ctx.Registry.Projects {
+		for contextName, regCtx := range project.Contexts {
+			//nolint:staticcheck // Intentionally reading the deprecated field to detect it
+			if len(regCtx.EnvOverrides) == 0 {
+				continue
+			}
+
+			//nolint:staticcheck // Intentionally reading the deprecated field to detect it
+			entry := fmt.Sprintf("%s:%s (%d override(s))", projectPath, contextName, len(regCtx.EnvOverrides))
+			legacy = append(legacy, entry)
+
+			if ctx.AutoFix {
+				if _, err := ctx.Registry.MigrateLegacyEnvOverrides(projectPath, contextName); err == nil {
+					migrated = append(migrated, entry)
+				}
+			}
+		}
+	}
+	sort.Strings(legacy)
+	sort.Strings(migrated)
+
+	if ctx.AutoFix && len(migrated) > 0 {
+		if err := ctx.Registry.SaveRegistry(); err == nil {
+			return check.
+				WithMessage(fmt.Sprintf("Migrated legacy overrides in %d context(s)", len(migrated))).
+				WithDetails(migrated...).
+				WithFixApplied()
+		}
+	}
+
+	if len(legacy) > 0 {
+		return check.
+			WithStatus(StatusWarn).
+			WithMessage(fmt.Sprintf("Found %d context(s) using legacy env overrides", len(legacy))).
+			WithDetails(legacy...).
+			WithFixAction("Run 'dual doctor --fix' to migrate them to the current registry format")
+	}
+
+	return check.WithMessage("All contexts use the current override format")
+}
+
+// CheckServiceEnvFiles detects generated service env files that are missing or
+// out of date with the current context's registry overrides and, with AutoFix,
+// regenerates them (the equivalent of 'dual env remap')
+func CheckServiceEnvFiles(ctx *CheckerContext) Check {
+	check := NewCheck("Service Env Files", StatusPass, "")
+
+	if ctx.Config == nil || ctx.Registry == nil {
+		return check.WithStatus(StatusWarn).WithMessage("Cannot check without configuration and registry")
+	}
+	if ctx.CurrentContext == "" {
+		return check.WithMessage("No current context, nothing to check")
+	}
+
+	drift, err := env.ServiceEnvFileDrift(ctx.Config, ctx.Registry, ctx.ProjectID, ctx.CurrentContext)
+	if err != nil {
+		return check.
+			WithStatus(StatusError).
+			WithMessage("Failed to compare service env files").
+			WithError(err)
+	}
+
+	if len(drift) == 0 {
+		return check.WithMessage("Service env files are up to date")
+	}
+
+	details := make([]string, 0, len(drift))
+	for serviceName, reason := range drift {
+		details = append(details, fmt.Sprintf("%s: %s", serviceName, reason))
+	}
+	sort.Strings(details)
+
+	if ctx.AutoFix {
+		if err := env.GenerateServiceEnvFiles(ctx.Config, ctx.Registry, ctx.ProjectID, ctx.ProjectID, ctx.CurrentContext); err == nil {
+			return check.
+				WithMessage(fmt.Sprintf("Regenerated %d service env file(s)", len(drift))).
+				WithDetails(details...).
+				WithFixApplied()
+		}
+	}
+
+	return check.
+		WithStatus(StatusWarn).
+		WithMessage(fmt.Sprintf("%d service env file(s) out of sync with registry", len(drift))).
+		WithDetails(details...).
+		WithFixAction("Run 'dual doctor --fix' or 'dual env remap' to regenerate them")
+}
+
 // CheckPermissions validates file permissions
 func CheckPermissions(ctx *CheckerContext) Check {
 	check := NewCheck("Permissions", StatusPass, "")
@@ -455,17 +786,85 @@ func CheckPermissions(ctx *CheckerContext) Check {
 		}
 	}
 
+	// Check hook scripts are executable, fixing them with --fix
+	fixAction := "Fix file permissions manually with chmod"
+	var fixed []string
+	for _, hookPath := range nonExecutableHookScripts(ctx.Config, ctx.ProjectRoot) {
+		if ctx.AutoFix {
+			if err := makeExecutable(hookPath); err == nil {
+				fixed = append(fixed, fmt.Sprintf("Made hook script executable: %s", hookPath))
+				continue
+			}
+		}
+		issues = append(issues, fmt.Sprintf("Hook script is not executable: %s", hookPath))
+		fixAction = "Run 'dual doctor --fix' to make hook scripts executable, or fix permissions manually with chmod"
+	}
+
 	if len(issues) > 0 {
 		return check.
 			WithStatus(StatusWarn).
 			WithMessage(fmt.Sprintf("Found %d permission issue(s)", len(issues))).
-			WithDetails(issues...).
-			WithFixAction("Fix file permissions manually with chmod")
+			WithDetails(append(issues, fixed...)...).
+			WithFixAction(fixAction)
+	}
+
+	if len(fixed) > 0 {
+		return check.
+			WithMessage(fmt.Sprintf("Made %d hook script(s) executable", len(fixed))).
+			WithDetails(fixed...).
+			WithFixApplied()
 	}
 
 	return check.WithMessage("All file permissions are correct")
 }
 
+// nonExecutableHookScripts returns the configured hook scripts that exist but
+// have no execute bit set, in sorted order. Missing scripts are reported by
+// config validation instead.
+func nonExecutableHookScripts(cfg *config.Config, projectRoot string) []string {
+	if cfg == nil || projectRoot == "" {
+		return nil
+	}
+
+	// Service hooks live in the same directory as project-level hooks
+	hookSets := []map[string][]string{cfg.Hooks}
+	for _, service := range cfg.Services {
+		hookSets = append(hookSets, service.Hooks)
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, hooks := range hookSets {
+		for _, scripts := range hooks {
+			for _, script := range scripts {
+				hookPath := filepath.Join(projectRoot, ".dual", "hooks", script)
+				if seen[hookPath] {
+					continue
+				}
+				seen[hookPath] = true
+
+				info, err := os.Stat(hookPath)
+				if err != nil || info.IsDir() || info.Mode()&0o111 != 0 {
+					continue
+				}
+				paths = append(paths, hookPath)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// makeExecutable adds an execute bit wherever the file has a read bit, like chmod +x
+func makeExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	mode := info.Mode().Perm()
+	return os.Chmod(path, mode|(mode&0o444)>>2)
+}
+
 // CheckServiceDetection validates service detection for current directory
 func CheckServiceDetection(ctx *CheckerContext) Check {
 	check := NewCheck("Service Detection", StatusPass, "")
@@ -485,7 +884,8 @@ func CheckServiceDetection(ctx *CheckerContext) Check {
 			WithError(err)
 	}
 
-	serviceName, err := service.DetectService(ctx.Config, ctx.ProjectRoot)
+	// DUAL_SERVICE takes precedence over detection, as in 'dual run'
+	serviceName, err := service.ResolveService(ctx.Config, ctx.ProjectRoot, "")
 	if err != nil {
 		if errors.Is(err, service.ErrServiceNotDetected) {
 			// This is OK - we might not be in a service directory
@@ -493,7 +893,14 @@ func CheckServiceDetection(ctx *CheckerContext) Check {
 				WithStatus(StatusWarn).
 				WithMessage("No service detected for current directory").
 				WithDetails(fmt.Sprintf("Current directory: %s", cwd)).
-				WithFixAction("Navigate to a service directory or use --service flag")
+				WithFixAction(fmt.Sprintf("Navigate to a service directory, use --service flag or set %s", service.EnvVar))
+		}
+		if errors.Is(err, service.ErrUnknownService) {
+			return check.
+				WithStatus(StatusError).
+				WithMessage(fmt.Sprintf("%s names a service that is not configured", service.EnvVar)).
+				WithError(err).
+				WithFixAction(fmt.Sprintf("Unset %s or set it to one of the configured services", service.EnvVar))
 		}
 		return check.
 			WithStatus(StatusError).
@@ -505,12 +912,65 @@ func CheckServiceDetection(ctx *CheckerContext) Check {
 		fmt.Sprintf("Current directory: %s", cwd),
 		fmt.Sprintf("Detected service: %s", serviceName),
 	}
+	if value := os.Getenv(service.EnvVar); value != "" {
+		details = append(details, fmt.Sprintf("Selected by %s=%s", service.EnvVar, value))
+	}
 
 	return check.
 		WithMessage(fmt.Sprintf("Service '%s' detected successfully", serviceName)).
 		WithDetails(details...)
 }
 
+// LowDiskSpaceThreshold is the free space below which CheckDiskSpace warns
+// and 'dual create' warns before creating a worktree
+const LowDiskSpaceThreshold uint64 = 1 << 30 // 1 GiB
+
+// CheckDiskSpace reports the free space on the filesystem that new worktrees
+// are created on, warning when it is low. Git fails with little explanation
+// when it runs out of space halfway through checking out a worktree.
+func CheckDiskSpace(ctx *CheckerContext) Check {
+	check := NewCheck("Disk Space", StatusPass, "")
+
+	if ctx.Config == nil {
+		return check.
+			WithStatus(StatusWarn).
+			WithMessage("Cannot determine the worktrees path without a configuration")
+	}
+
+	// Worktrees are created relative to the main repository
+	root := ctx.ProjectID
+	if root == "" {
+		root = ctx.ProjectRoot
+	}
+	worktreesPath := ctx.Config.GetWorktreePath(root)
+
+	free, err := worktree.FreeSpace(worktreesPath)
+	if err != nil {
+		return check.
+			WithStatus(StatusWarn).
+			WithMessage("Cannot determine free disk space").
+			WithError(err)
+	}
+
+	freeDisplay := worktree.FormatSize(int64(free))
+	details := []string{
+		fmt.Sprintf("Worktrees path: %s", worktreesPath),
+		fmt.Sprintf("Free space: %s", freeDisplay),
+	}
+
+	if free < LowDiskSpaceThreshold {
+		return check.
+			WithStatus(StatusWarn).
+			WithMessage(fmt.Sprintf("Only %s free where worktrees are created", freeDisplay)).
+			WithDetails(details...).
+			WithFixAction("Free up disk space, or create worktrees on another disk with 'dual create --path <dir>' or worktrees.path")
+	}
+
+	return check.
+		WithMessage(fmt.Sprintf("%s free where worktrees are created", freeDisplay)).
+		WithDetails(details...)
+}
+
 // Helper to update status
 func (c Check) WithStatus(status Status) Check {
 	c.Status = status
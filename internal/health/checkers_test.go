@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/lightfastai/dual/internal/config"
+	"github.com/lightfastai/dual/internal/context"
 	"github.com/lightfastai/dual/internal/registry"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -275,6 +276,27 @@ func TestCheckEnvironmentFiles(t *testing.T) {
 		assert.Equal(t, StatusWarn, check.Status)
 		assert.Contains(t, check.Message, "not found")
 	})
+
+	t.Run("Env file path is a directory", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "apps", "api"), 0o755))
+
+		ctx := &CheckerContext{
+			Config: &config.Config{
+				Services: map[string]config.Service{
+					"api": {Path: "apps/api", EnvFile: "apps/api"},
+					"web": {Path: "apps/web", EnvFile: "apps/web/.env"},
+				},
+			},
+			ProjectRoot: tmpDir,
+		}
+
+		check := CheckEnvironmentFiles(ctx)
+		assert.Equal(t, StatusError, check.Status)
+		require.Len(t, check.Details, 2)
+		assert.Contains(t, check.Details[0], "api: apps/api (is a directory")
+		assert.Contains(t, check.Details[1], "web: apps/web/.env (not found")
+	})
 }
 
 func TestCheckOrphanedContexts(t *testing.T) {
@@ -329,6 +351,123 @@ func TestCheckOrphanedContexts(t *testing.T) {
 	})
 }
 
+func TestCheckLegacyEnvOverrides(t *testing.T) {
+	t.Run("No legacy overrides", func(t *testing.T) {
+		reg := &registry.Registry{
+			Projects: map[string]registry.Project{
+				"/project": {
+					Contexts: map[string]registry.Context{
+						"main": {},
+					},
+				},
+			},
+		}
+
+		check := CheckLegacyEnvOverrides(&CheckerContext{Registry: reg})
+		assert.Equal(t, StatusPass, check.Status)
+	})
+
+	t.Run("Legacy overrides detected", func(t *testing.T) {
+		reg := &registry.Registry{
+			Projects: map[string]registry.Project{
+				"/project": {
+					Contexts: map[string]registry.Context{
+						"main": {
+							EnvOverrides: map[string]string{"DEBUG": "true"},
+						},
+					},
+				},
+			},
+		}
+
+		check := CheckLegacyEnvOverrides(&CheckerContext{Registry: reg})
+		assert.Equal(t, StatusWarn, check.Status)
+		assert.Contains(t, check.Message, "legacy")
+		assert.Contains(t, check.FixAction, "--fix")
+	})
+
+	t.Run("Legacy overrides migrated with fix", func(t *testing.T) {
+		projectRoot := t.TempDir()
+		reg, err := registry.LoadRegistry(projectRoot)
+		require.NoError(t, err)
+		defer reg.Close()
+
+		reg.Projects[projectRoot] = registry.Project{
+			Contexts: map[string]registry.Context{
+				"main": {
+					EnvOverrides: map[string]string{"DEBUG": "true"},
+				},
+			},
+		}
+
+		check := CheckLegacyEnvOverrides(&CheckerContext{Registry: reg, AutoFix: true})
+		assert.Equal(t, StatusPass, check.Status)
+		assert.True(t, check.FixApplied)
+
+		regCtx, err := reg.GetContext(projectRoot, "main")
+		require.NoError(t, err)
+		assert.Empty(t, regCtx.EnvOverrides)
+		assert.Equal(t, "true", regCtx.EnvOverridesV2.Global["DEBUG"])
+	})
+}
+
+func TestCheckServiceEnvFiles(t *testing.T) {
+	newCtx := func(t *testing.T) *CheckerContext {
+		projectRoot := t.TempDir()
+		reg, err := registry.LoadRegistry(projectRoot)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = reg.Close() })
+
+		require.NoError(t, reg.SetContext(projectRoot, "dev", ""))
+		require.NoError(t, reg.SetEnvOverrideForService(projectRoot, "dev", "PORT", "4001", "api"))
+
+		return &CheckerContext{
+			Config: &config.Config{
+				Services: map[string]config.Service{"api": {Path: "apps/api"}},
+			},
+			ProjectRoot:    projectRoot,
+			ProjectID:      projectRoot,
+			Registry:       reg,
+			CurrentContext: "dev",
+		}
+	}
+
+	t.Run("No current context", func(t *testing.T) {
+		ctx := newCtx(t)
+		ctx.CurrentContext = ""
+
+		check := CheckServiceEnvFiles(ctx)
+		assert.Equal(t, StatusPass, check.Status)
+	})
+
+	t.Run("Drift detected", func(t *testing.T) {
+		ctx := newCtx(t)
+
+		check := CheckServiceEnvFiles(ctx)
+		assert.Equal(t, StatusWarn, check.Status)
+		assert.Contains(t, check.Details, "api: missing")
+		assert.Contains(t, check.FixAction, "--fix")
+	})
+
+	t.Run("Drift repaired with fix", func(t *testing.T) {
+		ctx := newCtx(t)
+		ctx.AutoFix = true
+
+		check := CheckServiceEnvFiles(ctx)
+		assert.Equal(t, StatusPass, check.Status)
+		assert.True(t, check.FixApplied)
+
+		content, err := os.ReadFile(filepath.Join(ctx.ProjectID, ".dual", ".local", "service", "api", ".env"))
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "PORT=4001")
+
+		ctx.AutoFix = false
+		check = CheckServiceEnvFiles(ctx)
+		assert.Equal(t, StatusPass, check.Status)
+		assert.Contains(t, check.Message, "up to date")
+	})
+}
+
 func TestCheckPermissions(t *testing.T) {
 	ctx := &CheckerContext{
 		ProjectRoot: t.TempDir(),
@@ -340,6 +479,44 @@ func TestCheckPermissions(t *testing.T) {
 	// Just ensure it runs without panic
 }
 
+func TestCheckPermissions_HookScripts(t *testing.T) {
+	projectRoot := t.TempDir()
+	hooksDir := filepath.Join(projectRoot, ".dual", "hooks")
+	require.NoError(t, os.MkdirAll(hooksDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooksDir, "setup.sh"), []byte("#!/bin/sh\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(hooksDir, "ok.sh"), []byte("#!/bin/sh\n"), 0o755))
+
+	ctx := &CheckerContext{
+		ProjectRoot: projectRoot,
+		Config: &config.Config{
+			Version: 1,
+			Hooks: map[string][]string{
+				"postWorktreeCreate": {"setup.sh", "ok.sh", "missing.sh"},
+				"preWorktreeDelete":  {"setup.sh"},
+			},
+		},
+	}
+
+	check := CheckPermissions(ctx)
+	assert.Equal(t, StatusWarn, check.Status)
+	assert.Equal(t, []string{"Hook script is not executable: " + filepath.Join(hooksDir, "setup.sh")}, check.Details)
+	assert.Contains(t, check.FixAction, "dual doctor --fix")
+
+	ctx.AutoFix = true
+	check = CheckPermissions(ctx)
+	assert.Equal(t, StatusPass, check.Status)
+	assert.True(t, check.FixApplied)
+
+	info, err := os.Stat(filepath.Join(hooksDir, "setup.sh"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+
+	ctx.AutoFix = false
+	check = CheckPermissions(ctx)
+	assert.Equal(t, StatusPass, check.Status)
+	assert.False(t, check.FixApplied)
+}
+
 func TestCheckWorktrees(t *testing.T) {
 	ctx := &CheckerContext{
 		ProjectRoot: t.TempDir(),
@@ -364,3 +541,99 @@ func TestCheckServiceDetection(t *testing.T) {
 		assert.Contains(t, check.Message, "No services configured")
 	})
 }
+
+func TestCheckDiskSpace(t *testing.T) {
+	t.Run("No config", func(t *testing.T) {
+		check := CheckDiskSpace(&CheckerContext{ProjectRoot: t.TempDir()})
+		assert.Equal(t, StatusWarn, check.Status)
+	})
+
+	t.Run("Worktrees path not created yet", func(t *testing.T) {
+		projectRoot := t.TempDir()
+		ctx := &CheckerContext{
+			Config:      &config.Config{Worktrees: config.WorktreeConfig{Path: "../worktrees"}},
+			ProjectRoot: projectRoot,
+		}
+
+		check := CheckDiskSpace(ctx)
+		assert.Equal(t, "Disk Space", check.Name)
+		assert.Contains(t, check.Message, "free where worktrees are created")
+		assert.Contains(t, check.Details, "Worktrees path: "+filepath.Join(projectRoot, "../worktrees"))
+		if check.Status == StatusPass {
+			assert.Empty(t, check.FixAction)
+		} else {
+			assert.Equal(t, StatusWarn, check.Status)
+			assert.Contains(t, check.FixAction, "--path")
+		}
+	})
+}
+
+func TestCheckContextFile(t *testing.T) {
+	reg := &registry.Registry{
+		Projects: map[string]registry.Project{
+			"/test/project": {
+				Contexts: map[string]registry.Context{
+					"feature-x": {Created: time.Now()},
+				},
+			},
+		},
+	}
+
+	t.Run("No file", func(t *testing.T) {
+		t.Chdir(t.TempDir())
+		check := CheckContextFile(&CheckerContext{})
+		assert.Equal(t, "Context File", check.Name)
+		assert.Equal(t, StatusPass, check.Status)
+		assert.Equal(t, "No .dual-context file", check.Message)
+	})
+
+	t.Run("Registered context in a parent directory", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, context.DualContextFile), []byte("feature-x\n"), 0o644))
+		sub := filepath.Join(dir, "apps", "web")
+		require.NoError(t, os.MkdirAll(sub, 0o755))
+		t.Chdir(sub)
+
+		check := CheckContextFile(&CheckerContext{Registry: reg, ProjectID: "/test/project"})
+		assert.Equal(t, StatusPass, check.Status)
+		assert.Contains(t, check.Message, "'feature-x'")
+	})
+
+	t.Run("Context not in registry", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, context.DualContextFile), []byte("old-feature\n"), 0o644))
+		t.Chdir(dir)
+
+		check := CheckContextFile(&CheckerContext{Registry: reg, ProjectID: "/test/project", AutoFix: true})
+		assert.Equal(t, StatusWarn, check.Status)
+		assert.Contains(t, check.Message, "not in the registry")
+		// Only malformed files are removed
+		assert.FileExists(t, filepath.Join(dir, context.DualContextFile))
+	})
+
+	for _, tc := range []struct {
+		name    string
+		content string
+		message string
+	}{
+		{"Empty", "  \n", "is empty"},
+		{"Multiple lines", "feature-x\nfeature-y\n", "more than one line"},
+		{"Whitespace", "feature x\n", "contains whitespace"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, context.DualContextFile)
+			require.NoError(t, os.WriteFile(path, []byte(tc.content), 0o644))
+			t.Chdir(dir)
+
+			check := CheckContextFile(&CheckerContext{})
+			assert.Equal(t, StatusWarn, check.Status)
+			assert.Contains(t, check.Message, tc.message)
+			assert.Contains(t, check.FixAction, "--fix")
+
+			check = CheckContextFile(&CheckerContext{AutoFix: true})
+			assert.True(t, check.FixApplied)
+			assert.NoFileExists(t, path)
+		})
+	}
+}
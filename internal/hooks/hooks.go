@@ -11,6 +11,7 @@ import (
 
 	"github.com/lightfastai/dual/internal/config"
 	dualerrors "github.com/lightfastai/dual/internal/errors"
+	"github.com/lightfastai/dual/internal/logger"
 )
 
 // Manager handles the execution of lifecycle hooks
@@ -35,14 +36,16 @@ func (m *Manager) Execute(event HookEvent, ctx HookContext) (*EnvOverrides, erro
 		return nil, fmt.Errorf("invalid hook event: %s", event)
 	}
 
-	// Get hook scripts for this event from config
+	// Get hook scripts for this event from config: project-level hooks first,
+	// then each service's own hooks
 	scripts := m.config.GetHookScripts(event.String())
-	if len(scripts) == 0 {
+	serviceHooks := m.config.GetServiceHookScripts(event.String())
+	if len(scripts) == 0 && len(serviceHooks) == 0 {
 		// No hooks defined for this event, not an error
 		return NewEnvOverrides(), nil
 	}
 
-	fmt.Fprintf(os.Stderr, "[dual] Running %s hooks (%d scripts)...\n", event, len(scripts))
+	logger.Info("Running %s hooks (%d scripts)...", event, len(scripts)+len(serviceHooks))
 
 	// Accumulate env overrides from all hooks
 	allOverrides := NewEnvOverrides()
@@ -57,6 +60,16 @@ func (m *Manager) Execute(event HookEvent, ctx HookContext) (*EnvOverrides, erro
 		allOverrides.Merge(overrides)
 	}
 
+	for _, hook := range serviceHooks {
+		serviceCtx := ctx
+		serviceCtx.Service = hook.Service
+		overrides, err := m.executeScript(hook.Script, serviceCtx)
+		if err != nil {
+			return nil, fmt.Errorf("hook %s (service %s) failed: %w", hook.Script, hook.Service, err)
+		}
+		allOverrides.Merge(overrides)
+	}
+
 	return allOverrides, nil
 }
 
@@ -108,19 +121,24 @@ func (m *Manager) executeScript(scriptName string, ctx HookContext) (*EnvOverrid
 
 	// Prepare environment variables
 	env := m.buildEnv(ctx)
+	workDir := m.workingDir(ctx)
 
 	// Execute the hook script
 	// #nosec G204 - Script path is controlled by config file (trusted source)
 	cmd := exec.Command(hookPath)
 	cmd.Env = append(os.Environ(), env...)
-	cmd.Dir = ctx.ContextPath // Run hook in context directory
+	cmd.Dir = workDir
 
 	// Capture stdout for parsing env overrides
 	var stdout strings.Builder
 	cmd.Stdout = io.MultiWriter(os.Stdout, &stdout)
 	cmd.Stderr = os.Stderr
 
-	fmt.Fprintf(os.Stderr, "[dual] Executing hook: %s\n", scriptName)
+	if ctx.Service != "" {
+		logger.Info("Executing hook: %s (service %s)", scriptName, ctx.Service)
+	} else {
+		logger.Info("Executing hook: %s", scriptName)
+	}
 
 	if err := cmd.Run(); err != nil {
 		// Try to get exit code if it's an ExitError
@@ -130,25 +148,27 @@ func (m *Manager) executeScript(scriptName string, ctx HookContext) (*EnvOverrid
 		dualErr := dualerrors.New(dualerrors.ErrCommandFailed, "Hook script execution failed")
 		dualErr = dualErr.WithContext("Script", scriptName)
 		dualErr = dualErr.WithContext("Path", hookPath)
-		dualErr = dualErr.WithContext("Working directory", ctx.ContextPath)
+		dualErr = dualErr.WithContext("Working directory", workDir)
 		dualErr = dualErr.WithContext("Event", ctx.Event.String())
+		if ctx.Service != "" {
+			dualErr = dualErr.WithContext("Service", ctx.Service)
+		}
 
 		if isExitErr && exitErr.ExitCode() != -1 {
 			dualErr = dualErr.WithContext("Exit code", fmt.Sprintf("%d", exitErr.ExitCode()))
 		}
 
 		dualErr = dualErr.WithCause(err)
-		dualErr = dualErr.WithFixes(
-			"Debug the hook script manually:",
-			fmt.Sprintf("  cd %s", ctx.ContextPath),
-			fmt.Sprintf("  export DUAL_EVENT=%s", ctx.Event),
-			fmt.Sprintf("  export DUAL_CONTEXT_NAME=%s", ctx.ContextName),
-			fmt.Sprintf("  export DUAL_CONTEXT_PATH=%s", ctx.ContextPath),
-			fmt.Sprintf("  export DUAL_PROJECT_ROOT=%s", ctx.ProjectRoot),
+		fixes := []string{"Debug the hook script manually:", fmt.Sprintf("  cd %s", workDir)}
+		for _, v := range env {
+			fixes = append(fixes, "  export "+v)
+		}
+		fixes = append(fixes,
 			fmt.Sprintf("  %s", hookPath),
 			"",
 			"Check the script output above for error messages",
 		)
+		dualErr = dualErr.WithFixes(fixes...)
 
 		return nil, dualErr
 	}
@@ -161,13 +181,25 @@ func (m *Manager) executeScript(scriptName string, ctx HookContext) (*EnvOverrid
 
 	// Log parsed overrides if any
 	if !overrides.IsEmpty() {
-		fmt.Fprintf(os.Stderr, "[dual] Hook %s produced %d global and %d service-specific env overrides\n",
+		logger.Info("Hook %s produced %d global and %d service-specific env overrides",
 			scriptName, len(overrides.Global), len(overrides.Services))
 	}
 
 	return overrides, nil
 }
 
+// workingDir returns the directory a hook for ctx.Event runs in, as configured
+// by hookWorkingDir (worktree by default, project root for postWorktreeDelete)
+func (m *Manager) workingDir(ctx HookContext) string {
+	if m.config.GetHookWorkingDir(ctx.Event.String()) == config.HookDirProject {
+		if ctx.ProjectRoot != "" {
+			return ctx.ProjectRoot
+		}
+		return m.projectRoot
+	}
+	return ctx.ContextPath
+}
+
 // buildEnv constructs the environment variables to pass to the hook script
 func (m *Manager) buildEnv(ctx HookContext) []string {
 	env := []string{
@@ -176,6 +208,9 @@ func (m *Manager) buildEnv(ctx HookContext) []string {
 		fmt.Sprintf("DUAL_CONTEXT_PATH=%s", ctx.ContextPath),
 		fmt.Sprintf("DUAL_PROJECT_ROOT=%s", ctx.ProjectRoot),
 	}
+	if ctx.Service != "" {
+		env = append(env, fmt.Sprintf("DUAL_SERVICE=%s", ctx.Service))
+	}
 
 	return env
 }
@@ -186,7 +221,7 @@ func (m *Manager) buildEnv(ctx HookContext) []string {
 func (m *Manager) ExecuteWithFallback(event HookEvent, ctx HookContext) *EnvOverrides {
 	overrides, err := m.Execute(event, ctx)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "[dual] Warning: hook execution failed (continuing anyway): %v\n", err)
+		logger.Warn("hook execution failed (continuing anyway): %v", err)
 		return NewEnvOverrides()
 	}
 	return overrides
@@ -3,6 +3,7 @@ package hooks
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/lightfastai/dual/internal/config"
@@ -182,3 +183,125 @@ exit 0
 		t.Error("Execute() returned nil overrides")
 	}
 }
+
+func TestManager_Execute_WorkingDir(t *testing.T) {
+	projectRoot := t.TempDir()
+	worktree := t.TempDir()
+
+	hooksDir := filepath.Join(projectRoot, ".dual", "hooks")
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		t.Fatalf("Failed to create hooks directory: %v", err)
+	}
+
+	// The hook records its working directory in a file next to itself
+	scriptContent := `#!/bin/sh
+pwd -P > "$DUAL_PROJECT_ROOT/.dual/hooks/cwd-$DUAL_EVENT"
+`
+	if err := os.WriteFile(filepath.Join(hooksDir, "pwd.sh"), []byte(scriptContent), 0o755); err != nil {
+		t.Fatalf("Failed to write hook script: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		event   HookEvent
+		dirs    map[string]string
+		wantDir string
+	}{
+		{name: "postWorktreeCreate defaults to worktree", event: PostWorktreeCreate, wantDir: worktree},
+		{name: "postWorktreeDelete defaults to project", event: PostWorktreeDelete, wantDir: projectRoot},
+		{
+			name:    "configured project dir",
+			event:   PreWorktreeDelete,
+			dirs:    map[string]string{"preWorktreeDelete": config.HookDirProject},
+			wantDir: projectRoot,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Version:        1,
+				Hooks:          map[string][]string{tt.event.String(): {"pwd.sh"}},
+				HookWorkingDir: tt.dirs,
+			}
+
+			manager := NewManager(cfg, projectRoot)
+			ctx := HookContext{
+				Event:       tt.event,
+				ContextName: "test",
+				ContextPath: worktree,
+				ProjectRoot: projectRoot,
+			}
+			if _, err := manager.Execute(tt.event, ctx); err != nil {
+				t.Fatalf("Execute() failed: %v", err)
+			}
+
+			got, err := os.ReadFile(filepath.Join(hooksDir, "cwd-"+tt.event.String()))
+			if err != nil {
+				t.Fatalf("hook did not record its working directory: %v", err)
+			}
+			want, _ := filepath.EvalSymlinks(tt.wantDir)
+			if strings.TrimSpace(string(got)) != want {
+				t.Errorf("hook ran in %q, want %q", strings.TrimSpace(string(got)), want)
+			}
+		})
+	}
+}
+
+func TestManager_Execute_ServiceHooks(t *testing.T) {
+	tempDir := t.TempDir()
+
+	hooksDir := filepath.Join(tempDir, ".dual", "hooks")
+	if err := os.MkdirAll(hooksDir, 0o755); err != nil {
+		t.Fatalf("Failed to create hooks directory: %v", err)
+	}
+
+	// Each run appends its service (empty for project-level hooks) to a log
+	scriptContent := `#!/bin/sh
+echo "${DUAL_SERVICE:-project}" >> "$DUAL_PROJECT_ROOT/.dual/hooks/runs.log"
+if [ -n "$DUAL_SERVICE" ]; then
+  echo "$DUAL_SERVICE:SEEDED=true"
+fi
+`
+	if err := os.WriteFile(filepath.Join(hooksDir, "setup.sh"), []byte(scriptContent), 0o755); err != nil {
+		t.Fatalf("Failed to write hook script: %v", err)
+	}
+
+	cfg := &config.Config{
+		Version: 1,
+		Hooks:   map[string][]string{"postWorktreeCreate": {"setup.sh"}},
+		Services: map[string]config.Service{
+			"web":  {Path: "apps/web", Hooks: map[string][]string{"postWorktreeCreate": {"setup.sh"}}},
+			"api":  {Path: "apps/api", Hooks: map[string][]string{"postWorktreeCreate": {"setup.sh"}}},
+			"docs": {Path: "apps/docs", Hooks: map[string][]string{"preWorktreeDelete": {"setup.sh"}}},
+		},
+	}
+
+	manager := NewManager(cfg, tempDir)
+	ctx := HookContext{
+		Event:       PostWorktreeCreate,
+		ContextName: "test",
+		ContextPath: tempDir,
+		ProjectRoot: tempDir,
+	}
+
+	overrides, err := manager.Execute(PostWorktreeCreate, ctx)
+	if err != nil {
+		t.Fatalf("Execute() failed: %v", err)
+	}
+
+	// Project-level hooks run first, then services in name order
+	runs, err := os.ReadFile(filepath.Join(hooksDir, "runs.log"))
+	if err != nil {
+		t.Fatalf("Failed to read run log: %v", err)
+	}
+	if got, want := string(runs), "project\napi\nweb\n"; got != want {
+		t.Errorf("hook runs = %q, want %q", got, want)
+	}
+
+	for _, service := range []string{"api", "web"} {
+		if overrides.Services[service]["SEEDED"] != "true" {
+			t.Errorf("expected SEEDED override for %s, got %v", service, overrides.Services)
+		}
+	}
+}
@@ -12,6 +12,13 @@ const (
 
 	// PostWorktreeDelete is triggered after a worktree is deleted
 	PostWorktreeDelete HookEvent = "postWorktreeDelete"
+
+	// PreContextDelete is triggered before a context is removed from the
+	// registry without deleting a worktree (e.g. by 'dual context prune')
+	PreContextDelete HookEvent = "preContextDelete"
+
+	// PostContextDelete is triggered after such a context has been removed
+	PostContextDelete HookEvent = "postContextDelete"
 )
 
 // String returns the string representation of a HookEvent
@@ -22,7 +29,7 @@ func (e HookEvent) String() string {
 // IsValid checks if a HookEvent is one of the recognized events
 func (e HookEvent) IsValid() bool {
 	switch e {
-	case PostWorktreeCreate, PreWorktreeDelete, PostWorktreeDelete:
+	case PostWorktreeCreate, PreWorktreeDelete, PostWorktreeDelete, PreContextDelete, PostContextDelete:
 		return true
 	default:
 		return false
@@ -42,6 +49,10 @@ type HookContext struct {
 
 	// ProjectRoot is the absolute path to the main project repository
 	ProjectRoot string
+
+	// Service is the service whose hooks are running, passed to the script as
+	// DUAL_SERVICE. Empty for project-level hooks.
+	Service string
 }
 
 // HookResult contains the result of executing a hook
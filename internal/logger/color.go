@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// ColorMode selects when output is colored (--color)
+type ColorMode string
+
+const (
+	// ColorAuto colors output written to a terminal unless NO_COLOR is set
+	ColorAuto ColorMode = "auto"
+	// ColorAlways colors output even when it is piped
+	ColorAlways ColorMode = "always"
+	// ColorNever never colors output
+	ColorNever ColorMode = "never"
+)
+
+// ParseColorMode validates a --color value
+func ParseColorMode(value string) (ColorMode, error) {
+	switch mode := ColorMode(value); mode {
+	case ColorAuto, ColorAlways, ColorNever:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid --color %q (supported: auto, always, never)", value)
+}
+
+// ColorEnabled reports whether log messages on stderr are colored
+var ColorEnabled bool
+
+// SetColor applies mode to log messages on stderr and to colored command
+// output on stdout, such as the 'dual doctor' report. In auto mode each
+// stream is colored only when it is a terminal, and neither is when NO_COLOR
+// is set to a non-empty value (https://no-color.org) or TERM is "dumb".
+func SetColor(mode ColorMode) {
+	switch mode {
+	case ColorAlways:
+		ColorEnabled = true
+		color.NoColor = false
+	case ColorNever:
+		ColorEnabled = false
+		color.NoColor = true
+	default:
+		ColorEnabled = autoColor(os.Stderr)
+		color.NoColor = !autoColor(os.Stdout)
+	}
+}
+
+// autoColor reports whether f should be colored in auto mode
+func autoColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// colorize wraps s in the given SGR attribute when stderr is colored
+func colorize(attr color.Attribute, s string) string {
+	if !ColorEnabled {
+		return s
+	}
+	return fmt.Sprintf("\033[%dm%s\033[0m", attr, s)
+}
@@ -1,15 +1,60 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Prefix starts every Info, Warn and Error line in text mode
+const Prefix = "[dual]"
+
+// Level is the severity of a log message
+type Level int
+
+const (
+	// LevelDebug messages are shown only with --debug (or DUAL_DEBUG=1)
+	LevelDebug Level = iota
+	// LevelVerbose messages are shown with --verbose or --debug
+	LevelVerbose
+	// LevelInfo messages are shown unless --quiet is set
+	LevelInfo
+	// LevelWarn messages are shown unless --quiet is set
+	LevelWarn
+	// LevelError messages are always shown
+	LevelError
 )
 
+// String returns the level name used in JSON output
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelVerbose:
+		return "verbose"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
 var (
 	// VerboseEnabled controls whether Verbose messages are displayed
 	VerboseEnabled bool
 	// DebugEnabled controls whether Debug messages are displayed (also enables Verbose)
 	DebugEnabled bool
+	// JSONEnabled writes every message as a JSON object per line instead of text
+	JSONEnabled bool
+	// QuietEnabled suppresses Info, Detail, Success and Warn messages (errors are still shown)
+	QuietEnabled bool
 )
 
 // Init initializes the logger based on flags and environment variables
@@ -24,31 +69,113 @@ func Init(verbose, debug bool) {
 	}
 }
 
+// SetJSON switches between text output and one JSON object per message (--log-json)
+func SetJSON(enabled bool) {
+	JSONEnabled = enabled
+}
+
+// SetQuiet switches informational and warning output off or on (--quiet)
+func SetQuiet(enabled bool) {
+	QuietEnabled = enabled
+}
+
+// MinLevel returns the lowest level that is currently displayed.
+// Verbose and debug messages are explicitly requested, so --quiet does not
+// hide them.
+func MinLevel() Level {
+	switch {
+	case DebugEnabled:
+		return LevelDebug
+	case VerboseEnabled:
+		return LevelVerbose
+	case QuietEnabled:
+		return LevelError
+	}
+	return LevelInfo
+}
+
+// Enabled reports whether messages at level are currently displayed
+func Enabled(level Level) bool {
+	if QuietEnabled && (level == LevelInfo || level == LevelWarn) {
+		return false
+	}
+	return level >= MinLevel()
+}
+
 // Verbose prints verbose messages to stderr (shown when --verbose or --debug is enabled)
 func Verbose(format string, args ...interface{}) {
 	if VerboseEnabled {
-		fmt.Fprintf(os.Stderr, format+"\n", args...)
+		emit(LevelVerbose, "", format+"\n", args...)
 	}
 }
 
 // Debug prints debug messages to stderr (shown only when --debug is enabled)
 func Debug(format string, args ...interface{}) {
 	if DebugEnabled {
-		fmt.Fprintf(os.Stderr, format+"\n", args...)
+		emit(LevelDebug, "", format+"\n", args...)
 	}
 }
 
-// Info prints informational messages to stderr (always shown)
+// Info prints informational messages to stderr (hidden by --quiet)
 func Info(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	if QuietEnabled {
+		return
+	}
+	emit(LevelInfo, Prefix+" ", format+"\n", args...)
+}
+
+// Detail prints a continuation line for the preceding message, such as an
+// indented field or hint, without the prefix (hidden by --quiet)
+func Detail(format string, args ...interface{}) {
+	if QuietEnabled || (JSONEnabled && strings.TrimSpace(format) == "") {
+		return
+	}
+	emit(LevelInfo, "", format+"\n", args...)
 }
 
-// Success prints success messages with a checkmark to stderr (always shown)
+// Success prints success messages with a checkmark to stderr (hidden by --quiet)
 func Success(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, "\u2713 "+format+"\n", args...)
+	if QuietEnabled {
+		return
+	}
+	emit(LevelInfo, colorize(color.FgGreen, "✓")+" ", format+"\n", args...)
+}
+
+// Warn prints warning messages to stderr (hidden by --quiet)
+func Warn(format string, args ...interface{}) {
+	if QuietEnabled {
+		return
+	}
+	emit(LevelWarn, Prefix+" "+colorize(color.FgYellow, "Warning:")+" ", format+"\n", args...)
 }
 
 // Error prints error messages to stderr (always shown)
 func Error(format string, args ...interface{}) {
-	fmt.Fprintf(os.Stderr, "Error: "+format+"\n", args...)
+	emit(LevelError, Prefix+" "+colorize(color.FgRed, "Error:")+" ", format+"\n", args...)
+}
+
+// emit writes one newline-terminated message to stderr, as prefixed text or
+// as a JSON object
+func emit(level Level, prefix, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	if !JSONEnabled {
+		fmt.Fprint(os.Stderr, prefix+msg)
+		return
+	}
+
+	record := struct {
+		Time  string `json:"time"`
+		Level string `json:"level"`
+		Msg   string `json:"msg"`
+	}{
+		Time:  time.Now().Format(time.RFC3339),
+		Level: level.String(),
+		Msg:   strings.TrimSpace(msg),
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		fmt.Fprint(os.Stderr, prefix+msg)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s\n", data)
 }
@@ -2,8 +2,12 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
+	"strings"
 	"testing"
+
+	"github.com/fatih/color"
 )
 
 func TestInit(t *testing.T) {
@@ -254,7 +258,7 @@ func TestInfo(t *testing.T) {
 	buf.ReadFrom(r)
 
 	// Verify
-	want := "info message\n"
+	want := "[dual] info message\n"
 	if got := buf.String(); got != want {
 		t.Errorf("Info() output = %q, want %q", got, want)
 	}
@@ -298,8 +302,165 @@ func TestError(t *testing.T) {
 	buf.ReadFrom(r)
 
 	// Verify
-	want := "Error: error message\n"
+	want := "[dual] Error: error message\n"
 	if got := buf.String(); got != want {
 		t.Errorf("Error() output = %q, want %q", got, want)
 	}
 }
+
+// captureStderr returns everything fn writes to stderr
+func captureStderr(fn func()) string {
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	fn()
+
+	w.Close()
+	os.Stderr = oldStderr
+	var buf bytes.Buffer
+	buf.ReadFrom(r)
+	return buf.String()
+}
+
+func TestWarn(t *testing.T) {
+	want := "[dual] Warning: warn message 7\n"
+	if got := captureStderr(func() { Warn("warn message %d", 7) }); got != want {
+		t.Errorf("Warn() output = %q, want %q", got, want)
+	}
+}
+
+func TestDetail(t *testing.T) {
+	want := "  Path: /tmp/x\n"
+	if got := captureStderr(func() { Detail("  Path: %s", "/tmp/x") }); got != want {
+		t.Errorf("Detail() output = %q, want %q", got, want)
+	}
+}
+
+func TestMinLevel(t *testing.T) {
+	tests := []struct {
+		verbose bool
+		debug   bool
+		want    Level
+	}{
+		{want: LevelInfo},
+		{verbose: true, want: LevelVerbose},
+		{debug: true, want: LevelDebug},
+	}
+
+	for _, tt := range tests {
+		VerboseEnabled = tt.verbose || tt.debug
+		DebugEnabled = tt.debug
+		if got := MinLevel(); got != tt.want {
+			t.Errorf("MinLevel() with verbose=%v debug=%v = %s, want %s", tt.verbose, tt.debug, got, tt.want)
+		}
+	}
+	if Enabled(LevelDebug) != true || Enabled(LevelError) != true {
+		t.Error("Enabled() should show everything with debug on")
+	}
+
+	VerboseEnabled = false
+	DebugEnabled = false
+	if Enabled(LevelVerbose) {
+		t.Error("Enabled(LevelVerbose) should be false without --verbose")
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	SetJSON(true)
+	defer SetJSON(false)
+	VerboseEnabled = false
+	DebugEnabled = true
+	defer func() { DebugEnabled = false }()
+
+	got := captureStderr(func() {
+		Info("created %s", "ctx")
+		Warn("careful")
+		Error("broken")
+		Debug("details")
+		Detail("  Path: /tmp/x")
+		Detail("")
+	})
+
+	lines := strings.Split(strings.TrimSpace(got), "\n")
+	want := []struct{ level, msg string }{
+		{"info", "created ctx"},
+		{"warn", "careful"},
+		{"error", "broken"},
+		{"debug", "details"},
+		{"info", "Path: /tmp/x"},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("expected %d JSON lines, got %d:\n%s", len(want), len(lines), got)
+	}
+
+	for i, line := range lines {
+		var record struct {
+			Time  string `json:"time"`
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %d is not JSON: %v\n%s", i, err, line)
+		}
+		if record.Level != want[i].level || record.Msg != want[i].msg || record.Time == "" {
+			t.Errorf("line %d = %+v, want level %q msg %q", i, record, want[i].level, want[i].msg)
+		}
+	}
+}
+
+func TestQuiet(t *testing.T) {
+	SetQuiet(true)
+	defer SetQuiet(false)
+	VerboseEnabled = false
+	DebugEnabled = false
+
+	got := captureStderr(func() {
+		Info("created %s", "ctx")
+		Detail("  Path: /tmp/x")
+		Success("done")
+		Warn("careful")
+		Error("broken")
+	})
+
+	if got != Prefix+" Error: broken\n" {
+		t.Errorf("quiet output = %q, want only the error", got)
+	}
+	if Enabled(LevelInfo) || Enabled(LevelWarn) || !Enabled(LevelError) {
+		t.Error("Enabled() should only show errors with --quiet")
+	}
+}
+
+func TestParseColorMode(t *testing.T) {
+	for _, value := range []string{"auto", "always", "never"} {
+		if mode, err := ParseColorMode(value); err != nil || string(mode) != value {
+			t.Errorf("ParseColorMode(%q) = %q, %v", value, mode, err)
+		}
+	}
+	if _, err := ParseColorMode("yes"); err == nil {
+		t.Error("ParseColorMode(\"yes\") expected error")
+	}
+}
+
+func TestSetColor(t *testing.T) {
+	defer SetColor(ColorNever)
+
+	SetColor(ColorAlways)
+	if !ColorEnabled || color.NoColor {
+		t.Error("--color=always should color stderr and stdout")
+	}
+	want := Prefix + " \033[33mWarning:\033[0m careful\n"
+	if got := captureStderr(func() { Warn("careful") }); got != want {
+		t.Errorf("Warn() output = %q, want %q", got, want)
+	}
+
+	// NO_COLOR wins over a terminal in auto mode; tests never run on one anyway
+	t.Setenv("NO_COLOR", "1")
+	SetColor(ColorAuto)
+	if ColorEnabled || !color.NoColor {
+		t.Error("--color=auto should not color output when NO_COLOR is set")
+	}
+	if got := captureStderr(func() { Error("broken") }); got != Prefix+" Error: broken\n" {
+		t.Errorf("Error() output = %q, want no color codes", got)
+	}
+}
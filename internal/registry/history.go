@@ -0,0 +1,161 @@
+package registry
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxEnvOverrideHistory is the number of override changes kept per context
+// for 'dual env rollback'. Older changes are dropped first.
+const MaxEnvOverrideHistory = 50
+
+// ErrNoOverrideHistory is returned when there is no recorded change to roll back
+var ErrNoOverrideHistory = errors.New("no override history")
+
+// EnvOverrideChange records the value an override had before it was changed
+type EnvOverrideChange struct {
+	Key     string `json:"key"`
+	Service string `json:"service,omitempty"` // Empty for global overrides
+	// Previous is the value before the change, or nil if the override was not set
+	Previous *string   `json:"previous,omitempty"`
+	Changed  time.Time `json:"changed"`
+}
+
+// RecordEnvOverrideChange remembers the current value of an override before
+// it is set to value (nil when it is about to be unset), so
+// RollbackEnvOverride can restore it. Nothing is recorded when the value
+// does not change.
+func (r *Registry) RecordEnvOverrideChange(projectPath, contextName, key, serviceName string, value *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, exists := r.Projects[projectPath]
+	if !exists {
+		return ErrProjectNotFound
+	}
+
+	context, exists := project.Contexts[contextName]
+	if !exists {
+		return ErrContextNotFound
+	}
+
+	context.RecordEnvOverrideChange(key, serviceName, value)
+	project.Contexts[contextName] = context
+
+	return nil
+}
+
+// RollbackEnvOverride restores an override to the value recorded by its most
+// recent change, unsetting it if it was not set before, and removes that
+// change from the history. Returns ErrNoOverrideHistory if no change is
+// recorded for the key.
+func (r *Registry) RollbackEnvOverride(projectPath, contextName, key, serviceName string) (EnvOverrideChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, exists := r.Projects[projectPath]
+	if !exists {
+		return EnvOverrideChange{}, ErrProjectNotFound
+	}
+
+	context, exists := project.Contexts[contextName]
+	if !exists {
+		return EnvOverrideChange{}, ErrContextNotFound
+	}
+
+	change, ok := context.RollbackEnvOverride(key, serviceName)
+	if !ok {
+		return EnvOverrideChange{}, ErrNoOverrideHistory
+	}
+	project.Contexts[contextName] = context
+
+	return change, nil
+}
+
+// RecordEnvOverrideChange is the Context counterpart of
+// Registry.RecordEnvOverrideChange
+func (c *Context) RecordEnvOverrideChange(key, serviceName string, value *string) {
+	previous, wasSet := c.envOverrideAt(key, serviceName)
+	if wasSet && value != nil && *value == previous {
+		return
+	}
+	if !wasSet && value == nil {
+		return
+	}
+
+	change := EnvOverrideChange{
+		Key:     key,
+		Service: serviceName,
+		Changed: time.Now(),
+	}
+	if wasSet {
+		change.Previous = &previous
+	}
+
+	if c.EnvOverridesV2 == nil {
+		c.EnvOverridesV2 = &ContextEnvOverrides{}
+	}
+	history := append(c.EnvOverridesV2.History, change)
+	if len(history) > MaxEnvOverrideHistory {
+		history = history[len(history)-MaxEnvOverrideHistory:]
+	}
+	c.EnvOverridesV2.History = history
+}
+
+// RollbackEnvOverride is the Context counterpart of
+// Registry.RollbackEnvOverride. It reports false if no change is recorded.
+func (c *Context) RollbackEnvOverride(key, serviceName string) (EnvOverrideChange, bool) {
+	if c.EnvOverridesV2 == nil {
+		return EnvOverrideChange{}, false
+	}
+
+	history := c.EnvOverridesV2.History
+	for i := len(history) - 1; i >= 0; i-- {
+		change := history[i]
+		if change.Key != key || change.Service != serviceName {
+			continue
+		}
+
+		if change.Previous == nil {
+			c.UnsetEnvOverride(key, serviceName)
+		} else {
+			c.SetEnvOverride(key, *change.Previous, serviceName)
+		}
+
+		remaining := append(history[:i:i], history[i+1:]...)
+		if len(remaining) == 0 {
+			remaining = nil
+		}
+		c.EnvOverridesV2.History = remaining
+		return change, true
+	}
+	return EnvOverrideChange{}, false
+}
+
+// EnvOverrideHistory returns the recorded changes for an override, oldest first
+func (c *Context) EnvOverrideHistory(key, serviceName string) []EnvOverrideChange {
+	if c.EnvOverridesV2 == nil {
+		return nil
+	}
+	var changes []EnvOverrideChange
+	for _, change := range c.EnvOverridesV2.History {
+		if change.Key == key && change.Service == serviceName {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// envOverrideAt returns the value an override has in exactly the given layer:
+// the global overrides for an empty serviceName, otherwise the service's own
+func (c *Context) envOverrideAt(key, serviceName string) (string, bool) {
+	if c.EnvOverridesV2 == nil {
+		return "", false
+	}
+	if serviceName == "" {
+		value, ok := c.EnvOverridesV2.Global[key]
+		return value, ok
+	}
+	value, ok := c.EnvOverridesV2.Services[serviceName][key]
+	return value, ok
+}
@@ -0,0 +1,96 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestEnvOverrideRollback(t *testing.T) {
+	reg := &Registry{
+		Projects: map[string]Project{
+			"/project": {Contexts: map[string]Context{"main": {}}},
+		},
+	}
+
+	set := func(key, value, service string) {
+		t.Helper()
+		if err := reg.RecordEnvOverrideChange("/project", "main", key, service, &value); err != nil {
+			t.Fatal(err)
+		}
+		if err := reg.SetEnvOverrideForService("/project", "main", key, value, service); err != nil {
+			t.Fatal(err)
+		}
+	}
+	get := func(key, service string) (string, bool) {
+		t.Helper()
+		ctx, err := reg.GetContext("/project", "main")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ctx.envOverrideAt(key, service)
+	}
+
+	set("DATABASE_URL", "postgres://localhost/app", "")
+	set("DATABASE_URL", "postgres://typo", "")
+	set("DATABASE_URL", "postgres://typo", "") // unchanged, not recorded
+	set("PORT", "4001", "api")
+
+	// The service override has its own history
+	change, err := reg.RollbackEnvOverride("/project", "main", "PORT", "api")
+	if err != nil {
+		t.Fatalf("RollbackEnvOverride() error = %v", err)
+	}
+	if change.Previous != nil {
+		t.Errorf("Previous = %q, want nil", *change.Previous)
+	}
+	if _, ok := get("PORT", "api"); ok {
+		t.Error("PORT should be unset after rolling back its first set")
+	}
+
+	if _, err := reg.RollbackEnvOverride("/project", "main", "DATABASE_URL", ""); err != nil {
+		t.Fatalf("RollbackEnvOverride() error = %v", err)
+	}
+	if value, _ := get("DATABASE_URL", ""); value != "postgres://localhost/app" {
+		t.Errorf("DATABASE_URL = %q, want the previous value", value)
+	}
+
+	if _, err := reg.RollbackEnvOverride("/project", "main", "DATABASE_URL", ""); err != nil {
+		t.Fatalf("RollbackEnvOverride() error = %v", err)
+	}
+	if _, ok := get("DATABASE_URL", ""); ok {
+		t.Error("DATABASE_URL should be unset after rolling back to before it was set")
+	}
+
+	if _, err := reg.RollbackEnvOverride("/project", "main", "DATABASE_URL", ""); !errors.Is(err, ErrNoOverrideHistory) {
+		t.Errorf("RollbackEnvOverride() error = %v, want ErrNoOverrideHistory", err)
+	}
+	ctx, _ := reg.GetContext("/project", "main")
+	if ctx.EnvOverridesV2.History != nil {
+		t.Errorf("History = %v, want empty", ctx.EnvOverridesV2.History)
+	}
+}
+
+func TestEnvOverrideHistoryIsBounded(t *testing.T) {
+	ctx := &Context{}
+	for i := 0; i < MaxEnvOverrideHistory+10; i++ {
+		value := fmt.Sprint(i)
+		ctx.RecordEnvOverrideChange("PORT", "", &value)
+		ctx.SetEnvOverride("PORT", value, "")
+	}
+
+	history := ctx.EnvOverrideHistory("PORT", "")
+	if len(history) != MaxEnvOverrideHistory {
+		t.Fatalf("history length = %d, want %d", len(history), MaxEnvOverrideHistory)
+	}
+	// The oldest changes are dropped first
+	if history[0].Previous == nil || *history[0].Previous != "9" {
+		t.Errorf("oldest kept change = %+v, want previous value 9", history[0])
+	}
+
+	// Recording an unset of a missing override is a no-op
+	ctx.RecordEnvOverrideChange("MISSING", "", nil)
+	if len(ctx.EnvOverrideHistory("MISSING", "")) != 0 {
+		t.Error("unsetting a missing override should not be recorded")
+	}
+}
@@ -0,0 +1,62 @@
+package registry
+
+import (
+	"context"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/lightfastai/dual/internal/logger"
+)
+
+// LockTimeoutEnvVar names the environment variable that overrides
+// LockTimeout with a Go duration such as "30s", e.g. for busy CI machines
+const LockTimeoutEnvVar = "DUAL_LOCK_TIMEOUT"
+
+const (
+	// lockInitialBackoff is the wait after the first failed lock attempt
+	lockInitialBackoff = 10 * time.Millisecond
+	// lockMaxBackoff caps the wait between lock attempts
+	lockMaxBackoff = 500 * time.Millisecond
+)
+
+// lockTimeout returns how long to wait for the registry lock: LockTimeout,
+// unless $DUAL_LOCK_TIMEOUT holds a valid positive duration
+func lockTimeout() time.Duration {
+	value := os.Getenv(LockTimeoutEnvVar)
+	if value == "" {
+		return LockTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		logger.Warn("Ignoring invalid %s=%q; waiting %v for the registry lock", LockTimeoutEnvVar, value, LockTimeout)
+		return LockTimeout
+	}
+	return timeout
+}
+
+// acquireLock calls tryLock until it takes the lock, fails, or ctx is done,
+// in which case it returns false and no error. The wait between attempts
+// doubles from lockInitialBackoff up to lockMaxBackoff, plus up to 50% random
+// jitter so processes started together do not retry in lockstep. An
+// uncontended lock is taken by the first attempt without waiting.
+func acquireLock(ctx context.Context, tryLock func() (bool, error)) (bool, error) {
+	backoff := lockInitialBackoff
+	for {
+		locked, err := tryLock()
+		if err != nil || locked {
+			return locked, err
+		}
+
+		wait := backoff + rand.N(backoff/2+1)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, nil
+		case <-timer.C:
+		}
+
+		backoff = min(backoff*2, lockMaxBackoff)
+	}
+}
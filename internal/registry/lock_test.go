@@ -1,6 +1,8 @@
 package registry
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"sync"
@@ -147,6 +149,40 @@ func TestLockTimeout(t *testing.T) {
 	defer reg3.Close()
 }
 
+// TestReadOnlySharedLock tests that read-only loads share the lock but exclude writers
+func TestReadOnlySharedLock(t *testing.T) {
+	projectRoot := t.TempDir()
+
+	oldTimeout := LockTimeout
+	LockTimeout = 500 * time.Millisecond
+	defer func() { LockTimeout = oldTimeout }()
+
+	reader1, err := LoadRegistryReadOnly(projectRoot)
+	if err != nil {
+		t.Fatalf("LoadRegistryReadOnly() failed: %v", err)
+	}
+	defer reader1.Close()
+
+	// A second reader does not wait for the first
+	reader2, err := LoadRegistryReadOnly(projectRoot)
+	if err != nil {
+		t.Fatalf("second LoadRegistryReadOnly() failed: %v", err)
+	}
+	defer reader2.Close()
+
+	// A writer waits for the readers
+	if writer, err := LoadRegistry(projectRoot); err == nil {
+		writer.Close()
+		t.Fatal("Expected lock timeout while readers hold the lock")
+	} else if !isLockTimeoutError(err) {
+		t.Errorf("Expected lock timeout error, got: %v", err)
+	}
+
+	if err := reader1.SaveRegistry(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SaveRegistry() error = %v, want ErrReadOnly", err)
+	}
+}
+
 // TestStaleLockCleanup tests that stale locks can be detected and handled
 func TestStaleLockCleanup(t *testing.T) {
 	// Use a temporary directory as project root
@@ -248,6 +284,96 @@ func TestMultipleCloseCallsSafe(t *testing.T) {
 	}
 }
 
+// TestAcquireLockBackoff tests that lock attempts back off exponentially with jitter
+func TestAcquireLockBackoff(t *testing.T) {
+	// Uncontended: one attempt, no waiting
+	attempts := 0
+	start := time.Now()
+	locked, err := acquireLock(context.Background(), func() (bool, error) {
+		attempts++
+		return true, nil
+	})
+	if !locked || err != nil || attempts != 1 {
+		t.Fatalf("acquireLock() = %v, %v after %d attempts, want true on the first", locked, err, attempts)
+	}
+	if elapsed := time.Since(start); elapsed > lockInitialBackoff {
+		t.Errorf("uncontended lock took %v", elapsed)
+	}
+
+	// Contended: the gaps between attempts grow, within the jitter bounds
+	var times []time.Time
+	locked, err = acquireLock(context.Background(), func() (bool, error) {
+		times = append(times, time.Now())
+		return len(times) == 5, nil
+	})
+	if !locked || err != nil {
+		t.Fatalf("acquireLock() = %v, %v, want the lock on the fifth attempt", locked, err)
+	}
+	backoff := lockInitialBackoff
+	for i := 1; i < len(times); i++ {
+		gap := times[i].Sub(times[i-1])
+		if gap < backoff {
+			t.Errorf("gap %d = %v, want at least %v", i, gap, backoff)
+		}
+		backoff = min(backoff*2, lockMaxBackoff)
+	}
+
+	// Errors are returned immediately
+	lockErr := errors.New("permission denied")
+	if _, err := acquireLock(context.Background(), func() (bool, error) { return false, lockErr }); !errors.Is(err, lockErr) {
+		t.Errorf("acquireLock() error = %v, want %v", err, lockErr)
+	}
+
+	// A held lock gives up when the context ends, without an error
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	locked, err = acquireLock(ctx, func() (bool, error) { return false, nil })
+	if locked || err != nil {
+		t.Errorf("acquireLock() = %v, %v, want false, nil on timeout", locked, err)
+	}
+}
+
+// TestLockTimeoutEnvVar tests overriding the lock timeout from the environment
+func TestLockTimeoutEnvVar(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "", want: LockTimeout},
+		{value: "30s", want: 30 * time.Second},
+		{value: "250ms", want: 250 * time.Millisecond},
+		{value: "soon", want: LockTimeout},
+		{value: "-1s", want: LockTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Setenv(LockTimeoutEnvVar, tt.value)
+		if got := lockTimeout(); got != tt.want {
+			t.Errorf("lockTimeout() with %q = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	// The override applies to LoadRegistry
+	projectRoot := t.TempDir()
+	holder, err := LoadRegistry(projectRoot)
+	if err != nil {
+		t.Fatalf("LoadRegistry() failed: %v", err)
+	}
+	defer holder.Close()
+
+	t.Setenv(LockTimeoutEnvVar, "200ms")
+	start := time.Now()
+	if reg, err := LoadRegistry(projectRoot); err == nil {
+		reg.Close()
+		t.Fatal("expected a lock timeout")
+	} else if !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("expected ErrLockTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("LoadRegistry waited %v despite a 200ms %s", elapsed, LockTimeoutEnvVar)
+	}
+}
+
 // isLockTimeoutError checks if an error is a lock timeout error
 func isLockTimeoutError(err error) bool {
 	if err == nil {
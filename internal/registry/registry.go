@@ -5,21 +5,35 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/gofrs/flock"
+	"github.com/lightfastai/dual/internal/logger"
 )
 
 // Registry represents the project-local registry structure stored in $PROJECT_ROOT/.dual/.local/registry.json
 type Registry struct {
-	Projects    map[string]Project `json:"projects"`
-	mu          sync.RWMutex       `json:"-"`
-	flock       *flock.Flock       `json:"-"` // File lock for atomic operations
-	projectRoot string             `json:"-"` // Project root path for SaveRegistry
+	SchemaVersion int                `json:"schemaVersion"` // File format version, set on save
+	Projects      map[string]Project `json:"projects"`
+	mu            sync.RWMutex       `json:"-"`
+	flock         *flock.Flock       `json:"-"` // File lock for atomic operations
+	projectRoot   string             `json:"-"` // Project root path for SaveRegistry
+
+	// readOnly is set by LoadRegistryReadOnly, which holds a shared lock, and
+	// when the registry directory is not writable, where no lock is held.
+	// SaveRegistry refuses to write either way.
+	readOnly bool
+
+	// loaded is a copy of Projects as last read from or written to disk. It is
+	// the common ancestor SaveRegistry merges against when the file changed
+	// behind our back.
+	loaded map[string]Project
 }
 
 // Project represents a single project in the registry
@@ -31,6 +45,21 @@ type Project struct {
 type ContextEnvOverrides struct {
 	Global   map[string]string            `json:"global,omitempty"`   // Global overrides for all services
 	Services map[string]map[string]string `json:"services,omitempty"` // Service-specific overrides
+
+	// Optional notes explaining why an override is set, keyed like Global and
+	// Services. Kept apart from the values so registries without notes load
+	// unchanged.
+	GlobalNotes  map[string]string            `json:"globalNotes,omitempty"`
+	ServiceNotes map[string]map[string]string `json:"serviceNotes,omitempty"`
+
+	// History records previous override values for 'dual env rollback',
+	// oldest first and bounded by MaxEnvOverrideHistory
+	History []EnvOverrideChange `json:"history,omitempty"`
+
+	// Environments holds overlays keyed by environment name (e.g. staging),
+	// applied on top of the global and service overrides only when that
+	// environment is asked for. Generated service env files never include them.
+	Environments map[string]map[string]string `json:"environments,omitempty"`
 }
 
 // Context represents a development context (branch, worktree, etc.)
@@ -38,33 +67,79 @@ type Context struct {
 	Created        time.Time            `json:"created"`
 	Path           string               `json:"path,omitempty"`
 	EnvOverridesV2 *ContextEnvOverrides `json:"envOverridesV2,omitempty"` // Layered overrides
+
+	// EnvOverrides holds flat overrides written by registries that predate
+	// EnvOverridesV2. They are still read as the lowest-priority global layer
+	// until migrated with MigrateLegacyEnvOverrides.
+	//
+	// Deprecated: Use EnvOverridesV2.
+	EnvOverrides map[string]string `json:"envOverrides,omitempty"`
 }
 
+// SchemaVersion is the registry file format this build reads and writes.
+// Files without a schemaVersion predate the field and count as version 1.
+const SchemaVersion = 1
+
 var (
+	// ErrSchemaTooNew is returned when the registry was written by a newer dual
+	ErrSchemaTooNew = errors.New("registry was written by a newer version of dual")
 	// ErrProjectNotFound is returned when a project doesn't exist in the registry
 	ErrProjectNotFound = errors.New("project not found in registry")
 	// ErrContextNotFound is returned when a context doesn't exist in a project
 	ErrContextNotFound = errors.New("context not found in project")
+	// ErrReadOnly is returned when saving a registry loaded read-only
+	ErrReadOnly = errors.New("registry is read-only")
 	// ErrLockTimeout is returned when file lock acquisition times out
 	ErrLockTimeout = errors.New("timeout waiting for registry lock")
-	// LockTimeout is the timeout for acquiring the registry lock
+	// LockTimeout is the default timeout for acquiring the registry lock
+	// (see LockTimeoutEnvVar)
 	LockTimeout = 5 * time.Second
 )
 
-// GetRegistryPath returns the path to the project-local registry file
+// RegistryDirEnvVar names the environment variable that moves the registry
+// out of the project, e.g. when the checkout is on a read-only filesystem
+const RegistryDirEnvVar = "DUAL_REGISTRY_DIR"
+
+// registryDirFor returns the directory holding the registry and its lock file
+func registryDirFor(projectRoot string) string {
+	if dir := os.Getenv(RegistryDirEnvVar); dir != "" {
+		return dir
+	}
+	return filepath.Join(projectRoot, ".dual", ".local")
+}
+
+// GetRegistryPath returns the path to the project-local registry file,
+// or to registry.json in $DUAL_REGISTRY_DIR if set
 func GetRegistryPath(projectRoot string) (string, error) {
-	return filepath.Join(projectRoot, ".dual", ".local", "registry.json"), nil
+	return filepath.Join(registryDirFor(projectRoot), "registry.json"), nil
 }
 
-// GetLockPath returns the path to the project-local registry lock file
+// GetLockPath returns the path to the registry lock file next to the registry
 func GetLockPath(projectRoot string) (string, error) {
-	return filepath.Join(projectRoot, ".dual", ".local", "registry.json.lock"), nil
+	return filepath.Join(registryDirFor(projectRoot), "registry.json.lock"), nil
 }
 
 // LoadRegistry reads the registry from $PROJECT_ROOT/.dual/.local/registry.json with file locking
 // If the file doesn't exist or is corrupt, it returns a new empty registry
 // The caller MUST call Close() on the returned registry to release the lock
+//
+// If the registry directory is not writable (e.g. a read-only filesystem in
+// CI), no lock can be taken. The registry is then loaded read-only with a
+// warning: reads work, and SaveRegistry returns ErrReadOnly.
 func LoadRegistry(projectRoot string) (*Registry, error) {
+	return loadRegistry(projectRoot, false)
+}
+
+// LoadRegistryReadOnly is like LoadRegistry but takes a shared lock, so
+// read-only commands can run concurrently with each other while still
+// excluding writers. SaveRegistry on the result returns ErrReadOnly.
+// The caller MUST call Close() on the returned registry to release the lock
+func LoadRegistryReadOnly(projectRoot string) (*Registry, error) {
+	return loadRegistry(projectRoot, true)
+}
+
+// loadRegistry implements LoadRegistry and, with shared set, LoadRegistryReadOnly
+func loadRegistry(projectRoot string, shared bool) (*Registry, error) {
 	registryPath, err := GetRegistryPath(projectRoot)
 	if err != nil {
 		return nil, err
@@ -78,18 +153,29 @@ func LoadRegistry(projectRoot string) (*Registry, error) {
 	// Ensure directory exists before creating lock file
 	registryDir := filepath.Dir(registryPath)
 	if err := os.MkdirAll(registryDir, 0o750); err != nil {
+		if isNotWritable(err) {
+			return loadReadOnly(projectRoot, registryPath, err)
+		}
 		return nil, fmt.Errorf("failed to create project-local registry directory: %w", err)
 	}
 
 	// Create file lock
 	fileLock := flock.New(lockPath)
 
-	// Try to acquire lock with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), LockTimeout)
+	// Try to acquire lock with timeout, backing off while it is held
+	timeout := lockTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	locked, err := fileLock.TryLockContext(ctx, 100*time.Millisecond)
+	tryLock := fileLock.TryLock
+	if shared {
+		tryLock = fileLock.TryRLock
+	}
+	locked, err := acquireLock(ctx, tryLock)
 	if err != nil {
+		if isNotWritable(err) {
+			return loadReadOnly(projectRoot, registryPath, err)
+		}
 		return nil, fmt.Errorf("failed to acquire registry lock: %w", err)
 	}
 	if !locked {
@@ -97,7 +183,7 @@ func LoadRegistry(projectRoot string) (*Registry, error) {
 		return nil, fmt.Errorf("%w\n\n"+
 			"DETAILS:\n"+
 			"  Lock file:    %s\n"+
-			"  Waited:       %v\n"+
+			"  Waited:       %v (set %s to wait longer)\n"+
 			"\n"+
 			"POSSIBLE CAUSES:\n"+
 			"  • Another dual command is currently running\n"+
@@ -115,7 +201,7 @@ func LoadRegistry(projectRoot string) (*Registry, error) {
 			"\n"+
 			"  ⚠️  Only remove the lock file if you're certain no dual\n"+
 			"     commands are currently running!",
-			ErrLockTimeout, lockPath, LockTimeout, lockPath)
+			ErrLockTimeout, lockPath, timeout, LockTimeoutEnvVar, lockPath)
 	}
 
 	// Initialize registry
@@ -124,25 +210,59 @@ func LoadRegistry(projectRoot string) (*Registry, error) {
 		mu:          sync.RWMutex{},
 		flock:       fileLock,
 		projectRoot: projectRoot,
+		readOnly:    shared,
+	}
+
+	if err := registry.read(registryPath); err != nil {
+		// Release lock before returning error
+		_ = fileLock.Unlock()
+		return nil, err
 	}
 
-	// If file doesn't exist, return empty registry (but keep the lock)
+	return registry, nil
+}
+
+// loadReadOnly loads the registry without a lock after the registry directory
+// turned out not to be writable. cause is the error that showed it.
+func loadReadOnly(projectRoot, registryPath string, cause error) (*Registry, error) {
+	logger.Warn("Registry directory %s is not writable (%v); continuing read-only", filepath.Dir(registryPath), cause)
+	logger.Detail("  Set %s to a writable directory to allow changes", RegistryDirEnvVar)
+
+	registry := &Registry{
+		Projects:    make(map[string]Project),
+		projectRoot: projectRoot,
+		readOnly:    true,
+	}
+	if err := registry.read(registryPath); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
+
+// isNotWritable reports whether err means the registry location cannot be written
+func isNotWritable(err error) bool {
+	return errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EROFS)
+}
+
+// read loads the registry file at registryPath into r. A missing file leaves
+// r empty; a corrupt one is backed up and r is left empty.
+func (r *Registry) read(registryPath string) error {
+	// If file doesn't exist, keep the empty registry
 	if _, err := os.Stat(registryPath); os.IsNotExist(err) {
-		return registry, nil
+		return nil
 	}
 
 	// Read the file
 	// #nosec G304 - registryPath is from trusted GetRegistryPath() function
 	data, err := os.ReadFile(registryPath)
 	if err != nil {
-		// Release lock before returning error
-		_ = fileLock.Unlock()
-		return nil, fmt.Errorf("failed to read registry: %w", err)
+		return fmt.Errorf("failed to read registry: %w", err)
 	}
 
 	// Parse JSON
 	var loadedData struct {
-		Projects map[string]Project `json:"projects"`
+		SchemaVersion int                `json:"schemaVersion"`
+		Projects      map[string]Project `json:"projects"`
 	}
 	if err := json.Unmarshal(data, &loadedData); err != nil {
 		// Create backup of corrupted registry
@@ -150,49 +270,63 @@ func LoadRegistry(projectRoot string) (*Registry, error) {
 		_ = os.WriteFile(backupPath, data, 0o600) // Best effort backup
 
 		// Provide detailed error recovery information
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "═══════════════════════════════════════════════════════════════════\n")
-		fmt.Fprintf(os.Stderr, "ERROR: Registry file is corrupted\n")
-		fmt.Fprintf(os.Stderr, "═══════════════════════════════════════════════════════════════════\n")
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "  Registry file: %s\n", registryPath)
-		fmt.Fprintf(os.Stderr, "  Backup saved:  %s\n", backupPath)
-		fmt.Fprintf(os.Stderr, "  Parse error:   %v\n", err)
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "IMPACT:\n")
-		fmt.Fprintf(os.Stderr, "  • A new empty registry will be created\n")
-		fmt.Fprintf(os.Stderr, "  • Your worktrees still exist but aren't registered\n")
-		fmt.Fprintf(os.Stderr, "  • Environment overrides have been lost\n")
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "TO RECOVER:\n")
-		fmt.Fprintf(os.Stderr, "  1. Re-register existing worktrees:\n")
-		fmt.Fprintf(os.Stderr, "     dual create <branch-name> for each worktree\n")
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "  2. Or try to fix the backup file:\n")
-		fmt.Fprintf(os.Stderr, "     cat %s | jq . > %s\n", backupPath, registryPath)
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "  3. Run 'dual doctor' to diagnose issues\n")
-		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "═══════════════════════════════════════════════════════════════════\n")
-		fmt.Fprintf(os.Stderr, "\n")
-
-		return registry, nil
+		logger.Error("Registry file is corrupted")
+		logger.Detail("  Registry file: %s", registryPath)
+		logger.Detail("  Backup saved:  %s", backupPath)
+		logger.Detail("  Parse error:   %v", err)
+		logger.Detail("")
+		logger.Detail("IMPACT:")
+		logger.Detail("  • A new empty registry will be created")
+		logger.Detail("  • Your worktrees still exist but aren't registered")
+		logger.Detail("  • Environment overrides have been lost")
+		logger.Detail("")
+		logger.Detail("TO RECOVER:")
+		logger.Detail("  1. Re-register existing worktrees:")
+		logger.Detail("     dual create <branch-name> for each worktree")
+		logger.Detail("")
+		logger.Detail("  2. Or try to fix the backup file:")
+		logger.Detail("     cat %s | jq . > %s", backupPath, registryPath)
+		logger.Detail("")
+		logger.Detail("  3. Run 'dual doctor' to diagnose issues")
+		logger.Detail("")
+
+		return nil
+	}
+
+	// Never rewrite a file whose format this build does not know
+	if loadedData.SchemaVersion > SchemaVersion {
+		return schemaTooNewError(registryPath, loadedData.SchemaVersion)
 	}
 
 	// Load projects into registry
 	if loadedData.Projects != nil {
-		registry.Projects = loadedData.Projects
+		r.Projects = loadedData.Projects
 	}
+	r.loaded = cloneProjects(r.Projects)
 
-	return registry, nil
+	return nil
 }
 
 // SaveRegistry writes the registry to $PROJECT_ROOT/.dual/.local/registry.json atomically
 // Uses the stored projectRoot field from LoadRegistry
+//
+// The file lock normally keeps the file unchanged between LoadRegistry and
+// SaveRegistry, but a writer that bypassed it (for example after a stale lock
+// file was removed) could have saved in between. To avoid losing its updates,
+// the file is re-read and only the contexts changed since loading are applied
+// on top of it; everything else keeps its on-disk state.
 func (r *Registry) SaveRegistry() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if r.readOnly {
+		if r.flock != nil {
+			return fmt.Errorf("%w: loaded with LoadRegistryReadOnly", ErrReadOnly)
+		}
+		registryPath, _ := GetRegistryPath(r.projectRoot)
+		return fmt.Errorf("%w: %s is not writable\nHint: Set %s to a writable directory", ErrReadOnly, filepath.Dir(registryPath), RegistryDirEnvVar)
+	}
+
 	registryPath, err := GetRegistryPath(r.projectRoot)
 	if err != nil {
 		return err
@@ -204,6 +338,23 @@ func (r *Registry) SaveRegistry() error {
 		return fmt.Errorf("failed to create project-local registry directory: %w", err)
 	}
 
+	// #nosec G304 - registryPath is from trusted GetRegistryPath() function
+	if data, err := os.ReadFile(registryPath); err == nil {
+		var onDisk struct {
+			SchemaVersion int                `json:"schemaVersion"`
+			Projects      map[string]Project `json:"projects"`
+		}
+		if json.Unmarshal(data, &onDisk) == nil {
+			if onDisk.SchemaVersion > SchemaVersion {
+				return schemaTooNewError(registryPath, onDisk.SchemaVersion)
+			}
+			if onDisk.Projects != nil {
+				r.Projects = mergeProjects(r.loaded, r.Projects, onDisk.Projects)
+			}
+		}
+	}
+	r.SchemaVersion = SchemaVersion
+
 	// Marshal to JSON with indentation for readability
 	data, err := json.MarshalIndent(r, "", "  ")
 	if err != nil {
@@ -222,9 +373,120 @@ func (r *Registry) SaveRegistry() error {
 		return fmt.Errorf("failed to save registry: %w", err)
 	}
 
+	r.loaded = cloneProjects(r.Projects)
 	return nil
 }
 
+// ReadSchemaVersion returns the schemaVersion of the registry file for
+// projectRoot without locking it. It returns 0 if the file does not exist or
+// predates the field.
+func ReadSchemaVersion(projectRoot string) (int, error) {
+	registryPath, err := GetRegistryPath(projectRoot)
+	if err != nil {
+		return 0, err
+	}
+
+	// #nosec G304 - registryPath is from trusted GetRegistryPath() function
+	data, err := os.ReadFile(registryPath)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read registry: %w", err)
+	}
+
+	var header struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, fmt.Errorf("failed to parse registry: %w", err)
+	}
+	return header.SchemaVersion, nil
+}
+
+// schemaTooNewError reports a registry file in a format newer than SchemaVersion
+func schemaTooNewError(registryPath string, version int) error {
+	return fmt.Errorf("%w: %s has schema version %d, this build supports up to %d\nHint: Upgrade dual to the latest release",
+		ErrSchemaTooNew, registryPath, version, SchemaVersion)
+}
+
+// mergeProjects applies the contexts that changed between base and ours
+// (added, modified or deleted) onto theirs and returns the result. Contexts
+// ours did not touch keep their value from theirs, so concurrent changes to
+// other contexts survive. Projects left without contexts are dropped.
+func mergeProjects(base, ours, theirs map[string]Project) map[string]Project {
+	result := cloneProjects(theirs)
+
+	apply := func(projectPath, contextName string) {
+		mine, inOurs := ours[projectPath].Contexts[contextName]
+		original, inBase := base[projectPath].Contexts[contextName]
+		if inOurs == inBase && (!inOurs || sameContext(mine, original)) {
+			return // unchanged since loading
+		}
+
+		project, exists := result[projectPath]
+		if !exists {
+			project = Project{Contexts: make(map[string]Context)}
+		}
+		if inOurs {
+			project.Contexts[contextName] = cloneContext(mine)
+		} else {
+			delete(project.Contexts, contextName)
+		}
+		result[projectPath] = project
+	}
+
+	for projectPath, project := range ours {
+		for contextName := range project.Contexts {
+			apply(projectPath, contextName)
+		}
+	}
+	for projectPath, project := range base {
+		for contextName := range project.Contexts {
+			if _, exists := ours[projectPath].Contexts[contextName]; !exists {
+				apply(projectPath, contextName)
+			}
+		}
+	}
+
+	for projectPath, project := range result {
+		if len(project.Contexts) == 0 {
+			delete(result, projectPath)
+		}
+	}
+	return result
+}
+
+// sameContext reports whether two contexts serialize identically
+func sameContext(a, b Context) bool {
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && string(aData) == string(bData)
+}
+
+// cloneContext returns a deep copy of a context
+func cloneContext(c Context) Context {
+	var clone Context
+	data, err := json.Marshal(c)
+	if err != nil || json.Unmarshal(data, &clone) != nil {
+		return c
+	}
+	return clone
+}
+
+// cloneProjects returns a deep copy of a projects map
+func cloneProjects(projects map[string]Project) map[string]Project {
+	clone := make(map[string]Project, len(projects))
+	for projectPath, project := range projects {
+		contexts := make(map[string]Context, len(project.Contexts))
+		for contextName, ctx := range project.Contexts {
+			contexts[contextName] = cloneContext(ctx)
+		}
+		clone[projectPath] = Project{Contexts: contexts}
+	}
+	return clone
+}
+
 // GetContext retrieves a context for a given project
 func (r *Registry) GetContext(projectPath, contextName string) (*Context, error) {
 	r.mu.RLock()
@@ -269,6 +531,9 @@ func (r *Registry) SetContext(projectPath, contextName string, contextPath strin
 	if exists && existingContext.EnvOverridesV2 != nil {
 		newContext.EnvOverridesV2 = existingContext.EnvOverridesV2
 	}
+	if exists && len(existingContext.EnvOverrides) > 0 {
+		newContext.EnvOverrides = existingContext.EnvOverrides
+	}
 
 	project.Contexts[contextName] = newContext
 
@@ -303,6 +568,72 @@ func (r *Registry) SetEnvOverrideForService(projectPath, contextName, key, value
 	return nil
 }
 
+// SetEnvOverrideForEnvironment sets an override in a context's overlay for the
+// named environment
+func (r *Registry) SetEnvOverrideForEnvironment(projectPath, contextName, key, value, environment string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, exists := r.Projects[projectPath]
+	if !exists {
+		return ErrProjectNotFound
+	}
+
+	context, exists := project.Contexts[contextName]
+	if !exists {
+		return ErrContextNotFound
+	}
+
+	context.SetEnvironmentOverride(key, value, environment)
+	project.Contexts[contextName] = context
+
+	return nil
+}
+
+// UnsetEnvOverrideForEnvironment removes an override from a context's overlay
+// for the named environment
+func (r *Registry) UnsetEnvOverrideForEnvironment(projectPath, contextName, key, environment string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, exists := r.Projects[projectPath]
+	if !exists {
+		return ErrProjectNotFound
+	}
+
+	context, exists := project.Contexts[contextName]
+	if !exists {
+		return ErrContextNotFound
+	}
+
+	context.UnsetEnvironmentOverride(key, environment)
+	project.Contexts[contextName] = context
+
+	return nil
+}
+
+// SetEnvOverrideNote attaches a note to an environment variable override for a
+// context and optional service. An empty note removes it.
+func (r *Registry) SetEnvOverrideNote(projectPath, contextName, key, note, serviceName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, exists := r.Projects[projectPath]
+	if !exists {
+		return ErrProjectNotFound
+	}
+
+	context, exists := project.Contexts[contextName]
+	if !exists {
+		return ErrContextNotFound
+	}
+
+	context.SetEnvOverrideNote(key, note, serviceName)
+	project.Contexts[contextName] = context
+
+	return nil
+}
+
 // UnsetEnvOverride removes a global environment variable override for a context
 func (r *Registry) UnsetEnvOverride(projectPath, contextName, key string) error {
 	return r.UnsetEnvOverrideForService(projectPath, contextName, key, "")
@@ -355,6 +686,42 @@ func (r *Registry) DeleteContext(projectPath, contextName string) error {
 	return nil
 }
 
+// MigrateLegacyEnvOverrides moves a context's deprecated EnvOverrides into
+// EnvOverridesV2.Global. Existing V2 values win over legacy values with the
+// same key. Returns the number of legacy overrides that were migrated.
+func (r *Registry) MigrateLegacyEnvOverrides(projectPath, contextName string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	project, exists := r.Projects[projectPath]
+	if !exists {
+		return 0, ErrProjectNotFound
+	}
+
+	ctx, exists := project.Contexts[contextName]
+	if !exists {
+		return 0, ErrContextNotFound
+	}
+
+	count := len(ctx.EnvOverrides)
+	if count == 0 {
+		return 0, nil
+	}
+
+	for k, v := range ctx.EnvOverrides {
+		if ctx.EnvOverridesV2 != nil && ctx.EnvOverridesV2.Global != nil {
+			if _, exists := ctx.EnvOverridesV2.Global[k]; exists {
+				continue
+			}
+		}
+		ctx.SetEnvOverride(k, v, "")
+	}
+	ctx.EnvOverrides = nil
+
+	project.Contexts[contextName] = ctx
+	return count, nil
+}
+
 // ListContexts returns all contexts for a given project
 func (r *Registry) ListContexts(projectPath string) (map[string]Context, error) {
 	r.mu.RLock()
@@ -413,17 +780,45 @@ func (r *Registry) Close() error {
 	return nil
 }
 
+// Update loads the registry for projectRoot under the file lock, calls fn and,
+// if fn succeeds, saves the registry. The lock is always released, so callers
+// cannot leak it on an early return. Errors from fn are returned unchanged.
+func Update(projectRoot string, fn func(*Registry) error) (err error) {
+	reg, err := LoadRegistry(projectRoot)
+	if err != nil {
+		return fmt.Errorf("failed to load registry: %w", err)
+	}
+	defer func() {
+		if closeErr := reg.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	if err := fn(reg); err != nil {
+		return err
+	}
+
+	if err := reg.SaveRegistry(); err != nil {
+		return fmt.Errorf("failed to save registry: %w", err)
+	}
+	return nil
+}
+
 // GetEnvOverrides returns environment overrides for a context
 // serviceName can be empty string for global overrides
 func (c *Context) GetEnvOverrides(serviceName string) map[string]string {
-	// If nil, return empty map
-	if c.EnvOverridesV2 == nil {
-		return make(map[string]string)
-	}
-
 	// Merge global and service-specific overrides
 	result := make(map[string]string)
 
+	// Unmigrated legacy overrides act as the lowest-priority global layer
+	for k, v := range c.EnvOverrides {
+		result[k] = v
+	}
+
+	if c.EnvOverridesV2 == nil {
+		return result
+	}
+
 	// Start with global overrides
 	for k, v := range c.EnvOverridesV2.Global {
 		result[k] = v
@@ -441,6 +836,57 @@ func (c *Context) GetEnvOverrides(serviceName string) map[string]string {
 	return result
 }
 
+// GetEnvOverridesForEnvironment returns GetEnvOverrides(serviceName) with the
+// overlay for environment applied on top. An empty environment adds nothing.
+func (c *Context) GetEnvOverridesForEnvironment(serviceName, environment string) map[string]string {
+	result := c.GetEnvOverrides(serviceName)
+	for k, v := range c.GetEnvironmentOverrides(environment) {
+		result[k] = v
+	}
+	return result
+}
+
+// GetEnvironmentOverrides returns the overlay for the named environment
+func (c *Context) GetEnvironmentOverrides(environment string) map[string]string {
+	result := make(map[string]string)
+	if c.EnvOverridesV2 == nil || environment == "" {
+		return result
+	}
+	for k, v := range c.EnvOverridesV2.Environments[environment] {
+		result[k] = v
+	}
+	return result
+}
+
+// SetEnvironmentOverride sets an override in the overlay for the named environment
+func (c *Context) SetEnvironmentOverride(key, value, environment string) {
+	if c.EnvOverridesV2 == nil {
+		c.EnvOverridesV2 = &ContextEnvOverrides{}
+	}
+	if c.EnvOverridesV2.Environments == nil {
+		c.EnvOverridesV2.Environments = make(map[string]map[string]string)
+	}
+	if c.EnvOverridesV2.Environments[environment] == nil {
+		c.EnvOverridesV2.Environments[environment] = make(map[string]string)
+	}
+	c.EnvOverridesV2.Environments[environment][key] = value
+}
+
+// UnsetEnvironmentOverride removes an override from the overlay for the named
+// environment, dropping the overlay once it is empty
+func (c *Context) UnsetEnvironmentOverride(key, environment string) {
+	if c.EnvOverridesV2 == nil {
+		return
+	}
+	delete(c.EnvOverridesV2.Environments[environment], key)
+	if len(c.EnvOverridesV2.Environments[environment]) == 0 {
+		delete(c.EnvOverridesV2.Environments, environment)
+	}
+	if len(c.EnvOverridesV2.Environments) == 0 {
+		c.EnvOverridesV2.Environments = nil
+	}
+}
+
 // SetEnvOverride sets an environment override for a context
 // serviceName can be empty string for global overrides
 func (c *Context) SetEnvOverride(key, value, serviceName string) {
@@ -488,6 +934,80 @@ func (c *Context) UnsetEnvOverride(key, serviceName string) {
 			delete(c.EnvOverridesV2.Services[serviceName], key)
 		}
 	}
+
+	// A note without its override is meaningless
+	c.SetEnvOverrideNote(key, "", serviceName)
+}
+
+// SetEnvOverrideNote attaches a note to an override, or removes it if note is empty
+// serviceName can be empty string for global overrides
+func (c *Context) SetEnvOverrideNote(key, note, serviceName string) {
+	if c.EnvOverridesV2 == nil {
+		if note == "" {
+			return
+		}
+		c.EnvOverridesV2 = &ContextEnvOverrides{}
+	}
+	overrides := c.EnvOverridesV2
+
+	if serviceName == "" {
+		if note == "" {
+			delete(overrides.GlobalNotes, key)
+			if len(overrides.GlobalNotes) == 0 {
+				overrides.GlobalNotes = nil
+			}
+			return
+		}
+		if overrides.GlobalNotes == nil {
+			overrides.GlobalNotes = make(map[string]string)
+		}
+		overrides.GlobalNotes[key] = note
+		return
+	}
+
+	if note == "" {
+		delete(overrides.ServiceNotes[serviceName], key)
+		if len(overrides.ServiceNotes[serviceName]) == 0 {
+			delete(overrides.ServiceNotes, serviceName)
+		}
+		if len(overrides.ServiceNotes) == 0 {
+			overrides.ServiceNotes = nil
+		}
+		return
+	}
+	if overrides.ServiceNotes == nil {
+		overrides.ServiceNotes = make(map[string]map[string]string)
+	}
+	if overrides.ServiceNotes[serviceName] == nil {
+		overrides.ServiceNotes[serviceName] = make(map[string]string)
+	}
+	overrides.ServiceNotes[serviceName][key] = note
+}
+
+// GetEnvOverrideNotes returns the notes for the overrides GetEnvOverrides
+// returns, taken from the layer that supplies each value
+// serviceName can be empty string for global overrides
+func (c *Context) GetEnvOverrideNotes(serviceName string) map[string]string {
+	result := make(map[string]string)
+	if c.EnvOverridesV2 == nil {
+		return result
+	}
+
+	for k, note := range c.EnvOverridesV2.GlobalNotes {
+		result[k] = note
+	}
+
+	// A service override replaces the global value, and with it the global note
+	if serviceName != "" {
+		for k := range c.EnvOverridesV2.Services[serviceName] {
+			delete(result, k)
+		}
+		for k, note := range c.EnvOverridesV2.ServiceNotes[serviceName] {
+			result[k] = note
+		}
+	}
+
+	return result
 }
 
 // GetEnvOverrideValue returns the value of a specific override
@@ -503,3 +1023,10 @@ func (c *Context) HasEnvOverride(key, serviceName string) bool {
 	_, exists := overrides[key]
 	return exists
 }
+
+// HasEnvOverrideAt checks if an override exists in exactly the given layer,
+// without the global overrides a service inherits
+func (c *Context) HasEnvOverrideAt(key, serviceName string) bool {
+	_, exists := c.envOverrideAt(key, serviceName)
+	return exists
+}
@@ -2,8 +2,10 @@ package registry
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -61,6 +63,88 @@ func TestLoadRegistry_CorruptFile(t *testing.T) {
 	}
 }
 
+// TestLoadRegistry_SchemaTooNew tests that a registry from a newer dual is left untouched
+func TestLoadRegistry_SchemaTooNew(t *testing.T) {
+	projectRoot := t.TempDir()
+
+	registryDir := filepath.Join(projectRoot, ".dual", ".local")
+	if err := os.MkdirAll(registryDir, 0o755); err != nil {
+		t.Fatalf("Failed to create registry directory: %v", err)
+	}
+	registryPath := filepath.Join(registryDir, "registry.json")
+	content := []byte(`{"schemaVersion": 99, "projects": {}}`)
+	if err := os.WriteFile(registryPath, content, 0o644); err != nil {
+		t.Fatalf("Failed to write registry: %v", err)
+	}
+
+	if version, err := ReadSchemaVersion(projectRoot); err != nil || version != 99 {
+		t.Errorf("ReadSchemaVersion() = %d, %v; want 99", version, err)
+	}
+
+	if _, err := LoadRegistry(projectRoot); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("LoadRegistry() error = %v, want ErrSchemaTooNew", err)
+	}
+
+	// The lock was released, so loading again fails the same way instead of timing out
+	if _, err := LoadRegistry(projectRoot); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("second LoadRegistry() error = %v, want ErrSchemaTooNew", err)
+	}
+	if data, _ := os.ReadFile(registryPath); string(data) != string(content) {
+		t.Errorf("registry file was modified: %s", data)
+	}
+}
+
+// TestLoadRegistry_ReadOnly tests that an unwritable registry can still be read
+func TestLoadRegistry_ReadOnly(t *testing.T) {
+	projectRoot := t.TempDir()
+
+	seed := &Registry{Projects: make(map[string]Project), projectRoot: projectRoot}
+	if err := seed.SetContext("/test/project", "main", "/test/project"); err != nil {
+		t.Fatalf("SetContext() failed: %v", err)
+	}
+	if err := seed.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry() failed: %v", err)
+	}
+
+	registryPath, _ := GetRegistryPath(projectRoot)
+	registry, err := loadReadOnly(projectRoot, registryPath, syscall.EROFS)
+	if err != nil {
+		t.Fatalf("loadReadOnly() failed: %v", err)
+	}
+	defer registry.Close()
+
+	if !registry.ContextExists("/test/project", "main") {
+		t.Error("expected context main to be readable")
+	}
+	if err := registry.SaveRegistry(); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SaveRegistry() error = %v, want ErrReadOnly", err)
+	}
+	if !isNotWritable(&os.PathError{Op: "open", Path: registryPath, Err: syscall.EACCES}) {
+		t.Error("expected EACCES to count as not writable")
+	}
+}
+
+// TestRegistryDirEnvVar tests relocating the registry with DUAL_REGISTRY_DIR
+func TestRegistryDirEnvVar(t *testing.T) {
+	projectRoot := t.TempDir()
+	registryDir := filepath.Join(t.TempDir(), "registry")
+	t.Setenv(RegistryDirEnvVar, registryDir)
+
+	err := Update(projectRoot, func(reg *Registry) error {
+		return reg.SetContext(projectRoot, "main", projectRoot)
+	})
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(registryDir, "registry.json")); err != nil {
+		t.Errorf("expected registry in %s: %v", registryDir, err)
+	}
+	if _, err := os.Stat(filepath.Join(projectRoot, ".dual")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing written to the project, got %v", err)
+	}
+}
+
 // TestLoadRegistry_ValidFile tests loading a valid registry
 func TestLoadRegistry_ValidFile(t *testing.T) {
 	// Use a temporary directory as project root
@@ -176,6 +260,130 @@ func TestSaveRegistry(t *testing.T) {
 	}
 }
 
+// TestSaveRegistry_MergesConcurrentWrites tests that a save keeps changes
+// another writer made to the file after this registry was loaded
+func TestSaveRegistry_MergesConcurrentWrites(t *testing.T) {
+	projectRoot := t.TempDir()
+	const project = "/test/project"
+
+	seed := &Registry{Projects: make(map[string]Project), projectRoot: projectRoot}
+	for _, name := range []string{"keep", "edit", "remove", "theirs-edit"} {
+		if err := seed.SetContext(project, name, "/wt/"+name); err != nil {
+			t.Fatalf("SetContext(%s) failed: %v", name, err)
+		}
+	}
+	if err := seed.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry() failed: %v", err)
+	}
+
+	ours, err := LoadRegistry(projectRoot)
+	if err != nil {
+		t.Fatalf("LoadRegistry() failed: %v", err)
+	}
+	defer ours.Close()
+
+	// Another writer that bypassed the lock saves in between
+	theirs := &Registry{Projects: cloneProjects(ours.Projects), loaded: cloneProjects(ours.Projects), projectRoot: projectRoot}
+	if err := theirs.SetContext(project, "theirs-new", "/wt/theirs-new"); err != nil {
+		t.Fatalf("SetContext failed: %v", err)
+	}
+	if err := theirs.SetEnvOverrideForService(project, "theirs-edit", "FROM", "theirs", ""); err != nil {
+		t.Fatalf("SetEnvOverrideForService failed: %v", err)
+	}
+	if err := theirs.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry() failed: %v", err)
+	}
+
+	// Our own changes, made on the stale in-memory copy
+	if err := ours.SetContext(project, "ours-new", "/wt/ours-new"); err != nil {
+		t.Fatalf("SetContext failed: %v", err)
+	}
+	if err := ours.SetEnvOverrideForService(project, "edit", "FROM", "ours", ""); err != nil {
+		t.Fatalf("SetEnvOverrideForService failed: %v", err)
+	}
+	if err := ours.DeleteContext(project, "remove"); err != nil {
+		t.Fatalf("DeleteContext failed: %v", err)
+	}
+	if err := ours.SaveRegistry(); err != nil {
+		t.Fatalf("SaveRegistry() failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectRoot, ".dual", ".local", "registry.json"))
+	if err != nil {
+		t.Fatalf("failed to read registry: %v", err)
+	}
+	var saved struct {
+		Projects map[string]Project `json:"projects"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("failed to parse registry: %v", err)
+	}
+
+	contexts := saved.Projects[project].Contexts
+	for _, name := range []string{"keep", "edit", "theirs-edit", "theirs-new", "ours-new"} {
+		if _, ok := contexts[name]; !ok {
+			t.Errorf("expected context %q to be saved, got %v", name, contexts)
+		}
+	}
+	if _, ok := contexts["remove"]; ok {
+		t.Error("expected context \"remove\" to stay deleted")
+	}
+	edit, theirsEdit := contexts["edit"], contexts["theirs-edit"]
+	if got := edit.GetEnvOverrideValue("FROM", ""); got != "ours" {
+		t.Errorf("edit FROM = %q, want %q", got, "ours")
+	}
+	if got := theirsEdit.GetEnvOverrideValue("FROM", ""); got != "theirs" {
+		t.Errorf("theirs-edit FROM = %q, want %q", got, "theirs")
+	}
+
+	// The in-memory registry reflects the merged state
+	if !ours.ContextExists(project, "theirs-new") {
+		t.Error("expected merged context theirs-new in memory")
+	}
+}
+
+// TestUpdate tests that Update saves on success, skips the save on error and releases the lock
+func TestUpdate(t *testing.T) {
+	projectRoot := t.TempDir()
+	const project = "/test/project"
+
+	err := Update(projectRoot, func(reg *Registry) error {
+		return reg.SetContext(project, "saved", "/wt/saved")
+	})
+	if err != nil {
+		t.Fatalf("Update() failed: %v", err)
+	}
+
+	wantErr := errors.New("boom")
+	err = Update(projectRoot, func(reg *Registry) error {
+		if err := reg.SetContext(project, "discarded", "/wt/discarded"); err != nil {
+			return err
+		}
+		return wantErr
+	})
+	if !errors.Is(err, wantErr) {
+		t.Fatalf("Update() error = %v, want %v", err, wantErr)
+	}
+
+	// Loading again must not wait for a lock left behind by Update
+	oldTimeout := LockTimeout
+	LockTimeout = 500 * time.Millisecond
+	defer func() { LockTimeout = oldTimeout }()
+
+	reg, err := LoadRegistry(projectRoot)
+	if err != nil {
+		t.Fatalf("LoadRegistry() failed: %v", err)
+	}
+	defer reg.Close()
+
+	if !reg.ContextExists(project, "saved") {
+		t.Error("expected context \"saved\" to be saved")
+	}
+	if reg.ContextExists(project, "discarded") {
+		t.Error("expected context \"discarded\" not to be saved")
+	}
+}
+
 // TestGetContext tests retrieving a context
 func TestGetContext(t *testing.T) {
 	registry := &Registry{
@@ -302,6 +510,161 @@ func TestDeleteContext(t *testing.T) {
 	}
 }
 
+// TestMigrateLegacyEnvOverrides tests moving pre-V2 overrides into EnvOverridesV2
+func TestMigrateLegacyEnvOverrides(t *testing.T) {
+	registry := &Registry{
+		Projects: map[string]Project{
+			"/test/project": {
+				Contexts: map[string]Context{
+					"feature": {
+						Created:      time.Now(),
+						EnvOverrides: map[string]string{"DATABASE_URL": "legacy", "DEBUG": "true"},
+						EnvOverridesV2: &ContextEnvOverrides{
+							Global: map[string]string{"DATABASE_URL": "current"},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// Legacy values are visible before migration, but V2 wins
+	ctx, err := registry.GetContext("/test/project", "feature")
+	if err != nil {
+		t.Fatalf("GetContext() failed: %v", err)
+	}
+	overrides := ctx.GetEnvOverrides("")
+	if overrides["DATABASE_URL"] != "current" || overrides["DEBUG"] != "true" {
+		t.Errorf("GetEnvOverrides() before migration = %v", overrides)
+	}
+
+	count, err := registry.MigrateLegacyEnvOverrides("/test/project", "feature")
+	if err != nil {
+		t.Fatalf("MigrateLegacyEnvOverrides() failed: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("MigrateLegacyEnvOverrides() = %d, want 2", count)
+	}
+
+	ctx, _ = registry.GetContext("/test/project", "feature")
+	if len(ctx.EnvOverrides) != 0 {
+		t.Errorf("legacy overrides should be cleared, got %v", ctx.EnvOverrides)
+	}
+	if ctx.EnvOverridesV2.Global["DATABASE_URL"] != "current" {
+		t.Errorf("existing V2 value should win, got %q", ctx.EnvOverridesV2.Global["DATABASE_URL"])
+	}
+	if ctx.EnvOverridesV2.Global["DEBUG"] != "true" {
+		t.Errorf("legacy value should be migrated, got %q", ctx.EnvOverridesV2.Global["DEBUG"])
+	}
+
+	if _, err := registry.MigrateLegacyEnvOverrides("/test/project", "missing"); err != ErrContextNotFound {
+		t.Errorf("Expected ErrContextNotFound, got %v", err)
+	}
+}
+
+// TestEnvOverrideNotes tests attaching notes to overrides
+func TestEnvOverrideNotes(t *testing.T) {
+	registry := &Registry{Projects: make(map[string]Project)}
+	if err := registry.SetContext("/test/project", "feature", "/wt/feature"); err != nil {
+		t.Fatalf("SetContext() failed: %v", err)
+	}
+
+	for _, o := range []struct{ key, value, service, note string }{
+		{"DEBUG", "true", "", "investigating #123"},
+		{"LOG_LEVEL", "debug", "", "noisy"},
+		{"LOG_LEVEL", "info", "api", ""},
+	} {
+		if err := registry.SetEnvOverrideForService("/test/project", "feature", o.key, o.value, o.service); err != nil {
+			t.Fatalf("SetEnvOverrideForService(%s) failed: %v", o.key, err)
+		}
+		if err := registry.SetEnvOverrideNote("/test/project", "feature", o.key, o.note, o.service); err != nil {
+			t.Fatalf("SetEnvOverrideNote(%s) failed: %v", o.key, err)
+		}
+	}
+
+	ctx, _ := registry.GetContext("/test/project", "feature")
+	notes := ctx.GetEnvOverrideNotes("")
+	if notes["DEBUG"] != "investigating #123" || notes["LOG_LEVEL"] != "noisy" {
+		t.Errorf("GetEnvOverrideNotes(\"\") = %v", notes)
+	}
+
+	// The api override replaces the global LOG_LEVEL and its note
+	notes = ctx.GetEnvOverrideNotes("api")
+	if _, ok := notes["LOG_LEVEL"]; ok {
+		t.Errorf("expected no LOG_LEVEL note for api, got %v", notes)
+	}
+	if notes["DEBUG"] != "investigating #123" {
+		t.Errorf("expected global DEBUG note for api, got %v", notes)
+	}
+
+	// Unsetting an override drops its note
+	if err := registry.UnsetEnvOverrideForService("/test/project", "feature", "DEBUG", ""); err != nil {
+		t.Fatalf("UnsetEnvOverrideForService() failed: %v", err)
+	}
+	ctx, _ = registry.GetContext("/test/project", "feature")
+	if _, ok := ctx.GetEnvOverrideNotes("")["DEBUG"]; ok {
+		t.Error("expected DEBUG note to be removed with its override")
+	}
+
+	if err := registry.SetEnvOverrideNote("/test/project", "missing", "DEBUG", "x", ""); err != ErrContextNotFound {
+		t.Errorf("Expected ErrContextNotFound, got %v", err)
+	}
+}
+
+func TestEnvironmentOverrides(t *testing.T) {
+	registry := &Registry{Projects: make(map[string]Project)}
+	if err := registry.SetContext("/test/project", "feature", "/wt/feature"); err != nil {
+		t.Fatalf("SetContext() failed: %v", err)
+	}
+
+	for _, o := range []struct{ key, value, service string }{
+		{"API_URL", "http://localhost:4000", ""},
+		{"LOG_LEVEL", "debug", ""},
+		{"LOG_LEVEL", "trace", "api"},
+	} {
+		if err := registry.SetEnvOverrideForService("/test/project", "feature", o.key, o.value, o.service); err != nil {
+			t.Fatalf("SetEnvOverrideForService(%s) failed: %v", o.key, err)
+		}
+	}
+	if err := registry.SetEnvOverrideForEnvironment("/test/project", "feature", "API_URL", "https://staging.example.com", "staging"); err != nil {
+		t.Fatalf("SetEnvOverrideForEnvironment() failed: %v", err)
+	}
+	if err := registry.SetEnvOverrideForEnvironment("/test/project", "feature", "LOG_LEVEL", "warn", "staging"); err != nil {
+		t.Fatalf("SetEnvOverrideForEnvironment() failed: %v", err)
+	}
+
+	ctx, _ := registry.GetContext("/test/project", "feature")
+
+	// The default environment is unaffected by overlays
+	if got := ctx.GetEnvOverrides("api"); got["API_URL"] != "http://localhost:4000" || got["LOG_LEVEL"] != "trace" {
+		t.Errorf("GetEnvOverrides(api) = %v", got)
+	}
+	if got := ctx.GetEnvOverridesForEnvironment("api", ""); got["LOG_LEVEL"] != "trace" {
+		t.Errorf("GetEnvOverridesForEnvironment(api, \"\") = %v", got)
+	}
+
+	// The overlay wins over both global and service overrides
+	got := ctx.GetEnvOverridesForEnvironment("api", "staging")
+	if got["API_URL"] != "https://staging.example.com" || got["LOG_LEVEL"] != "warn" {
+		t.Errorf("GetEnvOverridesForEnvironment(api, staging) = %v", got)
+	}
+
+	// Removing the last key drops the overlay
+	for _, key := range []string{"API_URL", "LOG_LEVEL"} {
+		if err := registry.UnsetEnvOverrideForEnvironment("/test/project", "feature", key, "staging"); err != nil {
+			t.Fatalf("UnsetEnvOverrideForEnvironment(%s) failed: %v", key, err)
+		}
+	}
+	ctx, _ = registry.GetContext("/test/project", "feature")
+	if ctx.EnvOverridesV2.Environments != nil {
+		t.Errorf("Environments = %v, want nil", ctx.EnvOverridesV2.Environments)
+	}
+
+	if err := registry.SetEnvOverrideForEnvironment("/test/project", "missing", "A", "1", "staging"); err != ErrContextNotFound {
+		t.Errorf("Expected ErrContextNotFound, got %v", err)
+	}
+}
+
 // TestListContexts tests listing all contexts for a project
 func TestListContexts(t *testing.T) {
 	registry := &Registry{
@@ -486,6 +849,9 @@ func TestRegistryJSONFormat(t *testing.T) {
 	}
 
 	// Verify top-level structure
+	if rawRegistry["schemaVersion"] != float64(SchemaVersion) {
+		t.Errorf("Expected schemaVersion %d, got %v", SchemaVersion, rawRegistry["schemaVersion"])
+	}
 	projects, ok := rawRegistry["projects"].(map[string]interface{})
 	if !ok {
 		t.Fatal("Expected 'projects' field at top level")
@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/lightfastai/dual/internal/config"
@@ -18,6 +20,13 @@ var ErrServiceNotDetected = fmt.Errorf("no service detected for current director
 // ErrProjectRootNotFound is returned when the project root cannot be determined
 var ErrProjectRootNotFound = fmt.Errorf("project root not found")
 
+// ErrUnknownService is returned when an explicitly selected service is not in the config
+var ErrUnknownService = fmt.Errorf("service not found in config")
+
+// EnvVar is the environment variable that selects a service explicitly,
+// bypassing detection from the current working directory
+const EnvVar = "DUAL_SERVICE"
+
 // Detector handles service detection logic
 type Detector struct {
 	// gitCommand allows for dependency injection in tests
@@ -26,6 +35,8 @@ type Detector struct {
 	getwd func() (string, error)
 	// evalSymlinks allows for dependency injection in tests
 	evalSymlinks func(path string) (string, error)
+	// lookupEnv allows for dependency injection in tests
+	lookupEnv func(key string) (string, bool)
 }
 
 // NewDetector creates a new Detector with default implementations
@@ -34,7 +45,54 @@ func NewDetector() *Detector {
 		gitCommand:   execGitCommand,
 		getwd:        os.Getwd,
 		evalSymlinks: filepath.EvalSymlinks,
+		lookupEnv:    os.LookupEnv,
+	}
+}
+
+// NewDetectorInDir creates a Detector that detects the service for dir
+// instead of the current working directory
+func NewDetectorInDir(dir string) *Detector {
+	d := NewDetector()
+	d.getwd = func() (string, error) {
+		return filepath.Abs(dir)
+	}
+	return d
+}
+
+// ResolveService determines the service to use. In order of precedence:
+//  1. explicit (typically the --service flag)
+//  2. the DUAL_SERVICE environment variable
+//  3. detection from the current working directory
+//
+// An explicit or DUAL_SERVICE name must be a service in cfg.Services or one
+// of its aliases; the canonical service name is returned.
+func (d *Detector) ResolveService(cfg *config.Config, projectRoot, explicit string) (string, error) {
+	name, source := explicit, "--service"
+	if name == "" {
+		if value, ok := d.lookupEnv(EnvVar); ok && value != "" {
+			name, source = value, EnvVar
+		}
 	}
+
+	if name == "" {
+		return d.DetectService(cfg, projectRoot)
+	}
+
+	canonical, exists := cfg.ResolveServiceName(name)
+	if !exists {
+		names := make([]string, 0, len(cfg.Services))
+		for n := range cfg.Services {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return "", fmt.Errorf("%w: %q (from %s)\nAvailable services: %v", ErrUnknownService, name, source, names)
+	}
+
+	if canonical != name {
+		logger.Debug("Service alias %s resolves to %s", name, canonical)
+	}
+	logger.Debug("Service %s selected by %s", canonical, source)
+	return canonical, nil
 }
 
 // DetectService detects which service the current working directory belongs to
@@ -81,27 +139,61 @@ func (d *Detector) DetectService(cfg *config.Config, projectRoot string) (string
 
 	// Check if CWD is within any service path
 	// We need to find the longest matching path for nested structures
-	var longestMatch string
-	var longestMatchLen int
-
+	var matches []string
 	for name, servicePath := range servicePaths {
-		// Check if CWD is within this service path
 		if isWithinPath(resolvedCwd, servicePath) {
-			matchLen := len(servicePath)
-			if matchLen > longestMatchLen {
-				longestMatch = name
-				longestMatchLen = matchLen
-				logger.Debug("  %s: Match!", name)
-			}
+			matches = append(matches, name)
 		}
 	}
 
-	if longestMatch == "" {
-		return "", ErrServiceNotDetected
+	// Longest path first; ties broken by name so detection is deterministic
+	sort.Slice(matches, func(i, j int) bool {
+		li, lj := len(servicePaths[matches[i]]), len(servicePaths[matches[j]])
+		if li != lj {
+			return li > lj
+		}
+		return matches[i] < matches[j]
+	})
+
+	// Skip services whose ignore patterns cover CWD, falling back to the
+	// next-longest match (e.g. an enclosing service)
+	for _, name := range matches {
+		if pattern, ignored := isIgnored(cfg.Services[name].Ignore, servicePaths[name], resolvedCwd); ignored {
+			logger.Debug("  %s: Match, but ignored by %q", name, pattern)
+			continue
+		}
+		logger.Debug("  %s: Match!", name)
+		logger.Success("Service: %s", name)
+		return name, nil
 	}
 
-	logger.Success("Service: %s", longestMatch)
-	return longestMatch, nil
+	return "", ErrServiceNotDetected
+}
+
+// isIgnored reports whether targetPath lies in a subdirectory of servicePath
+// matched by one of the ignore patterns, returning the matching pattern.
+// Patterns are globs relative to servicePath and match a directory and
+// everything below it.
+func isIgnored(patterns []string, servicePath, targetPath string) (string, bool) {
+	if len(patterns) == 0 {
+		return "", false
+	}
+
+	rel, err := filepath.Rel(servicePath, targetPath)
+	if err != nil || rel == "." {
+		return "", false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+
+	for _, pattern := range patterns {
+		pattern = filepath.ToSlash(filepath.Clean(pattern))
+		for i := 1; i <= len(parts); i++ {
+			if matched, _ := path.Match(pattern, strings.Join(parts[:i], "/")); matched {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
 }
 
 // FindProjectRoot attempts to find the project root using git or by walking up the directory tree
@@ -186,6 +278,19 @@ func DetectService(cfg *config.Config, projectRoot string) (string, error) {
 	return detector.DetectService(cfg, projectRoot)
 }
 
+// ResolveService is a convenience function that creates a detector and resolves the service
+func ResolveService(cfg *config.Config, projectRoot, explicit string) (string, error) {
+	detector := NewDetector()
+	return detector.ResolveService(cfg, projectRoot, explicit)
+}
+
+// ResolveServiceInDir is like ResolveService, but falls back to detecting the
+// service from dir instead of the current working directory
+func ResolveServiceInDir(cfg *config.Config, projectRoot, explicit, dir string) (string, error) {
+	detector := NewDetectorInDir(dir)
+	return detector.ResolveService(cfg, projectRoot, explicit)
+}
+
 // DetectServiceWithRoot is a convenience function that finds the project root and detects the service
 func DetectServiceWithRoot(cfg *config.Config) (string, string, error) {
 	detector := NewDetector()
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -188,6 +189,80 @@ func TestDetectService_NestedServices(t *testing.T) {
 	}
 }
 
+// TestDetectService_IgnorePatterns tests that ignore patterns skip a service,
+// falling back to an enclosing service when there is one
+func TestDetectService_IgnorePatterns(t *testing.T) {
+	cfg := &config.Config{
+		Version: 1,
+		Services: map[string]config.Service{
+			"parent": {Path: "apps", Ignore: []string{"legacy"}},
+			"child":  {Path: "apps/web", Ignore: []string{"node_modules", "packages/*/dist"}},
+		},
+	}
+
+	tests := []struct { //nolint:govet // Test struct optimization not critical
+		name        string
+		cwd         string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:     "child root is not ignored",
+			cwd:      "/project/apps/web",
+			expected: "child",
+		},
+		{
+			name:     "ignored child subpath falls back to parent",
+			cwd:      "/project/apps/web/node_modules/react",
+			expected: "parent",
+		},
+		{
+			name:     "glob ignore pattern",
+			cwd:      "/project/apps/web/packages/ui/dist/esm",
+			expected: "parent",
+		},
+		{
+			name:     "glob ignore does not match sibling",
+			cwd:      "/project/apps/web/packages/ui/src",
+			expected: "child",
+		},
+		{
+			name:     "pattern matches whole path components only",
+			cwd:      "/project/apps/web/node_modules_cache",
+			expected: "child",
+		},
+		{
+			name:        "ignored by parent with no enclosing service",
+			cwd:         "/project/apps/legacy/old",
+			expectedErr: ErrServiceNotDetected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := &Detector{
+				gitCommand:   mockGitCommand("", fmt.Errorf("not used")),
+				getwd:        mockGetwd(tt.cwd, nil),
+				evalSymlinks: mockEvalSymlinks(map[string]string{}),
+			}
+
+			result, err := detector.DetectService(cfg, "/project")
+			if tt.expectedErr != nil {
+				if err != tt.expectedErr {
+					t.Fatalf("expected error %v, got %v (result %q)", tt.expectedErr, err, result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
 // TestDetectService_SymlinkResolution tests that symlinks are properly resolved
 func TestDetectService_SymlinkResolution(t *testing.T) {
 	cfg := &config.Config{
@@ -430,6 +505,101 @@ func TestDetectService_ConvenienceFunction(t *testing.T) {
 	}
 }
 
+// TestResolveServiceInDir tests detection from a directory other than the cwd
+func TestResolveServiceInDir(t *testing.T) {
+	t.Setenv(EnvVar, "")
+
+	projectRoot := t.TempDir()
+	apiDir := filepath.Join(projectRoot, "apps", "api")
+	if err := os.MkdirAll(apiDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		Version: 1,
+		Services: map[string]config.Service{
+			"web": {Path: "apps/web"},
+			"api": {Path: "apps/api"},
+		},
+	}
+
+	got, err := ResolveServiceInDir(cfg, projectRoot, "", apiDir)
+	if err != nil {
+		t.Fatalf("ResolveServiceInDir() error = %v", err)
+	}
+	if got != "api" {
+		t.Errorf("ResolveServiceInDir() = %q, want api", got)
+	}
+
+	// An explicit service still takes precedence
+	got, err = ResolveServiceInDir(cfg, projectRoot, "web", apiDir)
+	if err != nil {
+		t.Fatalf("ResolveServiceInDir() error = %v", err)
+	}
+	if got != "web" {
+		t.Errorf("ResolveServiceInDir() = %q, want web", got)
+	}
+}
+
+// TestResolveService tests the --service > DUAL_SERVICE > cwd precedence
+func TestResolveService(t *testing.T) {
+	cfg := &config.Config{
+		Version: 1,
+		Services: map[string]config.Service{
+			"api": {Path: "apps/api"},
+			"web": {Path: "apps/web", Aliases: []string{"frontend"}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		explicit    string
+		envValue    string
+		cwd         string
+		expected    string
+		expectedErr error
+	}{
+		{name: "explicit wins over env and cwd", explicit: "api", envValue: "web", cwd: "/project/apps/web", expected: "api"},
+		{name: "env wins over cwd", envValue: "api", cwd: "/project/apps/web", expected: "api"},
+		{name: "falls back to cwd", cwd: "/project/apps/web", expected: "web"},
+		{name: "env works outside service dirs", envValue: "web", cwd: "/project", expected: "web"},
+		{name: "explicit alias resolves to service", explicit: "frontend", cwd: "/project", expected: "web"},
+		{name: "env alias resolves to service", envValue: "frontend", cwd: "/project/apps/api", expected: "web"},
+		{name: "unknown explicit service", explicit: "db", cwd: "/project", expectedErr: ErrUnknownService},
+		{name: "unknown env service", envValue: "db", cwd: "/project/apps/web", expectedErr: ErrUnknownService},
+		{name: "nothing matches", cwd: "/project", expectedErr: ErrServiceNotDetected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := &Detector{
+				getwd:        mockGetwd(tt.cwd, nil),
+				evalSymlinks: mockEvalSymlinks(nil),
+				lookupEnv: func(key string) (string, bool) {
+					if key == EnvVar && tt.envValue != "" {
+						return tt.envValue, true
+					}
+					return "", false
+				},
+			}
+
+			got, err := detector.ResolveService(cfg, "/project", tt.explicit)
+			if tt.expectedErr != nil {
+				if !errors.Is(err, tt.expectedErr) {
+					t.Fatalf("expected error %v, got %v", tt.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("expected service %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
 // TestDetectServiceWithRoot tests the convenience function that finds root and detects service
 func TestDetectServiceWithRoot(t *testing.T) {
 	// Create a temporary directory structure
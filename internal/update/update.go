@@ -0,0 +1,227 @@
+// Package update checks GitHub releases for a newer version of dual.
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultReleaseURL is the GitHub API endpoint for dual's latest release
+	DefaultReleaseURL = "https://api.github.com/repos/lightfastai/dual/releases/latest"
+
+	// CacheTTL is how long a successful check is reused before asking GitHub again
+	CacheTTL = 24 * time.Hour
+
+	// requestTimeout keeps a check from hanging on a slow or offline network
+	requestTimeout = 3 * time.Second
+
+	// cacheFileName is the file in the cache dir holding the last check
+	cacheFileName = "latest-release.json"
+)
+
+// Release is the latest published release, as cached between checks
+type Release struct {
+	Version   string    `json:"version"`
+	URL       string    `json:"url"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Result is the outcome of comparing the running version with the latest release
+type Result struct {
+	Current string
+	Latest  Release
+
+	// UpdateAvailable is true when Latest is newer than Current
+	UpdateAvailable bool
+
+	// Comparable is false when Current is not a release version (e.g. "dev")
+	Comparable bool
+
+	// Cached is true when Latest came from the cache instead of GitHub
+	Cached bool
+}
+
+// Checker looks up the latest release, caching the answer on disk
+type Checker struct {
+	// ReleaseURL is the GitHub API endpoint to query
+	ReleaseURL string
+
+	// CacheDir holds the cached result; caching is disabled when empty
+	CacheDir string
+
+	// Client performs the request
+	Client *http.Client
+
+	// now allows for dependency injection in tests
+	now func() time.Time
+}
+
+// NewChecker creates a Checker for dual's GitHub releases that caches in the
+// user cache directory ($XDG_CACHE_HOME/dual on Linux)
+func NewChecker() *Checker {
+	cacheDir := ""
+	if dir, err := os.UserCacheDir(); err == nil {
+		cacheDir = filepath.Join(dir, "dual")
+	}
+	return &Checker{
+		ReleaseURL: DefaultReleaseURL,
+		CacheDir:   cacheDir,
+		Client:     &http.Client{Timeout: requestTimeout},
+		now:        time.Now,
+	}
+}
+
+// Check compares current against the latest release. A cached release younger
+// than CacheTTL is used without a request. Network and API failures are
+// returned as errors for the caller to report; they never panic or block for
+// longer than the client timeout.
+func (c *Checker) Check(current string) (*Result, error) {
+	latest, cached := c.readCache()
+	if !cached {
+		release, err := c.fetch()
+		if err != nil {
+			return nil, err
+		}
+		latest = release
+		c.writeCache(latest)
+	}
+
+	result := &Result{Current: current, Latest: latest, Cached: cached}
+	if cmp, ok := CompareVersions(latest.Version, current); ok {
+		result.Comparable = true
+		result.UpdateAvailable = cmp > 0
+	}
+	return result, nil
+}
+
+// fetch asks the GitHub API for the latest release
+func (c *Checker) fetch() (Release, error) {
+	req, err := http.NewRequest(http.MethodGet, c.ReleaseURL, nil)
+	if err != nil {
+		return Release{}, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return Release{}, fmt.Errorf("failed to reach GitHub: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Release{}, fmt.Errorf("GitHub returned %s", resp.Status)
+	}
+
+	var body struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Release{}, fmt.Errorf("failed to parse GitHub response: %w", err)
+	}
+	if body.TagName == "" {
+		return Release{}, fmt.Errorf("GitHub response has no release tag")
+	}
+
+	return Release{Version: body.TagName, URL: body.HTMLURL, CheckedAt: c.now()}, nil
+}
+
+// readCache returns the cached release if there is one younger than CacheTTL
+func (c *Checker) readCache() (Release, bool) {
+	if c.CacheDir == "" {
+		return Release{}, false
+	}
+	// #nosec G304 - The cache file lives in dual's own cache directory
+	data, err := os.ReadFile(filepath.Join(c.CacheDir, cacheFileName))
+	if err != nil {
+		return Release{}, false
+	}
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil || release.Version == "" {
+		return Release{}, false
+	}
+	if age := c.now().Sub(release.CheckedAt); age < 0 || age >= CacheTTL {
+		return Release{}, false
+	}
+	return release, true
+}
+
+// writeCache stores the release for later checks. Failures only mean the
+// next check asks GitHub again, so they are ignored.
+func (c *Checker) writeCache(release Release) {
+	if c.CacheDir == "" {
+		return
+	}
+	data, err := json.Marshal(release)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.CacheDir, 0o750); err != nil {
+		return
+	}
+	_ = os.WriteFile(filepath.Join(c.CacheDir, cacheFileName), data, 0o600)
+}
+
+// CompareVersions compares two release versions such as "v1.2.3" or
+// "1.3.0-rc.1". It returns -1, 0 or 1, and false if either is not a release
+// version. A pre-release sorts before the release it precedes.
+func CompareVersions(a, b string) (int, bool) {
+	aCore, aPre, ok := parseVersion(a)
+	if !ok {
+		return 0, false
+	}
+	bCore, bPre, ok := parseVersion(b)
+	if !ok {
+		return 0, false
+	}
+
+	for i := range aCore {
+		if aCore[i] != bCore[i] {
+			if aCore[i] < bCore[i] {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0, true
+	case aPre == "":
+		return 1, true
+	case bPre == "":
+		return -1, true
+	case aPre < bPre:
+		return -1, true
+	default:
+		return 1, true
+	}
+}
+
+// parseVersion splits "v1.2.3-rc.1+build" into [1 2 3] and "rc.1"
+func parseVersion(v string) ([3]int, string, bool) {
+	var core [3]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, pre, _ := strings.Cut(v, "-")
+
+	parts := strings.Split(v, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return core, "", false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return core, "", false
+		}
+		core[i] = n
+	}
+	return core, pre, true
+}
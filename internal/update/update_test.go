@@ -0,0 +1,124 @@
+package update
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b   string
+		want   int
+		wantOK bool
+	}{
+		{a: "v1.2.3", b: "1.2.3", want: 0, wantOK: true},
+		{a: "v1.3.0", b: "v1.2.9", want: 1, wantOK: true},
+		{a: "v1.10.0", b: "v1.9.0", want: 1, wantOK: true},
+		{a: "v2", b: "v1.99.99", want: 1, wantOK: true},
+		{a: "v1.2.3", b: "v1.2.4", want: -1, wantOK: true},
+		{a: "v1.3.0-rc.1", b: "v1.3.0", want: -1, wantOK: true},
+		{a: "v1.3.0", b: "v1.3.0-rc.1", want: 1, wantOK: true},
+		{a: "v1.3.0+build.5", b: "v1.3.0", want: 0, wantOK: true},
+		{a: "v1.2.3", b: "dev", wantOK: false},
+		{a: "latest", b: "v1.0.0", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := CompareVersions(tt.a, tt.b)
+		if ok != tt.wantOK || (ok && got != tt.want) {
+			t.Errorf("CompareVersions(%q, %q) = %d, %v, want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// newTestChecker returns a Checker against server with a fixed clock
+func newTestChecker(t *testing.T, url string, now time.Time) *Checker {
+	t.Helper()
+	return &Checker{
+		ReleaseURL: url,
+		CacheDir:   t.TempDir(),
+		Client:     &http.Client{Timeout: time.Second},
+		now:        func() time.Time { return now },
+	}
+}
+
+func TestCheck(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"tag_name": "v1.4.0", "html_url": "https://example.com/v1.4.0"}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	checker := newTestChecker(t, server.URL, now)
+
+	result, err := checker.Check("v1.3.2")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if !result.UpdateAvailable || !result.Comparable || result.Cached {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Latest.Version != "v1.4.0" || result.Latest.URL != "https://example.com/v1.4.0" {
+		t.Errorf("unexpected latest release: %+v", result.Latest)
+	}
+
+	// A second check within a day uses the cache
+	result, err = checker.Check("v1.4.0")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if requests != 1 || !result.Cached || result.UpdateAvailable {
+		t.Errorf("expected cached up-to-date result after 1 request, got %d requests and %+v", requests, result)
+	}
+
+	// After a day the cache is stale
+	checker.now = func() time.Time { return now.Add(CacheTTL) }
+	if _, err := checker.Check("v1.4.0"); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("expected a new request after the cache expired, got %d requests", requests)
+	}
+}
+
+func TestCheck_DevVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"tag_name": "v1.4.0"}`))
+	}))
+	defer server.Close()
+
+	result, err := newTestChecker(t, server.URL, time.Now()).Check("dev")
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if result.Comparable || result.UpdateAvailable {
+		t.Errorf("dev builds should not be comparable: %+v", result)
+	}
+}
+
+func TestCheck_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	checker := newTestChecker(t, server.URL, time.Now())
+	if _, err := checker.Check("v1.0.0"); err == nil {
+		t.Error("expected an error for a non-200 response")
+	}
+	if _, err := os.Stat(filepath.Join(checker.CacheDir, cacheFileName)); !os.IsNotExist(err) {
+		t.Errorf("failed checks must not be cached, stat error = %v", err)
+	}
+
+	// An unreachable server fails instead of hanging
+	server.Close()
+	if _, err := checker.Check("v1.0.0"); err == nil {
+		t.Error("expected an error for an unreachable server")
+	}
+}
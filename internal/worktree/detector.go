@@ -20,6 +20,15 @@ type Detector struct {
 	evalSymlinks func(path string) (string, error)
 }
 
+// Worktree is one entry of 'git worktree list --porcelain'
+type Worktree struct {
+	Path     string
+	Head     string
+	Branch   string // short branch name; empty when detached or bare
+	Bare     bool
+	Detached bool
+}
+
 // NewDetector creates a new Detector with default implementations
 func NewDetector() *Detector {
 	return &Detector{
@@ -74,8 +83,21 @@ func (d *Detector) IsWorktree(dir string) (bool, error) {
 
 // GetParentRepo returns the path to the parent repository for a worktree
 // Returns an error if the directory is not a worktree or if the parent cannot be found
+//
+// It is equivalent to GetParentWorkTree and kept for existing callers.
 func (d *Detector) GetParentRepo(worktreeDir string) (string, error) {
-	// First verify it's actually a worktree
+	return d.GetParentWorkTree(worktreeDir)
+}
+
+// GetCommonGitDir returns the absolute path of the git directory shared by all
+// worktrees of a repository (e.g. /path/to/repo/.git), given a linked worktree.
+//
+// The worktree's .git file points at its private gitdir
+// (<common>/worktrees/<name>), which may be relative to the worktree when
+// git is configured with worktree.useRelativePaths. The private gitdir's
+// "commondir" file, when present, is the authoritative link back to the
+// common dir; otherwise the common dir is two levels above the gitdir.
+func (d *Detector) GetCommonGitDir(worktreeDir string) (string, error) {
 	isWT, err := d.IsWorktree(worktreeDir)
 	if err != nil {
 		return "", err
@@ -97,31 +119,46 @@ func (d *Detector) GetParentRepo(worktreeDir string) (string, error) {
 		return "", fmt.Errorf("invalid .git file format: expected 'gitdir: <path>'")
 	}
 
-	// Extract the gitdir path
 	gitdir := strings.TrimPrefix(line, "gitdir: ")
+	if !filepath.IsAbs(gitdir) {
+		gitdir = filepath.Join(worktreeDir, gitdir)
+	}
+	gitdir = filepath.Clean(gitdir)
+
+	if commondir, err := d.readFile(filepath.Join(gitdir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(commondir))
+		if common != "" {
+			if !filepath.IsAbs(common) {
+				common = filepath.Join(gitdir, common)
+			}
+			return filepath.Clean(common), nil
+		}
+	}
 
-	// Use git rev-parse to find the parent repo's toplevel
-	// The gitdir points to something like: /path/to/repo/.git/worktrees/name
-	// or for submodules: /path/to/parent/.git/modules/submodule/worktrees/name
-	//
-	// The common-dir (parent of gitdir) is the .git directory that knows about the repo
-	// Using git rev-parse --show-toplevel on that location gives us the working tree root
-	commonDir := filepath.Dir(gitdir)
+	// <common>/worktrees/<name>
+	return filepath.Dir(filepath.Dir(gitdir)), nil
+}
 
-	// Run git command to get the toplevel directory
-	cmd := exec.Command("git", "-C", commonDir, "rev-parse", "--show-toplevel")
-	output, err := cmd.Output()
+// GetParentWorkTree returns the working tree root of the repository a linked
+// worktree belongs to, i.e. the main checkout directory rather than its .git.
+//
+//   - Normal repositories: the common dir is <root>/.git, so <root> is returned
+//   - Submodules: the common dir is <super>/.git/modules/<name>, whose
+//     core.worktree setting names the submodule's working tree
+//   - Bare repositories have no working tree; the bare repository directory
+//     itself is returned so all its worktrees share one identifier
+func (d *Detector) GetParentWorkTree(worktreeDir string) (string, error) {
+	commonDir, err := d.GetCommonGitDir(worktreeDir)
 	if err != nil {
-		// Fallback to old behavior (three directories up) if git command fails
-		parentRepo := filepath.Dir(filepath.Dir(filepath.Dir(gitdir)))
-		resolved, err := d.evalSymlinks(parentRepo)
-		if err != nil {
-			return parentRepo, nil
-		}
-		return resolved, nil
+		return "", err
 	}
 
-	parentRepo := strings.TrimSpace(string(output))
+	var parentRepo string
+	if filepath.Base(commonDir) == ".git" {
+		parentRepo = filepath.Dir(commonDir)
+	} else {
+		parentRepo = d.workTreeForGitDir(commonDir)
+	}
 
 	// Validate the parent repo exists
 	if _, err := d.stat(parentRepo); err != nil {
@@ -138,6 +175,65 @@ func (d *Detector) GetParentRepo(worktreeDir string) (string, error) {
 	return resolved, nil
 }
 
+// workTreeForGitDir finds the working tree of a git directory that is not
+// named .git. Submodule git dirs record it in core.worktree (relative to the
+// git dir). Bare repositories, and any case git cannot answer, resolve to the
+// git directory itself.
+func (d *Detector) workTreeForGitDir(gitDir string) string {
+	output, err := d.gitCommand("--git-dir", gitDir, "config", "--get", "core.worktree")
+	workTree := strings.TrimSpace(output)
+	if err != nil || workTree == "" {
+		return gitDir
+	}
+	if !filepath.IsAbs(workTree) {
+		workTree = filepath.Join(gitDir, workTree)
+	}
+	return filepath.Clean(workTree)
+}
+
+// ListWorktrees returns the worktrees git knows about for the repository
+// containing dir, main worktree first
+func (d *Detector) ListWorktrees(dir string) ([]Worktree, error) {
+	output, err := d.gitCommand("-C", dir, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list git worktrees: %w", err)
+	}
+	return parseWorktreeList(output), nil
+}
+
+// parseWorktreeList parses porcelain output: blank-line separated records of
+// "worktree <path>", "HEAD <sha>", "branch refs/heads/<name>", "bare" or
+// "detached" lines
+func parseWorktreeList(output string) []Worktree {
+	var worktrees []Worktree
+	var current *Worktree
+
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		key, value, _ := strings.Cut(line, " ")
+
+		switch {
+		case key == "worktree":
+			worktrees = append(worktrees, Worktree{Path: filepath.Clean(value)})
+			current = &worktrees[len(worktrees)-1]
+		case current == nil:
+			continue
+		case key == "HEAD":
+			current.Head = value
+		case key == "branch":
+			current.Branch = strings.TrimPrefix(value, "refs/heads/")
+		case key == "bare":
+			current.Bare = true
+		case key == "detached":
+			current.Detached = true
+		case line == "":
+			current = nil
+		}
+	}
+
+	return worktrees
+}
+
 // GetProjectRoot returns the project root, accounting for worktrees
 // If in a worktree, returns the parent repository path
 // If in a normal repo, returns the repository path
@@ -150,8 +246,8 @@ func (d *Detector) GetProjectRoot(dir string) (string, error) {
 	}
 
 	if isWT {
-		// Return parent repository path
-		return d.GetParentRepo(dir)
+		// Return the parent repository's working tree
+		return d.GetParentWorkTree(dir)
 	}
 
 	// Not a worktree, check if it's a normal git repo
@@ -3,6 +3,7 @@ package worktree
 import (
 	"errors"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -226,6 +227,124 @@ func TestGetParentRepo(t *testing.T) {
 	}
 }
 
+func TestGetParentWorkTree(t *testing.T) {
+	// existing reports every path in the set as an existing file or directory
+	existing := func(files map[string]bool) func(path string) (os.FileInfo, error) {
+		return func(path string) (os.FileInfo, error) {
+			if isDir, ok := files[path]; ok {
+				return mockFileInfo{name: path, isDir: isDir}, nil
+			}
+			return nil, os.ErrNotExist
+		}
+	}
+	contents := func(files map[string]string) func(path string) ([]byte, error) {
+		return func(path string) ([]byte, error) {
+			if content, ok := files[path]; ok {
+				return []byte(content), nil
+			}
+			return nil, os.ErrNotExist
+		}
+	}
+
+	tests := []struct {
+		name        string
+		worktreeDir string
+		stat        map[string]bool
+		files       map[string]string
+		gitConfig   map[string]string // git dir -> core.worktree
+		expected    string
+		expectedGit string
+	}{
+		{
+			name:        "relative gitdir path",
+			worktreeDir: "/home/user/worktrees/feature",
+			stat:        map[string]bool{"/home/user/worktrees/feature/.git": false, "/home/user/project": true},
+			files: map[string]string{
+				"/home/user/worktrees/feature/.git": "gitdir: ../../project/.git/worktrees/feature\n",
+			},
+			expected:    "/home/user/project",
+			expectedGit: "/home/user/project/.git",
+		},
+		{
+			name:        "commondir file is authoritative",
+			worktreeDir: "/home/user/project-wt",
+			stat:        map[string]bool{"/home/user/project-wt/.git": false, "/home/user/project": true},
+			files: map[string]string{
+				"/home/user/project-wt/.git":                             "gitdir: /home/user/project/.git/worktrees/project-wt",
+				"/home/user/project/.git/worktrees/project-wt/commondir": "../..\n",
+			},
+			expected:    "/home/user/project",
+			expectedGit: "/home/user/project/.git",
+		},
+		{
+			name:        "nested worktree inside the main checkout",
+			worktreeDir: "/home/user/project/worktrees/feature",
+			stat:        map[string]bool{"/home/user/project/worktrees/feature/.git": false, "/home/user/project": true},
+			files: map[string]string{
+				"/home/user/project/worktrees/feature/.git": "gitdir: /home/user/project/.git/worktrees/feature",
+			},
+			expected:    "/home/user/project",
+			expectedGit: "/home/user/project/.git",
+		},
+		{
+			name:        "bare repository",
+			worktreeDir: "/home/user/project/main",
+			stat:        map[string]bool{"/home/user/project/main/.git": false, "/home/user/project/.bare": true},
+			files: map[string]string{
+				"/home/user/project/main/.git": "gitdir: /home/user/project/.bare/worktrees/main",
+			},
+			expected:    "/home/user/project/.bare",
+			expectedGit: "/home/user/project/.bare",
+		},
+		{
+			name:        "submodule worktree",
+			worktreeDir: "/home/user/lib-wt",
+			stat:        map[string]bool{"/home/user/lib-wt/.git": false, "/home/user/super/lib": true},
+			files: map[string]string{
+				"/home/user/lib-wt/.git": "gitdir: /home/user/super/.git/modules/lib/worktrees/lib-wt",
+			},
+			gitConfig:   map[string]string{"/home/user/super/.git/modules/lib": "../../../lib"},
+			expected:    "/home/user/super/lib",
+			expectedGit: "/home/user/super/.git/modules/lib",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &Detector{
+				stat:         existing(tt.stat),
+				readFile:     contents(tt.files),
+				evalSymlinks: func(path string) (string, error) { return path, nil },
+				gitCommand: func(args ...string) (string, error) {
+					// git --git-dir <dir> config --get core.worktree
+					if len(args) == 5 && args[0] == "--git-dir" && args[2] == "config" {
+						if value, ok := tt.gitConfig[args[1]]; ok {
+							return value + "\n", nil
+						}
+					}
+					return "", errors.New("exit status 1")
+				},
+			}
+
+			commonDir, err := d.GetCommonGitDir(tt.worktreeDir)
+			if err != nil {
+				t.Fatalf("GetCommonGitDir() unexpected error: %v", err)
+			}
+			if commonDir != tt.expectedGit {
+				t.Errorf("GetCommonGitDir() = %q, want %q", commonDir, tt.expectedGit)
+			}
+
+			root, err := d.GetParentWorkTree(tt.worktreeDir)
+			if err != nil {
+				t.Fatalf("GetParentWorkTree() unexpected error: %v", err)
+			}
+			if root != tt.expected {
+				t.Errorf("GetParentWorkTree() = %q, want %q", root, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGetProjectRoot(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -491,3 +610,64 @@ func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(substr) == 0 ||
 		(len(s) > 0 && (s[:len(substr)] == substr || contains(s[1:], substr))))
 }
+
+func TestParseWorktreeList(t *testing.T) {
+	output := `worktree /home/user/project
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /home/user/worktrees/feature/auth
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/feature/auth
+
+worktree /home/user/worktrees/detached
+HEAD 3333333333333333333333333333333333333333
+detached
+
+worktree /home/user/bare.git
+bare
+
+`
+	want := []Worktree{
+		{Path: "/home/user/project", Head: "1111111111111111111111111111111111111111", Branch: "main"},
+		{Path: "/home/user/worktrees/feature/auth", Head: "2222222222222222222222222222222222222222", Branch: "feature/auth"},
+		{Path: "/home/user/worktrees/detached", Head: "3333333333333333333333333333333333333333", Detached: true},
+		{Path: "/home/user/bare.git", Bare: true},
+	}
+
+	got := parseWorktreeList(output)
+	if len(got) != len(want) {
+		t.Fatalf("parseWorktreeList() returned %d worktrees, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("parseWorktreeList()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestListWorktrees(t *testing.T) {
+	var gotArgs []string
+	d := &Detector{
+		gitCommand: func(args ...string) (string, error) {
+			gotArgs = args
+			return "worktree /repo\nHEAD abc\nbranch refs/heads/main\n", nil
+		},
+	}
+
+	worktrees, err := d.ListWorktrees("/repo/sub")
+	if err != nil {
+		t.Fatalf("ListWorktrees() unexpected error: %v", err)
+	}
+	if len(worktrees) != 1 || worktrees[0].Branch != "main" {
+		t.Errorf("ListWorktrees() = %+v", worktrees)
+	}
+	if want := []string{"-C", "/repo/sub", "worktree", "list", "--porcelain"}; !reflect.DeepEqual(gotArgs, want) {
+		t.Errorf("git args = %v, want %v", gotArgs, want)
+	}
+
+	d.gitCommand = func(args ...string) (string, error) { return "", errors.New("exit status 128") }
+	if _, err := d.ListWorktrees("/not-a-repo"); err == nil {
+		t.Error("ListWorktrees() expected error when git fails")
+	}
+}
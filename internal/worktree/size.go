@@ -0,0 +1,82 @@
+package worktree
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// DirSize returns the total size in bytes of the regular files under path.
+// Symlinks are not followed, and files or directories that cannot be read are
+// skipped, so the result is a lower bound for partially unreadable trees.
+func DirSize(path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == path {
+				return err
+			}
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// DirSizes computes DirSize for each path in parallel. Paths that cannot be
+// measured (e.g. no longer exist) are left out of the result.
+func DirSizes(paths []string) map[string]int64 {
+	sizes := make(map[string]int64, len(paths))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	// Bound the number of concurrent walks; each is mostly waiting on the disk
+	sem := make(chan struct{}, runtime.NumCPU())
+	for _, path := range paths {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			size, err := DirSize(path)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			sizes[path] = size
+			mu.Unlock()
+		}(path)
+	}
+	wg.Wait()
+
+	return sizes
+}
+
+// FormatSize renders a byte count with a binary unit, e.g. "1.5 GB"
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
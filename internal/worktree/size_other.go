@@ -0,0 +1,11 @@
+//go:build !unix
+
+package worktree
+
+import "errors"
+
+// FreeSpace reports an error: free space is only measured on Unix, so the
+// disk space checks are skipped elsewhere
+func FreeSpace(path string) (uint64, error) {
+	return 0, errors.New("free disk space is not supported on this platform")
+}
@@ -0,0 +1,89 @@
+package worktree
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "nested", "deeper"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]int{
+		"a.txt":                  100,
+		"nested/b.txt":           250,
+		"nested/deeper/c.bin":    1024,
+		"nested/deeper/empty.md": 0,
+	}
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Symlinks are not followed, so the target is not counted twice
+	if err := os.Symlink(filepath.Join(dir, "nested"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := DirSize(dir)
+	if err != nil {
+		t.Fatalf("DirSize() error = %v", err)
+	}
+	if size != 1374 {
+		t.Errorf("DirSize() = %d, want 1374", size)
+	}
+
+	if _, err := DirSize(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+}
+
+func TestDirSizes(t *testing.T) {
+	root := t.TempDir()
+	var paths []string
+	for i, size := range []int{10, 20, 30} {
+		dir := filepath.Join(root, string(rune('a'+i)))
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "file"), make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, dir)
+	}
+	missing := filepath.Join(root, "missing")
+	paths = append(paths, missing)
+
+	sizes := DirSizes(paths)
+	if len(sizes) != 3 {
+		t.Fatalf("expected 3 sizes, got %v", sizes)
+	}
+	for i, want := range []int64{10, 20, 30} {
+		if sizes[paths[i]] != want {
+			t.Errorf("size of %s = %d, want %d", paths[i], sizes[paths[i]], want)
+		}
+	}
+	if _, ok := sizes[missing]; ok {
+		t.Error("missing paths should be left out")
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		0:               "0 B",
+		1023:            "1023 B",
+		1024:            "1.0 KB",
+		1536:            "1.5 KB",
+		3 * 1024 * 1024: "3.0 MB",
+		5 << 30:         "5.0 GB",
+		1<<40 + 1<<39:   "1.5 TB",
+	}
+	for bytes, want := range tests {
+		if got := FormatSize(bytes); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+//go:build unix
+
+package worktree
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// FreeSpace returns the bytes available to unprivileged users on the
+// filesystem holding path. A path that does not exist yet (e.g. a worktrees
+// directory before the first 'dual create') is measured at its nearest
+// existing parent.
+func FreeSpace(path string) (uint64, error) {
+	dir := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		} else if !os.IsNotExist(err) {
+			return 0, err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return 0, fmt.Errorf("no existing parent directory for %s", path)
+		}
+		dir = parent
+	}
+
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, fmt.Errorf("failed to stat filesystem of %s: %w", dir, err)
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
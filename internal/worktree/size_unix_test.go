@@ -0,0 +1,24 @@
+//go:build unix
+
+package worktree
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFreeSpace(t *testing.T) {
+	dir := t.TempDir()
+	free, err := FreeSpace(dir)
+	if err != nil {
+		t.Fatalf("FreeSpace() error = %v", err)
+	}
+	if free == 0 {
+		t.Error("FreeSpace() = 0, want the free space of the temp filesystem")
+	}
+
+	// Paths that do not exist yet are measured at their nearest parent
+	if _, err := FreeSpace(filepath.Join(dir, "not", "created", "yet")); err != nil {
+		t.Errorf("FreeSpace() of a missing path error = %v", err)
+	}
+}
@@ -1,6 +1,8 @@
 package integration
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -17,10 +19,34 @@ services: {}
 `
 	h.WriteFile("dual.config.yml", invalidConfig)
 
-	// Try to use dual - should fail with version error
+	// Try to use dual - should fail with upgrade advice
 	stdout, stderr, exitCode := h.RunDual("service", "add", "test", "--path", "apps/test")
 	h.AssertExitCode(exitCode, 1, stdout+stderr)
-	h.AssertOutputContains(stderr, "Unsupported config version 99")
+	h.AssertOutputContains(stderr, "is out of date")
+	h.AssertOutputContains(stderr, "uses config version 99, but this build supports up to version 1")
+	h.AssertOutputContains(stderr, "Upgrade dual")
+}
+
+// TestRegistrySchemaTooNew tests that a registry from a newer dual is refused with upgrade advice
+func TestRegistrySchemaTooNew(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services: {}
+`)
+	registryContent := `{"schemaVersion": 99, "projects": {}}`
+	h.CreateDirectory(".dual/.local")
+	h.WriteFile(".dual/.local/registry.json", registryContent)
+
+	stdout, stderr, exitCode := h.RunDual("list")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "uses registry schema version 99, but this build supports up to version 1")
+
+	if got := h.ReadRegistryJSON(); got != registryContent {
+		t.Errorf("registry was modified:\n%s", got)
+	}
 }
 
 // TestConfigValidationMissingVersion tests config validation with missing version
@@ -68,6 +94,8 @@ func TestConfigValidationNonExistentPath(t *testing.T) {
 	stdout, stderr, exitCode := h.RunDual("service", "add", "test", "--path", "apps/nonexistent")
 	h.AssertExitCode(exitCode, 1, stdout+stderr)
 	h.AssertOutputContains(stderr, "path does not exist")
+	h.AssertOutputContains(stderr, "mkdir -p apps/nonexistent")
+	h.AssertOutputContains(stderr, "--create-dir")
 }
 
 // TestConfigValidationFileNotDirectory tests that file paths are rejected
@@ -324,3 +352,71 @@ func TestConfigWithSpecialCharacters(t *testing.T) {
 		h.AssertFileContains("dual.config.yml", serviceName+":")
 	}
 }
+
+// TestErrorJSON tests reporting failures as JSON with --error-json
+func TestErrorJSON(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+  web:
+    path: /absolute/web
+`)
+
+	type jsonError struct {
+		Code     string            `json:"code"`
+		Message  string            `json:"message"`
+		Context  map[string]string `json:"context"`
+		Fixes    []string          `json:"fixes"`
+		Problems []jsonError       `json:"problems"`
+	}
+	parse := func(stderr string) jsonError {
+		t.Helper()
+		// The error is the last line; log messages may precede it
+		lines := strings.Split(strings.TrimSpace(stderr), "\n")
+		var output struct {
+			Error jsonError `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &output); err != nil {
+			t.Fatalf("last line of stderr is not a JSON object: %v\n%s", err, stderr)
+		}
+		return output.Error
+	}
+
+	// Structured config errors keep their code, context and fixes
+	stdout, stderr, exitCode := h.RunDual("env", "show", "--error-json")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputNotContains(stderr, "Usage:")
+	result := parse(stderr)
+	if result.Code != "config_invalid" {
+		t.Errorf("expected code config_invalid, got %q", result.Code)
+	}
+	if len(result.Problems) != 2 {
+		t.Fatalf("expected 2 problems, got %+v", result.Problems)
+	}
+	if result.Problems[0].Context["Service"] != "api" || len(result.Problems[0].Fixes) == 0 {
+		t.Errorf("expected context and fixes for the api problem, got %+v", result.Problems[0])
+	}
+
+	// Plain errors report their hints as fixes
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: .
+`)
+	stdout, stderr, exitCode = h.RunDual("env", "set", "--service", "missing", "KEY", "value", "--error-json")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	result = parse(stderr)
+	if result.Code != "error" || result.Message == "" {
+		t.Errorf("unexpected plain error: %+v", result)
+	}
+
+	// Without the flag the text output is unchanged
+	stdout, stderr, exitCode = h.RunDual("env", "set", "--service", "missing", "KEY", "value")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "Error: ")
+}
@@ -2,6 +2,8 @@ package integration
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -116,6 +118,81 @@ worktrees:
 	}
 }
 
+// TestContextListSizes tests showing worktree disk usage with --sizes
+func TestContextListSizes(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: .
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Initial commit")
+
+	stdout, stderr, exitCode := h.RunDual("create", "big")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	stdout, stderr, exitCode = h.RunDual("create", "gone")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	// Grow one worktree and remove the other behind dual's back
+	bigPath := filepath.Join(h.TempDir, "worktrees", "big")
+	if err := os.WriteFile(filepath.Join(bigPath, "blob.bin"), make([]byte, 3*1024*1024), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(h.TempDir, "worktrees", "gone")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without --sizes there is no SIZE column
+	stdout, stderr, exitCode = h.RunDual("list")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputNotContains(stdout, "SIZE")
+
+	stdout, stderr, exitCode = h.RunDual("list", "--sizes")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "SIZE")
+	h.AssertOutputContains(stdout, "on disk)")
+	for _, line := range strings.Split(stdout, "\n") {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) > 0 && fields[0] == "big":
+			h.AssertOutputContains(line, "3.0 MB")
+		case len(fields) > 0 && fields[0] == "gone":
+			h.AssertOutputContains(line, " - ")
+		}
+	}
+
+	// JSON reports bytes for measured worktrees only
+	stdout, stderr, exitCode = h.RunDual("list", "--sizes", "--json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	var result struct {
+		Contexts []struct {
+			Name      string `json:"name"`
+			SizeBytes *int64 `json:"sizeBytes"`
+		} `json:"contexts"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, stdout)
+	}
+	for _, ctx := range result.Contexts {
+		switch ctx.Name {
+		case "big":
+			if ctx.SizeBytes == nil || *ctx.SizeBytes < 3*1024*1024 {
+				t.Errorf("expected big to be at least 3 MiB, got %v", ctx.SizeBytes)
+			}
+		case "gone":
+			if ctx.SizeBytes != nil {
+				t.Errorf("expected no size for a missing worktree, got %d", *ctx.SizeBytes)
+			}
+		}
+	}
+}
+
 // TestContextListWithPorts tests the context list command with --ports flag
 // REMOVED: This test was specific to port listing functionality which has been removed.
 // The worktree lifecycle manager no longer manages ports.
@@ -282,6 +359,22 @@ worktrees:
 	h.AssertOutputContains(stdout, "Project:")
 	h.AssertOutputContains(stdout, "trunk")
 	h.AssertOutputContains(stdout, "Total: 1 contexts across 1 projects")
+	h.AssertOutputContains(stdout, "EXISTS")
+	h.AssertOutputNotContains(stdout, "missing")
+
+	// Remove the worktree directory behind dual's back
+	if err := os.RemoveAll(filepath.Join(h.TempDir, "worktrees", "trunk")); err != nil {
+		t.Fatalf("failed to remove worktree: %v", err)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("list", "--all")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "missing")
+	h.AssertOutputContains(stdout, "1 context(s) point to worktrees that no longer exist")
+
+	stdout, stderr, exitCode = h.RunDual("list", "--all", "--json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, `"exists": false`)
 }
 
 // TestContextListWithJSONAndPorts tests combining --json and --ports flags
@@ -326,6 +419,79 @@ worktrees:
 	h.AssertOutputContains(output, "feature-a")
 }
 
+// TestContextDeleteJSON tests that --json reports the impact and only deletes with --force
+func TestContextDeleteJSON(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: services/api
+worktrees:
+  path: ../worktrees
+`)
+	h.CreateDirectory("services/api")
+	h.WriteFile("services/api/.gitkeep", "")
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Initial commit")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-a")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-a")
+
+	for _, args := range [][]string{
+		{"env", "set", "DEBUG", "true"},
+		{"env", "set", "PORT", "4001", "--service", "api"},
+		{"env", "set", "API_KEY", "dev", "--service", "api"},
+	} {
+		stdout, stderr, exitCode = h.RunDualInDir(worktreePath, args...)
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+	}
+
+	type impact struct {
+		Name           string `json:"name"`
+		Path           string `json:"path"`
+		WorktreeExists bool   `json:"worktreeExists"`
+		Overrides      struct {
+			Count    int            `json:"count"`
+			Global   int            `json:"global"`
+			Services map[string]int `json:"services"`
+		} `json:"overrides"`
+	}
+
+	// Without --force nothing is deleted
+	stdout, stderr, exitCode = h.RunDual("delete", "feature-a", "--json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	var got impact
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("delete --json output is not valid JSON: %v\n%s", err, stdout)
+	}
+	if got.Name != "feature-a" || !got.WorktreeExists || got.Overrides.Count != 3 || got.Overrides.Global != 1 || got.Overrides.Services["api"] != 2 {
+		t.Errorf("unexpected impact: %+v", got)
+	}
+	if !strings.HasSuffix(got.Path, filepath.Join("worktrees", "feature-a")) {
+		t.Errorf("path = %q, want the feature-a worktree", got.Path)
+	}
+	h.AssertOutputContains(h.ReadRegistryJSON(), "feature-a")
+	if _, err := os.Stat(worktreePath); err != nil {
+		t.Errorf("worktree should still exist: %v", err)
+	}
+
+	// With --force the report is followed by the deletion, keeping stdout JSON
+	stdout, stderr, exitCode = h.RunDual("delete", "feature-a", "--json", "--force")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("delete --json --force stdout is not valid JSON: %v\n%s", err, stdout)
+	}
+	h.AssertOutputContains(stderr, "deleted successfully")
+	h.AssertOutputNotContains(h.ReadRegistryJSON(), "feature-a")
+	if _, err := os.Stat(worktreePath); !os.IsNotExist(err) {
+		t.Errorf("worktree should be removed, stat error = %v", err)
+	}
+}
+
 // TestContextListSorting tests that contexts are listed in alphabetical order
 func TestContextListSorting(t *testing.T) {
 	h := NewTestHelper(t)
@@ -376,3 +542,207 @@ worktrees:
 		t.Errorf("contexts not in alphabetical order\nOutput: %s", stdout)
 	}
 }
+
+// TestContextPrune tests pruning old contexts whose worktrees are gone
+func TestContextPrune(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: services/api
+worktrees:
+  path: ../worktrees
+  naming: "{branch}"
+`)
+	h.CreateDirectory("services/api")
+	h.WriteFile("README.md", "# Test Project")
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Initial commit")
+
+	for _, branch := range []string{"old-gone", "old-present", "new-gone"} {
+		stdout, stderr, exitCode := h.RunDual("create", branch)
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+	}
+	for _, branch := range []string{"old-gone", "new-gone"} {
+		if err := os.RemoveAll(filepath.Join(h.TempDir, "worktrees", branch)); err != nil {
+			t.Fatalf("failed to remove worktree: %v", err)
+		}
+	}
+
+	// Backdate two of the contexts
+	registryPath := filepath.Join(h.ProjectDir, ".dual", ".local", "registry.json")
+	var reg map[string]interface{}
+	if err := json.Unmarshal([]byte(h.ReadRegistryJSON()), &reg); err != nil {
+		t.Fatalf("failed to parse registry: %v", err)
+	}
+	for _, project := range reg["projects"].(map[string]interface{}) {
+		contexts := project.(map[string]interface{})["contexts"].(map[string]interface{})
+		for _, name := range []string{"old-gone", "old-present"} {
+			contexts[name].(map[string]interface{})["created"] = "2020-01-01T00:00:00Z"
+		}
+	}
+	data, err := json.Marshal(reg)
+	if err != nil {
+		t.Fatalf("failed to marshal registry: %v", err)
+	}
+	if err := os.WriteFile(registryPath, data, 0o600); err != nil {
+		t.Fatalf("failed to write registry: %v", err)
+	}
+
+	// Dry run lists only the old, missing context
+	stdout, stderr, exitCode := h.RunDual("context", "prune", "--older-than", "30d", "--dry-run")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "old-gone")
+	h.AssertOutputNotContains(stderr, "old-present")
+	h.AssertOutputNotContains(stderr, "new-gone")
+	h.AssertOutputContains(stderr, "1 context(s) would be pruned")
+	h.AssertOutputContains(h.ReadRegistryJSON(), "old-gone")
+
+	stdout, stderr, exitCode = h.RunDual("context", "prune", "--older-than", "30d", "--force")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "Pruned 1 context(s)")
+
+	registryContent := h.ReadRegistryJSON()
+	h.AssertOutputNotContains(registryContent, "old-gone")
+	h.AssertOutputContains(registryContent, "old-present")
+	h.AssertOutputContains(registryContent, "new-gone")
+
+	// Invalid durations are rejected
+	stdout, stderr, exitCode = h.RunDual("context", "prune", "--older-than", "soon")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "invalid --older-than value")
+}
+
+// TestContextPruneHooks tests that prune runs the context delete hooks and
+// keeps contexts whose preContextDelete hook fails
+func TestContextPruneHooks(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory(".dual/hooks")
+	h.WriteFile(".dual/hooks/pre.sh", "#!/bin/sh\nif [ \"$DUAL_CONTEXT_NAME\" = keep-me ]; then exit 1; fi\necho \"pre $DUAL_CONTEXT_NAME $DUAL_EVENT\" >> hooks.log\n")
+	h.WriteFile(".dual/hooks/post.sh", "#!/bin/sh\necho \"post $DUAL_CONTEXT_NAME $DUAL_EVENT\" >> hooks.log\n")
+	for _, script := range []string{"pre.sh", "post.sh"} {
+		if err := os.Chmod(filepath.Join(h.ProjectDir, ".dual/hooks", script), 0o755); err != nil {
+			t.Fatalf("Failed to make hook executable: %v", err)
+		}
+	}
+	h.WriteFile("dual.config.yml", `version: 1
+
+worktrees:
+  path: ../worktrees
+
+hooks:
+  preContextDelete:
+    - pre.sh
+  postContextDelete:
+    - post.sh
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add context hooks")
+
+	for _, branch := range []string{"drop-me", "keep-me"} {
+		stdout, stderr, exitCode := h.RunDual("create", branch)
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+		if err := os.RemoveAll(filepath.Join(h.TempDir, "worktrees", branch)); err != nil {
+			t.Fatalf("failed to remove worktree: %v", err)
+		}
+	}
+
+	stdout, stderr, exitCode := h.RunDual("context", "prune", "--older-than", "0d", "--force")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "skipping keep-me: preContextDelete hook failed")
+	h.AssertOutputContains(stderr, "Pruned 1 context(s)")
+
+	registryContent := h.ReadRegistryJSON()
+	h.AssertOutputNotContains(registryContent, "drop-me")
+	h.AssertOutputContains(registryContent, "keep-me")
+
+	// Hooks run in the project root since the worktrees are gone
+	logData, err := os.ReadFile(filepath.Join(h.ProjectDir, "hooks.log"))
+	if err != nil {
+		t.Fatalf("failed to read hook log: %v", err)
+	}
+	want := "pre drop-me preContextDelete\npost drop-me postContextDelete\n"
+	if string(logData) != want {
+		t.Errorf("hook log = %q, want %q", string(logData), want)
+	}
+
+	// Every context failing its hook is an error
+	stdout, stderr, exitCode = h.RunDual("context", "prune", "--older-than", "0d", "--force")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "no contexts were pruned")
+}
+
+// TestContextInfo tests showing a single context with its env overrides
+func TestContextInfo(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: services/api
+worktrees:
+  path: ../worktrees
+`)
+	h.CreateDirectory("services/api")
+	h.WriteFile("services/api/.gitkeep", "")
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Initial commit")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-info")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-info")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "DEBUG", "true")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--service", "api", "PORT", "4001")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	// Current context from inside the worktree
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "context", "info")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Context: feature-info (current)")
+	h.AssertOutputContains(stdout, "Environment overrides: 2")
+	h.AssertOutputContains(stdout, "Global (1):")
+	h.AssertOutputContains(stdout, "DEBUG=true")
+	h.AssertOutputContains(stdout, "Service api (1):")
+	h.AssertOutputContains(stdout, "PORT=4001")
+
+	// Named context as JSON from the main checkout
+	stdout, stderr, exitCode = h.RunDual("context", "info", "feature-info", "--json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	var info struct {
+		Name      string `json:"name"`
+		Current   bool   `json:"current"`
+		Overrides struct {
+			Count    int                          `json:"count"`
+			Global   map[string]string            `json:"global"`
+			Services map[string]map[string]string `json:"services"`
+		} `json:"overrides"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &info); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\n%s", err, stdout)
+	}
+	if info.Name != "feature-info" || info.Current {
+		t.Errorf("unexpected name/current: %+v", info)
+	}
+	if info.Overrides.Count != 2 || info.Overrides.Global["DEBUG"] != "true" || info.Overrides.Services["api"]["PORT"] != "4001" {
+		t.Errorf("unexpected overrides: %+v", info.Overrides)
+	}
+
+	// Unknown context
+	stdout, stderr, exitCode = h.RunDual("context", "info", "nope")
+	if exitCode == 0 {
+		t.Fatalf("expected failure for unknown context, got: %s", stdout+stderr)
+	}
+	h.AssertOutputContains(stderr, `context "nope" not found`)
+	h.AssertOutputContains(stderr, "Run 'dual list' to see available contexts")
+}
@@ -443,3 +443,90 @@ worktrees:
 	output := stdout + stderr
 	assert.Contains(t, output, "Service Detection")
 }
+
+// TestDoctorUntrackedWorktrees tests reconciling git worktrees with contexts
+func TestDoctorUntrackedWorktrees(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-tracked")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	// Created with raw git, so dual doesn't know about it
+	worktreePath := h.CreateGitWorktree("feature-raw", "worktree-raw")
+
+	findCheck := func(stdout string) health.Check {
+		t.Helper()
+		var result health.Result
+		require.NoError(t, json.Unmarshal([]byte(stdout), &result), "output should be valid JSON")
+		for _, check := range result.Checks {
+			if check.Name == "Worktree Contexts" {
+				return check
+			}
+		}
+		t.Fatalf("no Worktree Contexts check in:\n%s", stdout)
+		return health.Check{}
+	}
+
+	stdout, _, _ = h.RunDual("doctor", "--json")
+	check := findCheck(stdout)
+	assert.Equal(t, health.StatusWarn, check.Status)
+	assert.Contains(t, strings.Join(check.Details, "\n"), "Worktree without context: "+worktreePath)
+	assert.NotContains(t, strings.Join(check.Details, "\n"), "feature-tracked")
+
+	stdout, _, _ = h.RunDual("doctor", "--json", "--fix")
+	check = findCheck(stdout)
+	assert.Equal(t, health.StatusPass, check.Status)
+	assert.True(t, check.FixApplied)
+	assert.Contains(t, h.ReadRegistryJSON(), "feature-raw")
+
+	stdout, _, _ = h.RunDual("doctor", "--json")
+	check = findCheck(stdout)
+	assert.Equal(t, health.StatusPass, check.Status)
+	assert.Equal(t, "Git worktrees and contexts are in sync", check.Message)
+}
+
+// TestDoctorColor tests that doctor output is only colored on request when piped
+func TestDoctorColor(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", "version: 1\n")
+
+	// Output is piped in tests, so auto mode leaves it plain
+	stdout, _, _ := h.RunDual("doctor")
+	assert.Contains(t, stdout, "Dual Health Check Results")
+	assert.NotContains(t, stdout, "\033[")
+
+	stdout, _, _ = h.RunDual("--force-color", "doctor")
+	assert.Contains(t, stdout, "\033[1mDual Health Check Results")
+
+	stdout, _, _ = h.RunDual("--color=always", "doctor")
+	assert.Contains(t, stdout, "\033[")
+
+	stdout, stderr, exitCode := h.RunDual("--color=rainbow", "doctor")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `invalid --color "rainbow" (supported: auto, always, never)`)
+
+	stdout, stderr, exitCode = h.RunDual("--no-color", "--force-color", "doctor")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--no-color and --force-color cannot be used together")
+}
@@ -0,0 +1,154 @@
+package integration
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestEnvCheckService tests validating a single service's environment
+func TestEnvCheckService(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/api/.env", "API_KEY=\nPORT=3000\n")
+	h.WriteFile("apps/web/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+    envFile: apps/api/.env
+  web:
+    path: apps/web
+    envFile: apps/web/.env.local
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-check")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-check")
+
+	// Unknown services are rejected
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "check", "--service", "nope")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `service "nope" not found`)
+
+	// Declared but empty variables are reported for the service
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "check", "--service", "api")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stdout, "Env file exists: apps/api/.env")
+	h.AssertOutputContains(stderr, "[api] 1 variable(s) have no value: API_KEY")
+
+	// A service override fills the gap
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--service", "api", "API_KEY", "secret")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "check", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Environment configuration is valid")
+
+	// A configured env file that is missing is an issue
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "check", "--service", "web")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "[web] Env file not found: apps/web/.env.local")
+}
+
+// TestEnvCheckStrictCycles tests that --strict reports circular variable expansion
+func TestEnvCheckStrictCycles(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile(".env.base", "BASE_URL=http://localhost\nAPI_URL=${BASE_URL}/api\n")
+	h.WriteFile("dual.config.yml", `version: 1
+env:
+  baseFile: .env.base
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+`)
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-strict")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-strict")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "check", "--strict")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "No circular variable expansion (1 env file(s) checked)")
+
+	// The uncommitted service env file is found through the parent repo
+	h.WriteFile("apps/api/.env", "DB_HOST=${DB_URL}\nDB_URL=postgres://$DB_HOST/app\n")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "check", "--strict")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "Circular variable expansion in apps/api/.env: DB_HOST -> DB_URL -> DB_HOST")
+
+	// Without --strict the cycle is not checked
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "check")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputNotContains(stderr, "Circular variable expansion")
+}
+
+// TestEnvCheckUndefined tests that --check-undefined reports references that
+// expand to nothing, in both env check and env export
+func TestEnvCheckUndefined(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile(".env.base", "BASE_URL=http://localhost\nAPI_URL=${BASE_URL}/api\n")
+	h.WriteFile("dual.config.yml", `version: 1
+env:
+  baseFile: .env.base
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+`)
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-undefined")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-undefined")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "check", "--check-undefined")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "No undefined variable references (1 env file(s) checked)")
+
+	// BASE_URL is defined in the base file, which the service file cannot see
+	h.WriteFile("apps/api/.env", "WS_URL=${BASE_URL}/ws\n")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "check", "--check-undefined")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "Undefined variable reference in apps/api/.env: WS_URL references BASE_URL")
+
+	// The base file alone is fine
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--check-undefined")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_URL=http://localhost/api")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--service", "api", "--check-undefined")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "apps/api/.env: WS_URL references BASE_URL")
+	h.AssertOutputNotContains(stdout, "WS_URL")
+
+	// Without the flag the empty expansion is exported silently
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "WS_URL=/ws")
+}
@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// TestEnvDiffThreeWay tests comparing two contexts against a common base
+func TestEnvDiffThreeWay(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: .
+env:
+  baseFile: .env.base
+worktrees:
+  path: ../worktrees
+`)
+	h.WriteFile(".env.base", "API_URL=http://api\nLOG_LEVEL=info\nPORT=3000\nUNTOUCHED=1\n")
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	set := func(contextName string, args ...string) {
+		t.Helper()
+		dir := filepath.Join(h.TempDir, "worktrees", contextName)
+		stdout, stderr, exitCode := h.RunDualInDir(dir, append([]string{"env", "set"}, args...)...)
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+	}
+	for _, name := range []string{"base", "feature-a", "feature-b"} {
+		stdout, stderr, exitCode := h.RunDual("create", name)
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+	}
+	set("feature-a", "API_URL", "http://a")
+	set("feature-b", "API_URL", "http://b")
+	set("feature-a", "PORT", "4001")
+	set("feature-a", "LOG_LEVEL", "debug")
+	set("feature-b", "LOG_LEVEL", "debug")
+	set("feature-b", "NEW_FLAG", "on")
+
+	stdout, stderr, exitCode := h.RunDual("env", "diff", "--base", "base", "feature-a", "feature-b")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Comparing environments: feature-a ↔ feature-b (base: base)")
+	h.AssertOutputContains(stdout, "Conflicts (changed differently in both):\n  API_URL\n    base:      http://api\n    feature-a: http://a\n    feature-b: http://b")
+	h.AssertOutputContains(stdout, "Changed in feature-a only:\n  PORT: 3000 → 4001")
+	h.AssertOutputContains(stdout, "Changed in feature-b only:\n  NEW_FLAG: (unset) → on")
+	h.AssertOutputContains(stdout, "Same change in both:\n  LOG_LEVEL: info → debug")
+	h.AssertOutputContains(stdout, "1 conflict(s), 1 variable(s) unchanged")
+	h.AssertOutputNotContains(stdout, "UNTOUCHED")
+
+	stdout, stderr, exitCode = h.RunDual("env", "diff", "--base", "base", "feature-a", "feature-b", "--json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	var result struct {
+		Variables []struct {
+			Key       string  `json:"key"`
+			Status    string  `json:"status"`
+			ChangedIn string  `json:"changedIn"`
+			Base      *string `json:"base"`
+			Right     *string `json:"right"`
+		} `json:"variables"`
+		Unchanged int `json:"unchanged"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("failed to parse JSON output: %v\nOutput: %s", err, stdout)
+	}
+	statuses := make(map[string]string)
+	for _, v := range result.Variables {
+		statuses[v.Key] = v.Status
+		if v.Key == "NEW_FLAG" && (v.Base != nil || v.Right == nil || v.ChangedIn != "feature-b") {
+			t.Errorf("unexpected NEW_FLAG entry: %+v", v)
+		}
+	}
+	want := map[string]string{"API_URL": "conflict", "PORT": "left", "NEW_FLAG": "right", "LOG_LEVEL": "same"}
+	for key, status := range want {
+		if statuses[key] != status {
+			t.Errorf("%s: status = %q, want %q", key, statuses[key], status)
+		}
+	}
+	if result.Unchanged != 1 {
+		t.Errorf("unchanged = %d, want 1", result.Unchanged)
+	}
+
+	// An unknown base context fails before anything is compared
+	stdout, stderr, exitCode = h.RunDual("env", "diff", "--base", "missing", "feature-a", "feature-b")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `context "missing" not found`)
+
+	// The two-way diff is unchanged and also available as JSON
+	stdout, stderr, exitCode = h.RunDual("env", "diff", "feature-a", "feature-b", "--json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, `"from": "http://a"`)
+}
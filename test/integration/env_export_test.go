@@ -0,0 +1,633 @@
+package integration
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEnvExportOutput tests writing the exported environment to a file
+func TestEnvExportOutput(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile(".env.base", "DATABASE_URL=postgres://localhost/dev\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: .
+env:
+  baseFile: .env.base
+`)
+
+	stdout, stderr, exitCode := h.RunDual("env", "export", "--output", ".env.local")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "Exported 1 variable(s) to .env.local")
+	h.AssertOutputContains(h.ReadFile(".env.local"), "DATABASE_URL=postgres://localhost/dev")
+
+	info, err := os.Stat(filepath.Join(h.ProjectDir, ".env.local"))
+	if err != nil {
+		t.Fatalf("failed to stat export file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("export file mode = %o, want 600", info.Mode().Perm())
+	}
+
+	// Re-exporting identical content is a no-op
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--output", ".env.local")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "already up to date")
+
+	// A file with different content is not overwritten without --force
+	h.WriteFile(".env.local", "HAND_EDITED=1\n")
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--output", ".env.local")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--force")
+	h.AssertOutputContains(h.ReadFile(".env.local"), "HAND_EDITED=1")
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--output", ".env.local", "--force")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(h.ReadFile(".env.local"), "DATABASE_URL=postgres://localhost/dev")
+	h.AssertOutputNotContains(h.ReadFile(".env.local"), "HAND_EDITED")
+}
+
+// TestEnvExportSort tests that --sort=off keeps the order variables were loaded in
+func TestEnvExportSort(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile(".env.base", "ZED=1\nALPHA=2\nMIDDLE=3\n")
+	h.WriteFile("dual.config.yml", `version: 1
+env:
+  baseFile: .env.base
+`)
+
+	stdout, stderr, exitCode := h.RunDual("env", "export")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if stdout != "ALPHA=2\nMIDDLE=3\nZED=1\n" {
+		t.Errorf("default export should be sorted by name, got:\n%s", stdout)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--sort=off")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if stdout != "ZED=1\nALPHA=2\nMIDDLE=3\n" {
+		t.Errorf("--sort=off should keep file order, got:\n%s", stdout)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--sort=off", "--format=json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if stdout != "{\n  \"ZED\": \"1\",\n  \"ALPHA\": \"2\",\n  \"MIDDLE\": \"3\"\n}\n" {
+		t.Errorf("--sort=off JSON should keep file order, got:\n%s", stdout)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--sort=random")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "unsupported sort order")
+}
+
+// TestEnvExportProperties tests exporting a Java .properties file
+func TestEnvExportProperties(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile(".env.base", "SERVER_PORT=8080\nDATABASE_URL=jdbc:postgresql://localhost/app\nGREETING=\"hello world\"\n")
+	h.WriteFile("dual.config.yml", `version: 1
+env:
+  baseFile: .env.base
+`)
+
+	stdout, stderr, exitCode := h.RunDual("env", "export", "--format=properties")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if want := "DATABASE_URL=jdbc\\:postgresql\\://localhost/app\nGREETING=hello world\nSERVER_PORT=8080\n"; stdout != want {
+		t.Errorf("properties export = %q, want %q", stdout, want)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--format=properties", "--key-transform=dotted", "-o", "application.properties")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(h.ReadFile("application.properties"), "database.url=jdbc\\:postgresql\\://localhost/app\ngreeting=hello world\nserver.port=8080\n")
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--key-transform=dotted")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--key-transform requires --format=properties")
+}
+
+// TestEnvExportKubernetes tests exporting as a Kubernetes ConfigMap or Secret
+func TestEnvExportKubernetes(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile(".env.base", "DEBUG=true\nPORT=8080\n")
+	h.WriteFile("dual.config.yml", `version: 1
+env:
+  baseFile: .env.base
+`)
+
+	stdout, stderr, exitCode := h.RunDual("env", "export", "--format=k8s-configmap", "--name", "web-env")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "kind: ConfigMap")
+	h.AssertOutputContains(stdout, "name: web-env")
+	h.AssertOutputContains(stdout, `DEBUG: "true"`)
+	h.AssertOutputContains(stdout, `PORT: "8080"`)
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--format=k8s-secret", "--name", "web-env")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "kind: Secret")
+	h.AssertOutputContains(stdout, "type: Opaque")
+	h.AssertOutputContains(stdout, "DEBUG: dHJ1ZQ==")
+	h.AssertOutputContains(stdout, "PORT: ODA4MA==")
+
+	// Default name is derived from the context
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--format=k8s-configmap")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "-env\n")
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--format=k8s-configmap", "--name", "Web_Env")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "invalid resource name")
+}
+
+// TestEnvExportEnvrc tests the direnv .envrc export format
+func TestEnvExportEnvrc(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile(".env.base", "GREETING='say \"hi\" to $USER'\n")
+	h.WriteFile("apps/api/.env", "PORT=4000\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+env:
+  baseFile: .env.base
+`)
+
+	stdout, stderr, exitCode := h.RunDual("env", "export", "--format=envrc", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, `export GREETING="say \"hi\" to \$USER"`)
+	h.AssertOutputContains(stdout, `export PORT="4000"`)
+	h.AssertOutputContains(stdout, `watch_file "`+filepath.Join(h.ProjectDir, ".env.base")+`"`)
+	h.AssertOutputContains(stdout, `watch_file "`+filepath.Join(h.ProjectDir, "apps", "api", ".env")+`"`)
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--format=envrc", "--watch=false")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputNotContains(stdout, "watch_file")
+}
+
+// TestEnvExportFilter tests --prefix and --match filtering of exported variables
+func TestEnvExportFilter(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	// The resolver command always fails, so exporting DB_PASSWORD would error
+	h.WriteFile(".env.base", "NEXT_PUBLIC_API=https://api\nNEXT_PUBLIC_SITE=site\nVITE_URL=http://vite\nDATABASE_URL=postgres://db\nDB_PASSWORD=${vault:secret/db#password}\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services: {}
+env:
+  baseFile: .env.base
+  secrets:
+    vault: "false {path}"
+`)
+
+	stdout, stderr, exitCode := h.RunDual("env", "export", "--prefix", "NEXT_PUBLIC_")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "NEXT_PUBLIC_API=https://api")
+	h.AssertOutputContains(stdout, "NEXT_PUBLIC_SITE=site")
+	h.AssertOutputNotContains(stdout, "DATABASE_URL")
+	h.AssertOutputNotContains(stdout, "DB_PASSWORD")
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--prefix", "VITE_", "--match", "*_URL", "--sort=off")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if stdout != "VITE_URL=http://vite\nDATABASE_URL=postgres://db\n" {
+		t.Errorf("unexpected filtered export:\n%s", stdout)
+	}
+
+	// Without a filter the failing secret is resolved and the export fails
+	stdout, stderr, exitCode = h.RunDual("env", "export")
+	if exitCode == 0 {
+		t.Fatalf("expected unfiltered export to fail resolving DB_PASSWORD, got: %s", stdout)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--match", "[A-")
+	if exitCode == 0 {
+		t.Fatalf("expected malformed --match to fail, got: %s", stdout)
+	}
+	h.AssertOutputContains(stderr, "invalid --match")
+}
+
+// TestEnvExportLogJSON tests that --log-json writes stderr messages as JSON lines
+func TestEnvExportLogJSON(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile(".env.base", "DATABASE_URL=postgres://localhost/dev\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: .
+env:
+  baseFile: .env.base
+`)
+
+	stdout, stderr, exitCode := h.RunDual("--log-json", "env", "export", "--output", ".env.local")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	found := false
+	for _, line := range strings.Split(strings.TrimSpace(stderr), "\n") {
+		var record struct {
+			Level string `json:"level"`
+			Msg   string `json:"msg"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("stderr line is not JSON: %q", line)
+		}
+		if record.Level == "info" && record.Msg == "Exported 1 variable(s) to .env.local" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected an info record for the export, got:\n%s", stderr)
+	}
+}
+
+// TestEnvExportOverridesOnly tests exporting only variables that differ from the base env
+func TestEnvExportOverridesOnly(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile(".env.base", "DATABASE_URL=postgres://localhost/dev\nDEBUG=false\nLOG_LEVEL=info\n")
+	h.WriteFile("apps/api/.env", "LOG_LEVEL=debug\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+env:
+  baseFile: .env.base
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-env")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-env")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "DATABASE_URL", "postgres://localhost/feature")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	// Setting a variable to its base value is not a change
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "DEBUG", "false")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--overrides-only", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if stdout != "DATABASE_URL=postgres://localhost/feature\nLOG_LEVEL=debug\n" {
+		t.Errorf("unexpected --overrides-only export:\n%s", stdout)
+	}
+}
+
+func TestEnvExportMergeFile(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile(".env.base", "DATABASE_URL=postgres://localhost/dev\nLOG_LEVEL=info\n")
+	h.WriteFile("dual.config.yml", `version: 1
+env:
+  baseFile: .env.base
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-merge")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-merge")
+
+	localFile := filepath.Join(worktreePath, ".env.local")
+	if err := os.WriteFile(localFile, []byte("LOCAL_TOKEN=abc\nLOG_LEVEL=trace\n"), 0o600); err != nil {
+		t.Fatalf("failed to write .env.local: %v", err)
+	}
+
+	t.Run("dual values win", func(t *testing.T) {
+		stdout, stderr, exitCode := h.RunDualInDir(worktreePath, "env", "export", "--merge-file", ".env.local", "-o", ".env.local")
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+		data, err := os.ReadFile(localFile)
+		if err != nil {
+			t.Fatalf("failed to read .env.local: %v", err)
+		}
+		want := "DATABASE_URL=postgres://localhost/dev\nLOCAL_TOKEN=abc\nLOG_LEVEL=info\n"
+		if string(data) != want {
+			t.Errorf("unexpected merged file:\n%s\nwant:\n%s", data, want)
+		}
+	})
+
+	t.Run("file values win", func(t *testing.T) {
+		if err := os.WriteFile(localFile, []byte("LOCAL_TOKEN=abc\nLOG_LEVEL=trace\n"), 0o600); err != nil {
+			t.Fatalf("failed to write .env.local: %v", err)
+		}
+		stdout, stderr, exitCode := h.RunDualInDir(worktreePath, "env", "export", "--merge-file", ".env.local", "--file-priority", "--sort=off")
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+		want := "DATABASE_URL=postgres://localhost/dev\nLOG_LEVEL=trace\nLOCAL_TOKEN=abc\n"
+		if stdout != want {
+			t.Errorf("unexpected export:\n%s\nwant:\n%s", stdout, want)
+		}
+	})
+
+	t.Run("file priority requires merge file", func(t *testing.T) {
+		stdout, stderr, exitCode := h.RunDualInDir(worktreePath, "env", "export", "--file-priority")
+		h.AssertExitCode(exitCode, 1, stdout+stderr)
+		h.AssertOutputContains(stderr, "--file-priority requires --merge-file")
+	})
+}
+
+// TestEnvExportSecretsSplit tests splitting the export into secrets and the rest
+func TestEnvExportSecretsSplit(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile(".env.base", "API_KEY=abc123\nDB_PASSWORD=hunter2\nLOG_LEVEL=info\nPORT=3000\n")
+	h.WriteFile("dual.config.yml", `version: 1
+env:
+  baseFile: .env.base
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	t.Run("only secrets", func(t *testing.T) {
+		stdout, stderr, exitCode := h.RunDual("env", "export", "--only-secrets")
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+		want := "API_KEY=abc123\nDB_PASSWORD=hunter2\n"
+		if stdout != want {
+			t.Errorf("unexpected export:\n%s\nwant:\n%s", stdout, want)
+		}
+	})
+
+	t.Run("exclude secrets", func(t *testing.T) {
+		stdout, stderr, exitCode := h.RunDual("env", "export", "--exclude-secrets")
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+		want := "LOG_LEVEL=info\nPORT=3000\n"
+		if stdout != want {
+			t.Errorf("unexpected export:\n%s\nwant:\n%s", stdout, want)
+		}
+	})
+
+	t.Run("merge file secrets are excluded too", func(t *testing.T) {
+		h.WriteFile(".env.local", "LOCAL_TOKEN=xyz\nDEBUG=1\n")
+		stdout, stderr, exitCode := h.RunDual("env", "export", "--exclude-secrets", "--merge-file", ".env.local")
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+		want := "DEBUG=1\nLOG_LEVEL=info\nPORT=3000\n"
+		if stdout != want {
+			t.Errorf("unexpected export:\n%s\nwant:\n%s", stdout, want)
+		}
+	})
+
+	t.Run("flags are exclusive", func(t *testing.T) {
+		stdout, stderr, exitCode := h.RunDual("env", "export", "--only-secrets", "--exclude-secrets")
+		h.AssertExitCode(exitCode, 1, stdout+stderr)
+		h.AssertOutputContains(stderr, "cannot be used together")
+	})
+}
+
+// TestEnvExportAll tests writing one env file per service
+func TestEnvExportAll(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.CreateDirectory("apps/web")
+	h.WriteFile(".env.base", "LOG_LEVEL=info\n")
+	h.WriteFile("apps/api/.env", "PORT=4000\n")
+	h.WriteFile("apps/web/.env", "PORT=3000\nPUBLIC_URL=http://localhost:3000\n")
+	h.WriteFile("dual.config.yml", `version: 1
+env:
+  baseFile: .env.base
+services:
+  api:
+    path: apps/api
+  web:
+    path: apps/web
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-all")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-all")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--service", "api", "DEBUG", "true")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--all", "--dir", "out/env")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "Exported 3 variable(s) to out/env/api.env")
+	h.AssertOutputContains(stderr, "Exported 3 variable(s) to out/env/web.env")
+
+	api, err := os.ReadFile(filepath.Join(worktreePath, "out", "env", "api.env"))
+	if err != nil {
+		t.Fatalf("failed to read api.env: %v", err)
+	}
+	if want := "DEBUG=true\nLOG_LEVEL=info\nPORT=4000\n"; string(api) != want {
+		t.Errorf("unexpected api.env:\n%s\nwant:\n%s", api, want)
+	}
+	web, err := os.ReadFile(filepath.Join(worktreePath, "out", "env", "web.env"))
+	if err != nil {
+		t.Fatalf("failed to read web.env: %v", err)
+	}
+	if want := "LOG_LEVEL=info\nPORT=3000\nPUBLIC_URL=http://localhost:3000\n"; string(web) != want {
+		t.Errorf("unexpected web.env:\n%s\nwant:\n%s", web, want)
+	}
+
+	// Re-running is a no-op, other formats get their own extension
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--all", "--dir", "out/env")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "out/env/api.env is already up to date")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--all", "--dir", "out/env", "--format=json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "out/env/web.json")
+
+	// Flag combinations
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--all")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--all requires --dir")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--all", "--dir", "out", "--service", "api")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--service cannot be used with --all")
+}
+
+// TestEnvExportTemplate tests rendering a template file with the merged environment
+func TestEnvExportTemplate(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile(".env.base", "DATABASE_URL=postgres://localhost/dev\nPORT=3000\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: .
+env:
+  baseFile: .env.base
+`)
+	h.WriteFile("config.json.tmpl", `{"db": "{{ .Env.DATABASE_URL }}", "port": {{ .Env.PORT }}, "key": "{{ .Env.API_KEY }}"}`+"\n")
+
+	// Unset variables are reported by name
+	stdout, stderr, exitCode := h.RunDual("env", "export", "--template", "config.json.tmpl")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "undefined variable(s): API_KEY")
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--template", "config.json.tmpl", "--template-default", "none")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, `{"db": "postgres://localhost/dev", "port": 3000, "key": "none"}`)
+
+	h.WriteFile("config.json.tmpl", `{"db": "{{ .Env.DATABASE_URL }}", "port": {{ .Env.PORT }}}`+"\n")
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--template", "config.json.tmpl", "--output", "config.json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "Rendered config.json.tmpl to config.json")
+	h.AssertFileContains("config.json", `{"db": "postgres://localhost/dev", "port": 3000}`)
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--template", "config.json.tmpl", "--format", "json")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--format cannot be used with --template")
+}
+
+// TestEnvExportGrouped tests exporting every layer next to the merged result
+func TestEnvExportGrouped(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile(".env.base", "PORT=3000\nLOG_LEVEL=info\n")
+	h.WriteFile("apps/api/.env", "PORT=4000\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+env:
+  baseFile: .env.base
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-grouped")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-grouped")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "DEBUG", "true", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--format=json", "--grouped", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	var grouped map[string]map[string]string
+	if err := json.Unmarshal([]byte(stdout), &grouped); err != nil {
+		t.Fatalf("grouped export is not valid JSON: %v\n%s", err, stdout)
+	}
+	want := map[string]map[string]string{
+		"base":        {"LOG_LEVEL": "info", "PORT": "3000"},
+		"serviceBase": {},
+		"service":     {"PORT": "4000"},
+		"overrides":   {"DEBUG": "true"},
+		"merged":      {"DEBUG": "true", "LOG_LEVEL": "info", "PORT": "4000"},
+	}
+	for name, vars := range want {
+		if len(grouped[name]) != len(vars) {
+			t.Errorf("%s = %v, want %v", name, grouped[name], vars)
+			continue
+		}
+		for k, v := range vars {
+			if grouped[name][k] != v {
+				t.Errorf("%s[%s] = %q, want %q", name, k, grouped[name][k], v)
+			}
+		}
+	}
+	h.AssertOutputContains(stdout, "{\n  \"base\": {\n    \"LOG_LEVEL\": \"info\",\n    \"PORT\": \"3000\"\n  },\n  \"serviceBase\": {},")
+
+	// The flat output is unchanged
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--format=json", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if stdout != "{\n  \"DEBUG\": \"true\",\n  \"LOG_LEVEL\": \"info\",\n  \"PORT\": \"4000\"\n}\n" {
+		t.Errorf("flat JSON export changed, got:\n%s", stdout)
+	}
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--grouped")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--grouped requires --format=json")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--format=json", "--grouped", "--overrides-only")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--overrides-only cannot be used with --grouped")
+}
+
+// TestEnvExportProfile tests that --profile layers a config profile above the
+// context overrides without saving it
+func TestEnvExportProfile(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.env", "PORT=4000\nLOG_LEVEL=info\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+profiles:
+  debug:
+    LOG_LEVEL: debug
+    VERBOSE: "1"
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-profile")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-profile")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "LOG_LEVEL", "warn")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--service", "api", "--profile", "debug")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if want := "LOG_LEVEL=debug\nPORT=4000\nVERBOSE=1\n"; stdout != want {
+		t.Errorf("export with --profile = %q, want %q", stdout, want)
+	}
+
+	// The profile is never saved
+	h.AssertOutputNotContains(h.ReadRegistryJSON(), "VERBOSE")
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if want := "LOG_LEVEL=warn\nPORT=4000\n"; stdout != want {
+		t.Errorf("export without --profile = %q, want %q", stdout, want)
+	}
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--profile", "trace")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `profile "trace" not found (available: debug)`)
+}
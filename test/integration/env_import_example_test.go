@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEnvImportExample tests seeding missing overrides from a .env.example file
+func TestEnvImportExample(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: .
+env:
+  baseFile: .env.base
+worktrees:
+  path: ../worktrees
+`)
+	h.WriteFile(".env.base", "LOG_LEVEL=info\n")
+	h.WriteFile(".env.example", "LOG_LEVEL=debug\nDATABASE_URL=postgres://localhost/app\nAPI_KEY=\nPORT=3000\n")
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-x")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktree := filepath.Join(h.TempDir, "worktrees", "feature-x")
+	stdout, stderr, exitCode = h.RunDualInDir(worktree, "env", "set", "PORT", "4001")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	// Interactive: accept the example DATABASE_URL and type an API_KEY
+	cmd := exec.Command(h.DualBin, "env", "import-example", ".env.example")
+	cmd.Dir = worktree
+	cmd.Env = append(os.Environ(), "HOME="+h.TestHome)
+	cmd.Stdin = strings.NewReader("\nsecret-key\n")
+	var out, errOut strings.Builder
+	cmd.Stdout = &out
+	cmd.Stderr = &errOut
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("import-example failed: %v\n%s%s", err, out.String(), errOut.String())
+	}
+	h.AssertOutputContains(errOut.String(), "DATABASE_URL [postgres://localhost/app]: ")
+	h.AssertOutputContains(out.String(), "Seeded 2 variable(s) from .env.example into context 'feature-x' (global):\n  DATABASE_URL\n  API_KEY")
+	h.AssertOutputContains(out.String(), "Already present (2):\n  LOG_LEVEL (env file)\n  PORT (override)")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktree, "env", "export")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_KEY=secret-key")
+	h.AssertOutputContains(stdout, "DATABASE_URL=postgres://localhost/app")
+	h.AssertOutputContains(stdout, "LOG_LEVEL=info")
+	h.AssertOutputContains(stdout, "PORT=4001")
+
+	// --defaults never prompts; empty example values are left unset
+	h.WriteFile("apps.env.example", "QUEUE_URL=redis://localhost\nSENTRY_DSN=\n")
+	stdout, stderr, exitCode = h.RunDualInDir(worktree, "env", "import-example", filepath.Join(h.ProjectDir, "apps.env.example"), "--defaults", "--service", "web")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Seeded 1 variable(s)")
+	h.AssertOutputContains(stdout, "(service 'web')")
+	h.AssertOutputContains(stdout, "Left unset, no value given (1):\n  SENTRY_DSN")
+	h.AssertOutputNotContains(stderr, "QUEUE_URL [")
+
+	// Without a terminal answer, prompting fails with a hint
+	stdout, stderr, exitCode = h.RunDualInDir(worktree, "env", "import-example", filepath.Join(h.ProjectDir, "apps.env.example"))
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--defaults")
+
+	// A missing file is an error
+	stdout, stderr, exitCode = h.RunDualInDir(worktree, "env", "import-example", "missing.env.example")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "failed to read example file")
+}
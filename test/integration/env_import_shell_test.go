@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestEnvImportShell tests capturing shell variables as context overrides
+func TestEnvImportShell(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/web/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: apps/web
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-import")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-import")
+
+	t.Setenv("IMPORT_TEST_DB", "postgres://localhost/import")
+	t.Setenv("IMPORT_TEST_KEY", "secret")
+	t.Setenv("OTHER_TEST_VAR", "ignored")
+
+	// Refuses to run without an explicit selection
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "import-shell")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stdout+stderr, "no variables selected")
+
+	// Dry run does not modify the registry
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "import-shell", "--prefix", "IMPORT_TEST_", "--dry-run")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Would import 2 variable(s)")
+	h.AssertOutputNotContains(h.ReadRegistryJSON(), "IMPORT_TEST_DB")
+
+	// Import by prefix
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "import-shell", "--prefix", "IMPORT_TEST_")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Imported 2 variable(s)")
+
+	registryContent := h.ReadRegistryJSON()
+	h.AssertOutputContains(registryContent, "postgres://localhost/import")
+	h.AssertOutputContains(registryContent, "IMPORT_TEST_KEY")
+	h.AssertOutputNotContains(registryContent, "OTHER_TEST_VAR")
+
+	// Import explicit keys as service overrides
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "import-shell", "--keys", "OTHER_TEST_VAR", "--service", "web")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "service 'web'")
+	h.AssertOutputContains(h.ReadRegistryJSON(), "OTHER_TEST_VAR")
+}
@@ -0,0 +1,480 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestEnvSetIfNotExists tests that --if-not-exists never overwrites an existing override
+func TestEnvSetIfNotExists(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-seed")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-seed")
+
+	// First run seeds the value
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--if-not-exists", "LOG_LEVEL", "info")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Set LOG_LEVEL=info")
+
+	// A user customization survives re-running the seed
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "LOG_LEVEL", "debug")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--if-not-exists", "LOG_LEVEL", "info")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "LOG_LEVEL already set in context 'feature-seed', skipped")
+
+	// Service scope: a new key is set, an existing service key is skipped
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--service", "api", "--if-not-exists", "PORT", "4001")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Set PORT=4001 for service 'api'")
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--service", "api", "--if-not-exists", "PORT", "5001")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "skipped")
+
+	// A global override does not count as set for the service layer
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--service", "api", "--if-not-exists", "LOG_LEVEL", "trace")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Set LOG_LEVEL=trace for service 'api'")
+
+	registryContent := h.ReadRegistryJSON()
+	h.AssertOutputContains(registryContent, `"LOG_LEVEL": "debug"`)
+	h.AssertOutputContains(registryContent, `"LOG_LEVEL": "trace"`)
+	h.AssertOutputContains(registryContent, `"PORT": "4001"`)
+	h.AssertOutputNotContains(registryContent, "5001")
+}
+
+// TestEnvUnsetServiceLayer tests that unset with --service only removes the
+// service's own override, never an inherited global one
+func TestEnvUnsetServiceLayer(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-unset")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-unset")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "LOG_LEVEL", "debug")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "unset", "--service", "api", "LOG_LEVEL")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "\"LOG_LEVEL\" is not set for service 'api'")
+	h.AssertOutputNotContains(stdout, "Removed override")
+
+	registryContent := h.ReadRegistryJSON()
+	h.AssertOutputContains(registryContent, `"LOG_LEVEL": "debug"`)
+}
+
+// TestEnvSetNote tests attaching a note to an override and showing it
+func TestEnvSetNote(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-note")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-note")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "DEBUG", "true", "--note", "investigating #123")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "show")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "DEBUG=true  # investigating #123")
+
+	// Changing the value without --note keeps the note
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "DEBUG", "false")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "show", "--overrides-only", "--values")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "DEBUG=false  # investigating #123")
+
+	// An empty note removes it
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "DEBUG", "false", "--note", "")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputNotContains(h.ReadRegistryJSON(), "investigating")
+}
+
+// TestEnvSetNoGenerate tests deferring service env file generation to dual env remap
+func TestEnvSetNoGenerate(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-bulk")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-bulk")
+	serviceEnv := filepath.Join(h.ProjectDir, ".dual", ".local", "service", "api", ".env")
+
+	for _, kv := range [][]string{{"A", "1"}, {"B", "2"}} {
+		stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--no-generate", kv[0], kv[1])
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+	}
+
+	// The registry is updated right away, the service file is not written
+	h.AssertOutputContains(h.ReadRegistryJSON(), `"B": "2"`)
+	if _, err := os.Stat(serviceEnv); !os.IsNotExist(err) {
+		t.Fatalf("expected no service env file before remap, got %v", err)
+	}
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "remap")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	data, err := os.ReadFile(serviceEnv)
+	if err != nil {
+		t.Fatalf("expected service env file after remap: %v", err)
+	}
+	h.AssertOutputContains(string(data), "A=1")
+	h.AssertOutputContains(string(data), "B=2")
+
+	// Unset defers too
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "unset", "--no-generate", "A")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	data, _ = os.ReadFile(serviceEnv)
+	h.AssertOutputContains(string(data), "A=1")
+}
+
+// TestEnvSetServiceAlias tests that service aliases resolve to the canonical service
+func TestEnvSetServiceAlias(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/web/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: apps/web
+    aliases: [frontend]
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-alias")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-alias")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--service", "frontend", "API_URL", "http://localhost:4000")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	// Overrides and generated files are keyed by the canonical name
+	h.AssertOutputContains(h.ReadRegistryJSON(), `"web": {`)
+	h.AssertOutputNotContains(h.ReadRegistryJSON(), `"frontend": {`)
+	h.AssertFileContains(".dual/.local/service/web/.env", "API_URL=http://localhost:4000")
+
+	t.Setenv("DUAL_SERVICE", "frontend")
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_URL=http://localhost:4000")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "service", "list")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "(aliases: frontend)")
+}
+
+// TestEnvSetJSON tests validating JSON values and setting overrides from a JSON file
+func TestEnvSetJSON(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-json")
+
+	// Malformed JSON is rejected and nothing is stored
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--json", "FLAGS", `{"beta": true`)
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "value for FLAGS is not valid JSON")
+	h.AssertOutputNotContains(h.ReadRegistryJSON(), "FLAGS")
+
+	// Valid JSON is stored exactly as given
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--json", "FLAGS", `{"beta": true}`)
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--format", "json")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, `"FLAGS": "{\"beta\": true}"`)
+
+	// A JSON file is flattened into one override per leaf
+	jsonFile := filepath.Join(h.TempDir, "config.json")
+	if err := os.WriteFile(jsonFile, []byte(`{"DB": {"HOST": "localhost", "PORT": 5432}, "HOSTS": ["a", "b"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--service", "api", "--from-json-file", jsonFile)
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Set 3 variable(s) from "+jsonFile+" in context 'feature-json' (service 'api')")
+	h.AssertFileContains(".dual/.local/service/api/.env", "DB__HOST=localhost")
+	h.AssertFileContains(".dual/.local/service/api/.env", "DB__PORT=5432")
+
+	// The separator is configurable
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--from-json-file", jsonFile, "--json-separator", "_")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(h.ReadRegistryJSON(), `"DB_HOST": "localhost"`)
+
+	// Keys that are not valid variable names fail the whole file
+	if err := os.WriteFile(jsonFile, []byte(`{"OK": 1, "NOT-OK": 2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--from-json-file", jsonFile)
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "NOT-OK")
+	h.AssertOutputNotContains(h.ReadRegistryJSON(), `"OK"`)
+
+	// --from-json-file replaces the <key> <value> arguments
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--from-json-file", jsonFile, "KEY", "value")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--from-json-file takes no <key> <value> arguments")
+}
+
+// TestEnvRollback tests undoing env set and env unset with dual env rollback
+func TestEnvRollback(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-rollback")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-rollback")
+
+	for _, value := range []string{"postgres://localhost/app", "postgres://typo"} {
+		stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "DATABASE_URL", value)
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+	}
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "rollback", "DATABASE_URL")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "restored DATABASE_URL=postgres://localhost/app")
+	h.AssertFileContains(".dual/.local/service/api/.env", "DATABASE_URL=postgres://localhost/app")
+
+	// An unset is undone too
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "unset", "DATABASE_URL")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "rollback", "DATABASE_URL")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "restored DATABASE_URL=postgres://localhost/app")
+
+	// Rolling back the first set removes the override
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "rollback", "DATABASE_URL")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "removed, it was not set before")
+	h.AssertOutputNotContains(h.ReadRegistryJSON(), "postgres://localhost/app")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "rollback", "DATABASE_URL")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "no recorded changes to DATABASE_URL")
+}
+
+// TestEnvEnvironmentOverlay tests --env overlays for set, unset, show and export
+func TestEnvEnvironmentOverlay(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-envs")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-envs")
+
+	for _, args := range [][]string{
+		{"env", "set", "API_URL", "http://localhost:4000"},
+		{"env", "set", "--service", "api", "LOG_LEVEL", "debug"},
+		{"env", "set", "--env", "staging", "API_URL", "https://staging.example.com"},
+		{"env", "set", "--env", "staging", "LOG_LEVEL", "warn"},
+	} {
+		stdout, stderr, exitCode = h.RunDualInDir(worktreePath, args...)
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+	}
+	h.AssertOutputContains(stdout, "Set LOG_LEVEL=warn for environment 'staging' in context 'feature-envs'")
+
+	// The overlay never reaches the generated service env file
+	h.AssertFileContains(".dual/.local/service/api/.env", "API_URL=http://localhost:4000")
+	h.AssertOutputNotContains(h.ReadFile(".dual/.local/service/api/.env"), "staging")
+
+	// Default export is unchanged
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_URL=http://localhost:4000")
+	h.AssertOutputContains(stdout, "LOG_LEVEL=debug")
+
+	// The overlay wins over global and service overrides
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--service", "api", "--env", "staging")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_URL=https://staging.example.com")
+	h.AssertOutputContains(stdout, "LOG_LEVEL=warn")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "show", "--env", "staging", "--values")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "https://staging.example.com")
+
+	// A typo in the environment name is pointed out
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--env", "stagng")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "has no overrides for environment 'stagng'")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--env", "staging", "--service", "api", "A", "1")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "--env and --service cannot be used together")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "unset", "--env", "staging", "API_URL")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Removed override for API_URL in environment 'staging'")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "export", "--env", "staging")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_URL=http://localhost:4000")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "unset", "--env", "staging", "API_URL")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "no override found for \"API_URL\" in environment 'staging'")
+}
+
+// TestEnvDryRun tests that --dry-run previews set, unset and remap without saving
+func TestEnvDryRun(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.WriteFile("apps/web/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+  web:
+    path: apps/web
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-dry-run")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-dry-run")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--service", "api", "PORT", "4000")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	registryBefore := h.ReadRegistryJSON()
+	apiBefore := h.ReadFile(".dual/.local/service/api/.env")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--dry-run", "DEBUG", "true")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Dry run for context 'feature-dry-run', nothing was saved")
+	h.AssertOutputContains(stdout, "+ DEBUG=true (global)")
+	h.AssertOutputContains(stdout, "update .dual/.local/service/api/.env")
+	h.AssertOutputContains(stdout, "create .dual/.local/service/web/.env")
+	h.AssertOutputNotContains(stdout, "Set DEBUG=true")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--dry-run", "--service", "api", "PORT", "4001")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "~ PORT=4001 (service api, was 4000)")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "unset", "--dry-run", "--service", "api", "PORT")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "- PORT (service api, was 4000)")
+	h.AssertOutputNotContains(stdout, "Removed override")
+
+	// Nothing was persisted
+	if got := h.ReadRegistryJSON(); got != registryBefore {
+		t.Errorf("registry changed by --dry-run:\n%s", got)
+	}
+	if got := h.ReadFile(".dual/.local/service/api/.env"); got != apiBefore {
+		t.Errorf("api env file changed by --dry-run:\n%s", got)
+	}
+	if h.FileExists(".dual/.local/service/web/.env") {
+		t.Error("web env file should not be created by --dry-run")
+	}
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "remap", "--dry-run")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Registry: no changes")
+	h.AssertOutputContains(stdout, "Service env files: up to date")
+}
@@ -0,0 +1,250 @@
+package integration
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+// TestEnvShowTree tests displaying each variable's value per layer
+func TestEnvShowTree(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile(".env.base", "DATABASE_URL=postgres://base\nLOG_LEVEL=info\n")
+	h.WriteFile("apps/api/.env", "DATABASE_URL=postgres://service\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+env:
+  baseFile: .env.base
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-tree")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-tree")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "--service", "api", "DATABASE_URL", "postgres://override")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "show", "--tree", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "DATABASE_URL: base=postgres://base service=postgres://service override=postgres://override (→ override)")
+	h.AssertOutputContains(stdout, "LOG_LEVEL: base=info (→ base)")
+}
+
+// TestEnvShowDiffBase tests listing base variables replaced by higher layers,
+// and the ones left in effect
+func TestEnvShowDiffBase(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile(".env.base", "DATABASE_URL=postgres://base\nLOG_LEVEL=info\nPORT=3000\n")
+	h.WriteFile("apps/api/.env", "PORT=4000\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+env:
+  baseFile: .env.base
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-diff")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-diff")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "DATABASE_URL", "postgres://override")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "show", "--diff-base", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "DATABASE_URL: base=postgres://base → override=postgres://override")
+	h.AssertOutputContains(stdout, "PORT: base=3000 → service=4000")
+	h.AssertOutputNotContains(stdout, "LOG_LEVEL")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "show", "--diff-base", "--json", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, `"overridingBase"`)
+	h.AssertOutputContains(stdout, `"layer": "service"`)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "show", "--export-unset", "--service", "api", "--values")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "LOG_LEVEL=info")
+	h.AssertOutputNotContains(stdout, "PORT")
+	h.AssertOutputNotContains(stdout, "DATABASE_URL")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "show", "--json", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, `"inheritedBase": {
+    "LOG_LEVEL": "info"
+  }`)
+}
+
+// TestEnvShowServiceBaseFile tests that a service baseFile shows up as its own layer
+func TestEnvShowServiceBaseFile(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile(".env.base", "LOG_LEVEL=info\nPORT=3000\n")
+	h.WriteFile(".env.api.base", "LOG_LEVEL=warn\nPORT=4000\n")
+	h.WriteFile("apps/api/.env", "PORT=4001\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+    baseFile: .env.api.base
+env:
+  baseFile: .env.base
+`)
+
+	stdout, stderr, exitCode := h.RunDual("env", "show", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Svc base:  .env.api.base (2 vars)")
+
+	stdout, stderr, exitCode = h.RunDual("env", "show", "--tree", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "LOG_LEVEL: base=info serviceBase=warn (→ serviceBase)")
+	h.AssertOutputContains(stdout, "PORT: base=3000 serviceBase=4000 service=4001 (→ service)")
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if stdout != "LOG_LEVEL=warn\nPORT=4001\n" {
+		t.Errorf("unexpected export:\n%s", stdout)
+	}
+}
+
+// TestEnvShowJSONLayers tests that --json reports the service and runtime layers
+func TestEnvShowJSONLayers(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile(".env.base", "DUAL_TEST_LOG_LEVEL=info\n")
+	h.WriteFile("apps/api/.env", "DUAL_TEST_PORT=4001\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+env:
+  baseFile: .env.base
+`)
+
+	// Passed on to the dual process by RunDual
+	t.Setenv("DUAL_TEST_LOG_LEVEL", "trace")
+
+	stdout, stderr, exitCode := h.RunDual("env", "show", "--json", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	var output struct {
+		Stats   map[string]int    `json:"stats"`
+		Service map[string]string `json:"service"`
+		Runtime map[string]string `json:"runtime"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &output); err != nil {
+		t.Fatalf("failed to parse JSON: %v\n%s", err, stdout)
+	}
+	if output.Service["DUAL_TEST_PORT"] != "4001" || output.Stats["serviceVars"] != 1 {
+		t.Errorf("unexpected service layer: %v (stats %v)", output.Service, output.Stats)
+	}
+	if output.Runtime["DUAL_TEST_LOG_LEVEL"] != "trace" || output.Stats["runtimeVars"] != 1 {
+		t.Errorf("unexpected runtime layer: %v (stats %v)", output.Runtime, output.Stats)
+	}
+}
+
+// TestEnvShowMissingBaseFile tests the warning for a configured but missing base file
+func TestEnvShowMissingBaseFile(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+env:
+  baseFile: .env.base
+`)
+
+	stdout, stderr, exitCode := h.RunDual("env", "show")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "Base file .env.base is configured but does not exist")
+	h.AssertOutputContains(stdout, "Base:      .env.base (0 vars)")
+
+	stdout, stderr, exitCode = h.RunDual("env", "show", "--base-only")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "Base file .env.base is configured but does not exist")
+
+	// No warning once the file exists
+	h.WriteFile(".env.base", "LOG_LEVEL=info\n")
+	stdout, stderr, exitCode = h.RunDual("env", "show")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputNotContains(stderr, "does not exist")
+}
+
+// TestEnvContextFlag tests inspecting another context's environment with --context
+func TestEnvContextFlag(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: .
+env:
+  baseFile: .env.base
+worktrees:
+  path: ../worktrees
+`)
+	h.WriteFile(".env.base", "API_URL=http://api\nPORT=3000\n")
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-x")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktree := filepath.Join(h.TempDir, "worktrees", "feature-x")
+	stdout, stderr, exitCode = h.RunDualInDir(worktree, "env", "set", "API_URL", "http://feature-x")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	// From the main repository, inspect the feature-x context
+	stdout, stderr, exitCode = h.RunDual("env", "show", "--context", "feature-x", "--overrides-only", "--values")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_URL=http://feature-x")
+
+	stdout, stderr, exitCode = h.RunDual("env", "export", "--context", "feature-x")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_URL=http://feature-x")
+	h.AssertOutputContains(stdout, "PORT=3000")
+
+	// Without --context the current context is unaffected
+	stdout, stderr, exitCode = h.RunDual("env", "export")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_URL=http://api")
+
+	stdout, stderr, exitCode = h.RunDual("env", "check", "--context", "feature-x")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Context selected: feature-x")
+	h.AssertOutputContains(stdout, "Context has 1 environment override(s)")
+
+	// Unknown contexts are rejected rather than shown without overrides
+	for _, command := range []string{"show", "export", "check"} {
+		stdout, stderr, exitCode = h.RunDual("env", command, "--context", "no-such-context")
+		if exitCode == 0 {
+			t.Errorf("env %s --context no-such-context: expected failure\nOutput: %s", command, stdout+stderr)
+		}
+		h.AssertOutputContains(stdout+stderr, "no-such-context")
+	}
+}
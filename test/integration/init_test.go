@@ -0,0 +1,74 @@
+package integration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestInitGitignore tests that dual init ignores .dual/.local/ exactly once
+func TestInitGitignore(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile(".gitignore", "node_modules/")
+
+	stdout, stderr, exitCode := h.RunDual("init")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Added /.dual/.local/ to")
+
+	// Running again must not add a second entry
+	stdout, stderr, exitCode = h.RunDual("init", "--force")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, ".gitignore already ignores .dual/.local/")
+
+	data, err := os.ReadFile(filepath.Join(h.ProjectDir, ".gitignore"))
+	if err != nil {
+		t.Fatalf("failed to read .gitignore: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "node_modules/\n") {
+		t.Errorf("existing entries should be kept:\n%s", data)
+	}
+	if n := strings.Count(string(data), "/.dual/.local/"); n != 1 {
+		t.Errorf("expected one .dual/.local/ entry, got %d:\n%s", n, data)
+	}
+
+	// The registry directory is now ignored by git
+	h.CreateDirectory(".dual/.local")
+	h.WriteFile(".dual/.local/registry.json", "{}")
+	status, err := h.RunGitCommand("status", "--porcelain", "--untracked-files=all")
+	if err != nil {
+		t.Fatalf("git status failed: %v\n%s", err, status)
+	}
+	h.AssertOutputNotContains(status, ".dual/")
+}
+
+// TestInitNoGitignore tests that --no-gitignore leaves .gitignore alone
+func TestInitNoGitignore(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+
+	stdout, stderr, exitCode := h.RunDual("init", "--no-gitignore")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	if _, err := os.Stat(filepath.Join(h.ProjectDir, ".gitignore")); !os.IsNotExist(err) {
+		t.Errorf("expected no .gitignore, got %v", err)
+	}
+}
+
+// TestInitGitignoreWholeDualDir tests the warning when all of .dual/ is ignored
+func TestInitGitignoreWholeDualDir(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile(".gitignore", ".dual/\n")
+
+	stdout, stderr, exitCode := h.RunDual("init")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "including hook scripts in .dual/hooks/")
+}
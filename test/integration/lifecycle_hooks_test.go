@@ -656,3 +656,135 @@ worktrees:
 
 	t.Log("Test completed successfully!")
 }
+
+// TestCreateHookFailurePolicy tests the onHookFailure policies for dual create
+func TestCreateHookFailurePolicy(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         string
+		wantExitCode   int
+		wantWorktree   bool
+		wantContext    bool
+		outputContains string
+	}{
+		{name: "warn", policy: "warn", wantExitCode: 0, wantWorktree: true, wantContext: true, outputContains: "Worktree created but hooks failed"},
+		{name: "abort", policy: "abort", wantExitCode: 1, wantWorktree: true, wantContext: true, outputContains: "kept at"},
+		{name: "rollback", policy: "rollback", wantExitCode: 1, wantWorktree: false, wantContext: false, outputContains: "rolling back context"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewTestHelper(t)
+			defer h.RestoreHome()
+
+			h.InitGitRepo()
+			h.CreateDirectory(".dual/hooks")
+			h.WriteFile(".dual/hooks/fail.sh", "#!/bin/sh\necho 'setup failed' >&2\nexit 1\n")
+			if err := os.Chmod(filepath.Join(h.ProjectDir, ".dual/hooks/fail.sh"), 0o755); err != nil {
+				t.Fatalf("Failed to make hook executable: %v", err)
+			}
+
+			h.WriteFile("dual.config.yml", `version: 1
+
+worktrees:
+  path: ../worktrees
+
+hooks:
+  postWorktreeCreate:
+    - fail.sh
+
+onHookFailure: `+tt.policy+`
+`)
+			h.RunGitCommand("add", ".")
+			h.RunGitCommand("commit", "-m", "Add failing hook")
+
+			stdout, stderr, exitCode := h.RunDual("create", "feature-x")
+			h.AssertExitCode(exitCode, tt.wantExitCode, stdout+stderr)
+			h.AssertOutputContains(stderr, tt.outputContains)
+
+			worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-x")
+			_, statErr := os.Stat(worktreePath)
+			if exists := statErr == nil; exists != tt.wantWorktree {
+				t.Errorf("worktree exists = %v, want %v", exists, tt.wantWorktree)
+			}
+
+			registryContent := h.ReadRegistryJSON()
+			if hasContext := strings.Contains(registryContent, "feature-x"); hasContext != tt.wantContext {
+				t.Errorf("context registered = %v, want %v\nRegistry: %s", hasContext, tt.wantContext, registryContent)
+			}
+		})
+	}
+}
+
+// TestCreateHookFailurePolicyFlag tests that --on-hook-failure overrides the config
+func TestCreateHookFailurePolicyFlag(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.RunDual("init")
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-x", "--on-hook-failure", "explode")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "Invalid hook failure policy")
+}
+
+// TestServiceHooks tests that hooks configured under a service run with
+// DUAL_SERVICE set, after the project-level hooks
+func TestServiceHooks(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.WriteFile("apps/web/.gitkeep", "")
+	h.CreateDirectory(".dual/hooks")
+	h.WriteFile(".dual/hooks/setup.sh", `#!/bin/sh
+echo "${DUAL_SERVICE:-project}" >> "$DUAL_CONTEXT_PATH/hook-runs.txt"
+`)
+	h.WriteFile(".dual/hooks/migrate.sh", `#!/bin/sh
+echo "$DUAL_SERVICE:MIGRATED=true"
+`)
+	for _, script := range []string{"setup.sh", "migrate.sh"} {
+		if err := os.Chmod(filepath.Join(h.ProjectDir, ".dual", "hooks", script), 0o755); err != nil {
+			t.Fatalf("failed to chmod hook: %v", err)
+		}
+	}
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+    hooks:
+      postWorktreeCreate:
+        - setup.sh
+        - migrate.sh
+  web:
+    path: apps/web
+hooks:
+  postWorktreeCreate:
+    - setup.sh
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-service-hooks")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "Running postWorktreeCreate hooks (3 scripts)")
+	h.AssertOutputContains(stderr, "Executing hook: migrate.sh (service api)")
+
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-service-hooks")
+	if got := h.ReadFileInDir(worktreePath, "hook-runs.txt"); got != "project\napi\n" {
+		t.Errorf("hook runs = %q, want project then api", got)
+	}
+
+	h.AssertFileContains(".dual/.local/service/api/.env", "MIGRATED=true")
+	if h.FileExists(".dual/.local/service/web/.env") {
+		t.Error("api's service override should not reach web")
+	}
+}
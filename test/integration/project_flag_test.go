@@ -0,0 +1,32 @@
+package integration
+
+import (
+	"testing"
+)
+
+// TestProjectFlag tests running commands against a project outside the cwd
+func TestProjectFlag(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/web")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: apps/web
+`)
+
+	// Without --project, commands run outside the project fail
+	stdout, stderr, exitCode := h.RunDualInDir(h.TempDir, "service", "list")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(h.TempDir, "service", "list", "--project", h.ProjectDir)
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "web")
+
+	// The path must contain a config file
+	stdout, stderr, exitCode = h.RunDualInDir(h.TempDir, "service", "list", "--project", h.TempDir)
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "invalid --project")
+}
@@ -0,0 +1,307 @@
+package integration
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// TestRunEnvOverride tests one-off overrides passed to dual run
+func TestRunEnvOverride(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile(".env.base", "PORT=3000\nLOG_LEVEL=info\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+env:
+  baseFile: .env.base
+`)
+
+	stdout, stderr, exitCode := h.RunDual("run", "--service", "api",
+		"--env-override", "PORT=4001", "--env-override", "EXTRA=a=b",
+		"--", "sh", "-c", "echo port=$PORT extra=$EXTRA level=$LOG_LEVEL")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "port=4001 extra=a=b level=info")
+
+	// Overrides are never persisted
+	if h.RegistryExists() {
+		h.AssertOutputNotContains(h.ReadRegistryJSON(), "4001")
+	}
+
+	// Invalid keys are rejected before running anything
+	stdout, stderr, exitCode = h.RunDual("run", "--service", "api", "--env-override", "BAD-KEY=1", "true")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "invalid --env-override")
+}
+
+// TestRunProfile tests that --profile applies a config profile for one run,
+// below --env-override
+func TestRunProfile(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.env", "PORT=4000\nLOG_LEVEL=info\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+profiles:
+  debug:
+    LOG_LEVEL: debug
+    PORT: "4100"
+`)
+
+	stdout, stderr, exitCode := h.RunDual("run", "--service", "api", "--profile", "debug",
+		"--env-override", "PORT=4001", "--", "sh", "-c", "echo port=$PORT level=$LOG_LEVEL")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "port=4001 level=debug")
+
+	// Unknown profiles are rejected before running anything
+	stdout, stderr, exitCode = h.RunDual("run", "--service", "api", "--profile", "trace", "--", "sh", "-c", "echo ran")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `profile "trace" not found`)
+	h.AssertOutputNotContains(stdout, "ran")
+}
+
+// TestRunContextEnvFile tests that dual run layers <env file>.<context> over
+// the service env file, below the context's registry overrides
+func TestRunContextEnvFile(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.env", "DATABASE_URL=postgres://localhost/app\nPORT=4000\n")
+	h.WriteFile("apps/api/.env.feature-db", "DATABASE_URL=postgres://localhost/feature_db\nPORT=4100\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-db")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktreePath := filepath.Join(h.TempDir, "worktrees", "feature-db")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "env", "set", "PORT", "4200", "--service", "api")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktreePath, "run", "--service", "api", "--", "sh", "-c", "echo db=$DATABASE_URL port=$PORT")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "db=postgres://localhost/feature_db port=4200")
+
+	// Other contexts have no file of their own and keep the service value
+	stdout, stderr, exitCode = h.RunDual("run", "--service", "api", "--", "sh", "-c", "echo db=$DATABASE_URL")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "db=postgres://localhost/app")
+}
+
+// TestRunSignalForwarding tests that signals sent to dual reach the command
+// and that its exit code is propagated
+func TestRunSignalForwarding(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+`)
+
+	cmd := exec.Command(h.DualBin, "run", "--service", "api", "--",
+		"sh", "-c", `trap 'echo got-term; exit 3' TERM; echo ready; while true; do sleep 0.1; done`)
+	cmd.Dir = h.ProjectDir
+	cmd.Env = append(os.Environ(), "HOME="+h.TestHome)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("failed to get stdout: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start dual run: %v", err)
+	}
+
+	reader := bufio.NewReader(stdout)
+	line, err := reader.ReadString('\n')
+	if err != nil || strings.TrimSpace(line) != "ready" {
+		t.Fatalf("expected child to report ready, got %q (%v)", line, err)
+	}
+
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("failed to signal dual: %v", err)
+	}
+
+	rest, _ := io.ReadAll(reader)
+	err = cmd.Wait()
+	h.AssertOutputContains(string(rest), "got-term")
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("expected dual to exit with the child's code 3, got %v", err)
+	}
+}
+
+// TestRunServiceFromEnvVar tests selecting the service with DUAL_SERVICE from outside service directories
+func TestRunServiceFromEnvVar(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/api/.env", "NAME=api\n")
+	h.WriteFile("apps/web/.env", "NAME=web\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+  web:
+    path: apps/web
+`)
+
+	// Without a service the project root cannot be resolved
+	stdout, stderr, exitCode := h.RunDual("run", "--", "sh", "-c", "echo name=$NAME")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "DUAL_SERVICE")
+
+	t.Setenv("DUAL_SERVICE", "web")
+	stdout, stderr, exitCode = h.RunDual("run", "--", "sh", "-c", "echo name=$NAME")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "name=web")
+
+	// --service takes precedence over DUAL_SERVICE
+	stdout, stderr, exitCode = h.RunDual("run", "--service", "api", "--", "sh", "-c", "echo name=$NAME")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "name=api")
+
+	// read-only env commands honor it too
+	stdout, stderr, exitCode = h.RunDual("env", "export")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "NAME=web")
+
+	t.Setenv("DUAL_SERVICE", "db")
+	stdout, stderr, exitCode = h.RunDual("run", "--", "true")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `"db" (from DUAL_SERVICE)`)
+}
+
+// TestRunEnvExclude tests that overrides matching env.exclude are kept out of
+// the generated service env file but still reach commands run with dual run
+func TestRunEnvExclude(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.env", "NAME=api\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+env:
+  exclude:
+    - CI
+    - GITHUB_*
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-x")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktree := filepath.Join(h.TempDir, "worktrees", "feature-x")
+
+	for _, kv := range [][]string{{"CI", "true"}, {"GITHUB_TOKEN", "ghp_secret"}, {"DEBUG", "1"}} {
+		stdout, stderr, exitCode = h.RunDualInDir(worktree, "env", "set", kv[0], kv[1])
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+	}
+
+	generated := h.ReadFile(".dual/.local/service/api/.env")
+	h.AssertOutputContains(generated, "DEBUG=1")
+	h.AssertOutputNotContains(generated, "CI=")
+	h.AssertOutputNotContains(generated, "GITHUB_TOKEN")
+
+	stdout, stderr, exitCode = h.RunDualInDir(worktree, "run", "--service", "api", "--",
+		"sh", "-c", "echo ci=$CI token=$GITHUB_TOKEN debug=$DEBUG name=$NAME")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "ci=true token=ghp_secret debug=1 name=api")
+
+	// Excluding every override still rewrites the file, dropping old values
+	stdout, stderr, exitCode = h.RunDualInDir(worktree, "env", "unset", "DEBUG")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputNotContains(h.ReadFile(".dual/.local/service/api/.env"), "DEBUG")
+}
+
+// TestRunCwd tests that --cwd selects the service and the command's working
+// directory without changing into it
+func TestRunCwd(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/api/.env", "NAME=api\n")
+	h.WriteFile("apps/web/.env", "NAME=web\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+  web:
+    path: apps/web
+`)
+
+	stdout, stderr, exitCode := h.RunDual("run", "--cwd", "apps/api", "--", "sh", "-c", "echo name=$NAME dir=$(basename $(pwd))")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "name=api dir=api")
+
+	stdout, stderr, exitCode = h.RunDual("run", "--cwd", "apps/missing", "--", "true")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "invalid --cwd")
+}
+
+// TestRunQuiet tests that --quiet hides [dual] messages but keeps the
+// command's output and errors
+func TestRunQuiet(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.env", "NAME=api\n")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+`)
+
+	stdout, stderr, exitCode := h.RunDual("-q", "run", "--service", "api", "--", "sh", "-c", "echo name=$NAME")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "name=api")
+	if stderr != "" {
+		t.Errorf("expected no stderr with --quiet, got:\n%s", stderr)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("--quiet", "run", "--service", "db", "--", "true")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `"db"`)
+}
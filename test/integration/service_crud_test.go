@@ -282,3 +282,28 @@ services: {}
 	stdout, _, _ = h.RunDual("service", "list")
 	h.AssertOutputContains(stdout, "No services configured")
 }
+
+// TestServiceAddCreateDir tests creating a missing service directory with --create-dir
+func TestServiceAddCreateDir(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.RunDual("init")
+
+	stdout, stderr, exitCode := h.RunDual("service", "add", "web", "--path", "apps/web", "--env-file", "apps/web/.env.local", "--create-dir")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Created directory apps/web")
+	h.AssertOutputContains(stdout, `Added service "web"`)
+	h.AssertFileExists("apps/web")
+
+	// An existing directory is left alone
+	stdout, stderr, exitCode = h.RunDual("service", "add", "web2", "--path", "apps/web", "--create-dir")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputNotContains(stdout, "Created directory")
+
+	// The config stays valid for later commands
+	stdout, stderr, exitCode = h.RunDual("service", "list")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "web")
+}
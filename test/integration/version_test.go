@@ -0,0 +1,50 @@
+package integration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestVersionCheckCached tests that dual version --check uses a fresh cached
+// release instead of asking GitHub
+func TestVersionCheckCached(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	cacheHome := filepath.Join(h.TempDir, "cache")
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	if err := os.MkdirAll(filepath.Join(cacheHome, "dual"), 0o755); err != nil {
+		t.Fatalf("failed to create cache dir: %v", err)
+	}
+	cache := fmt.Sprintf(`{"version": "v99.0.0", "url": "https://example.com/v99", "checkedAt": %q}`, time.Now().UTC().Format(time.RFC3339))
+	if err := os.WriteFile(filepath.Join(cacheHome, "dual", "latest-release.json"), []byte(cache), 0o600); err != nil {
+		t.Fatalf("failed to write cache: %v", err)
+	}
+
+	stdout, stderr, exitCode := h.RunDual("version")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "dual version dev")
+	h.AssertOutputNotContains(stdout, "v99.0.0")
+
+	// Test builds are development builds, so they cannot be compared
+	stdout, stderr, exitCode = h.RunDual("version", "--check")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Latest release is v99.0.0 (this is a development build)")
+}
+
+// TestVersionIgnoresNewerConfig tests that dual version works even when the
+// project needs a newer dual
+func TestVersionIgnoresNewerConfig(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", "version: 99\n")
+
+	stdout, stderr, exitCode := h.RunDual("version")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "dual version dev")
+}
@@ -1,6 +1,10 @@
 package integration
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -163,6 +167,58 @@ worktrees:
 	h.AssertOutputContains(stdout, "feature-other")
 }
 
+// TestContextSourceConfig tests that context.source reorders branch and .dual-context detection
+func TestContextSourceConfig(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateGitBranch("main")
+	h.CreateDirectory("apps/api")
+	h.WriteFile("apps/api/.gitkeep", "")
+	h.WriteFile(".dual-context", "pinned\n")
+
+	tests := []struct {
+		source   string
+		expected string
+	}{
+		{"", "main"},
+		{"branch", "main"},
+		{"fileFirst", "pinned"},
+		{"file", "pinned"},
+	}
+
+	for _, tt := range tests {
+		contextBlock := ""
+		if tt.source != "" {
+			contextBlock = "context:\n  source: " + tt.source + "\n"
+		}
+		h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+`+contextBlock)
+
+		stdout, stderr, exitCode := h.RunDual("run", "--service", "api", "true")
+		h.AssertExitCode(exitCode, 0, stdout+stderr)
+		h.AssertOutputContains(stderr, "[dual] Context: "+tt.expected)
+	}
+
+	// Invalid values are rejected by config validation
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  api:
+    path: apps/api
+context:
+  source: git
+`)
+	stdout, stderr, exitCode := h.RunDual("run", "--service", "api", "true")
+	if exitCode == 0 {
+		t.Fatalf("expected invalid context.source to fail, got: %s", stdout+stderr)
+	}
+	h.AssertOutputContains(stderr, "Invalid context source: git")
+}
+
 // TestWorktreeServiceDetection tests that service detection works correctly in worktrees
 func TestWorktreeServiceDetection(t *testing.T) {
 	h := NewTestHelper(t)
@@ -209,3 +265,422 @@ worktrees:
 	h.AssertOutputContains(stdout, "feature-test")
 	h.AssertOutputContains(stdout, "feature-api")
 }
+
+// TestCreateFromInsideWorktree tests that dual create run inside a worktree
+// operates on the main checkout instead of nesting worktrees
+func TestCreateFromInsideWorktree(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/web/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: apps/web
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-a")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	// Commit something in feature-a so we can tell which base the next branch uses
+	featureA := filepath.Join(h.TempDir, "worktrees", "feature-a")
+	if err := os.WriteFile(filepath.Join(featureA, "marker.txt"), []byte("a\n"), 0o644); err != nil {
+		t.Fatalf("failed to write marker: %v", err)
+	}
+	for _, args := range [][]string{{"add", "marker.txt"}, {"commit", "-m", "marker"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = featureA
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+
+	// Run from a subdirectory of the worktree
+	stdout, stderr, exitCode = h.RunDualInDir(filepath.Join(featureA, "apps", "web"), "create", "feature-b")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "creating from main checkout")
+
+	// Sibling of feature-a, not nested under it
+	featureB := filepath.Join(h.TempDir, "worktrees", "feature-b")
+	if _, err := os.Stat(featureB); err != nil {
+		t.Fatalf("expected worktree at %s: %v", featureB, err)
+	}
+	if _, err := os.Stat(filepath.Join(featureB, "marker.txt")); err != nil {
+		t.Errorf("expected feature-b to branch from feature-a's HEAD")
+	}
+
+	// Registered in the main project's registry
+	h.AssertOutputContains(h.ReadRegistryJSON(), "feature-b")
+}
+
+// TestCreateCopyContextFrom tests seeding a new context with another context's env overrides
+func TestCreateCopyContextFrom(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/web/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: apps/web
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-a")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	featureA := filepath.Join(h.TempDir, "worktrees", "feature-a")
+	stdout, stderr, exitCode = h.RunDualInDir(featureA, "env", "set", "API_URL", "http://api-a")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	stdout, stderr, exitCode = h.RunDualInDir(featureA, "env", "set", "--service", "web", "PORT", "4001")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+
+	// A missing source context fails before the worktree is created
+	stdout, stderr, exitCode = h.RunDual("create", "feature-x", "--copy-context-from", "missing")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `context "missing" not found`)
+	if _, err := os.Stat(filepath.Join(h.TempDir, "worktrees", "feature-x")); !os.IsNotExist(err) {
+		t.Errorf("expected no worktree for feature-x, stat err: %v", err)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("create", "feature-b", "--copy-context-from", "feature-a")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "Copied env overrides from context: feature-a")
+
+	featureB := filepath.Join(h.TempDir, "worktrees", "feature-b")
+	stdout, stderr, exitCode = h.RunDualInDir(featureB, "env", "export", "--service", "web")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_URL=http://api-a")
+	h.AssertOutputContains(stdout, "PORT=4001")
+
+	// The copy is independent of the source context
+	stdout, stderr, exitCode = h.RunDualInDir(featureB, "env", "set", "API_URL", "http://api-b")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	stdout, stderr, exitCode = h.RunDualInDir(featureA, "env", "export")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "API_URL=http://api-a")
+}
+
+// TestCreatePathOverride tests creating a worktree outside the configured worktrees path
+func TestCreatePathOverride(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: .
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	// A file is rejected before anything is created
+	h.WriteFile("not-a-dir", "")
+	stdout, stderr, exitCode := h.RunDual("create", "feature-x", "--path", "not-a-dir")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "is not a directory")
+
+	// An absolute path is used as-is
+	scratch := filepath.Join(h.TempDir, "scratch")
+	stdout, stderr, exitCode = h.RunDual("create", "feature-a", "--path", scratch)
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if _, err := os.Stat(filepath.Join(scratch, "feature-a", "dual.config.yml")); err != nil {
+		t.Errorf("expected worktree under --path: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(h.TempDir, "worktrees", "feature-a")); !os.IsNotExist(err) {
+		t.Errorf("expected no worktree under the configured path, stat err: %v", err)
+	}
+
+	// A relative path resolves against the current directory
+	stdout, stderr, exitCode = h.RunDual("create", "feature-b", "--path", "../elsewhere")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if _, err := os.Stat(filepath.Join(h.TempDir, "elsewhere", "feature-b", "dual.config.yml")); err != nil {
+		t.Errorf("expected worktree under relative --path: %v", err)
+	}
+
+	// The registry records where the worktrees actually are
+	registryJSON := h.ReadRegistryJSON()
+	h.AssertOutputContains(registryJSON, filepath.Join(scratch, "feature-a"))
+	h.AssertOutputContains(registryJSON, filepath.Join(h.TempDir, "elsewhere", "feature-b"))
+
+	// Deleting finds the worktree at its recorded path
+	stdout, stderr, exitCode = h.RunDual("delete", "feature-a", "--force")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if _, err := os.Stat(filepath.Join(scratch, "feature-a")); !os.IsNotExist(err) {
+		t.Errorf("expected feature-a worktree to be removed, stat err: %v", err)
+	}
+}
+
+// TestCreateWritesContextFile tests that dual create pins the context in a
+// .dual-context file that git ignores
+func TestCreateWritesContextFile(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: .
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature-x")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	worktree := filepath.Join(h.TempDir, "worktrees", "feature-x")
+	if got := strings.TrimSpace(h.ReadFileInDir(worktree, ".dual-context")); got != "feature-x" {
+		t.Errorf(".dual-context = %q, want feature-x", got)
+	}
+
+	// The file is ignored, so the worktree stays clean
+	status := exec.Command("git", "status", "--porcelain")
+	status.Dir = worktree
+	output, err := status.Output()
+	if err != nil {
+		t.Fatalf("git status failed: %v", err)
+	}
+	if len(strings.TrimSpace(string(output))) > 0 {
+		t.Errorf("expected a clean worktree, got:\n%s", output)
+	}
+
+	// Detection inside the worktree survives a detached HEAD
+	detach := exec.Command("git", "checkout", "--detach")
+	detach.Dir = worktree
+	if output, err := detach.CombinedOutput(); err != nil {
+		t.Fatalf("git checkout --detach failed: %v\n%s", err, output)
+	}
+	stdout, stderr, exitCode = h.RunDualInDir(worktree, "env", "check")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "Context detected: feature-x")
+
+	// A second worktree does not add the exclude entry twice
+	stdout, stderr, exitCode = h.RunDual("create", "feature-y")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	exclude := h.ReadFile(".git/info/exclude")
+	if count := strings.Count(exclude, "/.dual-context"); count != 1 {
+		t.Errorf("expected one /.dual-context entry in info/exclude, got %d:\n%s", count, exclude)
+	}
+
+	// Opting out with the flag or the config
+	stdout, stderr, exitCode = h.RunDual("create", "no-file", "--no-context-file")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if h.FileExistsInDir(filepath.Join(h.TempDir, "worktrees", "no-file"), ".dual-context") {
+		t.Error("expected no .dual-context with --no-context-file")
+	}
+
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: .
+worktrees:
+  path: ../worktrees
+  writeContextFile: false
+`)
+	stdout, stderr, exitCode = h.RunDual("create", "no-file-config")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if h.FileExistsInDir(filepath.Join(h.TempDir, "worktrees", "no-file-config"), ".dual-context") {
+		t.Error("expected no .dual-context with writeContextFile: false")
+	}
+}
+
+// TestAdoptWorktree tests registering a worktree created with raw git as a context
+func TestAdoptWorktree(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/web/.gitkeep", "")
+	h.WriteFile(".dual/hooks/setup.sh", "#!/bin/sh\necho \"GLOBAL:ADOPTED_AS=$DUAL_CONTEXT_NAME\"\n")
+	if err := os.Chmod(filepath.Join(h.ProjectDir, ".dual/hooks/setup.sh"), 0o755); err != nil {
+		t.Fatalf("failed to chmod hook: %v", err)
+	}
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: apps/web
+worktrees:
+  path: ../worktrees
+hooks:
+  postWorktreeCreate:
+    - setup.sh
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	// The main checkout is not a linked worktree
+	stdout, stderr, exitCode := h.RunDual("adopt", ".")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "is not a linked git worktree")
+
+	rawPath := h.CreateGitWorktree("feature-raw", "worktree-raw")
+	stdout, stderr, exitCode = h.RunDual("adopt", "../worktree-raw", "--run-hooks")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "Adopted worktree "+rawPath+" as context: feature-raw")
+	h.AssertOutputContains(h.ReadRegistryJSON(), "feature-raw")
+
+	stdout, stderr, exitCode = h.RunDualInDir(rawPath, "env", "export")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stdout, "ADOPTED_AS=feature-raw")
+
+	// Adopting the same worktree twice is refused, even under another name
+	stdout, stderr, exitCode = h.RunDualInDir(rawPath, "adopt")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `context "feature-raw" already exists`)
+	stdout, stderr, exitCode = h.RunDualInDir(rawPath, "adopt", "--name", "other")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `already registered as context "feature-raw"`)
+
+	// A detached worktree needs an explicit name
+	detachedPath := filepath.Join(h.TempDir, "worktree-detached")
+	h.RunGitCommand("worktree", "add", "--detach", detachedPath)
+	stdout, stderr, exitCode = h.RunDual("adopt", detachedPath)
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, "detached HEAD")
+	stdout, stderr, exitCode = h.RunDual("adopt", detachedPath, "--name", "experiment")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(h.ReadRegistryJSON(), "experiment")
+}
+
+// TestAdoptNameMismatch tests that a --name dual would not detect in the
+// worktree is warned about, or refused with --strict
+func TestAdoptNameMismatch(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/web/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: apps/web
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	rawPath := h.CreateGitWorktree("feature-raw", "worktree-raw")
+
+	stdout, stderr, exitCode := h.RunDual("adopt", rawPath, "--name", "raw", "--strict")
+	h.AssertExitCode(exitCode, 1, stdout+stderr)
+	h.AssertOutputContains(stderr, `context name "raw" differs from "feature-raw", which dual detects in `+rawPath)
+	h.AssertOutputContains(stderr, "set context.source: fileFirst")
+	if h.RegistryExists() {
+		h.AssertOutputNotContains(h.ReadRegistryJSON(), `"raw"`)
+	}
+
+	// A matching name passes --strict silently
+	stdout, stderr, exitCode = h.RunDual("adopt", rawPath, "--name", "feature-raw", "--strict")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputNotContains(stderr, "differs from")
+
+	otherPath := h.CreateGitWorktree("feature-other", "worktree-other")
+	stdout, stderr, exitCode = h.RunDual("adopt", otherPath, "--name", "other")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, `Warning: Context name "other" differs from "feature-other"`)
+	h.AssertOutputContains(stderr, `Hint: Omit --name to use "feature-other", or write "other" to `+filepath.Join(otherPath, ".dual-context"))
+	h.AssertOutputContains(h.ReadRegistryJSON(), `"other"`)
+}
+
+// TestCreateFromRemoteBranch tests that --from with a remote branch fetches it
+// if needed and tracks it, while local branches and commits are not tracked
+func TestCreateFromRemoteBranch(t *testing.T) {
+	h := NewTestHelper(t)
+	defer h.RestoreHome()
+
+	h.InitGitRepo()
+	h.CreateDirectory("apps/web")
+	h.WriteFile("apps/web/.gitkeep", "")
+	h.WriteFile("dual.config.yml", `version: 1
+services:
+  web:
+    path: apps/web
+worktrees:
+  path: ../worktrees
+`)
+	h.RunGitCommand("add", ".")
+	h.RunGitCommand("commit", "-m", "Add dual config")
+
+	originPath := filepath.Join(h.TempDir, "origin.git")
+	cmd := exec.Command("git", "init", "--bare", originPath)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to init bare repo: %v\n%s", err, output)
+	}
+	for _, args := range [][]string{
+		{"remote", "add", "origin", originPath},
+		{"push", "origin", "HEAD:refs/heads/feature"},
+		{"push", "origin", "HEAD:refs/heads/unfetched"},
+		// Only on the remote, as if someone else pushed it
+		{"update-ref", "-d", "refs/remotes/origin/unfetched"},
+		{"branch", "develop"},
+	} {
+		if output, err := h.RunGitCommand(args...); err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, output)
+		}
+	}
+	sha, err := h.RunGitCommand("rev-parse", "HEAD")
+	if err != nil {
+		t.Fatalf("git rev-parse failed: %v", err)
+	}
+
+	upstream := func(branch string) string {
+		t.Helper()
+		output, err := h.RunGitCommand("rev-parse", "--abbrev-ref", branch+"@{upstream}")
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(output)
+	}
+
+	stdout, stderr, exitCode := h.RunDual("create", "feature", "--from", "origin/feature")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if got := upstream("feature"); got != "origin/feature" {
+		t.Errorf("feature upstream = %q, want origin/feature", got)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("create", "unfetched", "--from", "origin/unfetched")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	h.AssertOutputContains(stderr, "Fetching unfetched from origin")
+	if got := upstream("unfetched"); got != "origin/unfetched" {
+		t.Errorf("unfetched upstream = %q, want origin/unfetched", got)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("create", "from-local", "--from", "develop")
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if got := upstream("from-local"); got != "" {
+		t.Errorf("from-local upstream = %q, want none", got)
+	}
+
+	stdout, stderr, exitCode = h.RunDual("create", "from-sha", "--from", strings.TrimSpace(sha))
+	h.AssertExitCode(exitCode, 0, stdout+stderr)
+	if got := upstream("from-sha"); got != "" {
+		t.Errorf("from-sha upstream = %q, want none", got)
+	}
+
+	// A branch missing from the remote fails before any worktree is created
+	stdout, stderr, exitCode = h.RunDual("create", "missing", "--from", "origin/missing")
+	if exitCode == 0 {
+		t.Fatalf("expected create from a missing remote branch to fail\n%s", stdout+stderr)
+	}
+	h.AssertOutputContains(stderr, "failed to fetch missing from origin")
+	if _, err := os.Stat(filepath.Join(h.TempDir, "worktrees", "missing")); err == nil {
+		t.Error("worktree should not be created when the fetch fails")
+	}
+}